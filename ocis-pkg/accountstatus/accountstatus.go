@@ -0,0 +1,129 @@
+// Package accountstatus checks whether user accounts are enabled. The graph service owns the
+// account status, services authenticating users read it from the accountEnabled property of
+// the users' /me resource.
+package accountstatus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/jellydator/ttlcache/v2"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/ocis-pkg/registry"
+	"go-micro.dev/v4/selector"
+)
+
+// graphService is the name of the graph service in the service registry
+const graphService = "com.owncloud.graph.graph"
+
+// ErrDisabled is returned for users whose account is disabled
+var ErrDisabled = errors.New("account disabled")
+
+// Checker checks the account status of users. It is safe for concurrent use.
+type Checker struct {
+	logger     log.Logger
+	graphURL   string
+	selector   selector.Selector
+	httpClient *http.Client
+	cache      *ttlcache.Cache
+	failOpen   bool
+}
+
+// NewChecker returns a new Checker
+func NewChecker(opts ...Option) *Checker {
+	options := newOptions(opts...)
+
+	cache := ttlcache.NewCache()
+	_ = cache.SetTTL(options.ttl)
+	cache.SkipTTLExtensionOnHit(true)
+
+	c := &Checker{
+		logger:     options.logger,
+		graphURL:   options.graphURL,
+		httpClient: options.httpClient,
+		cache:      cache,
+		failOpen:   options.failOpen,
+	}
+	if c.graphURL == "" {
+		c.selector = selector.NewSelector(selector.Registry(registry.GetRegistry()))
+	}
+	return c
+}
+
+// Check returns ErrDisabled if the account of the user is disabled. The token is a reva
+// access token of the user, it is used to read the user's own account status. When the
+// status can't be read the error is returned, unless the checker fails open.
+func (c *Checker) Check(ctx context.Context, userID, token string) error {
+	if enabled, err := c.cache.Get(userID); err == nil {
+		if !enabled.(bool) {
+			return ErrDisabled
+		}
+		return nil
+	}
+
+	enabled, err := c.accountEnabled(ctx, token)
+	if err != nil && c.failOpen {
+		// don't cache the status, it is read again on the next login
+		c.logger.Warn().Err(err).Str("userid", userID).Msg("could not get the account status, treating the account as enabled")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not get the account status of user %s: %w", userID, err)
+	}
+	_ = c.cache.Set(userID, enabled)
+	if !enabled {
+		c.logger.Debug().Str("userid", userID).Msg("account is disabled")
+		return ErrDisabled
+	}
+	return nil
+}
+
+func (c *Checker) accountEnabled(ctx context.Context, token string) (bool, error) {
+	graphURL, err := c.baseURL()
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, graphURL+"/me", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set(revactx.TokenHeader, token)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d from the graph service", res.StatusCode)
+	}
+
+	var me struct {
+		AccountEnabled *bool `json:"accountEnabled"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&me); err != nil {
+		return false, err
+	}
+	// identity backends without support for disabling users don't return the property
+	return me.AccountEnabled == nil || *me.AccountEnabled, nil
+}
+
+// baseURL returns the configured graph url or looks up the graph service in the registry
+func (c *Checker) baseURL() (string, error) {
+	if c.graphURL != "" {
+		return c.graphURL, nil
+	}
+	next, err := c.selector.Select(graphService)
+	if err != nil {
+		return "", err
+	}
+	node, err := next()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s://%s/graph/v1.0", node.Metadata["protocol"], node.Address), nil
+}
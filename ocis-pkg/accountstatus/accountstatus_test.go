@@ -0,0 +1,64 @@
+package accountstatus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "/graph/v1.0/me", r.URL.Path)
+		switch r.Header.Get(revactx.TokenHeader) {
+		case "enabled":
+			fmt.Fprint(w, `{"id":"u1","accountEnabled":true}`)
+		case "disabled":
+			fmt.Fprint(w, `{"id":"u2","accountEnabled":false}`)
+		case "unknown":
+			fmt.Fprint(w, `{"id":"u3"}`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewChecker(GraphURL(srv.URL + "/graph/v1.0"))
+	ctx := context.Background()
+
+	assert.NoError(t, c.Check(ctx, "u1", "enabled"))
+	assert.ErrorIs(t, c.Check(ctx, "u2", "disabled"), ErrDisabled)
+	assert.NoError(t, c.Check(ctx, "u3", "unknown"))
+	assert.Error(t, c.Check(ctx, "u4", "invalid"))
+	assert.NotErrorIs(t, c.Check(ctx, "u4", "invalid"), ErrDisabled)
+
+	// the status is cached per user
+	calls = 0
+	assert.ErrorIs(t, c.Check(ctx, "u2", "disabled"), ErrDisabled)
+	assert.NoError(t, c.Check(ctx, "u1", "enabled"))
+	assert.Equal(t, 0, calls)
+}
+
+func TestCheckUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprint(w, `{"id":"u1","accountEnabled":false}`)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := NewChecker(GraphURL(srv.URL+"/graph/v1.0"), Timeout(10*time.Millisecond))
+	err := c.Check(ctx, "u1", "token")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrDisabled)
+
+	c = NewChecker(GraphURL(srv.URL+"/graph/v1.0"), Timeout(10*time.Millisecond), FailOpen(true))
+	assert.NoError(t, c.Check(ctx, "u1", "token"))
+}
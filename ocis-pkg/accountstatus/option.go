@@ -0,0 +1,83 @@
+package accountstatus
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+)
+
+// Options are all the possible options.
+type Options struct {
+	logger     log.Logger
+	graphURL   string
+	httpClient *http.Client
+	timeout    time.Duration
+	ttl        time.Duration
+	failOpen   bool
+}
+
+// Option mutates option
+type Option func(*Options)
+
+// Logger sets a preconfigured logger
+func Logger(logger log.Logger) Option {
+	return func(o *Options) {
+		o.logger = logger
+	}
+}
+
+// GraphURL sets the url of the graph api, e.g. "http://localhost:9120/graph/v1.0". By default
+// the graph service is looked up in the service registry.
+func GraphURL(url string) Option {
+	return func(o *Options) {
+		o.graphURL = url
+	}
+}
+
+// HTTPClient sets the client used to talk to the graph service. The Timeout option doesn't
+// apply to it.
+func HTTPClient(c *http.Client) Option {
+	return func(o *Options) {
+		o.httpClient = c
+	}
+}
+
+// Timeout sets how long a request for the account status may take
+func Timeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.timeout = timeout
+	}
+}
+
+// FailOpen sets the policy for accounts whose status can't be read. When true they are
+// treated as enabled, otherwise the error is returned and the login is rejected.
+func FailOpen(failOpen bool) Option {
+	return func(o *Options) {
+		o.failOpen = failOpen
+	}
+}
+
+// TTL sets how long the status of an account is cached
+func TTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.ttl = ttl
+	}
+}
+
+func newOptions(opts ...Option) Options {
+	o := Options{
+		timeout: 5 * time.Second,
+		ttl:     30 * time.Second,
+	}
+
+	for _, v := range opts {
+		v(&o)
+	}
+
+	if o.httpClient == nil {
+		o.httpClient = &http.Client{Timeout: o.timeout}
+	}
+
+	return o
+}
@@ -0,0 +1,98 @@
+// Package apptoken implements app tokens (application passwords) which clients can use
+// with HTTP basic auth in place of the user's password.
+package apptoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Scopes an app token can be restricted to.
+const (
+	// ScopeReadOnly only allows requests which do not modify any data
+	ScopeReadOnly = "read"
+	// ScopeReadWrite allows all requests
+	ScopeReadWrite = "readwrite"
+)
+
+// tokenBytes is the number of random bytes of a token secret
+const tokenBytes = 32
+
+var (
+	// ErrNotFound is returned when a token does not exist
+	ErrNotFound = errors.New("app token not found")
+	// ErrExpired is returned when a token has expired
+	ErrExpired = errors.New("app token expired")
+	// ErrInvalidScope is returned when a token is created with an unknown scope
+	ErrInvalidScope = errors.New("invalid app token scope")
+)
+
+// Token is an app token. The secret is only known at creation time, only its hash is persisted.
+type Token struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Label     string    `json:"label"`
+	Scope     string    `json:"scope"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
+
+// Expired returns true if the token has an expiry date in the past.
+func (t *Token) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt)
+}
+
+// Allows returns true if the token scope permits requests with the given method.
+func (t *Token) Allows(method string) bool {
+	if t.Scope == ScopeReadWrite {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND", "REPORT", "SEARCH":
+		return true
+	default:
+		return false
+	}
+}
+
+// Manager persists app tokens. Implementations need to be safe for concurrent use.
+type Manager interface {
+	// Create creates a new token for the user and returns it along with its secret.
+	// A zero expiresAt creates a token which never expires.
+	Create(ctx context.Context, userID, label, scope string, expiresAt time.Time) (*Token, string, error)
+	// List returns the tokens of the user.
+	List(ctx context.Context, userID string) ([]*Token, error)
+	// Delete revokes a token of the user.
+	Delete(ctx context.Context, userID, tokenID string) error
+	// Authenticate returns the token matching the secret. It returns ErrNotFound for unknown
+	// and ErrExpired for expired tokens.
+	Authenticate(ctx context.Context, secret string) (*Token, error)
+}
+
+// ValidScope returns true for the known token scopes.
+func ValidScope(scope string) bool {
+	return scope == ScopeReadOnly || scope == ScopeReadWrite
+}
+
+// generateSecret returns a new random token secret.
+func generateSecret() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret returns the hex encoded SHA-256 hash of a secret. The secrets are random and long
+// enough that a slow password hash is not needed.
+func hashSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
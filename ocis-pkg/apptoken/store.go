@@ -0,0 +1,169 @@
+package apptoken
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	storemsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/store/v0"
+	storesvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/store/v0"
+	merrors "go-micro.dev/v4/errors"
+)
+
+const (
+	storeDatabase = "proxy"
+	storeTable    = "app-tokens"
+	metadataUser  = "user_id"
+	// listPageSize is the number of tokens read at once, the store returns 10 by default
+	listPageSize = 100
+)
+
+// storeManager persists the tokens in the ocis store service. The records are keyed by the
+// hash of the secret so that authenticating a request only needs a single read.
+type storeManager struct {
+	store storesvc.StoreService
+}
+
+// NewStoreManager returns a Manager which persists the tokens in the ocis store service.
+func NewStoreManager(store storesvc.StoreService) Manager {
+	return &storeManager{store: store}
+}
+
+// Create implements the Manager interface.
+func (m *storeManager) Create(ctx context.Context, userID, label, scope string, expiresAt time.Time) (*Token, string, error) {
+	if !ValidScope(scope) {
+		return nil, "", ErrInvalidScope
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	t := &Token{
+		ID:        uuid.Must(uuid.NewV4()).String(),
+		UserID:    userID,
+		Label:     label,
+		Scope:     scope,
+		Hash:      hashSecret(secret),
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+	value, err := json.Marshal(t)
+	if err != nil {
+		return nil, "", err
+	}
+	_, err = m.store.Write(ctx, &storesvc.WriteRequest{
+		Options: &storemsg.WriteOptions{
+			Database: storeDatabase,
+			Table:    storeTable,
+		},
+		Record: &storemsg.Record{
+			Key:   t.Hash,
+			Value: value,
+			Metadata: map[string]*storemsg.Field{
+				metadataUser: {Type: "string", Value: userID},
+			},
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return t, secret, nil
+}
+
+// List implements the Manager interface.
+func (m *storeManager) List(ctx context.Context, userID string) ([]*Token, error) {
+	tokens := []*Token{}
+	for offset := uint64(0); ; offset += listPageSize {
+		res, err := m.store.Read(ctx, &storesvc.ReadRequest{
+			Options: &storemsg.ReadOptions{
+				Database: storeDatabase,
+				Table:    storeTable,
+				Where: map[string]*storemsg.Field{
+					metadataUser: {Type: "string", Value: userID},
+				},
+				Limit:  listPageSize,
+				Offset: offset,
+			},
+		})
+		if err != nil {
+			if isNotFound(err) {
+				return tokens, nil
+			}
+			return nil, err
+		}
+
+		for _, rec := range res.GetRecords() {
+			t := &Token{}
+			if err := json.Unmarshal(rec.Value, t); err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+		}
+		if len(res.GetRecords()) < listPageSize {
+			return tokens, nil
+		}
+	}
+}
+
+// Delete implements the Manager interface.
+func (m *storeManager) Delete(ctx context.Context, userID, tokenID string) error {
+	tokens, err := m.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, t := range tokens {
+		if t.ID != tokenID {
+			continue
+		}
+		_, err := m.store.Delete(ctx, &storesvc.DeleteRequest{
+			Options: &storemsg.DeleteOptions{
+				Database: storeDatabase,
+				Table:    storeTable,
+			},
+			Key: t.Hash,
+		})
+		if isNotFound(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return ErrNotFound
+}
+
+// Authenticate implements the Manager interface.
+func (m *storeManager) Authenticate(ctx context.Context, secret string) (*Token, error) {
+	res, err := m.store.Read(ctx, &storesvc.ReadRequest{
+		Options: &storemsg.ReadOptions{
+			Database: storeDatabase,
+			Table:    storeTable,
+		},
+		Key: hashSecret(secret),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if len(res.GetRecords()) == 0 {
+		return nil, ErrNotFound
+	}
+
+	t := &Token{}
+	if err := json.Unmarshal(res.Records[0].Value, t); err != nil {
+		return nil, err
+	}
+	if t.Expired(time.Now()) {
+		return nil, ErrExpired
+	}
+	return t, nil
+}
+
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	return merrors.FromError(err).Code == http.StatusNotFound
+}
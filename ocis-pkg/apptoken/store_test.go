@@ -0,0 +1,162 @@
+package apptoken
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	storemsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/store/v0"
+	storesvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/store/v0"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go-micro.dev/v4/client"
+	merrors "go-micro.dev/v4/errors"
+)
+
+// fakeStore is an in memory stand-in for the store service supporting reads by key and by metadata.
+// Like the store service it returns at most 10 records per metadata read unless a limit is given.
+type fakeStore struct {
+	storesvc.StoreService
+	records map[string]*storemsg.Record
+}
+
+func (s *fakeStore) Read(ctx context.Context, in *storesvc.ReadRequest, opts ...client.CallOption) (*storesvc.ReadResponse, error) {
+	if in.Key != "" {
+		rec, ok := s.records[in.Key]
+		if !ok {
+			return nil, merrors.NotFound("store", "could not read record")
+		}
+		return &storesvc.ReadResponse{Records: []*storemsg.Record{rec}}, nil
+	}
+	keys := make([]string, 0, len(s.records))
+	for k := range s.records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var matches []*storemsg.Record
+	for _, key := range keys {
+		rec := s.records[key]
+		match := true
+		for k, v := range in.Options.Where {
+			if rec.Metadata[k].GetValue() != v.Value {
+				match = false
+			}
+		}
+		if match {
+			matches = append(matches, rec)
+		}
+	}
+
+	limit := 10
+	if in.Options.Limit > 0 {
+		limit = int(in.Options.Limit)
+	}
+	offset := int(in.Options.Offset)
+	if offset > len(matches) {
+		offset = len(matches)
+	}
+	matches = matches[offset:]
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return &storesvc.ReadResponse{Records: matches}, nil
+}
+
+func (s *fakeStore) Write(ctx context.Context, in *storesvc.WriteRequest, opts ...client.CallOption) (*storesvc.WriteResponse, error) {
+	s.records[in.Record.Key] = in.Record
+	return &storesvc.WriteResponse{}, nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, in *storesvc.DeleteRequest, opts ...client.CallOption) (*storesvc.DeleteResponse, error) {
+	if _, ok := s.records[in.Key]; !ok {
+		return nil, merrors.NotFound("store", "could not find record")
+	}
+	delete(s.records, in.Key)
+	return &storesvc.DeleteResponse{}, nil
+}
+
+func TestStoreManager(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{records: map[string]*storemsg.Record{}}
+	m := NewStoreManager(store)
+
+	token, secret, err := m.Create(ctx, "einstein", "laptop", ScopeReadWrite, time.Time{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, secret)
+	assert.NotContains(t, string(store.records[token.Hash].Value), secret, "the secret must not be persisted")
+
+	_, _, err = m.Create(ctx, "marie", "phone", ScopeReadOnly, time.Time{})
+	require.NoError(t, err)
+
+	tokens, err := m.List(ctx, "einstein")
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, token.ID, tokens[0].ID)
+	assert.Equal(t, "laptop", tokens[0].Label)
+
+	authenticated, err := m.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "einstein", authenticated.UserID)
+
+	_, err = m.Authenticate(ctx, "wrong")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.ErrorIs(t, m.Delete(ctx, "marie", token.ID), ErrNotFound, "users can only delete their own tokens")
+	require.NoError(t, m.Delete(ctx, "einstein", token.ID))
+	_, err = m.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreManagerManyTokens(t *testing.T) {
+	ctx := context.Background()
+	m := NewStoreManager(&fakeStore{records: map[string]*storemsg.Record{}})
+
+	var ids []string
+	for i := 0; i < 2*listPageSize+5; i++ {
+		token, _, err := m.Create(ctx, "einstein", "token", ScopeReadOnly, time.Time{})
+		require.NoError(t, err)
+		ids = append(ids, token.ID)
+	}
+
+	tokens, err := m.List(ctx, "einstein")
+	require.NoError(t, err)
+	assert.Len(t, tokens, len(ids))
+
+	for _, id := range ids {
+		require.NoError(t, m.Delete(ctx, "einstein", id))
+	}
+	tokens, err = m.List(ctx, "einstein")
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func TestStoreManagerExpiredToken(t *testing.T) {
+	m := NewStoreManager(&fakeStore{records: map[string]*storemsg.Record{}})
+	_, secret, err := m.Create(context.Background(), "einstein", "old", ScopeReadOnly, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	_, err = m.Authenticate(context.Background(), secret)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestStoreManagerInvalidScope(t *testing.T) {
+	m := NewStoreManager(&fakeStore{records: map[string]*storemsg.Record{}})
+	_, _, err := m.Create(context.Background(), "einstein", "laptop", "admin", time.Time{})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
+
+func TestTokenAllows(t *testing.T) {
+	ro := &Token{Scope: ScopeReadOnly}
+	rw := &Token{Scope: ScopeReadWrite}
+	for _, method := range []string{http.MethodGet, http.MethodHead, "PROPFIND"} {
+		assert.True(t, ro.Allows(method), method)
+		assert.True(t, rw.Allows(method), method)
+	}
+	for _, method := range []string{http.MethodPut, http.MethodDelete, "MKCOL", "MOVE", "PROPPATCH"} {
+		assert.False(t, ro.Allows(method), method)
+		assert.True(t, rw.Allows(method), method)
+	}
+}
@@ -0,0 +1,40 @@
+// Package events contains the events emitted by the ocis services which are not part of
+// reva. They are published and consumed with the reva events package.
+package events
+
+import (
+	"encoding/json"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// TagsAdded is emitted when tags have been added to a resource
+type TagsAdded struct {
+	SpaceOwner *user.UserId
+	Executant  *user.UserId
+	Ref        *provider.Reference
+	Tags       []string
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (TagsAdded) Unmarshal(v []byte) (interface{}, error) {
+	e := TagsAdded{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
+
+// TagsRemoved is emitted when tags have been removed from a resource
+type TagsRemoved struct {
+	SpaceOwner *user.UserId
+	Executant  *user.UserId
+	Ref        *provider.Reference
+	Tags       []string
+}
+
+// Unmarshal to fulfill umarshaller interface
+func (TagsRemoved) Unmarshal(v []byte) (interface{}, error) {
+	e := TagsRemoved{}
+	err := json.Unmarshal(v, &e)
+	return e, err
+}
@@ -0,0 +1,224 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+)
+
+var objectStart = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// Document gives access to the objects of a PDF document. The objects are found by scanning
+// the file instead of reading the cross-reference table, which makes it work with documents
+// whose offsets are broken.
+type Document struct {
+	objects map[int]interface{}
+	// order holds the object numbers in the order the objects appear in the file
+	order   []int
+	catalog Dict
+	// remaining is the amount of data that may still be decompressed
+	remaining int64
+}
+
+// Parse reads the objects of the document. Decode stops decompressing the streams of the
+// document once maxDecodedSize bytes have been decompressed.
+func Parse(data []byte, maxDecodedSize int64) (*Document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\n\f\r "), []byte("%PDF-")) {
+		return nil, errors.New(`not a pdf document`)
+	}
+
+	d := &Document{objects: map[int]interface{}{}, remaining: maxDecodedSize}
+	var objectStreams []*Stream
+	for pos := 0; pos < len(data); {
+		loc := objectStart.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		l := &Lexer{data: data, pos: pos + loc[1]}
+		pos += loc[1]
+
+		obj, err := l.Object()
+		if err != nil {
+			continue
+		}
+		if dict, ok := obj.(Dict); ok {
+			if stream, ok := l.stream(dict); ok {
+				obj = stream
+				if stream.Dict["Type"] == Name("ObjStm") {
+					objectStreams = append(objectStreams, stream)
+				}
+			}
+			if dict["Type"] == Name("Catalog") {
+				d.catalog = dict
+			}
+		}
+		d.add(num, obj)
+		pos = l.pos
+	}
+
+	for _, s := range objectStreams {
+		d.readObjectStream(s)
+	}
+	if len(d.objects) == 0 {
+		return nil, errors.New(`the pdf document contains no objects`)
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, errors.New(`encrypted pdf documents are not supported`)
+	}
+	return d, nil
+}
+
+// add stores an object, later objects are updates of the previous ones with the same number
+func (d *Document) add(num int, obj interface{}) {
+	if _, exists := d.objects[num]; !exists {
+		d.order = append(d.order, num)
+	}
+	d.objects[num] = obj
+}
+
+// readObjectStream adds the objects compressed into an object stream
+func (d *Document) readObjectStream(s *Stream) {
+	data, err := d.Decode(s)
+	if err != nil {
+		return
+	}
+	n, first := d.Int(s.Dict["N"]), d.Int(s.Dict["First"])
+	if first <= 0 || first > len(data) {
+		return
+	}
+	header := NewLexer(data[:first])
+	for i := 0; i < n; i++ {
+		num, err1 := header.Object()
+		off, err2 := header.Object()
+		if err1 != nil || err2 != nil {
+			return
+		}
+		objNum, ok1 := num.(float64)
+		offset, ok2 := off.(float64)
+		if !ok1 || !ok2 || first+int(offset) >= len(data) {
+			return
+		}
+		if _, exists := d.objects[int(objNum)]; exists {
+			continue
+		}
+		l := &Lexer{data: data, pos: first + int(offset)}
+		obj, err := l.Object()
+		if err != nil {
+			continue
+		}
+		if dict, ok := obj.(Dict); ok && dict["Type"] == Name("Catalog") && d.catalog == nil {
+			d.catalog = dict
+		}
+		d.add(int(objNum), obj)
+	}
+}
+
+// Catalog returns the document catalog, or nil if the document has none
+func (d *Document) Catalog() Dict {
+	return d.catalog
+}
+
+// Streams returns the streams of the document in the order they appear in the file
+func (d *Document) Streams() []*Stream {
+	var streams []*Stream
+	for _, num := range d.order {
+		if s, ok := d.objects[num].(*Stream); ok {
+			streams = append(streams, s)
+		}
+	}
+	return streams
+}
+
+// Resolve returns the object a reference points to
+func (d *Document) Resolve(obj interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj.(Ref)
+		if !ok {
+			return obj
+		}
+		obj = d.objects[ref.Num]
+	}
+	return nil
+}
+
+// Dict returns the dictionary, or the dictionary of the stream, the object refers to
+func (d *Document) Dict(obj interface{}) Dict {
+	switch o := d.Resolve(obj).(type) {
+	case Dict:
+		return o
+	case *Stream:
+		return o.Dict
+	}
+	return nil
+}
+
+func (d *Document) Array(obj interface{}) Array {
+	a, _ := d.Resolve(obj).(Array)
+	return a
+}
+
+func (d *Document) Number(obj interface{}) float64 {
+	f, _ := d.Resolve(obj).(float64)
+	return f
+}
+
+func (d *Document) Int(obj interface{}) int {
+	return int(d.Number(obj))
+}
+
+func (d *Document) Name(obj interface{}) Name {
+	n, _ := d.Resolve(obj).(Name)
+	return n
+}
+
+// FirstPage returns the first page and the attributes it inherits from the page tree
+func (d *Document) FirstPage() (Dict, error) {
+	node := d.Dict(d.catalog["Pages"])
+	inherited := Dict{}
+	for depth := 0; node != nil && depth < 64; depth++ {
+		for _, key := range []Name{"MediaBox", "CropBox", "Resources", "Rotate"} {
+			if v, ok := node[key]; ok {
+				inherited[key] = v
+			}
+		}
+		kids := d.Array(node["Kids"])
+		if len(kids) == 0 {
+			if d.Name(node["Type"]) == "Pages" {
+				break
+			}
+			page := Dict{}
+			for k, v := range node {
+				page[k] = v
+			}
+			for k, v := range inherited {
+				page[k] = v
+			}
+			return page, nil
+		}
+		node = d.Dict(kids[0])
+	}
+	return nil, errors.New(`the pdf document has no pages`)
+}
+
+// Content returns the decoded content streams of the page
+func (d *Document) Content(page Dict) ([]byte, error) {
+	switch c := d.Resolve(page["Contents"]).(type) {
+	case *Stream:
+		return d.Decode(c)
+	case Array:
+		var content []byte
+		for _, part := range c {
+			if s, ok := d.Resolve(part).(*Stream); ok {
+				data, err := d.Decode(s)
+				if err != nil {
+					return nil, err
+				}
+				content = append(append(content, data...), '\n')
+			}
+		}
+		return content, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,185 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Decode returns the decoded data of a stream. The data of DCTDecode streams is returned as is,
+// it has to be decoded as a JPEG image.
+func (d *Document) Decode(s *Stream) ([]byte, error) {
+	filters := d.Filters(s.Dict)
+	params := d.Resolve(s.Dict["DecodeParms"])
+	data := s.Raw
+	for i, f := range filters {
+		var p Dict
+		switch v := params.(type) {
+		case Dict:
+			p = v
+		case Array:
+			if i < len(v) {
+				p = d.Dict(v[i])
+			}
+		}
+
+		var err error
+		switch f {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data, &d.remaining)
+			if err == nil {
+				data, err = d.unpredict(data, p)
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = hexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = ascii85Decode(data)
+		case "DCTDecode", "DCT":
+			if i != len(filters)-1 {
+				return nil, errors.New(`unsupported filter after DCTDecode`)
+			}
+		default:
+			return nil, fmt.Errorf(`unsupported filter %s`, f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Filters returns the names of the filters applied to the data of a stream
+func (d *Document) Filters(dict Dict) []Name {
+	switch f := d.Resolve(dict["Filter"]).(type) {
+	case Name:
+		return []Name{f}
+	case Array:
+		names := make([]Name, 0, len(f))
+		for _, n := range f {
+			names = append(names, d.Name(n))
+		}
+		return names
+	}
+	return nil
+}
+
+// unpredict reverses the PNG predictors applied before compressing the data
+func (d *Document) unpredict(data []byte, params Dict) ([]byte, error) {
+	if params == nil || d.Int(params["Predictor"]) < 10 {
+		return data, nil
+	}
+	colors, bpc, columns := 1, 8, 1
+	if v, ok := params["Colors"]; ok {
+		colors = d.Int(v)
+	}
+	if v, ok := params["BitsPerComponent"]; ok {
+		bpc = d.Int(v)
+	}
+	if v, ok := params["Columns"]; ok {
+		columns = d.Int(v)
+	}
+	bpp := (colors*bpc + 7) / 8
+	rowSize := (colors*bpc*columns + 7) / 8
+	if bpp <= 0 || rowSize <= 0 {
+		return nil, errors.New(`invalid predictor parameters`)
+	}
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowSize)
+	for len(data) >= rowSize+1 {
+		filter, row := data[0], append([]byte(nil), data[1:rowSize+1]...)
+		data = data[rowSize+1:]
+		for i := range row {
+			var left, up, upLeft byte
+			if i >= bpp {
+				left, upLeft = row[i-bpp], prev[i-bpp]
+			}
+			up = prev[i]
+			switch filter {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// inflate decompresses the data. The size of the decompressed data is subtracted from
+// remaining, decompression stops when nothing remains.
+func inflate(data []byte, remaining *int64) ([]byte, error) {
+	if *remaining <= 0 {
+		return nil, ErrDecodeLimit
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	// streams are often followed by garbage or cut short, use what could be read
+	decoded, err := io.ReadAll(io.LimitReader(zr, *remaining))
+	*remaining -= int64(len(decoded))
+	if len(decoded) == 0 && err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func hexDecode(data []byte) ([]byte, error) {
+	digits := make([]byte, 0, len(data))
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if isSpace(c) {
+			continue
+		}
+		digits = append(digits, c)
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
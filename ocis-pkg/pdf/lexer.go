@@ -0,0 +1,295 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Lexer reads the objects of a PDF file or the operands and operators of a content stream
+type Lexer struct {
+	data []byte
+	pos  int
+}
+
+// NewLexer returns a Lexer reading the data from the start
+func NewLexer(data []byte) *Lexer {
+	return &Lexer{data: data}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return isSpace(c) || bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (l *Lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\r' && l.data[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Object reads the next object of a PDF file, including references to other objects
+func (l *Lexer) Object() (interface{}, error) {
+	return l.read(true)
+}
+
+// Next reads the next operand or operator of a content stream, the operators are returned as
+// Keyword. Content streams contain no references.
+func (l *Lexer) Next() (interface{}, error) {
+	return l.read(false)
+}
+
+// SkipInlineImage moves the lexer behind the data of an inline image, it has to be called
+// after reading the BI operator
+func (l *Lexer) SkipInlineImage() {
+	for {
+		obj, err := l.Next()
+		if err != nil {
+			return
+		}
+		if obj == Keyword("ID") {
+			break
+		}
+	}
+	for i := l.pos; i+2 < len(l.data); i++ {
+		if isSpace(l.data[i]) && l.data[i+1] == 'E' && l.data[i+2] == 'I' &&
+			(i+3 == len(l.data) || isDelimiter(l.data[i+3])) {
+			l.pos = i + 3
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
+
+func (l *Lexer) read(refs bool) (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, ErrEOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return Name(unescapeName(l.data[start:l.pos])), nil
+	case c == '(':
+		return l.literalString()
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		dict := Dict{}
+		for {
+			l.skipSpace()
+			if l.pos+1 < len(l.data) && l.data[l.pos] == '>' && l.data[l.pos+1] == '>' {
+				l.pos += 2
+				return dict, nil
+			}
+			key, err := l.read(refs)
+			if err != nil {
+				return nil, err
+			}
+			value, err := l.read(refs)
+			if err != nil {
+				return nil, err
+			}
+			if name, ok := key.(Name); ok {
+				dict[name] = value
+			}
+		}
+	case c == '<':
+		l.pos++
+		end := bytes.IndexByte(l.data[l.pos:], '>')
+		if end < 0 {
+			return nil, ErrEOF
+		}
+		s, err := hexDecode(l.data[l.pos : l.pos+end])
+		l.pos += end + 1
+		return String(s), err
+	case c == '[':
+		l.pos++
+		arr := Array{}
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return nil, ErrEOF
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return arr, nil
+			}
+			v, err := l.read(refs)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return Keyword(c), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	token := string(l.data[start:l.pos])
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return Keyword(token), nil
+	}
+
+	// an integer followed by a generation number and R is a reference
+	if refs && float64(int(f)) == f {
+		save := l.pos
+		l.skipSpace()
+		genStart := l.pos
+		for l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' {
+			l.pos++
+		}
+		if l.pos > genStart {
+			gen, _ := strconv.Atoi(string(l.data[genStart:l.pos]))
+			l.skipSpace()
+			if l.pos < len(l.data) && l.data[l.pos] == 'R' && (l.pos+1 == len(l.data) || isDelimiter(l.data[l.pos+1])) {
+				l.pos++
+				return Ref{Num: int(f), Gen: gen}, nil
+			}
+		}
+		l.pos = save
+	}
+	return f, nil
+}
+
+func (l *Lexer) literalString() (interface{}, error) {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return String(out), nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return nil, ErrEOF
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return nil, ErrEOF
+}
+
+// stream reads the data of the stream following the dictionary, if there is one
+func (l *Lexer) stream(dict Dict) (*Stream, bool) {
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		return nil, false
+	}
+	start := l.pos + len("stream")
+	if start < len(l.data) && l.data[start] == '\r' {
+		start++
+	}
+	if start < len(l.data) && l.data[start] == '\n' {
+		start++
+	}
+
+	// the length may be an indirect object which hasn't been read yet, so look for the end
+	// of the stream if the length doesn't fit
+	end := -1
+	if length, ok := dict["Length"].(float64); ok && length >= 0 && start+int(length) <= len(l.data) {
+		end = start + int(length)
+		if !bytes.HasPrefix(bytes.TrimLeft(l.data[end:], "\r\n "), []byte("endstream")) {
+			end = -1
+		}
+	}
+	if end < 0 {
+		i := bytes.Index(l.data[start:], []byte("endstream"))
+		if i < 0 {
+			return nil, false
+		}
+		end = start + i
+	}
+	l.pos = end
+	if i := bytes.Index(l.data[end:], []byte("endstream")); i >= 0 {
+		l.pos = end + i + len("endstream")
+	}
+	return &Stream{Dict: dict, Raw: l.data[start:end]}, true
+}
+
+func unescapeName(b []byte) string {
+	if bytes.IndexByte(b, '#') < 0 {
+		return string(b)
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
@@ -0,0 +1,27 @@
+// Package pdf reads the objects and content streams of PDF documents. It is shared by the
+// services which look into PDF documents, the thumbnails service renders their first page and
+// the search service extracts their text.
+package pdf
+
+import "errors"
+
+// The PDF objects are represented by these types, numbers by float64, booleans by bool and null by nil
+type (
+	Name    string
+	String  []byte
+	Keyword string
+	Array   []interface{}
+	Dict    map[Name]interface{}
+	Ref     struct{ Num, Gen int }
+	Stream  struct {
+		Dict Dict
+		Raw  []byte
+	}
+)
+
+var (
+	// ErrEOF is returned by the Lexer when the data ends within an object
+	ErrEOF = errors.New(`unexpected end of the pdf data`)
+	// ErrDecodeLimit is returned by Decode when the decompressed data of the document exceeds the limit
+	ErrDecodeLimit = errors.New(`the decompressed pdf data exceeds the limit`)
+)
@@ -0,0 +1,84 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pdfFile assembles a PDF file from the objects, numbering them from 1
+func pdfFile(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, o := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func streamObject(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func TestParse(t *testing.T) {
+	d, err := Parse(pdfFile(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 200 100] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [4 0 R 5 0 R] >>",
+		streamObject("", []byte("BT (Hello) Tj ET")),
+		streamObject("/Filter /ASCIIHexDecode", []byte("42 54 20 28 77 6f 72 6c 64 29 20 54 6a 20 45 54>")),
+	), 1<<20)
+	assert.NoError(t, err)
+	assert.Equal(t, Name("Catalog"), d.Name(d.Catalog()["Type"]))
+	assert.Len(t, d.Streams(), 2)
+
+	page, err := d.FirstPage()
+	assert.NoError(t, err)
+	assert.Equal(t, Array{0.0, 0.0, 200.0, 100.0}, d.Array(page["MediaBox"]), "the page inherits the media box")
+
+	content, err := d.Content(page)
+	assert.NoError(t, err)
+	assert.Equal(t, "BT (Hello) Tj ET\nBT (world) Tj ET\n", string(content))
+
+	l := NewLexer(content)
+	var objects []interface{}
+	for obj, err := l.Next(); err == nil; obj, err = l.Next() {
+		objects = append(objects, obj)
+	}
+	assert.Equal(t, []interface{}{Keyword("BT"), String("Hello"), Keyword("Tj"), Keyword("ET"), Keyword("BT"), String("world"), Keyword("Tj"), Keyword("ET")}, objects)
+
+	_, err = Parse([]byte("no pdf"), 1<<20)
+	assert.Error(t, err)
+	_, err = Parse(pdfFile("<< /Type /Catalog /Encrypt 2 0 R >>"), 1<<20)
+	assert.Error(t, err)
+}
+
+func TestDecodeLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write(make([]byte, 600<<10))
+	_ = zw.Close()
+
+	d, err := Parse(pdfFile(
+		streamObject("/Filter /FlateDecode", compressed.Bytes()),
+		streamObject("/Filter [/FlateDecode /FlateDecode]", compressed.Bytes()),
+	), 1<<20)
+	assert.NoError(t, err)
+	streams := d.Streams()
+
+	// the limit applies to all streams of the document together
+	data, err := d.Decode(streams[0])
+	assert.NoError(t, err)
+	assert.Len(t, data, 600<<10)
+	data, err = d.Decode(streams[0])
+	assert.NoError(t, err)
+	assert.Len(t, data, 424<<10)
+	_, err = d.Decode(streams[0])
+	assert.ErrorIs(t, err, ErrDecodeLimit)
+	_, err = d.Decode(streams[1])
+	assert.Error(t, err)
+}
@@ -0,0 +1,80 @@
+// Package tags handles the tags of resources. Tags are stored as a comma separated list in
+// the arbitrary metadata of the resources.
+package tags
+
+import (
+	"errors"
+	"strings"
+)
+
+// MetadataKey is the key of the tags in the arbitrary metadata of a resource
+const MetadataKey = "tags"
+
+// Parse returns the tags of the comma separated list
+func Parse(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// Format returns the tags as a comma separated list
+func Format(tags []string) string {
+	return strings.Join(tags, ",")
+}
+
+// Validate checks that the tags can be stored
+func Validate(tags []string) error {
+	if len(tags) == 0 {
+		return errors.New("missing tags")
+	}
+	for _, t := range tags {
+		switch {
+		case strings.TrimSpace(t) == "":
+			return errors.New("tags must not be empty")
+		case strings.Contains(t, ","):
+			return errors.New("tags must not contain ','")
+		}
+	}
+	return nil
+}
+
+// Add returns the tags with the added tags appended. Tags are compared case insensitively,
+// tags that are already present are not added again. The boolean reports whether any tag
+// was added.
+func Add(tags []string, added []string) ([]string, bool) {
+	changed := false
+	for _, t := range added {
+		t = strings.TrimSpace(t)
+		if t == "" || contains(tags, t) {
+			continue
+		}
+		tags = append(tags, t)
+		changed = true
+	}
+	return tags, changed
+}
+
+// Remove returns the tags without the removed tags. Tags are compared case insensitively.
+// The boolean reports whether any tag was removed.
+func Remove(tags []string, removed []string) ([]string, bool) {
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if !contains(removed, t) {
+			result = append(result, t)
+		}
+	}
+	return result, len(result) != len(tags)
+}
+
+func contains(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
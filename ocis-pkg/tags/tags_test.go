@@ -0,0 +1,40 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	assert.Nil(t, Parse(""))
+	assert.Equal(t, []string{"invoice", "2026", "to do"}, Parse("invoice, 2026,,to do "))
+	assert.Equal(t, "invoice,2026", Format(Parse("invoice,2026")))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]string{"invoice", "to do"}))
+	assert.Error(t, Validate(nil))
+	assert.Error(t, Validate([]string{"invoice", " "}))
+	assert.Error(t, Validate([]string{"a,b"}))
+}
+
+func TestAdd(t *testing.T) {
+	tags, changed := Add([]string{"invoice"}, []string{"Invoice", "2026", "2026"})
+	assert.True(t, changed)
+	assert.Equal(t, []string{"invoice", "2026"}, tags)
+
+	tags, changed = Add(tags, []string{"INVOICE"})
+	assert.False(t, changed)
+	assert.Equal(t, []string{"invoice", "2026"}, tags)
+}
+
+func TestRemove(t *testing.T) {
+	tags, changed := Remove([]string{"invoice", "2026", "draft"}, []string{"Draft", "missing"})
+	assert.True(t, changed)
+	assert.Equal(t, []string{"invoice", "2026"}, tags)
+
+	tags, changed = Remove(tags, []string{"draft"})
+	assert.False(t, changed)
+	assert.Equal(t, []string{"invoice", "2026"}, tags)
+}
@@ -75,6 +75,32 @@ func (h *webSearchProviderHandler) IndexSpace(w http.ResponseWriter, r *http.Req
 	render.JSON(w, r, resp)
 }
 
+func (h *webSearchProviderHandler) GetIndexStatus(w http.ResponseWriter, r *http.Request) {
+	req := &GetIndexStatusRequest{}
+	resp := &GetIndexStatusResponse{}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err := h.h.GetIndexStatus(
+		r.Context(),
+		req,
+		resp,
+	); err != nil {
+		if merr, ok := merrors.As(err); ok && merr.Code == http.StatusNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, resp)
+}
+
 func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middlewares ...func(http.Handler) http.Handler) {
 	handler := &webSearchProviderHandler{
 		r: r,
@@ -83,6 +109,7 @@ func RegisterSearchProviderWeb(r chi.Router, i SearchProviderHandler, middleware
 
 	r.MethodFunc("POST", "/api/v0/search/search", handler.Search)
 	r.MethodFunc("POST", "/api/v0/search/index-space", handler.IndexSpace)
+	r.MethodFunc("POST", "/api/v0/search/index-status", handler.GetIndexStatus)
 }
 
 type webIndexProviderHandler struct {
@@ -344,3 +371,75 @@ func (m *IndexSpaceResponse) UnmarshalJSON(b []byte) error {
 }
 
 var _ json.Unmarshaler = (*IndexSpaceResponse)(nil)
+
+// GetIndexStatusRequestJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetIndexStatusRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetIndexStatusRequestJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetIndexStatusRequest) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetIndexStatusRequestJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetIndexStatusRequest)(nil)
+
+// GetIndexStatusRequestJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetIndexStatusRequest. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetIndexStatusRequestJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetIndexStatusRequest) UnmarshalJSON(b []byte) error {
+	return GetIndexStatusRequestJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetIndexStatusRequest)(nil)
+
+// GetIndexStatusResponseJSONMarshaler describes the default jsonpb.Marshaler used by all
+// instances of GetIndexStatusResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetIndexStatusResponseJSONMarshaler = new(jsonpb.Marshaler)
+
+// MarshalJSON satisfies the encoding/json Marshaler interface. This method
+// uses the more correct jsonpb package to correctly marshal the message.
+func (m *GetIndexStatusResponse) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return json.Marshal(nil)
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err := GetIndexStatusResponseJSONMarshaler.Marshal(buf, m); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var _ json.Marshaler = (*GetIndexStatusResponse)(nil)
+
+// GetIndexStatusResponseJSONUnmarshaler describes the default jsonpb.Unmarshaler used by all
+// instances of GetIndexStatusResponse. This struct is safe to replace or modify but
+// should not be done so concurrently.
+var GetIndexStatusResponseJSONUnmarshaler = new(jsonpb.Unmarshaler)
+
+// UnmarshalJSON satisfies the encoding/json Unmarshaler interface. This method
+// uses the more correct jsonpb package to correctly unmarshal the message.
+func (m *GetIndexStatusResponse) UnmarshalJSON(b []byte) error {
+	return GetIndexStatusResponseJSONUnmarshaler.Unmarshal(bytes.NewReader(b), m)
+}
+
+var _ json.Unmarshaler = (*GetIndexStatusResponse)(nil)
@@ -18,33 +18,48 @@ type Config struct {
 	Debug      Debug       `yaml:"debug"`
 
 	HTTP HTTP `yaml:"http"`
+	API  API  `yaml:"api"`
 
 	Reva         *Reva         `yaml:"reva"`
 	TokenManager *TokenManager `yaml:"token_manager"`
 
+	MachineAuthAPIKey string `mask:"password" yaml:"machine_auth_api_key" env:"OCIS_MACHINE_AUTH_API_KEY;GRAPH_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to validate internal requests necessary for the access to resources from other services. Required when the SCIM endpoint is enabled."`
+
 	Spaces   Spaces   `yaml:"spaces"`
 	Identity Identity `yaml:"identity"`
 	Events   Events   `yaml:"events"`
+	SCIM     SCIM     `yaml:"scim"`
 
 	Context context.Context `yaml:"-"`
 }
 
+// API combines the configuration options for the graph API.
+type API struct {
+	MaxBatchSize int `yaml:"max_batch_size" env:"GRAPH_API_MAX_BATCH_SIZE" desc:"The maximum number of requests in a single JSON batch sent to the '$batch' endpoint."`
+	MaxSkip      int `yaml:"max_skip" env:"GRAPH_API_MAX_SKIP" desc:"The maximum value of the '$skip' query parameter. Collections read from LDAP are searched from the start for every page, so the cost of a page grows with the number of entries skipped. Set to 0 to allow any value."`
+}
+
 type Spaces struct {
 	WebDavBase                      string `yaml:"webdav_base" env:"OCIS_URL;GRAPH_SPACES_WEBDAV_BASE" desc:"The public facing URL of WebDAV."`
 	WebDavPath                      string `yaml:"webdav_path" env:"GRAPH_SPACES_WEBDAV_PATH" desc:"The WebDAV subpath for spaces."`
 	DefaultQuota                    string `yaml:"default_quota" env:"GRAPH_SPACES_DEFAULT_QUOTA" desc:"The default quota in bytes."`
 	ExtendedSpacePropertiesCacheTTL int    `yaml:"extended_space_properties_cache_ttl" env:"GRAPH_SPACES_EXTENDED_SPACE_PROPERTIES_CACHE_TTL" desc:"Max TTL in seconds for the spaces property cache."`
+	Insecure                        bool   `yaml:"insecure" env:"OCIS_INSECURE;GRAPH_SPACES_INSECURE" desc:"Disable TLS certificate validation for the connections to the data gateway when copying drive items. Do not set this in production environments."`
 }
 
 type LDAP struct {
-	URI                string `yaml:"uri" env:"LDAP_URI;GRAPH_LDAP_URI" desc:"URI of the LDAP Server to connect to. Supported URI schemes are 'ldaps://' and 'ldap://'"`
-	CACert             string `yaml:"cacert" env:"LDAP_CACERT;GRAPH_LDAP_CACERT" desc:"The certificate to verify TLS connections."`
-	Insecure           bool   `yaml:"insecure" env:"LDAP_INSECURE;GRAPH_LDAP_INSECURE" desc:"Disable TLS certificate validation for the LDAP connections. Do not set this in production environments."`
-	BindDN             string `yaml:"bind_dn" env:"LDAP_BIND_DN;GRAPH_LDAP_BIND_DN" desc:"LDAP DN to use for simple bind authentication with the target LDAP server."`
-	BindPassword       string `yaml:"bind_password" env:"LDAP_BIND_PASSWORD;GRAPH_LDAP_BIND_PASSWORD" desc:"Password to use for authenticating the 'bind_dn'."`
-	UseServerUUID      bool   `yaml:"use_server_uuid" env:"GRAPH_LDAP_SERVER_UUID" desc:"If set to true, rely on the LDAP Server to generate a unique ID for users and groups, like when using 'entryUUID' as the user ID attribute."`
-	UsePasswordModExOp bool   `yaml:"use_password_modify_exop" env:"GRAPH_LDAP_SERVER_USE_PASSWORD_MODIFY_EXOP" desc:"User the Password Modify Extended Operation for updating user passwords."`
-	WriteEnabled       bool   `yaml:"write_enabled" env:"GRAPH_LDAP_SERVER_WRITE_ENABLED" desc:"Allow to create, modify and delete LDAP users via GRAPH API. This is only works when the default Schema is used."`
+	URI                 string `yaml:"uri" env:"LDAP_URI;GRAPH_LDAP_URI" desc:"URI of the LDAP Server to connect to. Supported URI schemes are 'ldaps://' and 'ldap://'"`
+	CACert              string `yaml:"cacert" env:"LDAP_CACERT;GRAPH_LDAP_CACERT" desc:"The certificate to verify TLS connections."`
+	Insecure            bool   `yaml:"insecure" env:"LDAP_INSECURE;GRAPH_LDAP_INSECURE" desc:"Disable TLS certificate validation for the LDAP connections. Do not set this in production environments."`
+	BindDN              string `yaml:"bind_dn" env:"LDAP_BIND_DN;GRAPH_LDAP_BIND_DN" desc:"LDAP DN to use for simple bind authentication with the target LDAP server."`
+	BindPassword        string `yaml:"bind_password" env:"LDAP_BIND_PASSWORD;GRAPH_LDAP_BIND_PASSWORD" desc:"Password to use for authenticating the 'bind_dn'."`
+	UseServerUUID       bool   `yaml:"use_server_uuid" env:"GRAPH_LDAP_SERVER_UUID" desc:"If set to true, rely on the LDAP Server to generate a unique ID for users and groups, like when using 'entryUUID' as the user ID attribute."`
+	UsePasswordModExOp  bool   `yaml:"use_password_modify_exop" env:"GRAPH_LDAP_SERVER_USE_PASSWORD_MODIFY_EXOP" desc:"User the Password Modify Extended Operation for updating user passwords."`
+	WriteEnabled        bool   `yaml:"write_enabled" env:"GRAPH_LDAP_SERVER_WRITE_ENABLED" desc:"Allow to create, modify and delete LDAP users via GRAPH API. This is only works when the default Schema is used."`
+	PageSize            uint32 `yaml:"page_size" env:"GRAPH_LDAP_PAGE_SIZE" desc:"The number of entries to request per page when listing users and groups using the LDAP paged results control. Set to 0 to disable paged searches for LDAP servers not supporting the control."`
+	PoolSize            int    `yaml:"pool_size" env:"GRAPH_LDAP_POOL_SIZE" desc:"The maximum number of connections to the LDAP server. Requests wait for a free connection when all connections are in use."`
+	HealthCheckInterval int    `yaml:"health_check_interval" env:"GRAPH_LDAP_HEALTH_CHECK_INTERVAL" desc:"Interval in seconds after which idle connections to the LDAP server are checked before they are reused. Set to 0 to disable the checks."`
+	CacheTTL            int    `yaml:"cache_ttl" env:"GRAPH_LDAP_CACHE_TTL" desc:"TTL in seconds for the cache of LDAP users, groups and group memberships. Changes made through the graph service invalidate the cache, changes made directly in the LDAP server show up after the TTL. Set to 0 to disable the cache."`
 
 	UserBaseDN               string `yaml:"user_base_dn" env:"LDAP_USER_BASE_DN;GRAPH_LDAP_USER_BASE_DN" desc:"Search base DN for looking up LDAP users."`
 	UserSearchScope          string `yaml:"user_search_scope" env:"LDAP_USER_SCOPE;GRAPH_LDAP_USER_SCOPE" desc:"LDAP search scope to use when looking up users. Supported scopes are 'base', 'one' and 'sub'."`
@@ -54,6 +69,9 @@ type LDAP struct {
 	UserDisplayNameAttribute string `yaml:"user_displayname_attribute" env:"LDAP_USER_SCHEMA_DISPLAY_NAME;GRAPH_LDAP_USER_DISPLAYNAME_ATTRIBUTE" desc:"LDAP Attribute to use for the displayname of users."`
 	UserNameAttribute        string `yaml:"user_name_attribute" env:"LDAP_USER_SCHEMA_USERNAME;GRAPH_LDAP_USER_NAME_ATTRIBUTE" desc:"LDAP Attribute to use for username of users."`
 	UserIDAttribute          string `yaml:"user_id_attribute" env:"LDAP_USER_SCHEMA_ID;GRAPH_LDAP_USER_UID_ATTRIBUTE" desc:"LDAP Attribute to use as the unique ID for users. This should be a stable globally unique ID like a UUID."`
+	UserSurnameAttribute     string `yaml:"user_surname_attribute" env:"GRAPH_LDAP_USER_SURNAME_ATTRIBUTE" desc:"LDAP Attribute to use for the surname of users. Leave empty if the directory does not store surnames."`
+	UserEnabledAttribute     string `yaml:"user_enabled_attribute" env:"GRAPH_LDAP_USER_ENABLED_ATTRIBUTE" desc:"LDAP Attribute to use as a flag telling if the user account is enabled. It stores 'TRUE' or 'FALSE' and is only used when GRAPH_LDAP_DISABLE_USER_MECHANISM is set to 'attribute'. The default 'ownCloudUserEnabled' is part of the ownCloud LDAP schema."`
+	DisableUserMechanism     string `yaml:"disable_user_mechanism" env:"GRAPH_LDAP_DISABLE_USER_MECHANISM" desc:"The mechanism to disable user accounts. Supported mechanisms are 'none', 'attribute' to store the state in the attribute set with GRAPH_LDAP_USER_ENABLED_ATTRIBUTE and 'user_account_control' to set the ACCOUNTDISABLE flag of the Active Directory 'userAccountControl' attribute."`
 
 	GroupBaseDN        string `yaml:"group_base_dn" env:"LDAP_GROUP_BASE_DN;GRAPH_LDAP_GROUP_BASE_DN" desc:"Search base DN for looking up LDAP groups."`
 	GroupSearchScope   string `yaml:"group_search_scope" env:"LDAP_GROUP_SCOPE;GRAPH_LDAP_GROUP_SEARCH_SCOPE" desc:"LDAP search scope to use when looking up groups. Supported scopes are 'base', 'one' and 'sub'."`
@@ -61,6 +79,9 @@ type LDAP struct {
 	GroupObjectClass   string `yaml:"group_objectclass" env:"LDAP_GROUP_OBJECTCLASS;GRAPH_LDAP_GROUP_OBJECTCLASS" desc:"The object class to use for groups in the default group search filter ('groupOfNames'). "`
 	GroupNameAttribute string `yaml:"group_name_attribute" env:"LDAP_GROUP_SCHEMA_GROUPNAME;GRAPH_LDAP_GROUP_NAME_ATTRIBUTE" desc:"LDAP Attribute to use for the name of groups."`
 	GroupIDAttribute   string `yaml:"group_id_attribute" env:"LDAP_GROUP_SCHEMA_ID;GRAPH_LDAP_GROUP_ID_ATTRIBUTE" desc:"LDAP Attribute to use as the unique id for groups. This should be a stable globally unique ID like a UUID."`
+
+	NestedGroups         bool `yaml:"nested_groups" env:"GRAPH_LDAP_NESTED_GROUPS" desc:"Resolve nested group memberships. Members of a group that is a member of another group are then also members of the outer group. Uses LDAP_MATCHING_RULE_IN_CHAIN on Active Directory servers and resolves the memberships level by level on other servers."`
+	NestedGroupsMaxDepth int  `yaml:"nested_groups_max_depth" env:"GRAPH_LDAP_NESTED_GROUPS_MAX_DEPTH" desc:"The maximum number of nested group levels that are resolved on servers without support for LDAP_MATCHING_RULE_IN_CHAIN."`
 }
 
 type Identity struct {
@@ -75,3 +96,11 @@ type Events struct {
 	TLSInsecure          bool   `yaml:"tls_insecure" env:"OCIS_INSECURE;GRAPH_EVENTS_TLS_INSECURE" desc:"Whether to verify the server TLS certificates."`
 	TLSRootCACertificate string `yaml:"tls_root_ca_certificate" env:"GRAPH_EVENTS_TLS_ROOT_CA_CERTIFICATE" desc:"The root CA certificate used to validate the server's TLS certificate. If provided GRAPH_EVENTS_TLS_INSECURE will be seen as false."`
 }
+
+// SCIM combines the configuration options for the SCIM provisioning endpoint.
+type SCIM struct {
+	Enabled bool   `yaml:"enabled" env:"GRAPH_SCIM_ENABLED" desc:"Enable the SCIM 2.0 endpoint at '/scim/v2' to provision users and groups from external systems like HR software. It requires a writable identity backend."`
+	Token   string `yaml:"token" env:"GRAPH_SCIM_TOKEN" desc:"The bearer token SCIM clients have to send in the 'Authorization' header. Required when the SCIM endpoint is enabled."`
+	// the SCIM endpoint has no user of its own to delete the personal spaces of deleted users
+	AdminUserID string `yaml:"admin_user_id" env:"OCIS_ADMIN_USER_ID;GRAPH_SCIM_ADMIN_USER_ID" desc:"ID of the admin user the SCIM endpoint acts as when it deletes the personal spaces of deleted users. Required when the SCIM endpoint is enabled."`
+}
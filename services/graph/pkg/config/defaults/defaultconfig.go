@@ -26,6 +26,10 @@ func DefaultConfig() *config.Config {
 			Namespace: "com.owncloud.graph",
 			Root:      "/graph",
 		},
+		API: config.API{
+			MaxBatchSize: 20,
+			MaxSkip:      10000,
+		},
 		Service: config.Service{
 			Name: "graph",
 		},
@@ -47,6 +51,10 @@ func DefaultConfig() *config.Config {
 				UseServerUUID:            false,
 				UsePasswordModExOp:       true,
 				WriteEnabled:             true,
+				PageSize:                 500,
+				PoolSize:                 10,
+				HealthCheckInterval:      30,
+				CacheTTL:                 10,
 				UserBaseDN:               "ou=users,o=libregraph-idm",
 				UserSearchScope:          "sub",
 				UserFilter:               "",
@@ -56,13 +64,18 @@ func DefaultConfig() *config.Config {
 				UserNameAttribute:        "uid",
 				// FIXME: switch this to some more widely available attribute by default
 				//        ideally this needs to	be constant for the lifetime of a users
-				UserIDAttribute:    "owncloudUUID",
-				GroupBaseDN:        "ou=groups,o=libregraph-idm",
-				GroupSearchScope:   "sub",
-				GroupFilter:        "",
-				GroupObjectClass:   "groupOfNames",
-				GroupNameAttribute: "cn",
-				GroupIDAttribute:   "owncloudUUID",
+				UserIDAttribute:      "owncloudUUID",
+				UserSurnameAttribute: "sn",
+				UserEnabledAttribute: "ownCloudUserEnabled",
+				DisableUserMechanism: "attribute",
+				GroupBaseDN:          "ou=groups,o=libregraph-idm",
+				GroupSearchScope:     "sub",
+				GroupFilter:          "",
+				GroupObjectClass:     "groupOfNames",
+				GroupNameAttribute:   "cn",
+				GroupIDAttribute:     "owncloudUUID",
+				NestedGroups:         false,
+				NestedGroupsMaxDepth: 10,
 			},
 		},
 		Events: config.Events{
@@ -106,6 +119,14 @@ func EnsureDefaults(cfg *config.Config) {
 		cfg.CacheStore = &config.CacheStore{}
 	}
 
+	if cfg.MachineAuthAPIKey == "" && cfg.Commons != nil && cfg.Commons.MachineAuthAPIKey != "" {
+		cfg.MachineAuthAPIKey = cfg.Commons.MachineAuthAPIKey
+	}
+
+	if cfg.SCIM.AdminUserID == "" && cfg.Commons != nil {
+		cfg.SCIM.AdminUserID = cfg.Commons.AdminUserID
+	}
+
 	if cfg.TokenManager == nil && cfg.Commons != nil && cfg.Commons.TokenManager != nil {
 		cfg.TokenManager = &config.TokenManager{
 			JWTSecret: cfg.Commons.TokenManager.JWTSecret,
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	ociscfg "github.com/owncloud/ocis/v2/ocis-pkg/config"
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
@@ -42,5 +43,17 @@ func Validate(cfg *config.Config) error {
 		return shared.MissingLDAPBindPassword(cfg.Service.Name)
 	}
 
+	if cfg.SCIM.Enabled && cfg.SCIM.Token == "" {
+		return fmt.Errorf("The SCIM token has not been set for %s. Set it with GRAPH_SCIM_TOKEN or disable the SCIM endpoint.", cfg.Service.Name)
+	}
+
+	if cfg.SCIM.Enabled && cfg.MachineAuthAPIKey == "" {
+		return shared.MissingMachineAuthApiKeyError(cfg.Service.Name)
+	}
+
+	if cfg.SCIM.Enabled && cfg.SCIM.AdminUserID == "" {
+		return shared.MissingAdminUserID(cfg.Service.Name)
+	}
+
 	return nil
 }
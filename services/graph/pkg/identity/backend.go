@@ -3,6 +3,7 @@ package identity
 import (
 	"context"
 	"net/url"
+	"strconv"
 
 	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	libregraph "github.com/owncloud/libre-graph-api-go"
@@ -16,15 +17,32 @@ type Backend interface {
 	// UpdateUser applies changes to given user, identified by username or id
 	UpdateUser(ctx context.Context, nameOrID string, user libregraph.User) (*libregraph.User, error)
 	GetUser(ctx context.Context, nameOrID string, queryParam url.Values) (*libregraph.User, error)
+	// GetUsers lists the users matching the query. Backends may stop after the first
+	// $skip+$top+1 users, the caller applies the paging.
 	GetUsers(ctx context.Context, queryParam url.Values) ([]*libregraph.User, error)
+	// GetAccountEnabled returns whether the account of a user, identified by username or id,
+	// is enabled
+	GetAccountEnabled(ctx context.Context, nameOrID string) (bool, error)
+	// GetAccountsEnabled returns whether the accounts of the users, identified by id, are
+	// enabled. Users that can't be found are left out.
+	GetAccountsEnabled(ctx context.Context, ids []string) (map[string]bool, error)
+	// SetAccountEnabled enables or disables the account of a user, identified by username or id
+	SetAccountEnabled(ctx context.Context, nameOrID string, enabled bool) error
 
 	// CreateGroup creates the supplied group in the identity backend.
 	CreateGroup(ctx context.Context, group libregraph.Group) (*libregraph.Group, error)
 	// DeleteGroup deletes a given group, identified by id
 	DeleteGroup(ctx context.Context, id string) error
 	GetGroup(ctx context.Context, nameOrID string, queryParam url.Values) (*libregraph.Group, error)
+	// GetGroups lists the groups matching the query. Backends may stop after the first
+	// $skip+$top+1 groups, the caller applies the paging.
 	GetGroups(ctx context.Context, queryParam url.Values) ([]*libregraph.Group, error)
-	GetGroupMembers(ctx context.Context, id string) ([]*libregraph.User, error)
+	// GetGroupMembers lists the members of a group. Like GetUsers, backends may stop after
+	// the first $skip+$top+1 members.
+	GetGroupMembers(ctx context.Context, id string, queryParam url.Values) ([]*libregraph.User, error)
+	// GetDirectGroupMembers lists the members of a group without the members of nested
+	// groups. Changes of the members have to be based on them.
+	GetDirectGroupMembers(ctx context.Context, id string) ([]*libregraph.User, error)
 	// AddMembersToGroup adds new members (reference by a slice of IDs) to supplied group in the identity backend.
 	AddMembersToGroup(ctx context.Context, groupID string, memberID []string) error
 	// RemoveMemberFromGroup removes a single member (by ID) from a group
@@ -42,3 +60,22 @@ func CreateUserModelFromCS3(u *cs3.User) *libregraph.User {
 		Id:                       &u.Id.OpaqueId,
 	}
 }
+
+// resultLimit returns the number of entries a backend has to return for the page requested
+// by the $top and $skip query parameters. It includes one additional entry to tell whether
+// there is a next page. A limit of 0 means that the whole collection is needed, e.g. to sort
+// or to count it.
+func resultLimit(queryParam url.Values) int {
+	if queryParam.Get("$orderby") != "" || queryParam.Get("$count") == "true" {
+		return 0
+	}
+	top, err := strconv.Atoi(queryParam.Get("$top"))
+	if err != nil || top < 0 {
+		return 0
+	}
+	skip, err := strconv.Atoi(queryParam.Get("$skip"))
+	if err != nil || skip < 0 {
+		skip = 0
+	}
+	return skip + top + 1
+}
@@ -39,6 +39,26 @@ func (i *CS3) UpdateUser(ctx context.Context, nameOrID string, user libregraph.U
 	return nil, errNotImplemented
 }
 
+// GetAccountEnabled implements the Backend Interface. The CS3 backend can't disable users,
+// all accounts are enabled.
+func (i *CS3) GetAccountEnabled(ctx context.Context, nameOrID string) (bool, error) {
+	return true, nil
+}
+
+// GetAccountsEnabled implements the Backend Interface. All accounts are enabled.
+func (i *CS3) GetAccountsEnabled(ctx context.Context, ids []string) (map[string]bool, error) {
+	enabled := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		enabled[id] = true
+	}
+	return enabled, nil
+}
+
+// SetAccountEnabled implements the Backend Interface. It's currently not supported for the CS3 backend
+func (i *CS3) SetAccountEnabled(ctx context.Context, nameOrID string, enabled bool) error {
+	return errNotImplemented
+}
+
 func (i *CS3) GetUser(ctx context.Context, userID string, queryParam url.Values) (*libregraph.User, error) {
 	logger := i.Logger.SubloggerWithRequestID(ctx)
 	logger.Debug().Str("backend", "cs3").Msg("GetUser")
@@ -81,6 +101,18 @@ func (i *CS3) GetUsers(ctx context.Context, queryParam url.Values) ([]*libregrap
 		search = queryParam.Get("$search")
 	}
 
+	// the CS3 api can only search by prefix, any $filter is applied to the results
+	filter, err := parseFilter(ctx, queryParam)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		// evaluate the filter once up front to reject unsupported expressions before searching
+		if _, err := matchUser(filter, &libregraph.User{}); err != nil {
+			return nil, err
+		}
+	}
+
 	res, err := client.FindUsers(ctx, &cs3user.FindUsersRequest{
 		// FIXME presence match is currently not implemented, an empty search currently leads to
 		// Unwilling To Perform": Search Error: error parsing filter: (&(objectclass=posixAccount)(|(cn=*)(displayname=*)(mail=*))), error: Present filter match for cn not implemented
@@ -101,7 +133,13 @@ func (i *CS3) GetUsers(ctx context.Context, queryParam url.Values) ([]*libregrap
 	users := make([]*libregraph.User, 0, len(res.Users))
 
 	for _, user := range res.Users {
-		users = append(users, CreateUserModelFromCS3(user))
+		u := CreateUserModelFromCS3(user)
+		if filter != nil {
+			if match, _ := matchUser(filter, u); !match {
+				continue
+			}
+		}
+		users = append(users, u)
 	}
 
 	return users, nil
@@ -121,6 +159,18 @@ func (i *CS3) GetGroups(ctx context.Context, queryParam url.Values) ([]*libregra
 		search = queryParam.Get("$search")
 	}
 
+	// the CS3 api can only search by prefix, any $filter is applied to the results
+	filter, err := parseFilter(ctx, queryParam)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		// evaluate the filter once up front to reject unsupported expressions before searching
+		if _, err := matchGroup(filter, &libregraph.Group{}); err != nil {
+			return nil, err
+		}
+	}
+
 	res, err := client.FindGroups(ctx, &cs3group.FindGroupsRequest{
 		// FIXME presence match is currently not implemented, an empty search currently leads to
 		// Unwilling To Perform": Search Error: error parsing filter: (&(objectclass=posixAccount)(|(cn=*)(displayname=*)(mail=*))), error: Present filter match for cn not implemented
@@ -142,7 +192,13 @@ func (i *CS3) GetGroups(ctx context.Context, queryParam url.Values) ([]*libregra
 	groups := make([]*libregraph.Group, 0, len(res.Groups))
 
 	for _, group := range res.Groups {
-		groups = append(groups, createGroupModelFromCS3(group))
+		g := createGroupModelFromCS3(group)
+		if filter != nil {
+			if match, _ := matchGroup(filter, g); !match {
+				continue
+			}
+		}
+		groups = append(groups, g)
 	}
 
 	return groups, nil
@@ -188,7 +244,12 @@ func (i *CS3) DeleteGroup(ctx context.Context, id string) error {
 }
 
 // GetGroupMembers implements the Backend Interface. It's currently not supported for the CS3 backend
-func (i *CS3) GetGroupMembers(ctx context.Context, groupID string) ([]*libregraph.User, error) {
+func (i *CS3) GetGroupMembers(ctx context.Context, groupID string, queryParam url.Values) ([]*libregraph.User, error) {
+	return nil, errorcode.New(errorcode.NotSupported, "not implemented")
+}
+
+// GetDirectGroupMembers implements the Backend Interface. It's currently not supported for the CS3 backend
+func (i *CS3) GetDirectGroupMembers(ctx context.Context, groupID string) ([]*libregraph.User, error) {
 	return nil, errorcode.New(errorcode.NotSupported, "not implemented")
 }
 
@@ -0,0 +1,145 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/CiscoM31/godata"
+	libregraph "github.com/owncloud/libre-graph-api-go"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+)
+
+// parseFilter returns the parse tree of the $filter query parameter, or nil if the
+// request has no filter.
+func parseFilter(ctx context.Context, queryParam url.Values) (*godata.ParseNode, error) {
+	filter := queryParam.Get("$filter")
+	if filter == "" {
+		return nil, nil
+	}
+	q, err := godata.ParseFilterString(ctx, filter)
+	if err != nil {
+		return nil, errorcode.New(errorcode.InvalidRequest, err.Error())
+	}
+	return q.Tree, nil
+}
+
+func unsupportedFilter(node *godata.ParseNode) error {
+	return errorcode.New(errorcode.InvalidRequest, fmt.Sprintf("unsupported filter expression '%s'", node.Token.Value))
+}
+
+// filterComparison returns the property and the string value of comparisons like
+// "displayName eq 'value'" and "startswith(mail,'value')". Inside of a lambda expression
+// the property has to be navigated from the lambda variable, e.g. "g/id eq 'value'".
+func filterComparison(node *godata.ParseNode, variable string) (string, string, error) {
+	if len(node.Children) != 2 {
+		return "", "", unsupportedFilter(node)
+	}
+	prop, value := node.Children[0], node.Children[1]
+
+	var property string
+	switch {
+	case variable == "" && prop.Token.Type == godata.ExpressionTokenLiteral:
+		property = prop.Token.Value
+	case variable != "" && prop.Token.Type == godata.ExpressionTokenNav &&
+		len(prop.Children) == 2 && prop.Children[0].Token.Value == variable:
+		property = prop.Children[1].Token.Value
+	default:
+		return "", "", unsupportedFilter(prop)
+	}
+
+	if value.Token.Type != godata.ExpressionTokenString {
+		return "", "", unsupportedFilter(value)
+	}
+	// godata already unescaped the quotes inside of the string, only the enclosing quotes are left
+	return property, strings.TrimSuffix(strings.TrimPrefix(value.Token.Value, "'"), "'"), nil
+}
+
+// filterLambda returns the variable and the expression of an "any" lambda on the
+// navigation property nav, e.g. "memberOf/any(g:g/id eq 'value')".
+func filterLambda(node *godata.ParseNode, nav string) (string, *godata.ParseNode, error) {
+	if len(node.Children) != 2 || node.Children[0].Token.Value != nav {
+		return "", nil, unsupportedFilter(node)
+	}
+	lambda := node.Children[1]
+	if lambda.Token.Value != "any" || len(lambda.Children) != 2 ||
+		lambda.Children[0].Token.Type != godata.ExpressionTokenLiteral {
+		return "", nil, unsupportedFilter(lambda)
+	}
+	return lambda.Children[0].Token.Value, lambda.Children[1], nil
+}
+
+// matchUser evaluates the filter against the properties of a user. It is used by backends
+// that can't filter on the server side. Lambda expressions are not supported.
+func matchUser(node *godata.ParseNode, u *libregraph.User) (bool, error) {
+	return matchFilter(node, map[string]*string{
+		"id":                       u.Id,
+		"displayName":              u.DisplayName,
+		"mail":                     u.Mail,
+		"onPremisesSamAccountName": u.OnPremisesSamAccountName,
+		"surname":                  u.Surname,
+	})
+}
+
+// matchGroup evaluates the filter against the properties of a group. Like matchUser, it
+// does not support lambda expressions.
+func matchGroup(node *godata.ParseNode, g *libregraph.Group) (bool, error) {
+	return matchFilter(node, map[string]*string{
+		"id":          g.Id,
+		"displayName": g.DisplayName,
+	})
+}
+
+// matchFilter evaluates the filter against the given property values. Like LDAP matching
+// rules for names and mail addresses, comparisons ignore the case. Both sides of "and" and
+// "or" are always evaluated, so that unsupported expressions are reported for every item.
+func matchFilter(node *godata.ParseNode, values map[string]*string) (bool, error) {
+	switch {
+	case node.Token.Type == godata.ExpressionTokenLogical && (node.Token.Value == "and" || node.Token.Value == "or"):
+		if len(node.Children) != 2 {
+			return false, unsupportedFilter(node)
+		}
+		left, err := matchFilter(node.Children[0], values)
+		if err != nil {
+			return false, err
+		}
+		right, err := matchFilter(node.Children[1], values)
+		if err != nil {
+			return false, err
+		}
+		if node.Token.Value == "and" {
+			return left && right, nil
+		}
+		return left || right, nil
+	case node.Token.Type == godata.ExpressionTokenLogical && node.Token.Value == "not":
+		if len(node.Children) != 1 {
+			return false, unsupportedFilter(node)
+		}
+		match, err := matchFilter(node.Children[0], values)
+		return !match, err
+	case node.Token.Type == godata.ExpressionTokenLogical && (node.Token.Value == "eq" || node.Token.Value == "ne"),
+		node.Token.Type == godata.ExpressionTokenFunc && node.Token.Value == "startswith":
+		property, value, err := filterComparison(node, "")
+		if err != nil {
+			return false, err
+		}
+		v, ok := values[property]
+		if !ok {
+			return false, errorcode.New(errorcode.InvalidRequest, fmt.Sprintf("unsupported filter property '%s'", property))
+		}
+		actual := ""
+		if v != nil {
+			actual = *v
+		}
+		switch node.Token.Value {
+		case "eq":
+			return strings.EqualFold(actual, value), nil
+		case "ne":
+			return !strings.EqualFold(actual, value), nil
+		default:
+			return strings.HasPrefix(strings.ToLower(actual), strings.ToLower(value)), nil
+		}
+	}
+	return false, unsupportedFilter(node)
+}
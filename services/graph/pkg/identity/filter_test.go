@@ -0,0 +1,46 @@
+package identity
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	libregraph "github.com/owncloud/libre-graph-api-go"
+)
+
+func TestMatchUser(t *testing.T) {
+	user := &libregraph.User{
+		Id:                       libregraph.PtrString("abcd-defg"),
+		DisplayName:              libregraph.PtrString("Albert Einstein"),
+		Mail:                     libregraph.PtrString("einstein@example.org"),
+		OnPremisesSamAccountName: libregraph.PtrString("einstein"),
+	}
+	tests := []struct {
+		filter string
+		match  bool
+		err    bool
+	}{
+		{filter: "onPremisesSamAccountName eq 'einstein'", match: true},
+		{filter: "displayName eq 'albert einstein'", match: true},
+		{filter: "startswith(mail,'EIN') and id ne 'abcd-defg'", match: false},
+		{filter: "startswith(mail,'marie') or not (id eq 'other')", match: true},
+		{filter: "memberOf/any(g:g/id eq 'abcd')", err: true},
+		{filter: "surname eq 'Einstein'", match: false},
+		{filter: "givenName eq 'Albert'", err: true},
+	}
+	for _, tt := range tests {
+		node, err := parseFilter(context.Background(), url.Values{"$filter": []string{tt.filter}})
+		if err != nil {
+			t.Fatalf("Expected '%s' to parse, got '%s'", tt.filter, err.Error())
+		}
+		match, err := matchUser(node, user)
+		switch {
+		case tt.err && (err == nil || err.Error() != "invalidRequest"):
+			t.Errorf("Expected 'invalidRequest' for '%s', got '%v'", tt.filter, err)
+		case !tt.err && err != nil:
+			t.Errorf("Expected success for '%s', got '%s'", tt.filter, err.Error())
+		case match != tt.match:
+			t.Errorf("Expected match to be %t for '%s'", tt.match, tt.filter)
+		}
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/CiscoM31/godata"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/gofrs/uuid"
 	ldapdn "github.com/libregraph/idm/pkg/ldapdn"
@@ -23,10 +26,38 @@ var (
 	errNotFound = errorcode.New(errorcode.ItemNotFound, "not found")
 )
 
+// Mechanisms to disable user accounts
+const (
+	// DisableUserMechanismNone doesn't support disabling user accounts
+	DisableUserMechanismNone = "none"
+	// DisableUserMechanismAttribute stores "TRUE" or "FALSE" in a boolean attribute of the user
+	DisableUserMechanismAttribute = "attribute"
+	// DisableUserMechanismUserAccountControl sets the ACCOUNTDISABLE flag of the Active
+	// Directory userAccountControl attribute
+	DisableUserMechanismUserAccountControl = "user_account_control"
+)
+
+const (
+	userAccountControlAttribute = "userAccountControl"
+	// userAccountControlDisabled is the ACCOUNTDISABLE flag of userAccountControl
+	userAccountControlDisabled = 0x2
+	// userAccountControlNormal is the NORMAL_ACCOUNT flag AD sets on user accounts
+	userAccountControlNormal = 0x200
+)
+
 type LDAP struct {
 	useServerUUID   bool
 	writeEnabled    bool
 	usePwModifyExOp bool
+	// disableUserMechanism is one of the DisableUserMechanism constants
+	disableUserMechanism string
+	pageSize             uint32
+	// nestedGroups enables resolving the members of groups that are members of other groups
+	nestedGroups         bool
+	nestedGroupsMaxDepth int
+	// matchingRuleInChain is set when the server supports LDAP_MATCHING_RULE_IN_CHAIN
+	matchingRuleInChain     bool
+	matchingRuleInChainOnce sync.Once
 
 	userBaseDN       string
 	userFilter       string
@@ -49,6 +80,23 @@ type userAttributeMap struct {
 	id          string
 	mail        string
 	userName    string
+	// surname is optional, it is empty when the directory doesn't store surnames
+	surname string
+	// enabled is the attribute storing whether the account is enabled, it is empty when
+	// disabling accounts is not supported
+	enabled string
+}
+
+// attributes returns the LDAP attributes to request when looking up users
+func (m userAttributeMap) attributes() []string {
+	attrs := []string{m.displayName, m.id, m.mail, m.userName}
+	if m.surname != "" {
+		attrs = append(attrs, m.surname)
+	}
+	if m.enabled != "" {
+		attrs = append(attrs, m.enabled)
+	}
+	return attrs
 }
 
 type groupAttributeMap struct {
@@ -68,6 +116,22 @@ func NewLDAPBackend(lc ldap.Client, config config.LDAP, logger *log.Logger) (*LD
 		id:          config.UserIDAttribute,
 		mail:        config.UserEmailAttribute,
 		userName:    config.UserNameAttribute,
+		surname:     config.UserSurnameAttribute,
+	}
+
+	disableUserMechanism := config.DisableUserMechanism
+	switch disableUserMechanism {
+	case "", DisableUserMechanismNone:
+		disableUserMechanism = DisableUserMechanismNone
+	case DisableUserMechanismAttribute:
+		if config.UserEnabledAttribute == "" {
+			return nil, errors.New("the user enabled attribute is required to disable users by attribute")
+		}
+		uam.enabled = config.UserEnabledAttribute
+	case DisableUserMechanismUserAccountControl:
+		uam.enabled = userAccountControlAttribute
+	default:
+		return nil, fmt.Errorf("unknown disable user mechanism '%s'", disableUserMechanism)
 	}
 
 	if config.GroupNameAttribute == "" || config.GroupIDAttribute == "" {
@@ -80,6 +144,10 @@ func NewLDAPBackend(lc ldap.Client, config config.LDAP, logger *log.Logger) (*LD
 		memberSyntax: "dn",
 	}
 
+	if config.NestedGroups && config.NestedGroupsMaxDepth < 1 {
+		return nil, errors.New("the maximum depth of nested groups has to be at least 1")
+	}
+
 	var userScope, groupScope int
 	var err error
 	if userScope, err = stringToScope(config.UserSearchScope); err != nil {
@@ -91,21 +159,25 @@ func NewLDAPBackend(lc ldap.Client, config config.LDAP, logger *log.Logger) (*LD
 	}
 
 	return &LDAP{
-		useServerUUID:     config.UseServerUUID,
-		usePwModifyExOp:   config.UsePasswordModExOp,
-		userBaseDN:        config.UserBaseDN,
-		userFilter:        config.UserFilter,
-		userObjectClass:   config.UserObjectClass,
-		userScope:         userScope,
-		userAttributeMap:  uam,
-		groupBaseDN:       config.GroupBaseDN,
-		groupFilter:       config.GroupFilter,
-		groupObjectClass:  config.GroupObjectClass,
-		groupScope:        groupScope,
-		groupAttributeMap: gam,
-		logger:            logger,
-		conn:              lc,
-		writeEnabled:      config.WriteEnabled,
+		useServerUUID:        config.UseServerUUID,
+		usePwModifyExOp:      config.UsePasswordModExOp,
+		userBaseDN:           config.UserBaseDN,
+		userFilter:           config.UserFilter,
+		userObjectClass:      config.UserObjectClass,
+		userScope:            userScope,
+		userAttributeMap:     uam,
+		groupBaseDN:          config.GroupBaseDN,
+		groupFilter:          config.GroupFilter,
+		groupObjectClass:     config.GroupObjectClass,
+		groupScope:           groupScope,
+		groupAttributeMap:    gam,
+		logger:               logger,
+		conn:                 lc,
+		writeEnabled:         config.WriteEnabled,
+		pageSize:             config.PageSize,
+		disableUserMechanism: disableUserMechanism,
+		nestedGroups:         config.NestedGroups,
+		nestedGroupsMaxDepth: config.NestedGroupsMaxDepth,
 	}, nil
 }
 
@@ -141,6 +213,19 @@ func (i *LDAP) CreateUser(ctx context.Context, user libregraph.User) (*libregrap
 		},
 	}
 
+	// inetOrgPerson requires "sn" to be set. Set it to the Username if
+	// Surname is not set in the Request
+	sn := user.GetSurname()
+	if sn == "" {
+		sn = *user.OnPremisesSamAccountName
+	}
+	if i.userAttributeMap.surname != "" && !strings.EqualFold(i.userAttributeMap.surname, "sn") {
+		if user.GetSurname() != "" {
+			ar.Attribute(i.userAttributeMap.surname, []string{user.GetSurname()})
+		}
+	}
+	ar.Attribute("sn", []string{sn})
+
 	objectClasses := []string{"inetOrgPerson", "organizationalPerson", "person", "top"}
 
 	if !i.usePwModifyExOp && user.PasswordProfile != nil && user.PasswordProfile.Password != nil {
@@ -155,16 +240,6 @@ func (i *LDAP) CreateUser(ctx context.Context, user libregraph.User) (*libregrap
 	}
 	ar.Attribute("objectClass", objectClasses)
 
-	// inetOrgPerson requires "sn" to be set. Set it to the Username if
-	// Surname is not set in the Request
-	var sn string
-	if user.Surname != nil && *user.Surname != "" {
-		sn = *user.Surname
-	} else {
-		sn = *user.OnPremisesSamAccountName
-	}
-	ar.Attribute("sn", []string{sn})
-
 	if err := i.conn.Add(&ar); err != nil {
 		var lerr *ldap.Error
 		logger.Debug().Err(err).Msg("error adding user")
@@ -268,6 +343,23 @@ func (i *LDAP) UpdateUser(ctx context.Context, nameOrID string, user libregraph.
 			updateNeeded = true
 		}
 	}
+	if user.Surname != nil && i.userAttributeMap.surname != "" {
+		userName := e.GetEqualFoldAttributeValue(i.userAttributeMap.userName)
+		switch {
+		case *user.Surname == "" && strings.EqualFold(i.userAttributeMap.surname, "sn"):
+			// inetOrgPerson requires "sn", reset it to the user name like CreateUser does
+			if e.GetEqualFoldAttributeValue(i.userAttributeMap.surname) != userName {
+				mr.Replace(i.userAttributeMap.surname, []string{userName})
+				updateNeeded = true
+			}
+		case *user.Surname == "" && e.GetEqualFoldAttributeValue(i.userAttributeMap.surname) != "":
+			mr.Delete(i.userAttributeMap.surname, nil)
+			updateNeeded = true
+		case *user.Surname != "" && e.GetEqualFoldAttributeValue(i.userAttributeMap.surname) != *user.Surname:
+			mr.Replace(i.userAttributeMap.surname, []string{*user.Surname})
+			updateNeeded = true
+		}
+	}
 	if user.PasswordProfile != nil && user.PasswordProfile.Password != nil && *user.PasswordProfile.Password != "" {
 		if i.usePwModifyExOp {
 			if err := i.updateUserPassowrd(ctx, e.DN, user.PasswordProfile.GetPassword()); err != nil {
@@ -295,14 +387,108 @@ func (i *LDAP) UpdateUser(ctx context.Context, nameOrID string, user libregraph.
 	return i.createUserModelFromLDAP(e), nil
 }
 
-func (i *LDAP) getUserByDN(dn string) (*ldap.Entry, error) {
-	attrs := []string{
-		i.userAttributeMap.displayName,
-		i.userAttributeMap.id,
-		i.userAttributeMap.mail,
-		i.userAttributeMap.userName,
+// GetAccountEnabled implements the Backend Interface for the LDAP Backend. Accounts are
+// always enabled when no mechanism to disable users is configured.
+func (i *LDAP) GetAccountEnabled(ctx context.Context, nameOrID string) (bool, error) {
+	logger := i.logger.SubloggerWithRequestID(ctx)
+	logger.Debug().Str("backend", "ldap").Msg("GetAccountEnabled")
+	e, err := i.getLDAPUserByNameOrID(nameOrID)
+	if err != nil {
+		return false, err
 	}
-	return i.getEntryByDN(dn, attrs)
+	return i.accountEnabled(e), nil
+}
+
+// GetAccountsEnabled implements the Backend Interface for the LDAP Backend. The status of
+// all users is read with a single search.
+func (i *LDAP) GetAccountsEnabled(ctx context.Context, ids []string) (map[string]bool, error) {
+	logger := i.logger.SubloggerWithRequestID(ctx)
+	logger.Debug().Str("backend", "ldap").Int("users", len(ids)).Msg("GetAccountsEnabled")
+	enabled := make(map[string]bool, len(ids))
+	if i.disableUserMechanism == DisableUserMechanismNone {
+		for _, id := range ids {
+			enabled[id] = true
+		}
+		return enabled, nil
+	}
+	if len(ids) == 0 {
+		return enabled, nil
+	}
+	var idFilter strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&idFilter, "(%s=%s)", i.userAttributeMap.id, ldap.EscapeFilter(id))
+	}
+	searchRequest := ldap.NewSearchRequest(
+		i.userBaseDN, i.userScope, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf("(&%s(objectClass=%s)(|%s))", i.userFilter, i.userObjectClass, idFilter.String()),
+		[]string{i.userAttributeMap.id, i.userAttributeMap.enabled},
+		nil,
+	)
+	entries, err := i.searchPaged(searchRequest, 0)
+	if err != nil {
+		return nil, errorcode.New(errorcode.GeneralException, err.Error())
+	}
+	for _, e := range entries {
+		enabled[e.GetEqualFoldAttributeValue(i.userAttributeMap.id)] = i.accountEnabled(e)
+	}
+	return enabled, nil
+}
+
+// SetAccountEnabled implements the Backend Interface for the LDAP Backend
+func (i *LDAP) SetAccountEnabled(ctx context.Context, nameOrID string, enabled bool) error {
+	logger := i.logger.SubloggerWithRequestID(ctx)
+	logger.Debug().Str("backend", "ldap").Bool("enabled", enabled).Msg("SetAccountEnabled")
+	if !i.writeEnabled {
+		return errReadOnly
+	}
+	if i.disableUserMechanism == DisableUserMechanismNone {
+		return errorcode.New(errorcode.NotSupported, "disabling users is not supported")
+	}
+	e, err := i.getLDAPUserByNameOrID(nameOrID)
+	if err != nil {
+		return err
+	}
+	if i.accountEnabled(e) == enabled {
+		return nil
+	}
+
+	var value string
+	switch i.disableUserMechanism {
+	case DisableUserMechanismAttribute:
+		value = strings.ToUpper(strconv.FormatBool(enabled))
+	case DisableUserMechanismUserAccountControl:
+		flags, err := strconv.ParseInt(e.GetEqualFoldAttributeValue(i.userAttributeMap.enabled), 10, 64)
+		if err != nil {
+			flags = userAccountControlNormal
+		}
+		if enabled {
+			flags &^= userAccountControlDisabled
+		} else {
+			flags |= userAccountControlDisabled
+		}
+		value = strconv.FormatInt(flags, 10)
+	}
+	mr := ldap.ModifyRequest{DN: e.DN}
+	mr.Replace(i.userAttributeMap.enabled, []string{value})
+	return i.conn.Modify(&mr)
+}
+
+// accountEnabled returns false if the user entry is marked as disabled. Entries without the
+// attribute are enabled.
+func (i *LDAP) accountEnabled(e *ldap.Entry) bool {
+	value := e.GetEqualFoldAttributeValue(i.userAttributeMap.enabled)
+	switch i.disableUserMechanism {
+	case DisableUserMechanismAttribute:
+		return !strings.EqualFold(value, "FALSE")
+	case DisableUserMechanismUserAccountControl:
+		flags, err := strconv.ParseInt(value, 10, 64)
+		return err != nil || flags&userAccountControlDisabled == 0
+	}
+	return true
+}
+
+func (i *LDAP) getUserByDN(dn string) (*ldap.Entry, error) {
+	return i.getEntryByDN(dn, i.userAttributeMap.attributes())
 }
 
 func (i *LDAP) getGroupByDN(dn string) (*ldap.Entry, error) {
@@ -357,12 +543,7 @@ func (i *LDAP) getLDAPUserByFilter(filter string) (*ldap.Entry, error) {
 	searchRequest := ldap.NewSearchRequest(
 		i.userBaseDN, i.userScope, ldap.NeverDerefAliases, 1, 0, false,
 		fmt.Sprintf("(&%s(objectClass=%s)%s)", i.userFilter, i.userObjectClass, filter),
-		[]string{
-			i.userAttributeMap.displayName,
-			i.userAttributeMap.id,
-			i.userAttributeMap.mail,
-			i.userAttributeMap.userName,
-		},
+		i.userAttributeMap.attributes(),
 		nil,
 	)
 	i.logger.Debug().Str("backend", "ldap").
@@ -436,16 +617,15 @@ func (i *LDAP) GetUsers(ctx context.Context, queryParam url.Values) ([]*libregra
 			i.userAttributeMap.displayName, search,
 		)
 	}
-	userFilter = fmt.Sprintf("(&%s(objectClass=%s)%s)", i.userFilter, i.userObjectClass, userFilter)
+	filter, err := i.userFilterFromQuery(ctx, queryParam)
+	if err != nil {
+		return nil, err
+	}
+	userFilter = fmt.Sprintf("(&%s(objectClass=%s)%s%s)", i.userFilter, i.userObjectClass, userFilter, filter)
 	searchRequest := ldap.NewSearchRequest(
 		i.userBaseDN, i.userScope, ldap.NeverDerefAliases, 0, 0, false,
 		userFilter,
-		[]string{
-			i.userAttributeMap.displayName,
-			i.userAttributeMap.id,
-			i.userAttributeMap.mail,
-			i.userAttributeMap.userName,
-		},
+		i.userAttributeMap.attributes(),
 		nil,
 	)
 	logger.Debug().Str("backend", "ldap").
@@ -455,14 +635,14 @@ func (i *LDAP) GetUsers(ctx context.Context, queryParam url.Values) ([]*libregra
 		Int("sizelimit", searchRequest.SizeLimit).
 		Interface("attributes", searchRequest.Attributes).
 		Msg("GetUsers")
-	res, err := i.conn.Search(searchRequest)
+	entries, err := i.searchPaged(searchRequest, resultLimit(queryParam))
 	if err != nil {
 		return nil, errorcode.New(errorcode.ItemNotFound, err.Error())
 	}
 
-	users := make([]*libregraph.User, 0, len(res.Entries))
+	users := make([]*libregraph.User, 0, len(entries))
 
-	for _, e := range res.Entries {
+	for _, e := range entries {
 		sel := strings.Split(queryParam.Get("$select"), ",")
 		exp := strings.Split(queryParam.Get("$expand"), ",")
 		u := i.createUserModelFromLDAP(e)
@@ -490,6 +670,9 @@ func (i *LDAP) GetUsers(ctx context.Context, queryParam url.Values) ([]*libregra
 }
 
 func (i *LDAP) getGroupsForUser(dn string) ([]*ldap.Entry, error) {
+	if i.nestedGroups {
+		return i.getNestedGroupsForUser(dn)
+	}
 	groupFilter := fmt.Sprintf(
 		"(%s=%s)",
 		i.groupAttributeMap.member, dn,
@@ -515,7 +698,7 @@ func (i *LDAP) GetGroup(ctx context.Context, nameOrID string, queryParam url.Val
 		return nil, errorcode.New(errorcode.ItemNotFound, "not found")
 	}
 	if slices.Contains(sel, "members") || slices.Contains(exp, "members") {
-		members, err := i.expandLDAPGroupMembers(ctx, e)
+		members, err := i.expandLDAPGroupMembers(ctx, e, 0)
 		if err != nil {
 			return nil, err
 		}
@@ -601,6 +784,256 @@ func (i *LDAP) getLDAPGroupsByFilter(filter string, requestMembers, single bool)
 	return res.Entries, nil
 }
 
+// connectionPinner is implemented by LDAP clients that spread the requests over several
+// connections
+type connectionPinner interface {
+	WithConnection(f func(ldap.Client) error) error
+}
+
+// searchPaged runs the search request using the LDAP paged results control, so that large
+// directories can be listed without hitting the server side size limit. It stops requesting
+// further pages once limit entries were received, a limit of 0 returns all entries.
+//
+// The paging cookie is not kept between requests, so every Graph page ($top and $skip) starts
+// a new search and receives the skipped entries again. The graph service caps $skip to bound
+// the cost of that.
+func (i *LDAP) searchPaged(searchRequest *ldap.SearchRequest, limit int) ([]*ldap.Entry, error) {
+	if i.pageSize == 0 {
+		res, err := i.conn.Search(searchRequest)
+		if err != nil {
+			return nil, err
+		}
+		return res.Entries, nil
+	}
+
+	// the paging cookies are only valid on the connection that created them
+	if p, ok := i.conn.(connectionPinner); ok {
+		var entries []*ldap.Entry
+		err := p.WithConnection(func(conn ldap.Client) (err error) {
+			entries, err = i.searchPagedWith(conn, searchRequest, limit)
+			return err
+		})
+		return entries, err
+	}
+	return i.searchPagedWith(i.conn, searchRequest, limit)
+}
+
+func (i *LDAP) searchPagedWith(conn ldap.Client, searchRequest *ldap.SearchRequest, limit int) ([]*ldap.Entry, error) {
+	pageSize := i.pageSize
+	if limit > 0 && uint32(limit) < pageSize {
+		pageSize = uint32(limit)
+	}
+	paging := ldap.NewControlPaging(pageSize)
+	// work on a copy, the request is sent again when the connection fails
+	sr := *searchRequest
+	sr.Controls = append(append([]ldap.Control(nil), searchRequest.Controls...), paging)
+	searchRequest = &sr
+	var entries []*ldap.Entry
+	for {
+		res, err := conn.Search(searchRequest)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, res.Entries...)
+
+		ctrl, ok := ldap.FindControl(res.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging)
+		if !ok || len(ctrl.Cookie) == 0 {
+			// the server does not support paging or this was the last page
+			break
+		}
+		paging.SetCookie(ctrl.Cookie)
+
+		if limit > 0 && len(entries) >= limit {
+			// abandon the paged search to free the resources on the server
+			paging.PagingSize = 0
+			if _, err := conn.Search(searchRequest); err != nil {
+				i.logger.Debug().Err(err).Msg("could not abandon paged search")
+			}
+			break
+		}
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// noMatchFilter is an LDAP filter that matches no entry. It replaces lambda expressions
+// that don't match any related entry.
+const noMatchFilter = "(!(objectClass=*))"
+
+func (i *LDAP) userFilterAttributes() map[string]string {
+	attrs := map[string]string{
+		"id":                       i.userAttributeMap.id,
+		"displayName":              i.userAttributeMap.displayName,
+		"mail":                     i.userAttributeMap.mail,
+		"onPremisesSamAccountName": i.userAttributeMap.userName,
+	}
+	if i.userAttributeMap.surname != "" {
+		attrs["surname"] = i.userAttributeMap.surname
+	}
+	return attrs
+}
+
+func (i *LDAP) groupFilterAttributes() map[string]string {
+	return map[string]string{
+		"id":          i.groupAttributeMap.id,
+		"displayName": i.groupAttributeMap.name,
+	}
+}
+
+// userFilterFromQuery translates the $filter query parameter into an LDAP filter for
+// users. It returns an empty string if the request has no filter.
+func (i *LDAP) userFilterFromQuery(ctx context.Context, queryParam url.Values) (string, error) {
+	node, err := parseFilter(ctx, queryParam)
+	if err != nil || node == nil {
+		return "", err
+	}
+	return i.ldapFilter(node, "", i.userFilterAttributes(), func(node *godata.ParseNode) (string, error) {
+		return i.memberOfFilter(ctx, node)
+	})
+}
+
+// groupFilterFromQuery translates the $filter query parameter into an LDAP filter for
+// groups. It returns an empty string if the request has no filter.
+func (i *LDAP) groupFilterFromQuery(ctx context.Context, queryParam url.Values) (string, error) {
+	node, err := parseFilter(ctx, queryParam)
+	if err != nil || node == nil {
+		return "", err
+	}
+	return i.ldapFilter(node, "", i.groupFilterAttributes(), func(node *godata.ParseNode) (string, error) {
+		return i.membersFilter(ctx, node)
+	})
+}
+
+// ldapFilter translates an OData filter expression into an LDAP filter. attributes maps
+// the supported properties to their LDAP attributes, lambda translates lambda expressions
+// on navigation properties.
+func (i *LDAP) ldapFilter(node *godata.ParseNode, variable string, attributes map[string]string, lambda func(*godata.ParseNode) (string, error)) (string, error) {
+	switch {
+	case node.Token.Type == godata.ExpressionTokenLogical && (node.Token.Value == "and" || node.Token.Value == "or"):
+		if len(node.Children) != 2 {
+			return "", unsupportedFilter(node)
+		}
+		left, err := i.ldapFilter(node.Children[0], variable, attributes, lambda)
+		if err != nil {
+			return "", err
+		}
+		right, err := i.ldapFilter(node.Children[1], variable, attributes, lambda)
+		if err != nil {
+			return "", err
+		}
+		op := "&"
+		if node.Token.Value == "or" {
+			op = "|"
+		}
+		return fmt.Sprintf("(%s%s%s)", op, left, right), nil
+	case node.Token.Type == godata.ExpressionTokenLogical && node.Token.Value == "not":
+		if len(node.Children) != 1 {
+			return "", unsupportedFilter(node)
+		}
+		f, err := i.ldapFilter(node.Children[0], variable, attributes, lambda)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(!%s)", f), nil
+	case node.Token.Type == godata.ExpressionTokenLogical && (node.Token.Value == "eq" || node.Token.Value == "ne"),
+		node.Token.Type == godata.ExpressionTokenFunc && node.Token.Value == "startswith":
+		property, value, err := filterComparison(node, variable)
+		if err != nil {
+			return "", err
+		}
+		attr, ok := attributes[property]
+		if !ok {
+			return "", errorcode.New(errorcode.InvalidRequest, fmt.Sprintf("unsupported filter property '%s'", property))
+		}
+		switch node.Token.Value {
+		case "eq":
+			return fmt.Sprintf("(%s=%s)", attr, ldap.EscapeFilter(value)), nil
+		case "ne":
+			return fmt.Sprintf("(!(%s=%s))", attr, ldap.EscapeFilter(value)), nil
+		default:
+			return fmt.Sprintf("(%s=%s*)", attr, ldap.EscapeFilter(value)), nil
+		}
+	case node.Token.Type == godata.ExpressionTokenLambdaNav && lambda != nil:
+		return lambda(node)
+	}
+	return "", unsupportedFilter(node)
+}
+
+// memberOfFilter translates "memberOf/any(g:<group filter>)" into an LDAP filter matching
+// the ids of the members of all groups matching the group filter.
+func (i *LDAP) memberOfFilter(ctx context.Context, node *godata.ParseNode) (string, error) {
+	variable, expr, err := filterLambda(node, "memberOf")
+	if err != nil {
+		return "", err
+	}
+	groupFilter, err := i.ldapFilter(expr, variable, i.groupFilterAttributes(), nil)
+	if err != nil {
+		return "", err
+	}
+	groups, err := i.getLDAPGroupsByFilter(groupFilter, true, false)
+	if err != nil {
+		return "", err
+	}
+
+	ids := map[string]struct{}{}
+	var filter strings.Builder
+	for _, g := range groups {
+		members, err := i.expandLDAPGroupMembers(ctx, g, 0)
+		if err != nil {
+			return "", err
+		}
+		for _, m := range members {
+			id := m.GetEqualFoldAttributeValue(i.userAttributeMap.id)
+			if _, ok := ids[id]; ok || id == "" {
+				continue
+			}
+			ids[id] = struct{}{}
+			fmt.Fprintf(&filter, "(%s=%s)", i.userAttributeMap.id, ldap.EscapeFilter(id))
+		}
+	}
+	if len(ids) == 0 {
+		return noMatchFilter, nil
+	}
+	return "(|" + filter.String() + ")", nil
+}
+
+// membersFilter translates "members/any(m:<user filter>)" into an LDAP filter matching
+// the groups that have any of the users matching the user filter as member.
+func (i *LDAP) membersFilter(ctx context.Context, node *godata.ParseNode) (string, error) {
+	variable, expr, err := filterLambda(node, "members")
+	if err != nil {
+		return "", err
+	}
+	userFilter, err := i.ldapFilter(expr, variable, i.userFilterAttributes(), nil)
+	if err != nil {
+		return "", err
+	}
+	searchRequest := ldap.NewSearchRequest(
+		i.userBaseDN, i.userScope, ldap.NeverDerefAliases, 0, 0, false,
+		fmt.Sprintf("(&%s(objectClass=%s)%s)", i.userFilter, i.userObjectClass, userFilter),
+		[]string{i.userAttributeMap.id},
+		nil,
+	)
+	users, err := i.searchPaged(searchRequest, 0)
+	switch {
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject):
+		// an unknown member is no error, it just matches no group
+		return noMatchFilter, nil
+	case err != nil:
+		return "", errorcode.New(errorcode.GeneralException, err.Error())
+	case len(users) == 0:
+		return noMatchFilter, nil
+	}
+
+	var filter strings.Builder
+	for _, u := range users {
+		fmt.Fprintf(&filter, "(%s=%s)", i.groupAttributeMap.member, ldap.EscapeFilter(u.DN))
+	}
+	return "(|" + filter.String() + ")", nil
+}
+
 // removeMemberFromGroupEntry creates an LDAP Modify request (not sending it)
 // that would update the supplied entry to remove the specified member from the
 // group
@@ -665,7 +1098,11 @@ func (i *LDAP) GetGroups(ctx context.Context, queryParam url.Values) ([]*libregr
 			i.groupAttributeMap.id, search,
 		)
 	}
-	groupFilter = fmt.Sprintf("(&%s(objectClass=%s)%s)", i.groupFilter, i.groupObjectClass, groupFilter)
+	filter, err := i.groupFilterFromQuery(ctx, queryParam)
+	if err != nil {
+		return nil, err
+	}
+	groupFilter = fmt.Sprintf("(&%s(objectClass=%s)%s%s)", i.groupFilter, i.groupObjectClass, groupFilter, filter)
 
 	groupAttrs := []string{
 		i.groupAttributeMap.name,
@@ -688,20 +1125,20 @@ func (i *LDAP) GetGroups(ctx context.Context, queryParam url.Values) ([]*libregr
 		Int("sizelimit", searchRequest.SizeLimit).
 		Interface("attributes", searchRequest.Attributes).
 		Msg("GetGroups")
-	res, err := i.conn.Search(searchRequest)
+	entries, err := i.searchPaged(searchRequest, resultLimit(queryParam))
 	if err != nil {
 		return nil, errorcode.New(errorcode.ItemNotFound, err.Error())
 	}
 
-	groups := make([]*libregraph.Group, 0, len(res.Entries))
+	groups := make([]*libregraph.Group, 0, len(entries))
 
 	var g *libregraph.Group
-	for _, e := range res.Entries {
+	for _, e := range entries {
 		if g = i.createGroupModelFromLDAP(e); g == nil {
 			continue
 		}
 		if expandMembers {
-			members, err := i.expandLDAPGroupMembers(ctx, e)
+			members, err := i.expandLDAPGroupMembers(ctx, e, 0)
 			if err != nil {
 				return nil, err
 			}
@@ -721,7 +1158,7 @@ func (i *LDAP) GetGroups(ctx context.Context, queryParam url.Values) ([]*libregr
 }
 
 // GetGroupMembers implements the Backend Interface for the LDAP Backend
-func (i *LDAP) GetGroupMembers(ctx context.Context, groupID string) ([]*libregraph.User, error) {
+func (i *LDAP) GetGroupMembers(ctx context.Context, groupID string, queryParam url.Values) ([]*libregraph.User, error) {
 	logger := i.logger.SubloggerWithRequestID(ctx)
 	logger.Debug().Str("backend", "ldap").Msg("GetGroupMembers")
 	e, err := i.getLDAPGroupByNameOrID(groupID, true)
@@ -729,7 +1166,7 @@ func (i *LDAP) GetGroupMembers(ctx context.Context, groupID string) ([]*libregra
 		return nil, err
 	}
 
-	memberEntries, err := i.expandLDAPGroupMembers(ctx, e)
+	memberEntries, err := i.expandLDAPGroupMembers(ctx, e, resultLimit(queryParam))
 	result := make([]*libregraph.User, 0, len(memberEntries))
 	if err != nil {
 		return nil, err
@@ -743,15 +1180,53 @@ func (i *LDAP) GetGroupMembers(ctx context.Context, groupID string) ([]*libregra
 	return result, nil
 }
 
-func (i *LDAP) expandLDAPGroupMembers(ctx context.Context, e *ldap.Entry) ([]*ldap.Entry, error) {
+// GetDirectGroupMembers implements the Backend Interface for the LDAP Backend. Unlike
+// GetGroupMembers it doesn't include the members of nested groups.
+func (i *LDAP) GetDirectGroupMembers(ctx context.Context, groupID string) ([]*libregraph.User, error) {
 	logger := i.logger.SubloggerWithRequestID(ctx)
-	logger.Debug().Str("backend", "ldap").Msg("expandLDAPGroupMembers")
+	logger.Debug().Str("backend", "ldap").Msg("GetDirectGroupMembers")
+	e, err := i.getLDAPGroupByNameOrID(groupID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	memberEntries, err := i.expandDirectLDAPGroupMembers(ctx, e, 0)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*libregraph.User, 0, len(memberEntries))
+	for _, member := range memberEntries {
+		if u := i.createUserModelFromLDAP(member); u != nil {
+			result = append(result, u)
+		}
+	}
+	return result, nil
+}
+
+// expandLDAPGroupMembers looks up the user entries of the group members, including the
+// members of nested groups if they are enabled. It stops after limit members were found,
+// a limit of 0 expands all members.
+func (i *LDAP) expandLDAPGroupMembers(ctx context.Context, e *ldap.Entry, limit int) ([]*ldap.Entry, error) {
+	if i.nestedGroups {
+		return i.expandNestedLDAPGroupMembers(ctx, e, limit)
+	}
+	return i.expandDirectLDAPGroupMembers(ctx, e, limit)
+}
+
+// expandDirectLDAPGroupMembers looks up the user entries of the members listed in the group
+// entry. It stops after limit members were found, a limit of 0 expands all members.
+func (i *LDAP) expandDirectLDAPGroupMembers(ctx context.Context, e *ldap.Entry, limit int) ([]*ldap.Entry, error) {
+	logger := i.logger.SubloggerWithRequestID(ctx)
+	logger.Debug().Str("backend", "ldap").Msg("expandDirectLDAPGroupMembers")
 	result := []*ldap.Entry{}
 
 	for _, memberDN := range e.GetEqualFoldAttributeValues(i.groupAttributeMap.member) {
 		if memberDN == "" {
 			continue
 		}
+		if limit > 0 && len(result) >= limit {
+			break
+		}
 		logger.Debug().Str("memberDN", memberDN).Msg("lookup")
 		ue, err := i.getUserByDN(memberDN)
 		if err != nil {
@@ -950,12 +1425,16 @@ func (i *LDAP) createUserModelFromLDAP(e *ldap.Entry) *libregraph.User {
 	id := e.GetEqualFoldAttributeValue(i.userAttributeMap.id)
 
 	if id != "" && opsan != "" {
-		return &libregraph.User{
+		user := &libregraph.User{
 			DisplayName:              pointerOrNil(e.GetEqualFoldAttributeValue(i.userAttributeMap.displayName)),
 			Mail:                     pointerOrNil(e.GetEqualFoldAttributeValue(i.userAttributeMap.mail)),
 			OnPremisesSamAccountName: &opsan,
 			Id:                       &id,
 		}
+		if i.userAttributeMap.surname != "" {
+			user.Surname = pointerOrNil(e.GetEqualFoldAttributeValue(i.userAttributeMap.surname))
+		}
+		return user
 	}
 	i.logger.Warn().Str("dn", e.DN).Msg("Invalid User. Missing username or id attribute")
 	return nil
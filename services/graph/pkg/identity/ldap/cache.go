@@ -0,0 +1,162 @@
+package ldap
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/jellydator/ttlcache/v2"
+
+	"github.com/owncloud/ocis/v2/services/graph/pkg/metrics"
+)
+
+// CachingClient wraps an ldap.Client and caches the results of search requests for a short
+// time. All cached results are dropped when entries are changed through the client. Searches
+// with controls, like paged searches, are not cached.
+type CachingClient struct {
+	ldap.Client
+	cache *ttlcache.Cache
+	// generation is increased on every write, results of searches that were running
+	// while entries changed are not cached
+	generation uint64
+	metrics    *metrics.Metrics
+}
+
+// NewCachingClient returns a client caching search results for ttl. m may be nil.
+func NewCachingClient(client ldap.Client, ttl time.Duration, m *metrics.Metrics) *CachingClient {
+	cache := ttlcache.NewCache()
+	_ = cache.SetTTL(ttl)
+	cache.SkipTTLExtensionOnHit(true)
+	return &CachingClient{
+		Client:  client,
+		cache:   cache,
+		metrics: m,
+	}
+}
+
+func (c *CachingClient) Search(sr *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if len(sr.Controls) > 0 {
+		return c.Client.Search(sr)
+	}
+
+	key := searchKey(sr)
+	if res, err := c.cache.Get(key); err == nil {
+		if c.metrics != nil {
+			c.metrics.LDAPCacheHits.Inc()
+		}
+		return copyResult(res.(*ldap.SearchResult)), nil
+	}
+	if c.metrics != nil {
+		c.metrics.LDAPCacheMisses.Inc()
+	}
+
+	generation := atomic.LoadUint64(&c.generation)
+	res, err := c.Client.Search(sr)
+	if err != nil {
+		return nil, err
+	}
+	if atomic.LoadUint64(&c.generation) == generation {
+		_ = c.cache.Set(key, copyResult(res))
+	}
+	return res, nil
+}
+
+// WithConnection runs f with all requests bound to the same connection of the wrapped
+// client. The requests bypass the cache.
+func (c *CachingClient) WithConnection(f func(ldap.Client) error) error {
+	if p, ok := c.Client.(interface {
+		WithConnection(func(ldap.Client) error) error
+	}); ok {
+		return p.WithConnection(f)
+	}
+	return f(c.Client)
+}
+
+func (c *CachingClient) Add(a *ldap.AddRequest) error {
+	defer c.invalidate()
+	return c.Client.Add(a)
+}
+
+func (c *CachingClient) Del(d *ldap.DelRequest) error {
+	defer c.invalidate()
+	return c.Client.Del(d)
+}
+
+func (c *CachingClient) Modify(m *ldap.ModifyRequest) error {
+	defer c.invalidate()
+	return c.Client.Modify(m)
+}
+
+func (c *CachingClient) ModifyWithResult(m *ldap.ModifyRequest) (*ldap.ModifyResult, error) {
+	defer c.invalidate()
+	return c.Client.ModifyWithResult(m)
+}
+
+func (c *CachingClient) ModifyDN(m *ldap.ModifyDNRequest) error {
+	defer c.invalidate()
+	return c.Client.ModifyDN(m)
+}
+
+func (c *CachingClient) PasswordModify(m *ldap.PasswordModifyRequest) (*ldap.PasswordModifyResult, error) {
+	defer c.invalidate()
+	return c.Client.PasswordModify(m)
+}
+
+// invalidate drops all cached results. Writes can change any number of cached results, e.g.
+// renaming a user changes the member attribute of all groups of the user.
+func (c *CachingClient) invalidate() {
+	atomic.AddUint64(&c.generation, 1)
+	_ = c.cache.Purge()
+}
+
+func searchKey(sr *ldap.SearchRequest) string {
+	return strings.Join([]string{
+		sr.BaseDN,
+		strconv.Itoa(sr.Scope),
+		strconv.Itoa(sr.DerefAliases),
+		strconv.Itoa(sr.SizeLimit),
+		strconv.Itoa(sr.TimeLimit),
+		strconv.FormatBool(sr.TypesOnly),
+		sr.Filter,
+		strings.Join(sr.Attributes, ","),
+	}, "\x00")
+}
+
+// copyResult returns a deep copy of the result, so that callers can't change cached results by
+// modifying the returned entries
+func copyResult(res *ldap.SearchResult) *ldap.SearchResult {
+	entries := make([]*ldap.Entry, 0, len(res.Entries))
+	for _, e := range res.Entries {
+		entries = append(entries, copyEntry(e))
+	}
+	return &ldap.SearchResult{
+		Entries:   entries,
+		Referrals: append([]string(nil), res.Referrals...),
+		Controls:  append([]ldap.Control(nil), res.Controls...),
+	}
+}
+
+func copyEntry(e *ldap.Entry) *ldap.Entry {
+	if e == nil {
+		return nil
+	}
+	attrs := make([]*ldap.EntryAttribute, 0, len(e.Attributes))
+	for _, a := range e.Attributes {
+		if a == nil {
+			attrs = append(attrs, nil)
+			continue
+		}
+		byteValues := make([][]byte, 0, len(a.ByteValues))
+		for _, v := range a.ByteValues {
+			byteValues = append(byteValues, append([]byte(nil), v...))
+		}
+		attrs = append(attrs, &ldap.EntryAttribute{
+			Name:       a.Name,
+			Values:     append([]string(nil), a.Values...),
+			ByteValues: byteValues,
+		})
+	}
+	return &ldap.Entry{DN: e.DN, Attributes: attrs}
+}
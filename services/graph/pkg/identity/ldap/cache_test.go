@@ -0,0 +1,60 @@
+package ldap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCachingClient(t *testing.T) {
+	conn := &mocks.Client{}
+	conn.On("Search", mock.Anything).Return(&ldap.SearchResult{
+		Entries: []*ldap.Entry{ldap.NewEntry("uid=user", map[string][]string{"uid": {"user"}})},
+	}, nil)
+	conn.On("Modify", mock.Anything).Return(nil)
+
+	c := NewCachingClient(conn, time.Minute, nil)
+	sr := ldap.NewSearchRequest("ou=people,dc=test", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		"(uid=user)", []string{"uid"}, nil)
+
+	for i := 0; i < 2; i++ {
+		res, err := c.Search(sr)
+		if err != nil || len(res.Entries) != 1 {
+			t.Fatalf("Expected one entry, got %v, %v", res, err)
+		}
+		if uid := res.Entries[0].GetAttributeValue("uid"); uid != "user" {
+			t.Fatalf("Expected uid 'user', got '%s'", uid)
+		}
+		res.Entries[0].Attributes[0].Values[0] = "changed"
+		res.Entries[0].DN = "uid=changed"
+		res.Entries = nil
+	}
+	conn.AssertNumberOfCalls(t, "Search", 1)
+
+	other := *sr
+	other.Filter = "(uid=other)"
+	if _, err := c.Search(&other); err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	conn.AssertNumberOfCalls(t, "Search", 2)
+
+	if err := c.Modify(&ldap.ModifyRequest{DN: "uid=user"}); err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	if _, err := c.Search(sr); err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	conn.AssertNumberOfCalls(t, "Search", 3)
+
+	paged := *sr
+	paged.Controls = []ldap.Control{ldap.NewControlPaging(10)}
+	for i := 0; i < 2; i++ {
+		if _, err := c.Search(&paged); err != nil {
+			t.Fatalf("Expected success, got '%s'", err.Error())
+		}
+	}
+	conn.AssertNumberOfCalls(t, "Search", 5)
+}
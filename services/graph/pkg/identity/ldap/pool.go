@@ -0,0 +1,307 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+)
+
+var (
+	errMaxRetries = errors.New("max retries")
+)
+
+// Config holds the settings to connect and bind to the LDAP server
+type Config struct {
+	URI          string
+	BindDN       string
+	BindPassword string
+	TLSConfig    *tls.Config
+}
+
+// ConnPool implements the ldap.Client interface. It spreads the requests over a bounded
+// number of connections. Connections are created on demand, requests block while all of
+// them are in use. Connections that were idle for longer than the health check interval
+// are probed before they are reused, broken connections are replaced.
+type ConnPool struct {
+	idle                chan *pooledConn
+	slots               chan struct{}
+	dial                func() (ldap.Client, error)
+	healthCheckInterval time.Duration
+	retries             int
+	logger              *log.Logger
+}
+
+type pooledConn struct {
+	conn     ldap.Client
+	lastUsed time.Time
+}
+
+// NewConnPool returns a pool of at most size connections to the LDAP server
+func NewConnPool(logger *log.Logger, config Config, size int, healthCheckInterval time.Duration) *ConnPool {
+	return newConnPool(logger, size, healthCheckInterval, func() (ldap.Client, error) {
+		return connect(logger, config)
+	})
+}
+
+// connect dials the LDAP server and binds with the configured credentials
+func connect(logger *log.Logger, config Config) (*ldap.Conn, error) {
+	logger.Debug().Msgf("Connecting to %s", config.URI)
+
+	var err error
+	var l *ldap.Conn
+	if config.TLSConfig != nil {
+		l, err = ldap.DialURL(config.URI, ldap.DialWithTLSConfig(config.TLSConfig))
+	} else {
+		l, err = ldap.DialURL(config.URI)
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Msg("could not get ldap Connection")
+	} else {
+		logger.Debug().Msg("LDAP Connected")
+		if config.BindDN != "" {
+			logger.Debug().Msgf("Binding as %s", config.BindDN)
+			err = l.Bind(config.BindDN, config.BindPassword)
+			if err != nil {
+				logger.Error().Err(err).Msg("Bind failed")
+				l.Close()
+				return nil, err
+			}
+
+		}
+	}
+
+	return l, err
+}
+
+func newConnPool(logger *log.Logger, size int, healthCheckInterval time.Duration, dial func() (ldap.Client, error)) *ConnPool {
+	if size < 1 {
+		size = 1
+	}
+	return &ConnPool{
+		idle:                make(chan *pooledConn, size),
+		slots:               make(chan struct{}, size),
+		dial:                dial,
+		healthCheckInterval: healthCheckInterval,
+		retries:             1,
+		logger:              logger,
+	}
+}
+
+// acquire returns an idle connection or creates a new one. It blocks while all connections
+// are in use.
+func (p *ConnPool) acquire() (*pooledConn, error) {
+	p.slots <- struct{}{}
+	for {
+		var pc *pooledConn
+		select {
+		case pc = <-p.idle:
+		default:
+		}
+		if pc == nil {
+			break
+		}
+		if p.healthy(pc) {
+			return pc, nil
+		}
+		p.logger.Debug().Msg("discarding unhealthy LDAP connection")
+		pc.conn.Close()
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return &pooledConn{conn: conn}, nil
+}
+
+// release returns the connection to the pool. Broken connections are closed.
+func (p *ConnPool) release(pc *pooledConn, broken bool) {
+	defer func() { <-p.slots }()
+	if broken || pc.conn.IsClosing() {
+		pc.conn.Close()
+		return
+	}
+	pc.lastUsed = time.Now()
+	select {
+	case p.idle <- pc:
+	default:
+		pc.conn.Close()
+	}
+}
+
+// healthy checks connections that were idle for longer than the health check interval by
+// reading the root DSE
+func (p *ConnPool) healthy(pc *pooledConn) bool {
+	if pc.conn.IsClosing() {
+		return false
+	}
+	if p.healthCheckInterval <= 0 || time.Since(pc.lastUsed) < p.healthCheckInterval {
+		return true
+	}
+	_, err := pc.conn.Search(ldap.NewSearchRequest(
+		"", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false,
+		"(objectClass=*)", []string{"1.1"}, nil,
+	))
+	if err != nil {
+		p.logger.Debug().Err(err).Msg("LDAP connection health check failed")
+		return false
+	}
+	return true
+}
+
+// do runs the operation on a pooled connection. Operations failing with a network error
+// are retried on a new connection.
+func (p *ConnPool) do(operation string, f func(ldap.Client) error) error {
+	for try := 0; try <= p.retries; try++ {
+		pc, err := p.acquire()
+		if err != nil {
+			return err
+		}
+		err = f(pc.conn)
+		if !ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+			// non network error, return it to the client
+			p.release(pc, false)
+			return err
+		}
+		p.release(pc, true)
+
+		p.logger.Debug().Msgf("Network Error. attempt %d", try)
+		p.logger.Debug().Msgf("retrying LDAP %s", operation)
+	}
+	// if we get here we reached the maximum retries. So return an error
+	return ldap.NewError(ldap.ErrorNetwork, errMaxRetries)
+}
+
+// WithConnection runs f with all requests bound to the same connection. This is needed for
+// paged searches, as the paging cookies are only valid on the connection that created them.
+func (p *ConnPool) WithConnection(f func(ldap.Client) error) error {
+	return p.do("WithConnection", f)
+}
+
+func (p *ConnPool) Search(sr *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	var res *ldap.SearchResult
+	err := p.do("Search", func(conn ldap.Client) (err error) {
+		res, err = conn.Search(sr)
+		return err
+	})
+	return res, err
+}
+
+func (p *ConnPool) Add(a *ldap.AddRequest) error {
+	return p.do("Add", func(conn ldap.Client) error {
+		return conn.Add(a)
+	})
+}
+
+func (p *ConnPool) Del(d *ldap.DelRequest) error {
+	return p.do("Del", func(conn ldap.Client) error {
+		return conn.Del(d)
+	})
+}
+
+func (p *ConnPool) Modify(m *ldap.ModifyRequest) error {
+	return p.do("Modify", func(conn ldap.Client) error {
+		return conn.Modify(m)
+	})
+}
+
+func (p *ConnPool) ModifyWithResult(m *ldap.ModifyRequest) (*ldap.ModifyResult, error) {
+	var res *ldap.ModifyResult
+	err := p.do("ModifyWithResult", func(conn ldap.Client) (err error) {
+		res, err = conn.ModifyWithResult(m)
+		return err
+	})
+	return res, err
+}
+
+func (p *ConnPool) PasswordModify(m *ldap.PasswordModifyRequest) (*ldap.PasswordModifyResult, error) {
+	var res *ldap.PasswordModifyResult
+	err := p.do("Password Modify", func(conn ldap.Client) (err error) {
+		res, err = conn.PasswordModify(m)
+		return err
+	})
+	return res, err
+}
+
+func (p *ConnPool) ModifyDN(m *ldap.ModifyDNRequest) error {
+	return p.do("ModifyDN", func(conn ldap.Client) error {
+		return conn.ModifyDN(m)
+	})
+}
+
+func (p *ConnPool) SearchWithPaging(searchRequest *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error) {
+	var res *ldap.SearchResult
+	err := p.do("SearchWithPaging", func(conn ldap.Client) (err error) {
+		res, err = conn.SearchWithPaging(searchRequest, pagingSize)
+		return err
+	})
+	return res, err
+}
+
+// Close closes the idle connections of the pool
+func (p *ConnPool) Close() {
+	for {
+		select {
+		case pc := <-p.idle:
+			pc.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// Remaining methods to fulfill ldap.Client interface
+
+func (p *ConnPool) Start() {}
+
+func (p *ConnPool) StartTLS(*tls.Config) error {
+	return ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
+
+func (p *ConnPool) IsClosing() bool {
+	return false
+}
+
+func (p *ConnPool) SetTimeout(time.Duration) {}
+
+func (p *ConnPool) Bind(username, password string) error {
+	return ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
+
+func (p *ConnPool) UnauthenticatedBind(username string) error {
+	return ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
+
+func (p *ConnPool) SimpleBind(*ldap.SimpleBindRequest) (*ldap.SimpleBindResult, error) {
+	return nil, ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
+
+func (p *ConnPool) ExternalBind() error {
+	return ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
+
+func (p *ConnPool) Compare(dn, attribute, value string) (bool, error) {
+	return false, ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
+
+// NTLMUnauthenticatedBind implements the ldap.Client interface
+func (p *ConnPool) NTLMUnauthenticatedBind(domain, username string) error {
+	return ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
+
+// TLSConnectionState implements the ldap.Client interface
+func (p *ConnPool) TLSConnectionState() (tls.ConnectionState, bool) {
+	return tls.ConnectionState{}, false
+}
+
+// Unbind implements the ldap.Client interface
+func (p *ConnPool) Unbind() error {
+	return ldap.NewError(ldap.LDAPResultNotSupported, fmt.Errorf("not implemented"))
+}
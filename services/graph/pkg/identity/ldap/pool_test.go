@@ -0,0 +1,83 @@
+package ldap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+var logger = log.NewLogger(log.Level("error"))
+
+func TestConnPoolReusesConnections(t *testing.T) {
+	conn := &mocks.Client{}
+	conn.On("IsClosing").Return(false)
+	conn.On("Search", mock.Anything).Return(&ldap.SearchResult{}, nil)
+	dials := 0
+	p := newConnPool(&logger, 2, time.Minute, func() (ldap.Client, error) {
+		dials++
+		return conn, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.Search(&ldap.SearchRequest{}); err != nil {
+			t.Fatalf("Expected success, got '%s'", err.Error())
+		}
+	}
+	if dials != 1 {
+		t.Errorf("Expected the idle connection to be reused, got %d connections", dials)
+	}
+}
+
+func TestConnPoolReplacesBrokenConnections(t *testing.T) {
+	broken := &mocks.Client{}
+	broken.On("IsClosing").Return(false)
+	broken.On("Search", mock.Anything).Return(nil, ldap.NewError(ldap.ErrorNetwork, errors.New("mock")))
+	broken.On("Close").Return()
+	working := &mocks.Client{}
+	working.On("IsClosing").Return(false)
+	working.On("Search", mock.Anything).Return(&ldap.SearchResult{}, nil)
+
+	conns := []ldap.Client{broken, working}
+	p := newConnPool(&logger, 1, time.Minute, func() (ldap.Client, error) {
+		c := conns[0]
+		conns = conns[1:]
+		return c, nil
+	})
+
+	if _, err := p.Search(&ldap.SearchRequest{}); err != nil {
+		t.Fatalf("Expected the search to be retried on a new connection, got '%s'", err.Error())
+	}
+	broken.AssertCalled(t, "Close")
+}
+
+func TestConnPoolHealthCheck(t *testing.T) {
+	stale := &mocks.Client{}
+	stale.On("IsClosing").Return(false)
+	stale.On("Search", mock.MatchedBy(func(sr *ldap.SearchRequest) bool { return sr.BaseDN == "" })).
+		Return(nil, ldap.NewError(ldap.ErrorNetwork, errors.New("mock")))
+	stale.On("Search", mock.Anything).Return(&ldap.SearchResult{}, nil)
+	stale.On("Close").Return()
+	fresh := &mocks.Client{}
+	fresh.On("IsClosing").Return(false)
+	fresh.On("Search", mock.Anything).Return(&ldap.SearchResult{}, nil)
+
+	conns := []ldap.Client{stale, fresh}
+	p := newConnPool(&logger, 1, time.Nanosecond, func() (ldap.Client, error) {
+		c := conns[0]
+		conns = conns[1:]
+		return c, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.Search(&ldap.SearchRequest{BaseDN: "ou=people,dc=test"}); err != nil {
+			t.Fatalf("Expected success, got '%s'", err.Error())
+		}
+	}
+	stale.AssertCalled(t, "Close")
+	fresh.AssertNumberOfCalls(t, "Search", 1)
+}
@@ -0,0 +1,164 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	ldapdn "github.com/libregraph/idm/pkg/ldapdn"
+	"golang.org/x/exp/slices"
+)
+
+// matchingRuleInChain is the OID of the LDAP_MATCHING_RULE_IN_CHAIN extensible matching rule
+// of Active Directory. It walks the chain of ancestry of entries, so that a single search
+// returns all nested memberships.
+const matchingRuleInChain = "1.2.840.113556.1.4.1941"
+
+// activeDirectoryCapabilities are the OIDs in the supportedCapabilities attribute of the root
+// DSE announcing Active Directory (LDAP_CAP_ACTIVE_DIRECTORY_OID) and AD LDS
+// (LDAP_CAP_ACTIVE_DIRECTORY_ADAM_OID) servers, which support LDAP_MATCHING_RULE_IN_CHAIN
+var activeDirectoryCapabilities = []string{"1.2.840.113556.1.4.800", "1.2.840.113556.1.4.1851"}
+
+// supportsMatchingRuleInChain reads the capabilities of the server from the root DSE. Servers
+// that don't know the matching rule evaluate filters using it to undefined instead of failing,
+// so it has to be detected up front. The result is looked up once.
+func (i *LDAP) supportsMatchingRuleInChain() bool {
+	i.matchingRuleInChainOnce.Do(func() {
+		searchRequest := ldap.NewSearchRequest(
+			"", ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false,
+			"(objectClass=*)",
+			[]string{"supportedCapabilities"},
+			nil,
+		)
+		res, err := i.conn.Search(searchRequest)
+		if err != nil || len(res.Entries) == 0 {
+			i.logger.Debug().Err(err).Str("backend", "ldap").Msg("could not read the root DSE, resolving nested groups iteratively")
+			return
+		}
+		for _, capability := range res.Entries[0].GetEqualFoldAttributeValues("supportedCapabilities") {
+			if slices.Contains(activeDirectoryCapabilities, capability) {
+				i.matchingRuleInChain = true
+			}
+		}
+		i.logger.Debug().Str("backend", "ldap").Bool("supported", i.matchingRuleInChain).Msg("LDAP_MATCHING_RULE_IN_CHAIN")
+	})
+	return i.matchingRuleInChain
+}
+
+// getNestedGroupsForUser returns the groups the entry is a member of, directly or through
+// other groups
+func (i *LDAP) getNestedGroupsForUser(dn string) ([]*ldap.Entry, error) {
+	if i.supportsMatchingRuleInChain() {
+		groupFilter := fmt.Sprintf(
+			"(%s:%s:=%s)",
+			i.groupAttributeMap.member, matchingRuleInChain, ldap.EscapeFilter(dn),
+		)
+		return i.getLDAPGroupsByFilter(groupFilter, false, false)
+	}
+
+	var result []*ldap.Entry
+	seen := map[string]bool{normalizeDN(dn): true}
+	memberDNs := []string{dn}
+	// every round looks up the groups of the groups found in the previous round
+	for depth := 0; depth <= i.nestedGroupsMaxDepth && len(memberDNs) > 0; depth++ {
+		var groupFilter strings.Builder
+		groupFilter.WriteString("(|")
+		for _, memberDN := range memberDNs {
+			fmt.Fprintf(&groupFilter, "(%s=%s)", i.groupAttributeMap.member, ldap.EscapeFilter(memberDN))
+		}
+		groupFilter.WriteString(")")
+		groups, err := i.getLDAPGroupsByFilter(groupFilter.String(), false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		memberDNs = nil
+		for _, group := range groups {
+			key := normalizeDN(group.DN)
+			if seen[key] {
+				// cycle or group reached through several paths
+				continue
+			}
+			seen[key] = true
+			result = append(result, group)
+			memberDNs = append(memberDNs, group.DN)
+		}
+	}
+	return result, nil
+}
+
+// expandNestedLDAPGroupMembers looks up the user entries of the group members including the
+// members of nested groups. It stops after limit members were found, a limit of 0 expands
+// all members.
+func (i *LDAP) expandNestedLDAPGroupMembers(ctx context.Context, e *ldap.Entry, limit int) ([]*ldap.Entry, error) {
+	logger := i.logger.SubloggerWithRequestID(ctx)
+	logger.Debug().Str("backend", "ldap").Msg("expandNestedLDAPGroupMembers")
+
+	if i.supportsMatchingRuleInChain() {
+		searchRequest := ldap.NewSearchRequest(
+			i.userBaseDN, i.userScope, ldap.NeverDerefAliases, 0, 0, false,
+			fmt.Sprintf("(&%s(objectClass=%s)(memberOf:%s:=%s))",
+				i.userFilter, i.userObjectClass, matchingRuleInChain, ldap.EscapeFilter(e.DN)),
+			i.userAttributeMap.attributes(),
+			nil,
+		)
+		return i.searchPaged(searchRequest, limit)
+	}
+
+	result := []*ldap.Entry{}
+	attrs := append(i.userAttributeMap.attributes(), "objectClass", i.groupAttributeMap.member)
+	seen := map[string]bool{normalizeDN(e.DN): true}
+	groups := []*ldap.Entry{e}
+	for depth := 0; depth <= i.nestedGroupsMaxDepth && len(groups) > 0; depth++ {
+		var nested []*ldap.Entry
+		for _, group := range groups {
+			for _, memberDN := range group.GetEqualFoldAttributeValues(i.groupAttributeMap.member) {
+				if memberDN == "" {
+					continue
+				}
+				if limit > 0 && len(result) >= limit {
+					return result, nil
+				}
+				key := normalizeDN(memberDN)
+				if seen[key] {
+					// cycle or member reached through several groups
+					continue
+				}
+				seen[key] = true
+
+				logger.Debug().Str("memberDN", memberDN).Msg("lookup")
+				me, err := i.getEntryByDN(memberDN, attrs)
+				if err != nil {
+					// Ignore errors when reading a specific member fails, just log them and continue
+					logger.Debug().Err(err).Str("member", memberDN).Msg("error reading group member")
+					continue
+				}
+				if i.isGroupEntry(me) {
+					nested = append(nested, me)
+					continue
+				}
+				result = append(result, me)
+			}
+		}
+		groups = nested
+	}
+	return result, nil
+}
+
+func (i *LDAP) isGroupEntry(e *ldap.Entry) bool {
+	for _, objectClass := range e.GetEqualFoldAttributeValues("objectClass") {
+		if strings.EqualFold(objectClass, i.groupObjectClass) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDN returns a representation of the DN suitable for comparisons
+func normalizeDN(dn string) string {
+	if normalized, err := ldapdn.ParseNormalize(dn); err == nil {
+		return normalized
+	}
+	return strings.ToLower(dn)
+}
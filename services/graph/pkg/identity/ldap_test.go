@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/go-ldap/ldap/v3"
+	libregraph "github.com/owncloud/libre-graph-api-go"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/graph/mocks"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
@@ -14,7 +17,7 @@ import (
 )
 
 func getMockedBackend(l ldap.Client, lc config.LDAP, logger *log.Logger) (*LDAP, error) {
-	return NewLDAPBackend(l, lconfig, logger)
+	return NewLDAPBackend(l, lc, logger)
 }
 
 var lconfig = config.LDAP{
@@ -125,6 +128,200 @@ func TestCreateUserModelFromLDAP(t *testing.T) {
 	}
 }
 
+func TestUserSurnameAttribute(t *testing.T) {
+	entry := ldap.NewEntry("uid=user,ou=people,dc=test",
+		map[string][]string{
+			"uid":         {"user"},
+			"displayname": {"DisplayName"},
+			"mail":        {"user@example"},
+			"entryuuid":   {"abcd-defg"},
+			"familyname":  {"Surname"},
+		})
+	var modify *ldap.ModifyRequest
+	lm := &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(&ldap.SearchResult{Entries: []*ldap.Entry{entry}}, nil)
+	lm.On("Modify", mock.Anything).Return(func(mr *ldap.ModifyRequest) error {
+		modify = mr
+		return nil
+	})
+
+	tc := lconfig
+	tc.WriteEnabled = true
+	tc.UserSurnameAttribute = "familyName"
+	b, _ := getMockedBackend(lm, tc, &logger)
+
+	user := b.createUserModelFromLDAP(entry)
+	if user.GetSurname() != "Surname" {
+		t.Errorf("Expected surname 'Surname', got '%s'", user.GetSurname())
+	}
+
+	if _, err := b.UpdateUser(context.Background(), "user", libregraph.User{Surname: libregraph.PtrString("Other")}); err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	if modify == nil || len(modify.Changes) != 1 ||
+		modify.Changes[0].Modification.Type != "familyName" || modify.Changes[0].Modification.Vals[0] != "Other" {
+		t.Errorf("Expected a replace of 'familyName', got %v", modify)
+	}
+}
+
+func TestCreateUserSurname(t *testing.T) {
+	for _, attr := range []string{"sn", "familyName"} {
+		var add *ldap.AddRequest
+		lm := &mocks.Client{}
+		lm.On("Add", mock.Anything).Return(func(ar *ldap.AddRequest) error {
+			add = ar
+			return nil
+		})
+		lm.On("Search", mock.Anything).Return(&ldap.SearchResult{Entries: []*ldap.Entry{userEntry}}, nil)
+
+		tc := lconfig
+		tc.WriteEnabled = true
+		tc.UserSurnameAttribute = attr
+		b, _ := getMockedBackend(lm, tc, &logger)
+
+		_, err := b.CreateUser(context.Background(), libregraph.User{
+			OnPremisesSamAccountName: libregraph.PtrString("user"),
+			Mail:                     libregraph.PtrString("user@example"),
+			DisplayName:              libregraph.PtrString("DisplayName"),
+			Surname:                  libregraph.PtrString("Surname"),
+		})
+		if err != nil {
+			t.Fatalf("%s: expected success, got '%s'", attr, err.Error())
+		}
+		values := map[string][]string{}
+		for _, a := range add.Attributes {
+			if _, ok := values[a.Type]; ok {
+				t.Errorf("%s: attribute '%s' is sent twice", attr, a.Type)
+			}
+			values[a.Type] = a.Vals
+		}
+		if v := values[attr]; len(v) != 1 || v[0] != "Surname" {
+			t.Errorf("%s: expected the surname 'Surname', got %v", attr, v)
+		}
+		if v := values["sn"]; len(v) != 1 || v[0] != "Surname" {
+			t.Errorf("%s: expected 'sn' to be 'Surname', got %v", attr, v)
+		}
+	}
+}
+
+func TestUpdateUserEmptySurname(t *testing.T) {
+	entry := ldap.NewEntry("uid=user,ou=people,dc=test",
+		map[string][]string{
+			"uid":       {"user"},
+			"entryuuid": {"abcd-defg"},
+			"sn":        {"Surname"},
+		})
+	var modify *ldap.ModifyRequest
+	lm := &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(&ldap.SearchResult{Entries: []*ldap.Entry{entry}}, nil)
+	lm.On("Modify", mock.Anything).Return(func(mr *ldap.ModifyRequest) error {
+		modify = mr
+		return nil
+	})
+
+	tc := lconfig
+	tc.WriteEnabled = true
+	tc.UserSurnameAttribute = "sn"
+	b, _ := getMockedBackend(lm, tc, &logger)
+
+	if _, err := b.UpdateUser(context.Background(), "user", libregraph.User{Surname: libregraph.PtrString("")}); err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	// inetOrgPerson requires "sn", so it is reset to the user name instead of being deleted
+	if modify == nil || len(modify.Changes) != 1 || modify.Changes[0].Operation != ldap.ReplaceAttribute ||
+		modify.Changes[0].Modification.Type != "sn" || modify.Changes[0].Modification.Vals[0] != "user" {
+		t.Errorf("Expected a replace of 'sn' with 'user', got %v", modify)
+	}
+}
+
+func TestSetAccountEnabled(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		attrs     map[string][]string
+		enabled   bool
+		attribute string
+		value     string
+	}{
+		{mechanism: DisableUserMechanismAttribute, attrs: map[string][]string{}, enabled: false, attribute: "ownCloudUserEnabled", value: "FALSE"},
+		{mechanism: DisableUserMechanismAttribute, attrs: map[string][]string{"ownclouduserenabled": {"FALSE"}}, enabled: true, attribute: "ownCloudUserEnabled", value: "TRUE"},
+		{mechanism: DisableUserMechanismUserAccountControl, attrs: map[string][]string{"useraccountcontrol": {"66048"}}, enabled: false, attribute: "userAccountControl", value: "66050"},
+		{mechanism: DisableUserMechanismUserAccountControl, attrs: map[string][]string{"useraccountcontrol": {"514"}}, enabled: true, attribute: "userAccountControl", value: "512"},
+	}
+	for _, tt := range tests {
+		tt.attrs["uid"] = []string{"user"}
+		tt.attrs["entryuuid"] = []string{"abcd-defg"}
+		entry := ldap.NewEntry("uid=user,ou=people,dc=test", tt.attrs)
+		var modify *ldap.ModifyRequest
+		lm := &mocks.Client{}
+		lm.On("Search", mock.Anything).Return(&ldap.SearchResult{Entries: []*ldap.Entry{entry}}, nil)
+		lm.On("Modify", mock.Anything).Return(func(mr *ldap.ModifyRequest) error {
+			modify = mr
+			return nil
+		})
+
+		tc := lconfig
+		tc.WriteEnabled = true
+		tc.DisableUserMechanism = tt.mechanism
+		tc.UserEnabledAttribute = "ownCloudUserEnabled"
+		b, err := getMockedBackend(lm, tc, &logger)
+		if err != nil {
+			t.Fatalf("Expected success, got '%s'", err.Error())
+		}
+
+		if enabled := b.accountEnabled(entry); enabled == tt.enabled {
+			t.Errorf("%s: expected enabled=%v before the change", tt.mechanism, !tt.enabled)
+		}
+		if err := b.SetAccountEnabled(context.Background(), "user", tt.enabled); err != nil {
+			t.Fatalf("Expected success, got '%s'", err.Error())
+		}
+		if modify == nil || len(modify.Changes) != 1 ||
+			modify.Changes[0].Modification.Type != tt.attribute || modify.Changes[0].Modification.Vals[0] != tt.value {
+			t.Errorf("%s: expected a replace of '%s' with '%s', got %v", tt.mechanism, tt.attribute, tt.value, modify)
+		}
+	}
+
+	tc := lconfig
+	tc.WriteEnabled = true
+	tc.DisableUserMechanism = DisableUserMechanismNone
+	b, _ := getMockedBackend(&mocks.Client{}, tc, &logger)
+	if err := b.SetAccountEnabled(context.Background(), "user", false); err == nil {
+		t.Errorf("Expected an error when disabling users is not supported")
+	}
+}
+
+func TestGetAccountsEnabled(t *testing.T) {
+	var search *ldap.SearchRequest
+	lm := &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(func(sr *ldap.SearchRequest) *ldap.SearchResult {
+		search = sr
+		return &ldap.SearchResult{Entries: []*ldap.Entry{
+			ldap.NewEntry("uid=u1", map[string][]string{"entryuuid": {"id1"}, "ownclouduserenabled": {"FALSE"}}),
+			ldap.NewEntry("uid=u2", map[string][]string{"entryuuid": {"id2"}}),
+		}}
+	}, nil)
+
+	tc := lconfig
+	tc.DisableUserMechanism = DisableUserMechanismAttribute
+	tc.UserEnabledAttribute = "ownCloudUserEnabled"
+	b, err := getMockedBackend(lm, tc, &logger)
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+
+	enabled, err := b.GetAccountsEnabled(context.Background(), []string{"id1", "id2", "id3"})
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	lm.AssertNumberOfCalls(t, "Search", 1)
+	if !strings.Contains(search.Filter, "(|(entryUUID=id1)(entryUUID=id2)(entryUUID=id3))") {
+		t.Errorf("Expected a single search for all users, got filter %s", search.Filter)
+	}
+	expected := map[string]bool{"id1": false, "id2": true}
+	if !reflect.DeepEqual(enabled, expected) {
+		t.Errorf("Expected %v, got %v", expected, enabled)
+	}
+}
+
 func TestGetUser(t *testing.T) {
 	// Mock a Sizelimit Error
 	lm := &mocks.Client{}
@@ -244,6 +441,214 @@ func TestGetUsers(t *testing.T) {
 	}
 }
 
+func TestGetUsersPaged(t *testing.T) {
+	newUserEntry := func(uid string) *ldap.Entry {
+		return ldap.NewEntry("uid="+uid, map[string][]string{
+			"uid":         {uid},
+			"displayname": {uid},
+			"mail":        {uid + "@example"},
+			"entryuuid":   {uid + "-id"},
+		})
+	}
+	pagingControl := func(sr *ldap.SearchRequest) *ldap.ControlPaging {
+		return ldap.FindControl(sr.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging)
+	}
+	cookie := ldap.NewControlPaging(0)
+	cookie.SetCookie([]byte("page2"))
+
+	pagedConfig := lconfig
+	pagedConfig.PageSize = 2
+
+	lm := &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(func(sr *ldap.SearchRequest) *ldap.SearchResult {
+		paging := pagingControl(sr)
+		switch {
+		case paging.PagingSize == 0:
+			return &ldap.SearchResult{}
+		case len(paging.Cookie) == 0:
+			return &ldap.SearchResult{
+				Entries:  []*ldap.Entry{newUserEntry("user1"), newUserEntry("user2")},
+				Controls: []ldap.Control{cookie},
+			}
+		default:
+			return &ldap.SearchResult{Entries: []*ldap.Entry{newUserEntry("user3")}}
+		}
+	}, nil)
+
+	b, _ := getMockedBackend(lm, pagedConfig, &logger)
+	users, err := b.GetUsers(context.Background(), url.Values{})
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	if len(users) != 3 {
+		t.Errorf("Expected all 3 users from both pages, got %d", len(users))
+	}
+
+	lm.Calls = nil
+	users, err = b.GetUsers(context.Background(), url.Values{"$top": []string{"1"}})
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	if len(users) != 2 {
+		t.Errorf("Expected the requested user and one more to detect the next page, got %d", len(users))
+	}
+	if len(lm.Calls) != 2 || pagingControl(lm.Calls[1].Arguments[0].(*ldap.SearchRequest)).PagingSize != 0 {
+		t.Errorf("Expected the paged search to be abandoned after the first page")
+	}
+}
+
+func TestGetUsersFilter(t *testing.T) {
+	var filters []string
+	lm := &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(func(sr *ldap.SearchRequest) *ldap.SearchResult {
+		filters = append(filters, sr.Filter)
+		switch {
+		case sr.BaseDN == lconfig.GroupBaseDN:
+			return &ldap.SearchResult{Entries: []*ldap.Entry{groupEntry}}
+		case sr.BaseDN == "uid=user,ou=people,dc=test":
+			return &ldap.SearchResult{Entries: []*ldap.Entry{userEntry}}
+		case sr.BaseDN == lconfig.UserBaseDN:
+			return &ldap.SearchResult{Entries: []*ldap.Entry{userEntry}}
+		}
+		return &ldap.SearchResult{}
+	}, nil)
+	b, _ := getMockedBackend(lm, lconfig, &logger)
+
+	tests := []struct {
+		filter string
+		ldap   string
+	}{
+		{
+			filter: "displayName eq 'Al*ce' and startswith(mail,'al')",
+			ldap:   `(&(objectClass=inetOrgPerson)(&(displayname=Al\2ace)(mail=al*)))`,
+		},
+		{
+			filter: "not (onPremisesSamAccountName eq 'admin') or id ne 'abcd'",
+			ldap:   "(&(objectClass=inetOrgPerson)(|(!(uid=admin))(!(entryUUID=abcd))))",
+		},
+		{
+			filter: "memberOf/any(g:g/id eq 'abcd-defg')",
+			ldap:   "(&(objectClass=inetOrgPerson)(|(entryUUID=abcd-defg)))",
+		},
+	}
+	for _, tt := range tests {
+		filters = nil
+		users, err := b.GetUsers(context.Background(), url.Values{"$filter": []string{tt.filter}})
+		if err != nil {
+			t.Errorf("Expected success for '%s', got '%s'", tt.filter, err.Error())
+			continue
+		}
+		if len(users) != 1 {
+			t.Errorf("Expected one user for '%s', got %d", tt.filter, len(users))
+		}
+		if filters[len(filters)-1] != tt.ldap {
+			t.Errorf("Expected filter '%s' for '%s', got '%s'", tt.ldap, tt.filter, filters[len(filters)-1])
+		}
+	}
+
+	for _, filter := range []string{"accountEnabled eq true", "contains(mail,'user')", "memberOf/all(g:g/id eq 'abcd')", "displayName eq"} {
+		_, err := b.GetUsers(context.Background(), url.Values{"$filter": []string{filter}})
+		if err == nil || err.Error() != "invalidRequest" {
+			t.Errorf("Expected 'invalidRequest' for '%s', got '%v'", filter, err)
+		}
+	}
+}
+
+func TestNestedGroups(t *testing.T) {
+	userDN := "uid=user,ou=people,dc=test"
+	nestedUser := ldap.NewEntry(userDN, map[string][]string{
+		"objectclass": {"inetOrgPerson"},
+		"uid":         {"user"},
+		"displayname": {"DisplayName"},
+		"mail":        {"user@example"},
+		"entryuuid":   {"user-id"},
+	})
+	// inner and outer are members of each other
+	inner := ldap.NewEntry("cn=inner,ou=groups,dc=test", map[string][]string{
+		"objectclass": {"groupOfNames"},
+		"cn":          {"inner"},
+		"entryuuid":   {"inner-id"},
+		"member":      {userDN, "cn=outer,ou=groups,dc=test"},
+	})
+	outer := ldap.NewEntry("cn=outer,ou=groups,dc=test", map[string][]string{
+		"objectclass": {"groupOfNames"},
+		"cn":          {"outer"},
+		"entryuuid":   {"outer-id"},
+		"member":      {"cn=inner,ou=groups,dc=test"},
+	})
+
+	tc := lconfig
+	tc.NestedGroups = true
+	tc.NestedGroupsMaxDepth = 5
+
+	lm := &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(func(sr *ldap.SearchRequest) *ldap.SearchResult {
+		switch {
+		case sr.BaseDN == "":
+			return &ldap.SearchResult{Entries: []*ldap.Entry{ldap.NewEntry("", map[string][]string{
+				"supportedCapabilities": {"1.3.6.1.4.1.4203.1.5.1"},
+			})}}
+		case sr.BaseDN == userDN:
+			return &ldap.SearchResult{Entries: []*ldap.Entry{nestedUser}}
+		case sr.BaseDN == inner.DN:
+			return &ldap.SearchResult{Entries: []*ldap.Entry{inner}}
+		case strings.Contains(sr.Filter, "(member="+userDN+")"):
+			return &ldap.SearchResult{Entries: []*ldap.Entry{inner}}
+		case strings.Contains(sr.Filter, "(member="+inner.DN+")"):
+			return &ldap.SearchResult{Entries: []*ldap.Entry{outer}}
+		case strings.Contains(sr.Filter, "(member="+outer.DN+")"):
+			return &ldap.SearchResult{Entries: []*ldap.Entry{inner}}
+		}
+		return &ldap.SearchResult{}
+	}, nil)
+	b, err := getMockedBackend(lm, tc, &logger)
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+
+	groups, err := b.getGroupsForUser(userDN)
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	if len(groups) != 2 || groups[0].DN != inner.DN || groups[1].DN != outer.DN {
+		t.Errorf("Expected the direct and the nested group, got %v", groups)
+	}
+
+	members, err := b.expandLDAPGroupMembers(context.Background(), outer, 0)
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	if len(members) != 1 || members[0].DN != userDN {
+		t.Errorf("Expected the member of the nested group, got %v", members)
+	}
+
+	// Active Directory resolves the memberships on the server
+	lm = &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(func(sr *ldap.SearchRequest) *ldap.SearchResult {
+		switch {
+		case sr.BaseDN == "":
+			return &ldap.SearchResult{Entries: []*ldap.Entry{ldap.NewEntry("", map[string][]string{
+				"supportedCapabilities": {"1.2.840.113556.1.4.800"},
+			})}}
+		case strings.Contains(sr.Filter, "(member:1.2.840.113556.1.4.1941:="):
+			return &ldap.SearchResult{Entries: []*ldap.Entry{inner, outer}}
+		case strings.Contains(sr.Filter, "(memberOf:1.2.840.113556.1.4.1941:="):
+			return &ldap.SearchResult{Entries: []*ldap.Entry{nestedUser}}
+		}
+		return &ldap.SearchResult{}
+	}, nil)
+	b, _ = getMockedBackend(lm, tc, &logger)
+
+	if groups, err = b.getGroupsForUser(userDN); err != nil || len(groups) != 2 {
+		t.Errorf("Expected both groups, got %v, %v", groups, err)
+	}
+	if members, err = b.expandLDAPGroupMembers(context.Background(), outer, 0); err != nil || len(members) != 1 {
+		t.Errorf("Expected the nested member, got %v, %v", members, err)
+	}
+	// the root DSE is only read once
+	lm.AssertNumberOfCalls(t, "Search", 3)
+}
+
 func TestGetGroup(t *testing.T) {
 	// Mock a Sizelimit Error
 	lm := &mocks.Client{}
@@ -443,3 +848,41 @@ func TestGetGroups(t *testing.T) {
 		}
 	}
 }
+
+func TestGetGroupsFilter(t *testing.T) {
+	var filters []string
+	lm := &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(func(sr *ldap.SearchRequest) *ldap.SearchResult {
+		filters = append(filters, sr.Filter)
+		if sr.BaseDN == lconfig.UserBaseDN {
+			return &ldap.SearchResult{Entries: []*ldap.Entry{ldap.NewEntry("uid=user,ou=people,dc=test", nil)}}
+		}
+		return &ldap.SearchResult{Entries: []*ldap.Entry{groupEntry}}
+	}, nil)
+	b, _ := getMockedBackend(lm, lconfig, &logger)
+
+	_, err := b.GetGroups(context.Background(), url.Values{"$filter": []string{"members/any(m:m/onPremisesSamAccountName eq 'user') and startswith(displayName,'gr')"}})
+	if err != nil {
+		t.Fatalf("Expected success, got '%s'", err.Error())
+	}
+	expected := []string{
+		"(&(objectClass=inetOrgPerson)(uid=user))",
+		"(&(objectClass=groupOfNames)(&(|(member=uid=user,ou=people,dc=test))(cn=gr*)))",
+	}
+	if len(filters) != 2 || filters[0] != expected[0] || filters[1] != expected[1] {
+		t.Errorf("Expected filters '%v', got '%v'", expected, filters)
+	}
+
+	_, err = b.GetGroups(context.Background(), url.Values{"$filter": []string{"mail eq 'group@example'"}})
+	if err == nil || err.Error() != "invalidRequest" {
+		t.Errorf("Expected 'invalidRequest', got '%v'", err)
+	}
+
+	lm = &mocks.Client{}
+	lm.On("Search", mock.Anything).Return(nil, ldap.NewError(ldap.LDAPResultBusy, errors.New("busy")))
+	b, _ = getMockedBackend(lm, lconfig, &logger)
+	_, err = b.GetGroups(context.Background(), url.Values{"$filter": []string{"members/any(m:m/onPremisesSamAccountName eq 'user')"}})
+	if err == nil || err.Error() != "generalException" {
+		t.Errorf("Expected 'generalException', got '%v'", err)
+	}
+}
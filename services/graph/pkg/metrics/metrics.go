@@ -13,7 +13,9 @@ var (
 // Metrics defines the available metrics of this service.
 type Metrics struct {
 	// Counter  *prometheus.CounterVec
-	BuildInfo *prometheus.GaugeVec
+	BuildInfo       *prometheus.GaugeVec
+	LDAPCacheHits   prometheus.Counter
+	LDAPCacheMisses prometheus.Counter
 }
 
 // New initializes the available metrics.
@@ -25,9 +27,23 @@ func New() *Metrics {
 			Name:      "build_info",
 			Help:      "Build information",
 		}, []string{"version"}),
+		LDAPCacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "ldap_cache_hits_total",
+			Help:      "Number of LDAP searches answered from the cache",
+		}),
+		LDAPCacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "ldap_cache_misses_total",
+			Help:      "Number of LDAP searches sent to the server",
+		}),
 	}
 
 	_ = prometheus.Register(m.BuildInfo)
+	_ = prometheus.Register(m.LDAPCacheHits)
+	_ = prometheus.Register(m.LDAPCacheMisses)
 	// TODO: implement metrics
 	return m
 }
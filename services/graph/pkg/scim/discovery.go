@@ -0,0 +1,207 @@
+package scim
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// schemaAttribute describes an attribute of a schema, see RFC 7643 section 7
+type schemaAttribute struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	MultiValued   bool              `json:"multiValued"`
+	Required      bool              `json:"required"`
+	CaseExact     bool              `json:"caseExact"`
+	Mutability    string            `json:"mutability"`
+	Returned      string            `json:"returned"`
+	Uniqueness    string            `json:"uniqueness"`
+	SubAttributes []schemaAttribute `json:"subAttributes,omitempty"`
+}
+
+type schema struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Attributes  []schemaAttribute `json:"attributes"`
+	Meta        *meta             `json:"meta,omitempty"`
+}
+
+type resourceType struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Endpoint    string   `json:"endpoint"`
+	Description string   `json:"description"`
+	Schema      string   `json:"schema"`
+	Meta        *meta    `json:"meta,omitempty"`
+}
+
+func attribute(name, typ, mutability string) schemaAttribute {
+	return schemaAttribute{
+		Name:       name,
+		Type:       typ,
+		Mutability: mutability,
+		Returned:   "default",
+		Uniqueness: "none",
+	}
+}
+
+func complexAttribute(name, mutability string, multiValued bool, sub ...schemaAttribute) schemaAttribute {
+	a := attribute(name, "complex", mutability)
+	a.MultiValued = multiValued
+	a.SubAttributes = sub
+	return a
+}
+
+// schemas returns the supported attributes of the core schemas
+func (h *Handler) schemas() []schema {
+	userName := attribute("userName", "string", "readWrite")
+	userName.Required = true
+	userName.Uniqueness = "server"
+	password := attribute("password", "string", "writeOnly")
+	password.Returned = "never"
+	groupName := attribute("displayName", "string", "immutable")
+	groupName.Required = true
+
+	return []schema{
+		{
+			Schemas:     []string{"urn:ietf:params:scim:schemas:core:2.0:Schema"},
+			ID:          userSchema,
+			Name:        "User",
+			Description: "User Account",
+			Attributes: []schemaAttribute{
+				userName,
+				complexAttribute("name", "readWrite", false,
+					attribute("formatted", "string", "readWrite"),
+					attribute("familyName", "string", "readWrite"),
+					attribute("givenName", "string", "readWrite"),
+				),
+				attribute("displayName", "string", "readWrite"),
+				complexAttribute("emails", "readWrite", true,
+					attribute("value", "string", "readWrite"),
+					attribute("type", "string", "readWrite"),
+					attribute("primary", "boolean", "readWrite"),
+				),
+				attribute("active", "boolean", "readWrite"),
+				password,
+				complexAttribute("groups", "readOnly", true,
+					attribute("value", "string", "readOnly"),
+					attribute("$ref", "reference", "readOnly"),
+					attribute("display", "string", "readOnly"),
+				),
+			},
+			Meta: &meta{ResourceType: "Schema", Location: h.location("Schemas", userSchema)},
+		},
+		{
+			Schemas:     []string{"urn:ietf:params:scim:schemas:core:2.0:Schema"},
+			ID:          groupSchema,
+			Name:        "Group",
+			Description: "Group",
+			Attributes: []schemaAttribute{
+				groupName,
+				complexAttribute("members", "readWrite", true,
+					attribute("value", "string", "immutable"),
+					attribute("$ref", "reference", "immutable"),
+					attribute("display", "string", "readOnly"),
+				),
+			},
+			Meta: &meta{ResourceType: "Schema", Location: h.location("Schemas", groupSchema)},
+		},
+	}
+}
+
+func (h *Handler) resourceTypes() []resourceType {
+	return []resourceType{
+		{
+			Schemas:     []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"},
+			ID:          "User",
+			Name:        "User",
+			Endpoint:    "/Users",
+			Description: "User Account",
+			Schema:      userSchema,
+			Meta:        &meta{ResourceType: "ResourceType", Location: h.location("ResourceTypes", "User")},
+		},
+		{
+			Schemas:     []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"},
+			ID:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Group",
+			Schema:      groupSchema,
+			Meta:        &meta{ResourceType: "ResourceType", Location: h.location("ResourceTypes", "Group")},
+		},
+	}
+}
+
+// GetServiceProviderConfig describes the supported features, see RFC 7643 section 5
+func (h *Handler) GetServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	supported := func(s bool) map[string]bool { return map[string]bool{"supported": s} }
+	render(w, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": maxResults},
+		"changePassword": supported(true),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]interface{}{
+			{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication with the configured SCIM bearer token",
+				"primary":     true,
+			},
+		},
+		"meta": meta{ResourceType: "ServiceProviderConfig", Location: h.baseURL + Root + "/ServiceProviderConfig"},
+	})
+}
+
+// ListResourceTypes lists the User and Group resource types
+func (h *Handler) ListResourceTypes(w http.ResponseWriter, r *http.Request) {
+	types := h.resourceTypes()
+	render(w, http.StatusOK, listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(types),
+		StartIndex:   1,
+		ItemsPerPage: len(types),
+		Resources:    types,
+	})
+}
+
+// GetResourceType returns a single resource type
+func (h *Handler) GetResourceType(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	for _, t := range h.resourceTypes() {
+		if t.ID == id {
+			render(w, http.StatusOK, t)
+			return
+		}
+	}
+	renderError(w, http.StatusNotFound, "", "unknown resource type '"+id+"'")
+}
+
+// ListSchemas lists the core User and Group schemas
+func (h *Handler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas := h.schemas()
+	render(w, http.StatusOK, listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(schemas),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    schemas,
+	})
+}
+
+// GetSchema returns a single schema
+func (h *Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	for _, s := range h.schemas() {
+		if s.ID == id {
+			render(w, http.StatusOK, s)
+			return
+		}
+	}
+	renderError(w, http.StatusNotFound, "", "unknown schema '"+id+"'")
+}
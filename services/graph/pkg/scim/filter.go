@@ -0,0 +1,374 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// filterNode is a node of a parsed SCIM filter, see RFC 7644 section 3.4.2.2
+type filterNode struct {
+	// op is "and", "or", "not", "[]" for value paths like 'emails[type eq "work"]' or one of
+	// the attribute operators "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le" and "pr"
+	op string
+	// left and right are the operands of "and" and "or", not and value paths only use left
+	left, right *filterNode
+	// attr is the lower case attribute path of attribute operators and value paths
+	attr string
+	// value is the comparison value, isString tells whether it was a JSON string
+	value    string
+	isString bool
+}
+
+var comparisonOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseFilter parses a SCIM filter expression
+func parseFilter(filter string) (*filterNode, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s'", p.tokens[p.pos])
+	}
+	return node, nil
+}
+
+// tokenizeFilter splits a filter into parentheses, brackets, JSON strings and words
+func tokenizeFilter(filter string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		switch {
+		case c == ' ':
+			i++
+		case strings.IndexByte("()[]", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(filter) && filter[j] != '"'; j++ {
+				if filter[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(filter) {
+				return nil, errors.New("unterminated string")
+			}
+			tokens = append(tokens, filter[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(filter) && filter[j] != ' ' && strings.IndexByte("()[]\"", filter[j]) < 0; j++ {
+			}
+			tokens = append(tokens, filter[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *filterParser) expect(token string) error {
+	if t := p.next(); t != token {
+		return fmt.Errorf("expected '%s' but got '%s'", token, t)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (*filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (*filterNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseFactor() (*filterNode, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of filter")
+	case strings.EqualFold(token, "not"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNode{op: "not", left: inner}, p.expect(")")
+	case token == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case !isAttrPath(token):
+		return nil, fmt.Errorf("unexpected '%s'", token)
+	}
+
+	attr := normalizeAttr(token)
+	if p.peek() == "[" {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNode{op: "[]", attr: attr, left: inner}, p.expect("]")
+	}
+
+	op := strings.ToLower(p.next())
+	if op == "pr" {
+		return &filterNode{op: op, attr: attr}, nil
+	}
+	if !comparisonOperators[op] {
+		return nil, fmt.Errorf("unknown operator '%s'", op)
+	}
+	value := p.next()
+	switch {
+	case strings.HasPrefix(value, `"`):
+		var s string
+		if err := json.Unmarshal([]byte(value), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %s", value)
+		}
+		return &filterNode{op: op, attr: attr, value: s, isString: true}, nil
+	case value == "" || value == "(" || value == ")" || value == "[" || value == "]":
+		return nil, fmt.Errorf("missing value for '%s'", token)
+	}
+	return &filterNode{op: op, attr: attr, value: value}, nil
+}
+
+func isAttrPath(token string) bool {
+	for _, c := range token {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune(":._-$", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeAttr returns the lower case attribute path without the schema prefix of the
+// core schemas, attribute names are case insensitive in SCIM
+func normalizeAttr(attr string) string {
+	attr = strings.ToLower(attr)
+	for _, schema := range []string{userSchema, groupSchema} {
+		attr = strings.TrimPrefix(attr, strings.ToLower(schema)+":")
+	}
+	return attr
+}
+
+// multiValuedAttribute describes a multi valued SCIM attribute that maps to a navigation
+// property of the graph api, like the groups of a user.
+type multiValuedAttribute struct {
+	nav      string
+	variable string
+	sub      map[string]string
+}
+
+// filterAttributes maps SCIM attributes to the properties of the graph api
+type filterAttributes struct {
+	simple map[string]string
+	multi  map[string]multiValuedAttribute
+}
+
+var userFilterAttributes = filterAttributes{
+	simple: map[string]string{
+		"id":           "id",
+		"username":     "onPremisesSamAccountName",
+		"displayname":  "displayName",
+		"emails":       "mail",
+		"emails.value": "mail",
+	},
+	multi: map[string]multiValuedAttribute{
+		"groups": {
+			nav:      "memberOf",
+			variable: "g",
+			sub:      map[string]string{"value": "id", "display": "displayName"},
+		},
+	},
+}
+
+var groupFilterAttributes = filterAttributes{
+	simple: map[string]string{
+		"id":          "id",
+		"displayname": "displayName",
+	},
+	multi: map[string]multiValuedAttribute{
+		"members": {
+			nav:      "members",
+			variable: "m",
+			sub:      map[string]string{"value": "id", "display": "displayName"},
+		},
+	},
+}
+
+// odataFilter translates a SCIM filter into the OData $filter understood by the identity
+// backends. Only string comparisons with "eq", "ne" and "sw" are supported.
+func odataFilter(node *filterNode, attrs filterAttributes) (string, error) {
+	return translateFilter(node, attrs.simple, func(node *filterNode, attr string) (string, error) {
+		// 'groups.value eq "id"' is a short form of 'groups[value eq "id"]'
+		name, sub := attr, ""
+		if i := strings.IndexByte(attr, '.'); i >= 0 {
+			name, sub = attr[:i], attr[i+1:]
+		}
+		multi, ok := attrs.multi[name]
+		if !ok {
+			return "", fmt.Errorf("unsupported attribute '%s'", attr)
+		}
+		props := make(map[string]string, len(multi.sub))
+		for k, v := range multi.sub {
+			props[k] = multi.variable + "/" + v
+		}
+
+		inner := node
+		switch {
+		case node.op == "[]" && sub == "":
+			inner = node.left
+		case node.op != "[]" && sub != "":
+			inner = &filterNode{op: node.op, attr: sub, value: node.value, isString: node.isString}
+		default:
+			return "", fmt.Errorf("unsupported attribute '%s'", attr)
+		}
+		f, err := translateFilter(inner, props, nil)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/any(%s:%s)", multi.nav, multi.variable, f), nil
+	})
+}
+
+// translateFilter translates the filter using the properties for simple attributes. Other
+// attributes are passed to multi.
+func translateFilter(node *filterNode, props map[string]string, multi func(*filterNode, string) (string, error)) (string, error) {
+	switch node.op {
+	case "and", "or":
+		left, err := translateFilter(node.left, props, multi)
+		if err != nil {
+			return "", err
+		}
+		right, err := translateFilter(node.right, props, multi)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s %s %s)", left, node.op, right), nil
+	case "not":
+		inner, err := translateFilter(node.left, props, multi)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("not (%s)", inner), nil
+	}
+
+	prop, ok := props[node.attr]
+	if !ok {
+		if multi == nil {
+			return "", fmt.Errorf("unsupported attribute '%s'", node.attr)
+		}
+		return multi(node, node.attr)
+	}
+	if node.op == "[]" {
+		return "", fmt.Errorf("'%s' is not a multi valued attribute", node.attr)
+	}
+	if !node.isString {
+		return "", fmt.Errorf("unsupported value '%s' for '%s', only strings are supported", node.value, node.attr)
+	}
+	value := strings.ReplaceAll(node.value, "'", "''")
+	switch node.op {
+	case "eq", "ne":
+		return fmt.Sprintf("%s %s '%s'", prop, node.op, value), nil
+	case "sw":
+		return fmt.Sprintf("startswith(%s,'%s')", prop, value), nil
+	}
+	return "", fmt.Errorf("unsupported operator '%s'", node.op)
+}
+
+// matchValue evaluates a value path filter like 'value eq "id"' against a value of a multi
+// valued attribute.
+func matchValue(node *filterNode, values map[string]string) (bool, error) {
+	switch node.op {
+	case "and", "or":
+		left, err := matchValue(node.left, values)
+		if err != nil {
+			return false, err
+		}
+		right, err := matchValue(node.right, values)
+		if err != nil {
+			return false, err
+		}
+		if node.op == "and" {
+			return left && right, nil
+		}
+		return left || right, nil
+	case "not":
+		match, err := matchValue(node.left, values)
+		return !match, err
+	}
+
+	v, ok := values[node.attr]
+	if !ok {
+		return false, fmt.Errorf("unsupported attribute '%s'", node.attr)
+	}
+	switch node.op {
+	case "eq":
+		return v == node.value, nil
+	case "ne":
+		return v != node.value, nil
+	case "sw":
+		return strings.HasPrefix(v, node.value), nil
+	case "pr":
+		return v != "", nil
+	}
+	return false, fmt.Errorf("unsupported operator '%s'", node.op)
+}
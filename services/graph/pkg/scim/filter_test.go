@@ -0,0 +1,104 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CiscoM31/godata"
+)
+
+func TestODataFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		attrs  filterAttributes
+		odata  string
+		err    bool
+	}{
+		{filter: `userName eq "einstein"`, attrs: userFilterAttributes, odata: "onPremisesSamAccountName eq 'einstein'"},
+		{filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName Eq "einstein"`, attrs: userFilterAttributes, odata: "onPremisesSamAccountName eq 'einstein'"},
+		{filter: `emails.value sw "ein" and not (displayName ne "O'Brien")`, attrs: userFilterAttributes, odata: "(startswith(mail,'ein') and not (displayName ne 'O''Brien'))"},
+		{filter: `groups.value eq "g1"`, attrs: userFilterAttributes, odata: "memberOf/any(g:g/id eq 'g1')"},
+		{filter: `groups[value eq "g1" or display eq "physics"]`, attrs: userFilterAttributes, odata: "memberOf/any(g:(g/id eq 'g1' or g/displayName eq 'physics'))"},
+		{filter: `displayName eq "physics" and members[value eq "u1"]`, attrs: groupFilterAttributes, odata: "(displayName eq 'physics' and members/any(m:m/id eq 'u1'))"},
+		{filter: `userName co "ein"`, attrs: userFilterAttributes, err: true},
+		{filter: `userName pr`, attrs: userFilterAttributes, err: true},
+		{filter: `active eq true`, attrs: userFilterAttributes, err: true},
+		{filter: `id eq 1`, attrs: userFilterAttributes, err: true},
+		{filter: `userName[value eq "x"]`, attrs: userFilterAttributes, err: true},
+		{filter: `userName eq "einstein" or`, attrs: userFilterAttributes, err: true},
+		{filter: `(userName eq "einstein"`, attrs: userFilterAttributes, err: true},
+		{filter: `userName eq "einstein`, attrs: userFilterAttributes, err: true},
+	}
+	for _, tt := range tests {
+		node, err := parseFilter(tt.filter)
+		var odata string
+		if err == nil {
+			odata, err = odataFilter(node, tt.attrs)
+		}
+		switch {
+		case tt.err && err == nil:
+			t.Errorf("filter %q: expected an error, got %q", tt.filter, odata)
+		case !tt.err && err != nil:
+			t.Errorf("filter %q: unexpected error: %v", tt.filter, err)
+		case !tt.err && odata != tt.odata:
+			t.Errorf("filter %q: expected %q, got %q", tt.filter, tt.odata, odata)
+		case !tt.err:
+			// the translated filter has to be understood by the identity backends
+			if _, err := godata.ParseFilterString(context.Background(), odata); err != nil {
+				t.Errorf("filter %q: could not parse %q: %v", tt.filter, odata, err)
+			}
+		}
+	}
+}
+
+func TestMatchValue(t *testing.T) {
+	values := map[string]string{"value": "u1", "display": "Albert Einstein"}
+	tests := []struct {
+		filter string
+		match  bool
+		err    bool
+	}{
+		{filter: `value eq "u1"`, match: true},
+		{filter: `value eq "u2" or display sw "Albert"`, match: true},
+		{filter: `not (value eq "u1")`, match: false},
+		{filter: `display pr and value ne "u1"`, match: false},
+		{filter: `type eq "work"`, err: true},
+		{filter: `value gt "u0"`, err: true},
+	}
+	for _, tt := range tests {
+		node, err := parseFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("filter %q: %v", tt.filter, err)
+		}
+		match, err := matchValue(node, values)
+		switch {
+		case tt.err && err == nil:
+			t.Errorf("filter %q: expected an error", tt.filter)
+		case !tt.err && err != nil:
+			t.Errorf("filter %q: unexpected error: %v", tt.filter, err)
+		case match != tt.match:
+			t.Errorf("filter %q: expected %v, got %v", tt.filter, tt.match, match)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	tests := []struct {
+		query                  string
+		startIndex, start, end int
+	}{
+		{query: "", startIndex: 1, start: 0, end: 10},
+		{query: "startIndex=3&count=4", startIndex: 3, start: 2, end: 6},
+		{query: "startIndex=0&count=-1", startIndex: 1, start: 0, end: 0},
+		{query: "startIndex=20", startIndex: 20, start: 10, end: 10},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/Users?"+tt.query, nil)
+		startIndex, start, end := page(r, 10)
+		if startIndex != tt.startIndex || start != tt.start || end != tt.end {
+			t.Errorf("query %q: expected (%d, %d, %d), got (%d, %d, %d)", tt.query, tt.startIndex, tt.start, tt.end, startIndex, start, end)
+		}
+	}
+}
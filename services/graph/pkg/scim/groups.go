@@ -0,0 +1,387 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/go-chi/chi/v5"
+	libregraph "github.com/owncloud/libre-graph-api-go"
+)
+
+// group is the SCIM representation of a group
+type group struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []multiValue `json:"members,omitempty"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+func (h *Handler) groupResource(g *libregraph.Group) group {
+	res := group{
+		Schemas:     []string{groupSchema},
+		ID:          g.GetId(),
+		DisplayName: g.GetDisplayName(),
+		Meta: &meta{
+			ResourceType: "Group",
+			Location:     h.location("Groups", g.GetId()),
+		},
+	}
+	for _, m := range g.Members {
+		res.Members = append(res.Members, multiValue{
+			Value:   m.GetId(),
+			Display: m.GetDisplayName(),
+			Ref:     h.location("Users", m.GetId()),
+		})
+	}
+	return res
+}
+
+// ListGroups lists the groups matching the filter, see RFC 7644 section 3.4.2
+func (h *Handler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim list groups")
+
+	query := url.Values{"$expand": []string{"members"}}
+	if filter := r.URL.Query().Get("filter"); filter != "" {
+		f, err := backendFilter(filter, groupFilterAttributes)
+		if err != nil {
+			logger.Debug().Err(err).Str("filter", filter).Msg("could not list groups: invalid filter")
+			renderErr(w, err)
+			return
+		}
+		query.Set("$filter", f)
+	}
+
+	groups, err := h.backend.GetGroups(r.Context(), query)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not list groups: backend error")
+		renderErr(w, err)
+		return
+	}
+	// sort the groups to return stable pages
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].GetDisplayName() < groups[j].GetDisplayName()
+	})
+
+	startIndex, start, end := page(r, len(groups))
+	resources := make([]group, 0, end-start)
+	for _, g := range groups[start:end] {
+		resources = append(resources, h.groupResource(g))
+	}
+	render(w, http.StatusOK, listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(groups),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetGroup returns a group with its members
+func (h *Handler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim get group")
+
+	id := chi.URLParam(r, "id")
+	g, err := h.getGroup(r.Context(), id)
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not get group: backend error")
+		renderErr(w, err)
+		return
+	}
+	render(w, http.StatusOK, h.groupResource(g))
+}
+
+// CreateGroup creates a group with its members, see RFC 7644 section 3.3
+func (h *Handler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim create group")
+
+	var g group
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		logger.Debug().Err(err).Msg("could not create group: invalid request body")
+		renderError(w, http.StatusBadRequest, errInvalidSyntax, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+	if g.DisplayName == "" {
+		logger.Debug().Msg("could not create group: missing display name")
+		renderError(w, http.StatusBadRequest, errInvalidValue, "the attribute 'displayName' is required")
+		return
+	}
+
+	created, err := h.backend.CreateGroup(r.Context(), libregraph.Group{DisplayName: libregraph.PtrString(g.DisplayName)})
+	if err != nil {
+		logger.Debug().Err(err).Str("displayName", g.DisplayName).Msg("could not create group: backend error")
+		renderErr(w, err)
+		return
+	}
+	h.publishEvent(events.GroupCreated{GroupID: created.GetId()})
+
+	if err := h.updateMembers(r.Context(), created, memberIDs(g.Members)); err != nil {
+		logger.Debug().Err(err).Str("id", created.GetId()).Msg("could not add group members: backend error")
+		renderErr(w, err)
+		return
+	}
+
+	created, err = h.getGroup(r.Context(), created.GetId())
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not get created group: backend error")
+		renderErr(w, err)
+		return
+	}
+	res := h.groupResource(created)
+	w.Header().Set("Location", res.Meta.Location)
+	render(w, http.StatusCreated, res)
+}
+
+// ReplaceGroup replaces the members of a group, see RFC 7644 section 3.5.1. Groups can't
+// be renamed.
+func (h *Handler) ReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim replace group")
+
+	id := chi.URLParam(r, "id")
+	var g group
+	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+		logger.Debug().Err(err).Msg("could not replace group: invalid request body")
+		renderError(w, http.StatusBadRequest, errInvalidSyntax, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+
+	current, err := h.getGroupForUpdate(r.Context(), id)
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not replace group: backend error")
+		renderErr(w, err)
+		return
+	}
+	if g.DisplayName != current.GetDisplayName() {
+		logger.Debug().Str("id", id).Msg("could not replace group: renaming groups is not supported")
+		renderError(w, http.StatusBadRequest, errMutability, "renaming groups is not supported")
+		return
+	}
+	h.replaceMembers(w, r, current, memberIDs(g.Members))
+}
+
+// PatchGroup adds and removes members of a group, see RFC 7644 section 3.5.2
+func (h *Handler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim patch group")
+
+	id := chi.URLParam(r, "id")
+	var req patchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Debug().Err(err).Msg("could not patch group: invalid request body")
+		renderError(w, http.StatusBadRequest, errInvalidSyntax, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+	targets, err := req.targets()
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not patch group: invalid operations")
+		renderErr(w, err)
+		return
+	}
+
+	current, err := h.getGroupForUpdate(r.Context(), id)
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not patch group: backend error")
+		renderErr(w, err)
+		return
+	}
+
+	members := current.Members
+	for _, t := range targets {
+		if members, err = patchMembers(current, members, t); err != nil {
+			logger.Debug().Err(err).Str("id", id).Str("attribute", t.attr).Msg("could not patch group: invalid operation")
+			renderErr(w, err)
+			return
+		}
+	}
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.GetId())
+	}
+	h.replaceMembers(w, r, current, ids)
+}
+
+// patchMembers applies a patch operation to the members of a group. Only members with an
+// id are needed in the result.
+func patchMembers(current *libregraph.Group, members []libregraph.User, t patchTarget) ([]libregraph.User, error) {
+	switch {
+	case t.attr == "displayname":
+		if t.op != "remove" {
+			if name, err := t.stringValue(); err == nil && name == current.GetDisplayName() {
+				return members, nil
+			}
+		}
+		return nil, newError(http.StatusBadRequest, errMutability, "renaming groups is not supported")
+	case t.attr == "id", t.attr == "externalid":
+		// some clients send the id of the group with every change
+		return members, nil
+	case t.attr != "members" || t.sub != "":
+		return nil, newError(http.StatusBadRequest, errInvalidPath, fmt.Sprintf("the attribute '%s' is not supported", t.attr))
+	}
+
+	var values []multiValue
+	if len(t.value) > 0 && string(t.value) != "null" {
+		var err error
+		if values, err = t.multiValues(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case t.op == "add":
+		for _, id := range memberIDs(values) {
+			members = append(members, libregraph.User{Id: libregraph.PtrString(id)})
+		}
+	case t.op == "replace" && t.filter == nil:
+		members = members[:0:0]
+		for _, id := range memberIDs(values) {
+			members = append(members, libregraph.User{Id: libregraph.PtrString(id)})
+		}
+	case t.op == "remove" && t.filter != nil:
+		remaining := make([]libregraph.User, 0, len(members))
+		for _, m := range members {
+			match, err := matchValue(t.filter, map[string]string{"value": m.GetId(), "display": m.GetDisplayName()})
+			if err != nil {
+				return nil, newError(http.StatusBadRequest, errInvalidFilter, err.Error())
+			}
+			if !match {
+				remaining = append(remaining, m)
+			}
+		}
+		members = remaining
+	case t.op == "remove" && len(values) > 0:
+		// Azure AD sends the members to remove as value instead of a filter
+		remove := make(map[string]bool, len(values))
+		for _, id := range memberIDs(values) {
+			remove[id] = true
+		}
+		remaining := make([]libregraph.User, 0, len(members))
+		for _, m := range members {
+			if !remove[m.GetId()] {
+				remaining = append(remaining, m)
+			}
+		}
+		members = remaining
+	case t.op == "remove":
+		members = nil
+	default:
+		return nil, newError(http.StatusBadRequest, errInvalidPath, "value filters are only supported to remove members")
+	}
+	return members, nil
+}
+
+// replaceMembers changes the members of the group to the given members and renders the
+// updated group
+func (h *Handler) replaceMembers(w http.ResponseWriter, r *http.Request, current *libregraph.Group, ids []string) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+
+	if err := h.updateMembers(r.Context(), current, ids); err != nil {
+		logger.Debug().Err(err).Str("id", current.GetId()).Msg("could not update group members: backend error")
+		renderErr(w, err)
+		return
+	}
+	g, err := h.getGroup(r.Context(), current.GetId())
+	if err != nil {
+		logger.Debug().Err(err).Str("id", current.GetId()).Msg("could not get updated group: backend error")
+		renderErr(w, err)
+		return
+	}
+	render(w, http.StatusOK, h.groupResource(g))
+}
+
+// updateMembers adds and removes members of the group until the group has the given members.
+// The members of the group have to be its direct members, see getGroupForUpdate.
+func (h *Handler) updateMembers(ctx context.Context, g *libregraph.Group, ids []string) error {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	existing := make(map[string]bool, len(g.Members))
+	for _, m := range g.Members {
+		existing[m.GetId()] = true
+	}
+
+	var add []string
+	for _, id := range ids {
+		if !existing[id] {
+			add = append(add, id)
+			existing[id] = true
+		}
+	}
+	if len(add) > 0 {
+		if err := h.backend.AddMembersToGroup(ctx, g.GetId(), add); err != nil {
+			return err
+		}
+		for _, id := range add {
+			h.publishEvent(events.GroupMemberAdded{GroupID: g.GetId(), UserID: id})
+		}
+	}
+
+	for _, m := range g.Members {
+		if wanted[m.GetId()] {
+			continue
+		}
+		if err := h.backend.RemoveMemberFromGroup(ctx, g.GetId(), m.GetId()); err != nil {
+			return err
+		}
+		h.publishEvent(events.GroupMemberRemoved{GroupID: g.GetId(), UserID: m.GetId()})
+	}
+	return nil
+}
+
+// DeleteGroup deletes a group, see RFC 7644 section 3.6
+func (h *Handler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim delete group")
+
+	id := chi.URLParam(r, "id")
+	if err := h.backend.DeleteGroup(r.Context(), id); err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not delete group: backend error")
+		renderErr(w, err)
+		return
+	}
+	h.publishEvent(events.GroupDeleted{GroupID: id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getGroup returns the group with all its members, including the members of nested groups
+func (h *Handler) getGroup(ctx context.Context, id string) (*libregraph.Group, error) {
+	return h.backend.GetGroup(ctx, id, url.Values{"$expand": []string{"members"}})
+}
+
+// getGroupForUpdate returns the group with its direct members. Changes of the members are
+// based on them, members of nested groups can't be added or removed directly.
+func (h *Handler) getGroupForUpdate(ctx context.Context, id string) (*libregraph.Group, error) {
+	g, err := h.backend.GetGroup(ctx, id, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	members, err := h.backend.GetDirectGroupMembers(ctx, g.GetId())
+	if err != nil {
+		return nil, err
+	}
+	g.Members = make([]libregraph.User, 0, len(members))
+	for _, m := range members {
+		g.Members = append(g.Members, *m)
+	}
+	return g, nil
+}
+
+// memberIDs returns the ids of the members, ignoring members without an id
+func memberIDs(members []multiValue) []string {
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.Value != "" {
+			ids = append(ids, m.Value)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,76 @@
+package scim
+
+import (
+	"context"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/identity"
+)
+
+// Option defines a single option function.
+type Option func(o *Options)
+
+// Options defines the available options for this package.
+type Options struct {
+	Logger          log.Logger
+	Token           string
+	BaseURL         string
+	IdentityBackend identity.Backend
+	EventsPublisher events.Publisher
+	// PersonalSpaceDeleter deletes the personal space of a user before the user is deleted
+	PersonalSpaceDeleter func(ctx context.Context, userID string) error
+}
+
+// newOptions initializes the available default options.
+func newOptions(opts ...Option) Options {
+	opt := Options{}
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	return opt
+}
+
+// Logger provides a function to set the logger option.
+func Logger(val log.Logger) Option {
+	return func(o *Options) {
+		o.Logger = val
+	}
+}
+
+// Token provides a function to set the bearer token SCIM clients have to authenticate with.
+func Token(val string) Option {
+	return func(o *Options) {
+		o.Token = val
+	}
+}
+
+// BaseURL provides a function to set the public url used for the locations of resources.
+func BaseURL(val string) Option {
+	return func(o *Options) {
+		o.BaseURL = val
+	}
+}
+
+// IdentityBackend provides a function to set the identity backend option.
+func IdentityBackend(val identity.Backend) Option {
+	return func(o *Options) {
+		o.IdentityBackend = val
+	}
+}
+
+// EventsPublisher provides a function to set the events publisher option.
+func EventsPublisher(val events.Publisher) Option {
+	return func(o *Options) {
+		o.EventsPublisher = val
+	}
+}
+
+// PersonalSpaceDeleter provides a function to set the personal space deleter option.
+func PersonalSpaceDeleter(val func(ctx context.Context, userID string) error) Option {
+	return func(o *Options) {
+		o.PersonalSpaceDeleter = val
+	}
+}
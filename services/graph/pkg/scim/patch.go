@@ -0,0 +1,107 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// patchRequest is the body of a PATCH request, see RFC 7644 section 3.5.2
+type patchRequest struct {
+	Schemas    []string `json:"schemas"`
+	Operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	} `json:"Operations"`
+}
+
+// patchTarget is a single attribute changed by a patch operation
+type patchTarget struct {
+	op string
+	// attr is the lower case attribute path, e.g. "displayname" or "emails.value"
+	attr string
+	// filter is the value filter of paths like 'members[value eq "id"]'
+	filter *filterNode
+	// sub is the lower case sub attribute following a value filter
+	sub   string
+	value json.RawMessage
+}
+
+// targets splits the operations of the request into the attributes they change. Operations
+// without a path carry an object with the new values of the attributes.
+func (req patchRequest) targets() ([]patchTarget, error) {
+	if len(req.Operations) == 0 {
+		return nil, newError(http.StatusBadRequest, errInvalidSyntax, "the request does not contain any operations")
+	}
+	var targets []patchTarget
+	for _, o := range req.Operations {
+		op := strings.ToLower(o.Op)
+		switch op {
+		case "add", "replace", "remove":
+		default:
+			return nil, newError(http.StatusBadRequest, errInvalidSyntax, fmt.Sprintf("unknown operation '%s'", o.Op))
+		}
+
+		if o.Path == "" {
+			if op == "remove" {
+				return nil, newError(http.StatusBadRequest, errNoTarget, "remove operations need a path")
+			}
+			values := map[string]json.RawMessage{}
+			if err := json.Unmarshal(o.Value, &values); err != nil {
+				return nil, newError(http.StatusBadRequest, errInvalidValue, "operations without a path need an object value")
+			}
+			attrs := make([]string, 0, len(values))
+			for attr := range values {
+				attrs = append(attrs, attr)
+			}
+			sort.Strings(attrs)
+			for _, attr := range attrs {
+				targets = append(targets, patchTarget{op: op, attr: normalizeAttr(attr), value: values[attr]})
+			}
+			continue
+		}
+
+		t := patchTarget{op: op, attr: normalizeAttr(o.Path), value: o.Value}
+		if i := strings.IndexByte(o.Path, '['); i >= 0 {
+			j := strings.LastIndexByte(o.Path, ']')
+			if j < i {
+				return nil, newError(http.StatusBadRequest, errInvalidPath, fmt.Sprintf("invalid path '%s'", o.Path))
+			}
+			filter, err := parseFilter(o.Path[i+1 : j])
+			if err != nil {
+				return nil, newError(http.StatusBadRequest, errInvalidPath, fmt.Sprintf("invalid path '%s': %s", o.Path, err.Error()))
+			}
+			t.attr = normalizeAttr(o.Path[:i])
+			t.filter = filter
+			t.sub = strings.ToLower(strings.TrimPrefix(o.Path[j+1:], "."))
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// stringValue returns the value of the target, which has to be a string
+func (t patchTarget) stringValue() (string, error) {
+	var s string
+	if err := json.Unmarshal(t.value, &s); err != nil {
+		return "", newError(http.StatusBadRequest, errInvalidValue, fmt.Sprintf("the value of '%s' must be a string", t.attr))
+	}
+	return s, nil
+}
+
+// multiValues returns the value of the target, which has to be a list of values of a multi
+// valued attribute. A single value is accepted as well.
+func (t patchTarget) multiValues() ([]multiValue, error) {
+	var values []multiValue
+	if err := json.Unmarshal(t.value, &values); err == nil {
+		return values, nil
+	}
+	var value multiValue
+	if err := json.Unmarshal(t.value, &value); err != nil {
+		return nil, newError(http.StatusBadRequest, errInvalidValue, fmt.Sprintf("invalid value for '%s'", t.attr))
+	}
+	return []multiValue{value}, nil
+}
@@ -0,0 +1,245 @@
+// Package scim implements a SCIM 2.0 (RFC 7643, RFC 7644) provisioning endpoint for users
+// and groups on top of the graph identity backend.
+package scim
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/identity"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+)
+
+// Root is the path the SCIM endpoint is served at
+const Root = "/scim/v2"
+
+const (
+	userSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	groupSchema        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	listResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	patchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	errorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	contentType = "application/scim+json"
+
+	// maxResults is the maximum number of resources returned in a single list response
+	maxResults = 200
+)
+
+// scimType values of the error responses, see RFC 7644 section 3.12
+const (
+	errInvalidFilter = "invalidFilter"
+	errInvalidPath   = "invalidPath"
+	errInvalidSyntax = "invalidSyntax"
+	errInvalidValue  = "invalidValue"
+	errMutability    = "mutability"
+	errNoTarget      = "noTarget"
+	errUniqueness    = "uniqueness"
+)
+
+// Handler serves the SCIM endpoint
+type Handler struct {
+	mux       *chi.Mux
+	logger    log.Logger
+	baseURL   string
+	backend   identity.Backend
+	publisher events.Publisher
+
+	deletePersonalSpace func(ctx context.Context, userID string) error
+}
+
+// NewHandler returns a handler serving the SCIM endpoint below Root. All requests have to be
+// authenticated with the configured bearer token.
+func NewHandler(opts ...Option) *Handler {
+	options := newOptions(opts...)
+
+	h := &Handler{
+		mux:       chi.NewMux(),
+		logger:    options.Logger,
+		baseURL:   strings.TrimSuffix(options.BaseURL, "/"),
+		backend:   options.IdentityBackend,
+		publisher: options.EventsPublisher,
+
+		deletePersonalSpace: options.PersonalSpaceDeleter,
+	}
+
+	h.mux.Use(middleware.RequestID, authenticate(options.Token))
+	h.mux.Route(Root, func(r chi.Router) {
+		r.Use(middleware.StripSlashes)
+		r.Get("/ServiceProviderConfig", h.GetServiceProviderConfig)
+		r.Get("/ResourceTypes", h.ListResourceTypes)
+		r.Get("/ResourceTypes/{id}", h.GetResourceType)
+		r.Get("/Schemas", h.ListSchemas)
+		r.Get("/Schemas/{id}", h.GetSchema)
+		r.Route("/Users", func(r chi.Router) {
+			r.Get("/", h.ListUsers)
+			r.Post("/", h.CreateUser)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetUser)
+				r.Put("/", h.ReplaceUser)
+				r.Patch("/", h.PatchUser)
+				r.Delete("/", h.DeleteUser)
+			})
+		})
+		r.Route("/Groups", func(r chi.Router) {
+			r.Get("/", h.ListGroups)
+			r.Post("/", h.CreateGroup)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetGroup)
+				r.Put("/", h.ReplaceGroup)
+				r.Patch("/", h.PatchGroup)
+				r.Delete("/", h.DeleteGroup)
+			})
+		})
+	})
+	return h
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// authenticate only lets requests pass that carry the token in the 'Authorization' header
+func authenticate(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, bearer, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if token == "" || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+				renderError(w, http.StatusUnauthorized, "", "invalid bearer token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// location returns the public url of a resource
+func (h *Handler) location(resourceType, id string) string {
+	return h.baseURL + Root + "/" + resourceType + "/" + id
+}
+
+func (h *Handler) publishEvent(ev interface{}) {
+	if h.publisher != nil {
+		if err := events.Publish(h.publisher, ev); err != nil {
+			h.logger.Error().
+				Err(err).
+				Msg("could not publish scim event")
+		}
+	}
+}
+
+func render(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	// the status has been sent already, encoding errors can't be reported to the client
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// renderError writes a SCIM error response, see RFC 7644 section 3.12
+func renderError(w http.ResponseWriter, status int, scimType, detail string) {
+	res := map[string]interface{}{
+		"schemas": []string{errorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	}
+	if scimType != "" {
+		res["scimType"] = scimType
+	}
+	render(w, status, res)
+}
+
+// scimError is an error with the status and the scimType of its error response
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func newError(status int, scimType, detail string) error {
+	return scimError{status: status, scimType: scimType, detail: detail}
+}
+
+func (e scimError) Error() string {
+	return e.detail
+}
+
+// renderErr writes the error response for err. Errors of the identity backend are mapped
+// to the matching SCIM errors.
+func renderErr(w http.ResponseWriter, err error) {
+	var serr scimError
+	if errors.As(err, &serr) {
+		renderError(w, serr.status, serr.scimType, serr.detail)
+		return
+	}
+	var errcode errorcode.Error
+	if !errors.As(err, &errcode) {
+		renderError(w, http.StatusInternalServerError, "", err.Error())
+		return
+	}
+	switch errcode.GetCode() {
+	case errorcode.ItemNotFound:
+		renderError(w, http.StatusNotFound, "", errcode.GetMessage())
+	case errorcode.NameAlreadyExists:
+		renderError(w, http.StatusConflict, errUniqueness, errcode.GetMessage())
+	case errorcode.InvalidRequest:
+		renderError(w, http.StatusBadRequest, errInvalidValue, errcode.GetMessage())
+	case errorcode.NotSupported:
+		renderError(w, http.StatusBadRequest, errMutability, errcode.GetMessage())
+	case errorcode.NotAllowed, errorcode.AccessDenied:
+		renderError(w, http.StatusForbidden, "", errcode.GetMessage())
+	default:
+		renderError(w, http.StatusInternalServerError, "", errcode.GetMessage())
+	}
+}
+
+// listResponse is the response to a query, see RFC 7644 section 3.4.2
+type listResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// pageParams returns the 'startIndex' and 'count' query parameters of the page requested.
+// startIndex is 1-based like in the requests.
+func pageParams(r *http.Request) (startIndex, count int) {
+	startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err = strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count > maxResults {
+		count = maxResults
+	}
+	if count < 0 {
+		count = 0
+	}
+	return startIndex, count
+}
+
+// page returns the bounds of the page requested with the 'startIndex' and 'count' query
+// parameters. startIndex is 1-based like in the requests.
+func page(r *http.Request, total int) (startIndex, start, end int) {
+	startIndex, count := pageParams(r)
+	start = startIndex - 1
+	if start > total {
+		start = total
+	}
+	end = start + count
+	if end > total {
+		end = total
+	}
+	return startIndex, start, end
+}
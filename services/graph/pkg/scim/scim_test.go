@@ -0,0 +1,370 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	libregraph "github.com/owncloud/libre-graph-api-go"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+	"github.com/stretchr/testify/mock"
+)
+
+const token = "secret"
+
+func newTestHandler() (*Handler, *mocks.IdentityBackend, *mocks.Publisher) {
+	backend := &mocks.IdentityBackend{}
+	publisher := &mocks.Publisher{}
+	h := NewHandler(
+		Logger(log.NewLogger()),
+		Token(token),
+		BaseURL("https://localhost:9200/"),
+		IdentityBackend(backend),
+		EventsPublisher(publisher),
+	)
+	return h, backend, publisher
+}
+
+func do(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, r)
+	return rr
+}
+
+func decode(t *testing.T, rr *httptest.ResponseRecorder) map[string]interface{} {
+	res := map[string]interface{}{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid response body %q: %v", rr.Body.String(), err)
+	}
+	return res
+}
+
+func TestAuthentication(t *testing.T) {
+	h, _, _ := newTestHandler()
+	for _, auth := range []string{"", "Bearer wrong", token} {
+		r := httptest.NewRequest(http.MethodGet, Root+"/ServiceProviderConfig", nil)
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("authorization %q: expected status 401, got %d", auth, rr.Code)
+		}
+	}
+
+	rr := do(h, http.MethodGet, Root+"/ServiceProviderConfig", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("expected content type %q, got %q", contentType, ct)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	h, backend, publisher := newTestHandler()
+	backend.On("CreateUser", mock.Anything, mock.MatchedBy(func(u libregraph.User) bool {
+		return u.GetOnPremisesSamAccountName() == "einstein" &&
+			u.GetDisplayName() == "Albert Einstein" &&
+			u.GetMail() == "einstein@example.org" &&
+			u.GetPasswordProfile().Password != nil
+	})).Return(&libregraph.User{
+		Id:                       libregraph.PtrString("u1"),
+		OnPremisesSamAccountName: libregraph.PtrString("einstein"),
+		DisplayName:              libregraph.PtrString("Albert Einstein"),
+		Mail:                     libregraph.PtrString("einstein@example.org"),
+	}, nil)
+	publisher.On("Publish", mock.Anything, events.UserCreated{UserID: "u1"}, mock.Anything).Return(nil)
+
+	rr := do(h, http.MethodPost, Root+"/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "einstein",
+		"name": {"givenName": "Albert", "familyName": "Einstein"},
+		"emails": [{"value": "einstein@example.org", "primary": true}],
+		"password": "relativity"
+	}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if loc := rr.Header().Get("Location"); loc != "https://localhost:9200/scim/v2/Users/u1" {
+		t.Errorf("unexpected location %q", loc)
+	}
+	res := decode(t, rr)
+	if res["id"] != "u1" || res["userName"] != "einstein" {
+		t.Errorf("unexpected user %v", res)
+	}
+	backend.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	rr = do(h, http.MethodPost, Root+"/Users", `{"userName": "einstein"}`)
+	if rr.Code != http.StatusBadRequest || decode(t, rr)["scimType"] != errInvalidValue {
+		t.Errorf("expected invalidValue error for a user without email, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	h, backend, _ := newTestHandler()
+	backend.On("GetUsers", mock.Anything, url.Values{
+		"$expand": []string{"memberOf"},
+		"$filter": []string{"onPremisesSamAccountName eq 'einstein'"},
+		"$skip":   []string{"0"},
+		"$top":    []string{"200"},
+	}).Return([]*libregraph.User{
+		{Id: libregraph.PtrString("u1"), OnPremisesSamAccountName: libregraph.PtrString("einstein")},
+	}, nil)
+	backend.On("GetAccountsEnabled", mock.Anything, []string{"u1"}).Return(map[string]bool{"u1": true}, nil)
+
+	rr := do(h, http.MethodGet, Root+"/Users?filter="+url.QueryEscape(`userName eq "einstein"`), "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	res := decode(t, rr)
+	if res["totalResults"] != float64(1) || len(res["Resources"].([]interface{})) != 1 {
+		t.Errorf("unexpected list response %v", res)
+	}
+
+	// the backend pages the users, their status is read at once
+	backend.On("GetUsers", mock.Anything, url.Values{
+		"$expand": []string{"memberOf"},
+		"$skip":   []string{"1"},
+		"$top":    []string{"2"},
+	}).Return([]*libregraph.User{
+		{Id: libregraph.PtrString("u1"), OnPremisesSamAccountName: libregraph.PtrString("einstein")},
+		{Id: libregraph.PtrString("u2"), OnPremisesSamAccountName: libregraph.PtrString("marie")},
+		{Id: libregraph.PtrString("u3"), OnPremisesSamAccountName: libregraph.PtrString("richard")},
+		{Id: libregraph.PtrString("u4"), OnPremisesSamAccountName: libregraph.PtrString("moss")},
+	}, nil)
+	backend.On("GetAccountsEnabled", mock.Anything, []string{"u2", "u3"}).Return(map[string]bool{"u2": false, "u3": true}, nil)
+
+	rr = do(h, http.MethodGet, Root+"/Users?startIndex=2&count=2", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	res = decode(t, rr)
+	resources := res["Resources"].([]interface{})
+	if res["totalResults"] != float64(4) || res["startIndex"] != float64(2) || len(resources) != 2 {
+		t.Fatalf("unexpected list response %v", res)
+	}
+	if first := resources[0].(map[string]interface{}); first["id"] != "u2" || first["active"] != false {
+		t.Errorf("unexpected first user %v", first)
+	}
+	backend.AssertNotCalled(t, "GetAccountEnabled", mock.Anything, mock.Anything)
+
+	rr = do(h, http.MethodGet, Root+"/Users?filter="+url.QueryEscape(`userName co "ein"`), "")
+	if rr.Code != http.StatusBadRequest || decode(t, rr)["scimType"] != errInvalidFilter {
+		t.Errorf("expected invalidFilter error, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDeactivateUser(t *testing.T) {
+	h, backend, publisher := newTestHandler()
+	backend.On("UpdateUser", mock.Anything, "u1", libregraph.User{}).Return(&libregraph.User{}, nil)
+	backend.On("SetAccountEnabled", mock.Anything, "u1", false).Return(nil)
+	backend.On("GetUser", mock.Anything, "u1", mock.Anything).Return(&libregraph.User{
+		Id:                       libregraph.PtrString("u1"),
+		OnPremisesSamAccountName: libregraph.PtrString("einstein"),
+	}, nil)
+	backend.On("GetAccountEnabled", mock.Anything, "u1").Return(false, nil)
+	publisher.On("Publish", mock.Anything, events.UserFeatureChanged{
+		UserID:   "u1",
+		Features: []events.UserFeature{{Name: "accountEnabled", Value: "false"}},
+	}, mock.Anything).Return(nil)
+
+	rr := do(h, http.MethodPatch, Root+"/Users/u1", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "value": {"active": false}}]
+	}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if active := decode(t, rr)["active"]; active != false {
+		t.Errorf("expected an inactive user, got active=%v", active)
+	}
+	backend.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+}
+
+func TestPatchUserEmailFilter(t *testing.T) {
+	h, backend, publisher := newTestHandler()
+	backend.On("UpdateUser", mock.Anything, "u1", libregraph.User{Mail: libregraph.PtrString("einstein@example.org")}).Return(&libregraph.User{}, nil)
+	backend.On("GetUser", mock.Anything, "u1", mock.Anything).Return(&libregraph.User{
+		Id:                       libregraph.PtrString("u1"),
+		OnPremisesSamAccountName: libregraph.PtrString("einstein"),
+		Mail:                     libregraph.PtrString("einstein@example.org"),
+	}, nil)
+	backend.On("GetAccountEnabled", mock.Anything, "u1").Return(true, nil)
+	publisher.On("Publish", mock.Anything, events.UserFeatureChanged{
+		UserID:   "u1",
+		Features: []events.UserFeature{{Name: "email", Value: "einstein@example.org"}},
+	}, mock.Anything).Return(nil)
+
+	rr := do(h, http.MethodPatch, Root+"/Users/u1", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "einstein@example.org"}]
+	}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	backend.AssertExpectations(t)
+
+	backend.Calls = nil
+	for path, scimType := range map[string]string{
+		`emails[type eq \"home\"].value`: errNoTarget,
+		`emails[display eq \"x\"].value`: errInvalidFilter,
+	} {
+		rr = do(h, http.MethodPatch, Root+"/Users/u1", `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "path": "`+path+`", "value": "einstein@example.com"}]
+		}`)
+		if rr.Code != http.StatusBadRequest || decode(t, rr)["scimType"] != scimType {
+			t.Errorf("expected status 400 %s for %s, got %d: %s", scimType, path, rr.Code, rr.Body.String())
+		}
+	}
+	backend.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteUser(t *testing.T) {
+	h, backend, publisher := newTestHandler()
+	var deletedSpaces []string
+	h.deletePersonalSpace = func(ctx context.Context, userID string) error {
+		deletedSpaces = append(deletedSpaces, userID)
+		return nil
+	}
+	backend.On("GetUser", mock.Anything, "einstein", mock.Anything).Return(&libregraph.User{
+		Id:                       libregraph.PtrString("u1"),
+		OnPremisesSamAccountName: libregraph.PtrString("einstein"),
+	}, nil)
+	backend.On("DeleteUser", mock.Anything, "u1").Return(nil)
+	publisher.On("Publish", mock.Anything, events.UserDeleted{UserID: "u1"}, mock.Anything).Return(nil)
+
+	rr := do(h, http.MethodDelete, Root+"/Users/einstein", "")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(deletedSpaces) != 1 || deletedSpaces[0] != "u1" {
+		t.Errorf("expected the personal space of u1 to be deleted, got %v", deletedSpaces)
+	}
+	backend.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	// the user is kept if the personal space can't be deleted
+	h.deletePersonalSpace = func(ctx context.Context, userID string) error {
+		return errors.New("could not delete homespace, aborting")
+	}
+	backend.Calls = nil
+	rr = do(h, http.MethodDelete, Root+"/Users/einstein", "")
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d: %s", rr.Code, rr.Body.String())
+	}
+	backend.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+}
+
+func TestGetUserNotFound(t *testing.T) {
+	h, backend, _ := newTestHandler()
+	backend.On("GetUser", mock.Anything, "missing", mock.Anything).
+		Return(nil, errorcode.New(errorcode.ItemNotFound, "not found"))
+
+	rr := do(h, http.MethodGet, Root+"/Users/missing", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestPatchGroupMembers(t *testing.T) {
+	h, backend, publisher := newTestHandler()
+	backend.On("GetGroup", mock.Anything, "g1", mock.Anything).Return(&libregraph.Group{
+		Id:          libregraph.PtrString("g1"),
+		DisplayName: libregraph.PtrString("physics"),
+		Members: []libregraph.User{
+			{Id: libregraph.PtrString("u1"), DisplayName: libregraph.PtrString("Albert Einstein")},
+			{Id: libregraph.PtrString("u2"), DisplayName: libregraph.PtrString("Marie Curie")},
+		},
+	}, nil)
+	backend.On("GetDirectGroupMembers", mock.Anything, "g1").Return([]*libregraph.User{
+		{Id: libregraph.PtrString("u1"), DisplayName: libregraph.PtrString("Albert Einstein")},
+		{Id: libregraph.PtrString("u2"), DisplayName: libregraph.PtrString("Marie Curie")},
+	}, nil)
+	backend.On("AddMembersToGroup", mock.Anything, "g1", []string{"u3"}).Return(nil)
+	backend.On("RemoveMemberFromGroup", mock.Anything, "g1", "u1").Return(nil)
+	publisher.On("Publish", mock.Anything, events.GroupMemberAdded{GroupID: "g1", UserID: "u3"}, mock.Anything).Return(nil)
+	publisher.On("Publish", mock.Anything, events.GroupMemberRemoved{GroupID: "g1", UserID: "u1"}, mock.Anything).Return(nil)
+
+	rr := do(h, http.MethodPatch, Root+"/Groups/g1", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "add", "path": "members", "value": [{"value": "u3"}]},
+			{"op": "remove", "path": "members[value eq \"u1\"]"}
+		]
+	}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	backend.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	rr = do(h, http.MethodPatch, Root+"/Groups/g1", `{
+		"Operations": [{"op": "replace", "path": "displayName", "value": "chemistry"}]
+	}`)
+	if rr.Code != http.StatusBadRequest || decode(t, rr)["scimType"] != errMutability {
+		t.Errorf("expected mutability error, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestPatchGroupNestedMembers(t *testing.T) {
+	h, backend, publisher := newTestHandler()
+	// u3 is a member of a nested group, it is only part of the expanded members
+	backend.On("GetGroup", mock.Anything, "g1", mock.Anything).Return(&libregraph.Group{
+		Id:          libregraph.PtrString("g1"),
+		DisplayName: libregraph.PtrString("physics"),
+		Members: []libregraph.User{
+			{Id: libregraph.PtrString("u1")},
+			{Id: libregraph.PtrString("u2")},
+			{Id: libregraph.PtrString("u3")},
+		},
+	}, nil)
+	backend.On("GetDirectGroupMembers", mock.Anything, "g1").Return([]*libregraph.User{
+		{Id: libregraph.PtrString("u1")},
+		{Id: libregraph.PtrString("u2")},
+	}, nil)
+	backend.On("AddMembersToGroup", mock.Anything, "g1", []string{"u3"}).Return(nil)
+	backend.On("RemoveMemberFromGroup", mock.Anything, "g1", "u2").Return(nil)
+	publisher.On("Publish", mock.Anything, events.GroupMemberAdded{GroupID: "g1", UserID: "u3"}, mock.Anything).Return(nil)
+	publisher.On("Publish", mock.Anything, events.GroupMemberRemoved{GroupID: "g1", UserID: "u2"}, mock.Anything).Return(nil)
+
+	rr := do(h, http.MethodPatch, Root+"/Groups/g1", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "add", "path": "members", "value": [{"value": "u3"}]},
+			{"op": "remove", "path": "members[value eq \"u2\"]"}
+		]
+	}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	backend.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+
+	// replacing the members doesn't remove the members of nested groups
+	backend.Calls = nil
+	publisher.Calls = nil
+	rr = do(h, http.MethodPatch, Root+"/Groups/g1", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "members", "value": [{"value": "u1"}]}]
+	}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	backend.AssertNotCalled(t, "RemoveMemberFromGroup", mock.Anything, "g1", "u3")
+	backend.AssertNotCalled(t, "AddMembersToGroup", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,467 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/go-chi/chi/v5"
+	libregraph "github.com/owncloud/libre-graph-api-go"
+)
+
+// multiValue is a value of a multi valued attribute like emails or members
+type multiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+}
+
+// user is the SCIM representation of a user. The password is only read from requests.
+type user struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        *name        `json:"name,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []multiValue `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Password    string       `json:"password,omitempty"`
+	Groups      []multiValue `json:"groups,omitempty"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+// mail returns the primary email address, or the first one if none is marked as primary
+func (u user) mail() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// displayName falls back to the name and the user name of users without a display name
+func (u user) displayName() string {
+	switch {
+	case u.DisplayName != "":
+		return u.DisplayName
+	case u.Name != nil && u.Name.Formatted != "":
+		return u.Name.Formatted
+	case u.Name != nil && (u.Name.GivenName != "" || u.Name.FamilyName != ""):
+		return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	}
+	return u.UserName
+}
+
+// validate checks the attributes required to create or replace a user
+func (u user) validate() error {
+	switch {
+	case u.UserName == "":
+		return newError(http.StatusBadRequest, errInvalidValue, "the attribute 'userName' is required")
+	case u.mail() == "":
+		return newError(http.StatusBadRequest, errInvalidValue, "the attribute 'emails' is required")
+	}
+	return nil
+}
+
+// userResource returns the SCIM representation of the user, active tells whether the account
+// of the user is enabled
+func (h *Handler) userResource(u *libregraph.User, active bool) user {
+	res := user{
+		Schemas:     []string{userSchema},
+		ID:          u.GetId(),
+		UserName:    u.GetOnPremisesSamAccountName(),
+		DisplayName: u.GetDisplayName(),
+		Active:      &active,
+		Meta: &meta{
+			ResourceType: "User",
+			Location:     h.location("Users", u.GetId()),
+		},
+	}
+	if u.GetMail() != "" {
+		res.Emails = []multiValue{{Value: u.GetMail(), Type: "work", Primary: true}}
+	}
+	if u.GetSurname() != "" {
+		res.Name = &name{FamilyName: u.GetSurname()}
+	}
+	for _, g := range u.MemberOf {
+		res.Groups = append(res.Groups, multiValue{
+			Value:   g.GetId(),
+			Display: g.GetDisplayName(),
+			Ref:     h.location("Groups", g.GetId()),
+		})
+	}
+	return res
+}
+
+// ListUsers lists the users matching the filter, see RFC 7644 section 3.4.2. The users are
+// paged by the backend in the order it returns them. Backends may stop after the requested
+// page and one more user, so totalResults is only exact on the last page, before it just
+// tells that there are more results.
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim list users")
+
+	startIndex, count := pageParams(r)
+	query := url.Values{
+		"$expand": []string{"memberOf"},
+		"$skip":   []string{strconv.Itoa(startIndex - 1)},
+		"$top":    []string{strconv.Itoa(count)},
+	}
+	if filter := r.URL.Query().Get("filter"); filter != "" {
+		f, err := backendFilter(filter, userFilterAttributes)
+		if err != nil {
+			logger.Debug().Err(err).Str("filter", filter).Msg("could not list users: invalid filter")
+			renderErr(w, err)
+			return
+		}
+		query.Set("$filter", f)
+	}
+
+	users, err := h.backend.GetUsers(r.Context(), query)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not list users: backend error")
+		renderErr(w, err)
+		return
+	}
+
+	_, start, end := page(r, len(users))
+	ids := make([]string, 0, end-start)
+	for _, u := range users[start:end] {
+		ids = append(ids, u.GetId())
+	}
+	enabled, err := h.backend.GetAccountsEnabled(r.Context(), ids)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not list users: backend error")
+		renderErr(w, err)
+		return
+	}
+	resources := make([]user, 0, end-start)
+	for _, u := range users[start:end] {
+		// users deleted in the meantime are reported as active, like users without a status
+		active, ok := enabled[u.GetId()]
+		resources = append(resources, h.userResource(u, active || !ok))
+	}
+	render(w, http.StatusOK, listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(users),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// GetUser returns a user
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim get user")
+
+	id := chi.URLParam(r, "id")
+	u, err := h.backend.GetUser(r.Context(), id, url.Values{"$expand": []string{"memberOf"}})
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not get user: backend error")
+		renderErr(w, err)
+		return
+	}
+	active, err := h.backend.GetAccountEnabled(r.Context(), u.GetId())
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not get user: backend error")
+		renderErr(w, err)
+		return
+	}
+	render(w, http.StatusOK, h.userResource(u, active))
+}
+
+// CreateUser creates a user, see RFC 7644 section 3.3
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim create user")
+
+	var u user
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		logger.Debug().Err(err).Msg("could not create user: invalid request body")
+		renderError(w, http.StatusBadRequest, errInvalidSyntax, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+	if err := u.validate(); err != nil {
+		logger.Debug().Err(err).Str("userName", u.UserName).Msg("could not create user: invalid user")
+		renderErr(w, err)
+		return
+	}
+
+	newUser := libregraph.User{
+		OnPremisesSamAccountName: libregraph.PtrString(u.UserName),
+		DisplayName:              libregraph.PtrString(u.displayName()),
+		Mail:                     libregraph.PtrString(u.mail()),
+	}
+	if u.Name != nil && u.Name.FamilyName != "" {
+		newUser.Surname = libregraph.PtrString(u.Name.FamilyName)
+	}
+	if u.Password != "" {
+		newUser.PasswordProfile = &libregraph.PasswordProfile{Password: libregraph.PtrString(u.Password)}
+	}
+	created, err := h.backend.CreateUser(r.Context(), newUser)
+	if err != nil {
+		logger.Debug().Err(err).Str("userName", u.UserName).Msg("could not create user: backend error")
+		renderErr(w, err)
+		return
+	}
+
+	h.publishEvent(events.UserCreated{UserID: created.GetId()})
+
+	active := u.Active == nil || *u.Active
+	if !active {
+		if err := h.backend.SetAccountEnabled(r.Context(), created.GetId(), false); err != nil {
+			logger.Debug().Err(err).Str("id", created.GetId()).Msg("could not disable created user: backend error")
+			renderErr(w, err)
+			return
+		}
+		h.publishEvent(events.UserFeatureChanged{
+			UserID:   created.GetId(),
+			Features: []events.UserFeature{{Name: "accountEnabled", Value: "false"}},
+		})
+	}
+	res := h.userResource(created, active)
+	w.Header().Set("Location", res.Meta.Location)
+	render(w, http.StatusCreated, res)
+}
+
+// ReplaceUser replaces the attributes of a user, see RFC 7644 section 3.5.1
+func (h *Handler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim replace user")
+
+	id := chi.URLParam(r, "id")
+	var u user
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		logger.Debug().Err(err).Msg("could not replace user: invalid request body")
+		renderError(w, http.StatusBadRequest, errInvalidSyntax, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+	if err := u.validate(); err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not replace user: invalid user")
+		renderErr(w, err)
+		return
+	}
+
+	changes := userChanges{
+		user: libregraph.User{
+			OnPremisesSamAccountName: libregraph.PtrString(u.UserName),
+			DisplayName:              libregraph.PtrString(u.displayName()),
+			Mail:                     libregraph.PtrString(u.mail()),
+		},
+		active: u.Active,
+	}
+	if u.Password != "" {
+		changes.user.PasswordProfile = &libregraph.PasswordProfile{Password: libregraph.PtrString(u.Password)}
+	}
+	h.updateUser(w, r, id, changes)
+}
+
+// PatchUser changes single attributes of a user, see RFC 7644 section 3.5.2. The display
+// name, the email address, the password and whether the user is active can be changed.
+func (h *Handler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim patch user")
+
+	id := chi.URLParam(r, "id")
+	var req patchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Debug().Err(err).Msg("could not patch user: invalid request body")
+		renderError(w, http.StatusBadRequest, errInvalidSyntax, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+	targets, err := req.targets()
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not patch user: invalid operations")
+		renderErr(w, err)
+		return
+	}
+
+	var changes userChanges
+	for _, t := range targets {
+		if err := patchUser(&changes, t); err != nil {
+			logger.Debug().Err(err).Str("id", id).Str("attribute", t.attr).Msg("could not patch user: invalid operation")
+			renderErr(w, err)
+			return
+		}
+	}
+	h.updateUser(w, r, id, changes)
+}
+
+// userChanges are the changes to apply to a user. The libregraph user model lacks the
+// account status.
+type userChanges struct {
+	user   libregraph.User
+	active *bool
+}
+
+// patchUser applies a patch operation to the changes of a user
+func patchUser(changes *userChanges, t patchTarget) error {
+	if t.op == "remove" {
+		return newError(http.StatusBadRequest, errMutability, fmt.Sprintf("the attribute '%s' can not be removed", t.attr))
+	}
+	if t.filter != nil {
+		if err := matchEmail(t); err != nil {
+			return err
+		}
+	}
+	switch {
+	case t.attr == "displayname", t.attr == "username", t.attr == "password",
+		t.attr == "emails.value", t.attr == "emails" && t.sub == "value":
+		value, err := t.stringValue()
+		if err != nil {
+			return err
+		}
+		switch t.attr {
+		case "displayname":
+			changes.user.DisplayName = &value
+		case "username":
+			changes.user.OnPremisesSamAccountName = &value
+		case "password":
+			changes.user.PasswordProfile = &libregraph.PasswordProfile{Password: &value}
+		default:
+			changes.user.Mail = &value
+		}
+	case t.attr == "emails" && t.sub == "":
+		values, err := t.multiValues()
+		if err != nil {
+			return err
+		}
+		mail := user{Emails: values}.mail()
+		changes.user.Mail = &mail
+	case t.attr == "active":
+		var active bool
+		if err := json.Unmarshal(t.value, &active); err != nil {
+			return newError(http.StatusBadRequest, errInvalidValue, "the value of 'active' must be a boolean")
+		}
+		changes.active = &active
+	default:
+		return newError(http.StatusBadRequest, errInvalidPath, fmt.Sprintf("the attribute '%s' is not supported", t.attr))
+	}
+	return nil
+}
+
+// matchEmail checks a value filter like 'emails[type eq "work"].value' against the only email
+// address of a user, which is rendered as the primary work address.
+func matchEmail(t patchTarget) error {
+	if t.attr != "emails" || t.sub != "value" {
+		return newError(http.StatusBadRequest, errInvalidFilter, fmt.Sprintf("value filters are not supported for the attribute '%s'", t.attr))
+	}
+	match, err := matchValue(t.filter, map[string]string{"type": "work", "primary": "true"})
+	if err != nil {
+		return newError(http.StatusBadRequest, errInvalidFilter, err.Error())
+	}
+	if !match {
+		return newError(http.StatusBadRequest, errNoTarget, "the value filter matches no email address")
+	}
+	return nil
+}
+
+// updateUser applies the changes to the user and renders the updated user
+func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request, id string, changes userChanges) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+
+	var features []events.UserFeature
+	if changes.user.Mail != nil {
+		features = append(features, events.UserFeature{Name: "email", Value: *changes.user.Mail})
+	}
+	if changes.user.DisplayName != nil {
+		features = append(features, events.UserFeature{Name: "displayname", Value: *changes.user.DisplayName})
+	}
+	if changes.user.PasswordProfile != nil {
+		features = append(features, events.UserFeature{Name: "password", Value: "***"})
+	}
+
+	if _, err := h.backend.UpdateUser(r.Context(), id, changes.user); err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not update user: backend error")
+		renderErr(w, err)
+		return
+	}
+	if changes.active != nil {
+		if err := h.backend.SetAccountEnabled(r.Context(), id, *changes.active); err != nil {
+			logger.Debug().Err(err).Str("id", id).Msg("could not update account status: backend error")
+			renderErr(w, err)
+			return
+		}
+		features = append(features, events.UserFeature{Name: "accountEnabled", Value: strconv.FormatBool(*changes.active)})
+	}
+	if len(features) > 0 {
+		h.publishEvent(events.UserFeatureChanged{UserID: id, Features: features})
+	}
+
+	u, err := h.backend.GetUser(r.Context(), id, url.Values{"$expand": []string{"memberOf"}})
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not get updated user: backend error")
+		renderErr(w, err)
+		return
+	}
+	active, err := h.backend.GetAccountEnabled(r.Context(), id)
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not get updated user: backend error")
+		renderErr(w, err)
+		return
+	}
+	render(w, http.StatusOK, h.userResource(u, active))
+}
+
+// DeleteUser deletes a user, see RFC 7644 section 3.6
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	logger := h.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling scim delete user")
+
+	id := chi.URLParam(r, "id")
+	u, err := h.backend.GetUser(r.Context(), id, url.Values{})
+	if err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not delete user: backend error")
+		renderErr(w, err)
+		return
+	}
+	id = u.GetId()
+	if h.deletePersonalSpace != nil {
+		if err := h.deletePersonalSpace(r.Context(), id); err != nil {
+			logger.Error().Err(err).Str("id", id).Msg("could not delete user: deleting the personal space failed")
+			renderErr(w, err)
+			return
+		}
+	}
+	if err := h.backend.DeleteUser(r.Context(), id); err != nil {
+		logger.Debug().Err(err).Str("id", id).Msg("could not delete user: backend error")
+		renderErr(w, err)
+		return
+	}
+	h.publishEvent(events.UserDeleted{UserID: id})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// backendFilter translates a SCIM filter into the $filter of the identity backend
+func backendFilter(filter string, attrs filterAttributes) (string, error) {
+	node, err := parseFilter(filter)
+	if err == nil {
+		filter, err = odataFilter(node, attrs)
+	}
+	if err != nil {
+		return "", newError(http.StatusBadRequest, errInvalidFilter, err.Error())
+	}
+	return filter, nil
+}
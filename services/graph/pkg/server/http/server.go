@@ -88,6 +88,7 @@ func Server(opts ...Option) (http.Service, error) {
 			),
 		),
 		svc.EventsPublisher(publisher),
+		svc.Metrics(options.Metrics),
 	)
 
 	if handle == nil {
@@ -0,0 +1,148 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+)
+
+// appToken is the representation of an app token in the API. The token secret is only
+// returned once when the token is created.
+type appToken struct {
+	ID                 string     `json:"id"`
+	DisplayName        string     `json:"displayName"`
+	Scope              string     `json:"scope"`
+	CreatedDateTime    time.Time  `json:"createdDateTime"`
+	ExpirationDateTime *time.Time `json:"expirationDateTime,omitempty"`
+	Token              string     `json:"token,omitempty"`
+}
+
+func newAppToken(t *apptoken.Token) *appToken {
+	at := &appToken{
+		ID:              t.ID,
+		DisplayName:     t.Label,
+		Scope:           t.Scope,
+		CreatedDateTime: t.CreatedAt,
+	}
+	if !t.ExpiresAt.IsZero() {
+		expires := t.ExpiresAt
+		at.ExpirationDateTime = &expires
+	}
+	return at
+}
+
+// ListAppTokens implements the Service interface. It lists the app tokens of the current user.
+func (g Graph) ListAppTokens(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling list app tokens")
+	u, ok := revactx.ContextGetUser(r.Context())
+	if !ok {
+		logger.Error().Msg("user not in context")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, "user not in context")
+		return
+	}
+
+	tokens, err := g.appTokenManager.List(r.Context(), u.Id.OpaqueId)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not list app tokens")
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	values := make([]*appToken, 0, len(tokens))
+	for _, t := range tokens {
+		values = append(values, newAppToken(t))
+	}
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &listResponse{Value: values})
+}
+
+// CreateAppToken implements the Service interface. It creates an app token for the current user.
+func (g Graph) CreateAppToken(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling create app token")
+	u, ok := revactx.ContextGetUser(r.Context())
+	if !ok {
+		logger.Error().Msg("user not in context")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, "user not in context")
+		return
+	}
+
+	req := &appToken{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		logger.Debug().Err(err).Msg("could not create app token: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.DisplayName == "" {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "missing displayName")
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = apptoken.ScopeReadWrite
+	}
+	if !apptoken.ValidScope(req.Scope) {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid scope, possible values are 'read' and 'readwrite'")
+		return
+	}
+	var expires time.Time
+	if req.ExpirationDateTime != nil {
+		if req.ExpirationDateTime.Before(time.Now()) {
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "expirationDateTime must be in the future")
+			return
+		}
+		expires = req.ExpirationDateTime.UTC()
+	}
+
+	t, secret, err := g.appTokenManager.Create(r.Context(), u.Id.OpaqueId, req.DisplayName, req.Scope, expires)
+	if err != nil {
+		logger.Error().Err(err).Msg("could not create app token")
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res := newAppToken(t)
+	res.Token = secret
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, res)
+}
+
+// DeleteAppToken implements the Service interface. It revokes an app token of the current user.
+func (g Graph) DeleteAppToken(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling delete app token")
+	u, ok := revactx.ContextGetUser(r.Context())
+	if !ok {
+		logger.Error().Msg("user not in context")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, "user not in context")
+		return
+	}
+
+	tokenID, err := url.PathUnescape(chi.URLParam(r, "tokenID"))
+	if err != nil || tokenID == "" {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "missing or invalid token id")
+		return
+	}
+
+	err = g.appTokenManager.Delete(r.Context(), u.Id.OpaqueId, tokenID)
+	switch {
+	case errors.Is(err, apptoken.ErrNotFound):
+		errorcode.ItemNotFound.Render(w, r, http.StatusNotFound, "app token not found")
+		return
+	case err != nil:
+		logger.Error().Err(err).Str("id", tokenID).Msg("could not delete app token")
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+	render.NoContent(w, r)
+}
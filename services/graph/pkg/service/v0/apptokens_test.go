@@ -0,0 +1,124 @@
+package svc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config/defaults"
+	service "github.com/owncloud/ocis/v2/services/graph/pkg/service/v0"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("App tokens", func() {
+	var (
+		svc             service.Service
+		appTokenManager *mocks.AppTokenManager
+		ctx             context.Context
+		cfg             *config.Config
+		user            *userv1beta1.User
+	)
+
+	JustBeforeEach(func() {
+		cfg = defaults.FullDefaultConfig()
+		cfg.Identity.LDAP.CACert = "" // skip the startup checks, we don't use LDAP at all in this tests
+		cfg.TokenManager.JWTSecret = "loremipsum"
+		cfg.Commons = &shared.Commons{}
+
+		appTokenManager = &mocks.AppTokenManager{}
+		svc = service.NewService(
+			service.Config(cfg),
+			service.WithGatewayClient(&mocks.GatewayClient{}),
+			service.AppTokenManager(appTokenManager),
+		)
+		user = &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "user"}}
+		ctx = revactx.ContextSetUser(context.Background(), user)
+	})
+
+	It("lists the tokens without their hashes", func() {
+		appTokenManager.On("List", mock.Anything, "user").Return([]*apptoken.Token{
+			{ID: "token1", UserID: "user", Label: "laptop", Scope: apptoken.ScopeReadOnly, Hash: "secrethash"},
+		}, nil)
+
+		r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/me/appTokens", nil)
+		rr := httptest.NewRecorder()
+		svc.ListAppTokens(rr, r.WithContext(ctx))
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		Expect(rr.Body.String()).ToNot(ContainSubstring("secrethash"))
+		res := struct {
+			Value []map[string]interface{}
+		}{}
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+		Expect(len(res.Value)).To(Equal(1))
+		Expect(res.Value[0]["displayName"]).To(Equal("laptop"))
+		Expect(res.Value[0]["scope"]).To(Equal("read"))
+	})
+
+	It("creates a token and returns its secret", func() {
+		expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		appTokenManager.On("Create", mock.Anything, "user", "laptop", apptoken.ScopeReadOnly, expires).
+			Return(&apptoken.Token{ID: "token1", UserID: "user", Label: "laptop", Scope: apptoken.ScopeReadOnly, ExpiresAt: expires}, "s3cr3t", nil)
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"displayName":        "laptop",
+			"scope":              "read",
+			"expirationDateTime": expires,
+		})
+		r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/me/appTokens", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		svc.CreateAppToken(rr, r.WithContext(ctx))
+
+		Expect(rr.Code).To(Equal(http.StatusCreated))
+		res := map[string]interface{}{}
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+		Expect(res["token"]).To(Equal("s3cr3t"))
+		Expect(res["id"]).To(Equal("token1"))
+	})
+
+	DescribeTable("rejects invalid tokens",
+		func(req map[string]interface{}) {
+			body, _ := json.Marshal(req)
+			r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/me/appTokens", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+			svc.CreateAppToken(rr, r.WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			appTokenManager.AssertNotCalled(GinkgoT(), "Create")
+		},
+		Entry("without a name", map[string]interface{}{"scope": "read"}),
+		Entry("with an unknown scope", map[string]interface{}{"displayName": "laptop", "scope": "admin"}),
+		Entry("with an expiry in the past", map[string]interface{}{"displayName": "laptop", "expirationDateTime": time.Now().Add(-time.Hour)}),
+	)
+
+	It("deletes a token", func() {
+		appTokenManager.On("Delete", mock.Anything, "user", "token1").Return(nil)
+		appTokenManager.On("Delete", mock.Anything, "user", "unknown").Return(apptoken.ErrNotFound)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("tokenID", "token1")
+		r := httptest.NewRequest(http.MethodDelete, "/graph/v1.0/me/appTokens/token1", nil)
+		rr := httptest.NewRecorder()
+		svc.DeleteAppToken(rr, r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx)))
+		Expect(rr.Code).To(Equal(http.StatusNoContent))
+
+		rctx = chi.NewRouteContext()
+		rctx.URLParams.Add("tokenID", "unknown")
+		r = httptest.NewRequest(http.MethodDelete, "/graph/v1.0/me/appTokens/unknown", nil)
+		rr = httptest.NewRecorder()
+		svc.DeleteAppToken(rr, r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx)))
+		Expect(rr.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,247 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+)
+
+// batchRequest is a single request of a JSON batch. The url is relative to the API
+// version, e.g. "/users".
+type batchRequest struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      json.RawMessage   `json:"body,omitempty"`
+	DependsOn []string          `json:"dependsOn,omitempty"`
+}
+
+// batchResponse is the response to a single request of a JSON batch
+type batchResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    interface{}       `json:"body,omitempty"`
+}
+
+// Batch implements the Service interface. It runs the requests of a JSON batch through the
+// graph router with the identity of the caller. The requests run one after the other, every
+// request after the requests it depends on. Requests depending on a failed request are not
+// run and fail with "424 Failed Dependency".
+func (g Graph) Batch(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling batch")
+
+	batch := struct {
+		Requests []batchRequest `json:"requests"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		logger.Debug().Err(err).Interface("body", r.Body).Msg("could not run batch: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err.Error()))
+		return
+	}
+	switch {
+	case len(batch.Requests) == 0:
+		logger.Debug().Msg("could not run batch: no requests")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "the batch does not contain any requests")
+		return
+	case len(batch.Requests) > g.config.API.MaxBatchSize:
+		logger.Debug().Int("requests", len(batch.Requests)).Msg("could not run batch: too many requests")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, fmt.Sprintf("the batch must not contain more than %d requests", g.config.API.MaxBatchSize))
+		return
+	}
+
+	order, err := batchOrder(batch.Requests)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not run batch: invalid dependencies")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	responses := make([]batchResponse, len(batch.Requests))
+	failed := make(map[string]bool, len(batch.Requests))
+	for _, i := range order {
+		req := batch.Requests[i]
+		rw := &batchResponseWriter{header: http.Header{}}
+
+		var failedDependency string
+		for _, dep := range req.DependsOn {
+			if failed[dep] {
+				failedDependency = dep
+				break
+			}
+		}
+
+		sub, err := g.newBatchSubRequest(r, req)
+		switch {
+		case err != nil:
+			logger.Debug().Err(err).Str("id", req.ID).Msg("invalid batch request")
+			errorcode.InvalidRequest.Render(rw, r.Clone(r.Context()), http.StatusBadRequest, err.Error())
+		case failedDependency != "":
+			logger.Debug().Str("id", req.ID).Str("dependency", failedDependency).Msg("skipping batch request: failed dependency")
+			errorcode.GeneralException.Render(rw, sub, http.StatusFailedDependency, fmt.Sprintf("the request '%s' this request depends on failed", failedDependency))
+		default:
+			g.mux.ServeHTTP(rw, sub)
+		}
+
+		responses[i] = rw.response(req.ID)
+		failed[req.ID] = responses[i].Status >= http.StatusBadRequest
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, map[string]interface{}{"responses": responses})
+}
+
+// batchForwardedHeaders are the headers of the batch request passed on to its requests, so they
+// are authenticated like the batch itself. All other headers, e.g. Content-Type and
+// Content-Length, describe the batch request and are not passed on.
+var batchForwardedHeaders = []string{"Authorization", "X-Access-Token", "X-Request-ID", "Accept-Language"}
+
+// newBatchSubRequest returns the http request for a request of a JSON batch. It carries the
+// forwarded headers of the batch request and the headers of the batch entry.
+func (g Graph) newBatchSubRequest(r *http.Request, req batchRequest) (*http.Request, error) {
+	method := strings.ToUpper(req.Method)
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil, fmt.Errorf("unsupported method '%s'", req.Method)
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || u.IsAbs() || !strings.HasPrefix(u.Path, "/") {
+		return nil, fmt.Errorf("invalid url '%s', the url must be relative to the API version", req.URL)
+	}
+	if path.Clean(u.Path) == "/$batch" {
+		return nil, errors.New("batches can not be nested")
+	}
+	u.Path = path.Join(g.config.HTTP.Root, "v1.0", u.Path)
+
+	// reset the routing context of the batch request, so the router resolves the sub request
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+	sub, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	sub.RemoteAddr = r.RemoteAddr
+	for _, k := range batchForwardedHeaders {
+		for _, v := range r.Header.Values(k) {
+			sub.Header.Add(k, v)
+		}
+	}
+	for k, v := range req.Headers {
+		// the length is the one of the body of the entry
+		if strings.EqualFold(k, "Content-Length") {
+			continue
+		}
+		sub.Header.Set(k, v)
+	}
+	if len(req.Body) > 0 && sub.Header.Get("Content-Type") == "" {
+		sub.Header.Set("Content-Type", "application/json")
+	}
+	return sub, nil
+}
+
+// batchOrder returns the order to run the requests of a batch in, so that every request
+// runs after the requests it depends on. Independent requests keep the order of the batch.
+func batchOrder(requests []batchRequest) ([]int, error) {
+	index := make(map[string]int, len(requests))
+	for i, req := range requests {
+		if req.ID == "" {
+			return nil, errors.New("every request of the batch needs an id")
+		}
+		if _, ok := index[req.ID]; ok {
+			return nil, fmt.Errorf("the id '%s' is used by more than one request", req.ID)
+		}
+		index[req.ID] = i
+	}
+	for _, req := range requests {
+		for _, dep := range req.DependsOn {
+			if _, ok := index[dep]; !ok {
+				return nil, fmt.Errorf("the request '%s' depends on the unknown request '%s'", req.ID, dep)
+			}
+		}
+	}
+
+	order := make([]int, 0, len(requests))
+	done := make([]bool, len(requests))
+	for len(order) < len(requests) {
+		progress := false
+		for i, req := range requests {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, dep := range req.DependsOn {
+				if !done[index[dep]] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[i] = true
+				order = append(order, i)
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, errors.New("the dependencies of the requests contain a cycle")
+		}
+	}
+	return order, nil
+}
+
+// batchResponseWriter records the response to a request of a JSON batch
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// response returns the recorded response. JSON bodies are embedded as they are, other
+// bodies as a string.
+func (w *batchResponseWriter) response(id string) batchResponse {
+	res := batchResponse{ID: id, Status: w.status}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+	if len(w.header) > 0 {
+		res.Headers = make(map[string]string, len(w.header))
+		for k := range w.header {
+			res.Headers[k] = w.header.Get(k)
+		}
+	}
+	if w.body.Len() > 0 {
+		if json.Valid(w.body.Bytes()) {
+			res.Body = json.RawMessage(w.body.Bytes())
+		} else {
+			res.Body = w.body.String()
+		}
+	}
+	return res
+}
@@ -0,0 +1,186 @@
+package svc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config/defaults"
+	service "github.com/owncloud/ocis/v2/services/graph/pkg/service/v0"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Batch", func() {
+	var (
+		svc             service.Service
+		appTokenManager *mocks.AppTokenManager
+		ctx             context.Context
+		cfg             *config.Config
+		// headers holds the headers of the requests of the batches
+		headers []http.Header
+	)
+
+	type batchResponse struct {
+		ID     string
+		Status int
+		Body   map[string]interface{}
+	}
+
+	runBatch := func(requests []map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"requests": requests})
+		r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/$batch", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		svc.ServeHTTP(rr, r.WithContext(ctx))
+		return rr
+	}
+
+	JustBeforeEach(func() {
+		cfg = defaults.FullDefaultConfig()
+		cfg.Identity.LDAP.CACert = "" // skip the startup checks, we don't use LDAP at all in this tests
+		cfg.TokenManager.JWTSecret = "loremipsum"
+		cfg.Commons = &shared.Commons{}
+
+		appTokenManager = &mocks.AppTokenManager{}
+		headers = nil
+		svc = service.NewService(
+			service.Config(cfg),
+			service.WithGatewayClient(&mocks.GatewayClient{}),
+			service.AppTokenManager(appTokenManager),
+			service.Middleware(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if !strings.HasSuffix(r.URL.Path, "/$batch") {
+						headers = append(headers, r.Header.Clone())
+					}
+					next.ServeHTTP(w, r)
+				})
+			}),
+		)
+		user := &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "user"}}
+		ctx = revactx.ContextSetUser(context.Background(), user)
+	})
+
+	It("runs the requests in the order of their dependencies", func() {
+		appTokenManager.On("Create", mock.Anything, "user", "laptop", apptoken.ScopeReadOnly, mock.Anything).
+			Return(&apptoken.Token{ID: "token1", UserID: "user", Label: "laptop", Scope: apptoken.ScopeReadOnly}, "s3cr3t", nil)
+		appTokenManager.On("List", mock.Anything, "user").
+			Return([]*apptoken.Token{{ID: "token1", UserID: "user", Label: "laptop", Scope: apptoken.ScopeReadOnly}}, nil)
+		appTokenManager.On("Delete", mock.Anything, "user", "unknown").Return(apptoken.ErrNotFound)
+
+		rr := runBatch([]map[string]interface{}{
+			{"id": "list", "method": "GET", "url": "/me/appTokens", "dependsOn": []string{"create"}},
+			{"id": "create", "method": "POST", "url": "/me/appTokens", "body": map[string]interface{}{"displayName": "laptop", "scope": "read"}},
+			{"id": "delete", "method": "DELETE", "url": "/me/appTokens/unknown"},
+			{"id": "skipped", "method": "GET", "url": "/me/appTokens", "dependsOn": []string{"delete"}},
+		})
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		res := struct {
+			Responses []batchResponse
+		}{}
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+		Expect(len(res.Responses)).To(Equal(4))
+		Expect(res.Responses[0].ID).To(Equal("list"))
+		Expect(res.Responses[0].Status).To(Equal(http.StatusOK))
+		Expect(len(res.Responses[0].Body["value"].([]interface{}))).To(Equal(1))
+		Expect(res.Responses[1].ID).To(Equal("create"))
+		Expect(res.Responses[1].Status).To(Equal(http.StatusCreated))
+		Expect(res.Responses[1].Body["token"]).To(Equal("s3cr3t"))
+		Expect(res.Responses[2].Status).To(Equal(http.StatusNotFound))
+		Expect(res.Responses[3].Status).To(Equal(http.StatusFailedDependency))
+
+		methods := []string{}
+		for _, c := range appTokenManager.Calls {
+			methods = append(methods, c.Method)
+		}
+		Expect(methods).To(Equal([]string{"Create", "Delete", "List"}))
+	})
+
+	It("passes only the authentication and request headers of the batch on to its requests", func() {
+		appTokenManager.On("List", mock.Anything, "user").Return([]*apptoken.Token{}, nil)
+		appTokenManager.On("Create", mock.Anything, "user", "laptop", apptoken.ScopeReadOnly, mock.Anything).
+			Return(&apptoken.Token{ID: "token1", UserID: "user", Label: "laptop", Scope: apptoken.ScopeReadOnly}, "s3cr3t", nil)
+
+		body, _ := json.Marshal(map[string]interface{}{"requests": []map[string]interface{}{
+			{"id": "list", "method": "GET", "url": "/me/appTokens"},
+			{"id": "create", "method": "POST", "url": "/me/appTokens", "headers": map[string]string{"Content-Type": "application/json", "Content-Length": "1"},
+				"body": map[string]interface{}{"displayName": "laptop", "scope": "read"}},
+		}})
+		r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/$batch", bytes.NewBuffer(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		r.Header.Set("Authorization", "Bearer token")
+		r.Header.Set("X-Request-ID", "request")
+		r.Header.Set("Accept-Language", "de")
+		r.Header.Set("X-Custom", "custom")
+		rr := httptest.NewRecorder()
+		svc.ServeHTTP(rr, r.WithContext(ctx))
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		Expect(headers).To(HaveLen(2))
+		Expect(headers[0]).To(Equal(http.Header{
+			"Authorization":   {"Bearer token"},
+			"X-Request-Id":    {"request"},
+			"Accept-Language": {"de"},
+		}))
+		Expect(headers[1]).To(Equal(http.Header{
+			"Authorization":   {"Bearer token"},
+			"X-Request-Id":    {"request"},
+			"Accept-Language": {"de"},
+			"Content-Type":    {"application/json"},
+		}))
+		Expect(appTokenManager.Calls).To(HaveLen(2))
+	})
+
+	It("rejects nested batches", func() {
+		rr := runBatch([]map[string]interface{}{
+			{"id": "1", "method": "POST", "url": "/$batch", "body": map[string]interface{}{"requests": []interface{}{}}},
+		})
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		res := struct {
+			Responses []batchResponse
+		}{}
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+		Expect(res.Responses[0].Status).To(Equal(http.StatusBadRequest))
+	})
+
+	DescribeTable("rejects invalid batches",
+		func(requests []map[string]interface{}) {
+			rr := runBatch(requests)
+
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			Expect(appTokenManager.Calls).To(BeEmpty())
+		},
+		Entry("without requests", []map[string]interface{}{}),
+		Entry("with too many requests", func() []map[string]interface{} {
+			requests := make([]map[string]interface{}, 21)
+			for i := range requests {
+				requests[i] = map[string]interface{}{"id": string(rune('a' + i)), "method": "GET", "url": "/me/appTokens"}
+			}
+			return requests
+		}()),
+		Entry("with duplicate ids", []map[string]interface{}{
+			{"id": "1", "method": "GET", "url": "/me/appTokens"},
+			{"id": "1", "method": "GET", "url": "/me/appTokens"},
+		}),
+		Entry("with an unknown dependency", []map[string]interface{}{
+			{"id": "1", "method": "GET", "url": "/me/appTokens", "dependsOn": []string{"2"}},
+		}),
+		Entry("with cyclic dependencies", []map[string]interface{}{
+			{"id": "1", "method": "GET", "url": "/me/appTokens", "dependsOn": []string{"2"}},
+			{"id": "2", "method": "GET", "url": "/me/appTokens", "dependsOn": []string{"1"}},
+		}),
+	)
+})
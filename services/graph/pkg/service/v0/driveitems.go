@@ -2,20 +2,27 @@ package svc
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	cs3rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/rhttp"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	libregraph "github.com/owncloud/libre-graph-api-go"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/net"
 )
 
 // GetRootDriveChildren implements the Service interface.
@@ -150,9 +157,17 @@ func cs3ResourceToDriveItem(res *storageprovider.ResourceInfo) (*libregraph.Driv
 		Size: size,
 	}
 
-	if name := path.Base(res.Path); name != "" {
+	if res.Name != "" {
+		driveItem.Name = libregraph.PtrString(res.Name)
+	} else if name := path.Base(res.Path); name != "" {
 		driveItem.Name = &name
 	}
+	if res.ParentId != nil {
+		driveItem.ParentReference = &libregraph.ItemReference{
+			DriveId: libregraph.PtrString(storagespace.FormatStorageID(res.ParentId.StorageId, res.ParentId.SpaceId)),
+			Id:      libregraph.PtrString(storagespace.FormatResourceID(*res.ParentId)),
+		}
+	}
 	if res.Etag != "" {
 		driveItem.ETag = &res.Etag
 	}
@@ -261,3 +276,542 @@ func (g Graph) getSpecialDriveItem(ctx context.Context, id storageprovider.Resou
 
 	return spaceItem
 }
+
+// GetDriveItem implements the Service interface. It returns a single item of a drive.
+func (g Graph) GetDriveItem(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling get drive item")
+
+	ref, err := driveItemRef(r)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not get drive item: invalid reference")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := g.GetGatewayClient().Stat(r.Context(), &storageprovider.StatRequest{Ref: ref})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending stat grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case res.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, res.Status)
+		return
+	}
+
+	item, err := cs3ResourceToDriveItem(res.Info)
+	if err != nil {
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, item)
+}
+
+// GetDriveItemChildren implements the Service interface. It lists the children of a folder.
+func (g Graph) GetDriveItemChildren(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling get drive item children")
+
+	ref, err := driveItemRef(r)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not list drive item children: invalid reference")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := g.GetGatewayClient().ListContainer(r.Context(), &storageprovider.ListContainerRequest{Ref: ref})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending list container grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case res.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, res.Status)
+		return
+	}
+
+	items, err := formatDriveItems(res.Infos)
+	if err != nil {
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &listResponse{Value: items})
+}
+
+// CreateDriveItem implements the Service interface. It creates an empty file or a folder
+// in the folder addressed by the request.
+func (g Graph) CreateDriveItem(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling create drive item")
+
+	parent, err := driveItemRef(r)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not create drive item: invalid reference")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item := libregraph.DriveItem{}
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		logger.Debug().Err(err).Msg("could not create drive item: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+	if err := validateItemName(item.GetName()); err != nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (item.Folder == nil) == (item.File == nil) {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "either the 'folder' or the 'file' facet must be set")
+		return
+	}
+
+	ref := childRef(parent.ResourceId, item.GetName())
+	client := g.GetGatewayClient()
+	var status *cs3rpc.Status
+	if item.Folder != nil {
+		var res *storageprovider.CreateContainerResponse
+		res, err = client.CreateContainer(r.Context(), &storageprovider.CreateContainerRequest{Ref: ref})
+		status = res.GetStatus()
+	} else {
+		var res *storageprovider.TouchFileResponse
+		res, err = client.TouchFile(r.Context(), &storageprovider.TouchFileRequest{Ref: ref})
+		status = res.GetStatus()
+	}
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending create grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case status.GetCode() != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, status)
+		return
+	}
+
+	g.renderDriveItem(w, r, ref, http.StatusCreated)
+}
+
+// UpdateDriveItem implements the Service interface. It renames an item and/or moves it to another
+// folder of the same drive.
+func (g Graph) UpdateDriveItem(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling update drive item")
+
+	ref, err := driveItemRef(r)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not update drive item: invalid reference")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	changes := libregraph.DriveItem{}
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		logger.Debug().Err(err).Msg("could not update drive item: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+	parentRef := changes.GetParentReference()
+	if changes.Name == nil && parentRef.Id == nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "either 'name' or 'parentReference' must be set")
+		return
+	}
+
+	client := g.GetGatewayClient()
+	sRes, err := client.Stat(r.Context(), &storageprovider.StatRequest{Ref: ref})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending stat grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case sRes.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, sRes.Status)
+		return
+	}
+	if sRes.Info.GetParentId() == nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "the root of a drive cannot be renamed or moved")
+		return
+	}
+
+	name := sRes.Info.GetName()
+	if name == "" {
+		name = path.Base(sRes.Info.GetPath())
+	}
+	if changes.Name != nil {
+		name = changes.GetName()
+	}
+	if err := validateItemName(name); err != nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	parentID := sRes.Info.GetParentId()
+	if parentRef.Id != nil {
+		rid, err := storagespace.ParseID(parentRef.GetId())
+		if err != nil || rid.OpaqueId == "" {
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid parentReference id")
+			return
+		}
+		if rid.StorageId != parentID.StorageId || rid.SpaceId != parentID.SpaceId {
+			errorcode.NotSupported.Render(w, r, http.StatusBadRequest, "items can only be moved within a drive, use copy instead")
+			return
+		}
+		parentID = &rid
+	}
+
+	target := childRef(parentID, name)
+	mRes, err := client.Move(r.Context(), &storageprovider.MoveRequest{Source: ref, Destination: target})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending move grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case mRes.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, mRes.Status)
+		return
+	}
+
+	g.renderDriveItem(w, r, target, http.StatusOK)
+}
+
+// CopyDriveItem implements the Service interface. It copies an item, folders are copied recursively.
+// Unlike MS Graph the copy is done synchronously and the response contains the new item.
+func (g Graph) CopyDriveItem(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling copy drive item")
+
+	ref, err := driveItemRef(r)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not copy drive item: invalid reference")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req := libregraph.DriveItem{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Debug().Err(err).Msg("could not copy drive item: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err.Error()))
+		return
+	}
+
+	client := g.GetGatewayClient()
+	sRes, err := client.Stat(r.Context(), &storageprovider.StatRequest{Ref: ref})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending stat grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case sRes.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, sRes.Status)
+		return
+	}
+	source := sRes.Info
+
+	name := source.GetName()
+	if name == "" {
+		name = path.Base(source.GetPath())
+	}
+	if req.Name != nil {
+		name = req.GetName()
+	}
+	if err := validateItemName(name); err != nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	parentID := source.GetParentId()
+	if parentRef := req.GetParentReference(); parentRef.Id != nil {
+		rid, err := storagespace.ParseID(parentRef.GetId())
+		if err != nil || rid.OpaqueId == "" {
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid parentReference id")
+			return
+		}
+		parentID = &rid
+	}
+	if parentID == nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "missing parentReference")
+		return
+	}
+
+	if source.Type == storageprovider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		inside, err := g.isDescendant(r.Context(), parentID, source.Id)
+		if err != nil {
+			logger.Error().Err(err).Msg("could not check the copy target")
+			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if inside {
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "a folder cannot be copied into itself")
+			return
+		}
+	}
+
+	target := childRef(parentID, name)
+	tRes, err := client.Stat(r.Context(), &storageprovider.StatRequest{Ref: target})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending stat grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case tRes.Status.Code == cs3rpc.Code_CODE_OK:
+		errorcode.NameAlreadyExists.Render(w, r, http.StatusConflict, "an item with this name already exists")
+		return
+	}
+
+	status, err := g.copyResource(r.Context(), source, target)
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("could not copy drive item")
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case status.GetCode() != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, status)
+		return
+	}
+
+	g.renderDriveItem(w, r, target, http.StatusCreated)
+}
+
+// DeleteDriveItem implements the Service interface. It moves an item to the trash bin of the drive.
+func (g Graph) DeleteDriveItem(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling delete drive item")
+
+	ref, err := driveItemRef(r)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not delete drive item: invalid reference")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ref.GetResourceId().GetOpaqueId() == ref.GetResourceId().GetSpaceId() {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "the root of a drive cannot be deleted, delete the drive instead")
+		return
+	}
+
+	res, err := g.GetGatewayClient().Delete(r.Context(), &storageprovider.DeleteRequest{Ref: ref})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending delete grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case res.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, res.Status)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+	render.NoContent(w, r)
+}
+
+// renderDriveItem stats the referenced item and renders it with the given status
+func (g Graph) renderDriveItem(w http.ResponseWriter, r *http.Request, ref *storageprovider.Reference, status int) {
+	res, err := g.GetGatewayClient().Stat(r.Context(), &storageprovider.StatRequest{Ref: ref})
+	switch {
+	case err != nil:
+		g.logger.Error().Err(err).Msg("error sending stat grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case res.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, res.Status)
+		return
+	}
+	item, err := cs3ResourceToDriveItem(res.Info)
+	if err != nil {
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	render.Status(r, status)
+	render.JSON(w, r, item)
+}
+
+// copyResource copies the source to the target reference. Files are copied by streaming the
+// content from the data gateway, folders are copied recursively.
+func (g Graph) copyResource(ctx context.Context, source *storageprovider.ResourceInfo, target *storageprovider.Reference) (*cs3rpc.Status, error) {
+	client := g.GetGatewayClient()
+
+	if source.Type == storageprovider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		cRes, err := client.CreateContainer(ctx, &storageprovider.CreateContainerRequest{Ref: target})
+		if err != nil || cRes.Status.Code != cs3rpc.Code_CODE_OK {
+			return cRes.GetStatus(), err
+		}
+		lRes, err := client.ListContainer(ctx, &storageprovider.ListContainerRequest{
+			Ref: &storageprovider.Reference{ResourceId: source.Id, Path: "."},
+		})
+		if err != nil || lRes.Status.Code != cs3rpc.Code_CODE_OK {
+			return lRes.GetStatus(), err
+		}
+		for _, child := range lRes.Infos {
+			name := child.GetName()
+			if name == "" {
+				name = path.Base(child.GetPath())
+			}
+			childTarget := &storageprovider.Reference{
+				ResourceId: target.ResourceId,
+				Path:       utils.MakeRelativePath(path.Join(target.Path, name)),
+			}
+			status, err := g.copyResource(ctx, child, childTarget)
+			if err != nil || status.GetCode() != cs3rpc.Code_CODE_OK {
+				return status, err
+			}
+		}
+		return cRes.Status, nil
+	}
+
+	dRes, err := client.InitiateFileDownload(ctx, &storageprovider.InitiateFileDownloadRequest{
+		Ref: &storageprovider.Reference{ResourceId: source.Id, Path: "."},
+	})
+	if err != nil || dRes.Status.Code != cs3rpc.Code_CODE_OK {
+		return dRes.GetStatus(), err
+	}
+	var downloadEP, downloadToken string
+	for _, p := range dRes.Protocols {
+		if p.Protocol == "spaces" {
+			downloadEP, downloadToken = p.DownloadEndpoint, p.Token
+		}
+	}
+
+	uRes, err := client.InitiateFileUpload(ctx, &storageprovider.InitiateFileUploadRequest{
+		Ref: target,
+		Opaque: &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				net.HeaderUploadLength: {
+					Decoder: "plain",
+					Value:   []byte(strconv.FormatUint(source.GetSize(), 10)),
+				},
+			},
+		},
+	})
+	if err != nil || uRes.Status.Code != cs3rpc.Code_CODE_OK {
+		return uRes.GetStatus(), err
+	}
+	var uploadEP, uploadToken string
+	for _, p := range uRes.Protocols {
+		if p.Protocol == "simple" {
+			uploadEP, uploadToken = p.UploadEndpoint, p.Token
+		}
+	}
+	if downloadEP == "" || uploadEP == "" {
+		return nil, errors.New("no suitable data transfer protocol available")
+	}
+
+	dReq, err := rhttp.NewRequest(ctx, http.MethodGet, downloadEP, nil)
+	if err != nil {
+		return nil, err
+	}
+	if downloadToken != "" {
+		dReq.Header.Set(net.HeaderTokenTransport, downloadToken)
+	}
+	download, err := g.httpClient.Do(dReq)
+	if err != nil {
+		return nil, err
+	}
+	defer download.Body.Close()
+	if download.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s failed with status %d", source.Id.OpaqueId, download.StatusCode)
+	}
+
+	uReq, err := rhttp.NewRequest(ctx, http.MethodPut, uploadEP, download.Body)
+	if err != nil {
+		return nil, err
+	}
+	uReq.Header.Set(net.HeaderTokenTransport, uploadToken)
+	uReq.ContentLength = int64(source.GetSize())
+	upload, err := g.httpClient.Do(uReq)
+	if err != nil {
+		return nil, err
+	}
+	defer upload.Body.Close()
+	if upload.StatusCode < 200 || upload.StatusCode > 299 {
+		return nil, fmt.Errorf("uploading to %s failed with status %d", target.Path, upload.StatusCode)
+	}
+	return uRes.Status, nil
+}
+
+// isDescendant returns true if the item is the folder or one of its descendants.
+func (g Graph) isDescendant(ctx context.Context, item, folder *storageprovider.ResourceId) (bool, error) {
+	if item.StorageId != folder.StorageId || item.SpaceId != folder.SpaceId {
+		return false, nil
+	}
+	if item.OpaqueId == folder.OpaqueId {
+		return true, nil
+	}
+	itemPath, err := g.getPathForResource(ctx, *item)
+	if err != nil {
+		return false, err
+	}
+	folderPath, err := g.getPathForResource(ctx, *folder)
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(path.Clean(itemPath)+"/", path.Clean(folderPath)+"/"), nil
+}
+
+// driveItemRef returns the reference to the drive item addressed by the request.
+// The item id 'root' addresses the root folder of the drive.
+func driveItemRef(r *http.Request) (*storageprovider.Reference, error) {
+	driveID, err := url.PathUnescape(chi.URLParam(r, "driveID"))
+	if err != nil {
+		return nil, errors.New("unescaping drive id failed")
+	}
+	itemID, err := url.PathUnescape(chi.URLParam(r, "itemID"))
+	if err != nil {
+		return nil, errors.New("unescaping item id failed")
+	}
+	drive, err := storagespace.ParseID(driveID)
+	if err != nil {
+		return nil, errors.New("invalid drive id")
+	}
+	if itemID == "root" {
+		drive.OpaqueId = drive.SpaceId
+		return &storageprovider.Reference{ResourceId: &drive, Path: "."}, nil
+	}
+	item, err := storagespace.ParseID(itemID)
+	if err != nil || item.OpaqueId == "" {
+		return nil, errors.New("invalid item id")
+	}
+	if item.StorageId != drive.StorageId || item.SpaceId != drive.SpaceId {
+		return nil, errors.New("the item does not belong to the drive")
+	}
+	return &storageprovider.Reference{ResourceId: &item, Path: "."}, nil
+}
+
+// childRef returns the reference to the item with the given name in the parent folder
+func childRef(parent *storageprovider.ResourceId, name string) *storageprovider.Reference {
+	return &storageprovider.Reference{
+		ResourceId: parent,
+		Path:       utils.MakeRelativePath(name),
+	}
+}
+
+func validateItemName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("missing name")
+	case name == "." || name == "..":
+		return errors.New("invalid name")
+	case strings.Contains(name, "/"):
+		return errors.New("the name must not contain '/'")
+	}
+	return nil
+}
+
+// renderDriveItemStatus renders the error for a failed CS3 request on a drive item
+func renderDriveItemStatus(w http.ResponseWriter, r *http.Request, status *cs3rpc.Status) {
+	switch status.GetCode() {
+	case cs3rpc.Code_CODE_NOT_FOUND:
+		errorcode.ItemNotFound.Render(w, r, http.StatusNotFound, status.GetMessage())
+	case cs3rpc.Code_CODE_PERMISSION_DENIED:
+		// TODO check if we should return 404 to not disclose existing items
+		errorcode.AccessDenied.Render(w, r, http.StatusForbidden, status.GetMessage())
+	case cs3rpc.Code_CODE_ALREADY_EXISTS:
+		errorcode.NameAlreadyExists.Render(w, r, http.StatusConflict, status.GetMessage())
+	case cs3rpc.Code_CODE_FAILED_PRECONDITION, cs3rpc.Code_CODE_INVALID_ARGUMENT:
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, status.GetMessage())
+	case cs3rpc.Code_CODE_INSUFFICIENT_STORAGE:
+		errorcode.QuotaLimitReached.Render(w, r, http.StatusInsufficientStorage, status.GetMessage())
+	default:
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, status.GetMessage())
+	}
+}
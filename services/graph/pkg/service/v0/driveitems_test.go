@@ -0,0 +1,256 @@
+package svc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	libregraph "github.com/owncloud/libre-graph-api-go"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config/defaults"
+	service "github.com/owncloud/ocis/v2/services/graph/pkg/service/v0"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("DriveItems", func() {
+	var (
+		svc           service.Service
+		gatewayClient *mocks.GatewayClient
+		httpClient    *mocks.HTTPClient
+		ctx           context.Context
+		cfg           *config.Config
+		rctx          *chi.Context
+
+		folderID = &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "folder"}
+		fileID   = &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}
+	)
+
+	JustBeforeEach(func() {
+		cfg = defaults.FullDefaultConfig()
+		cfg.Identity.LDAP.CACert = "" // skip the startup checks, we don't use LDAP at all in this tests
+		cfg.TokenManager.JWTSecret = "loremipsum"
+		cfg.Commons = &shared.Commons{}
+
+		gatewayClient = &mocks.GatewayClient{}
+		httpClient = &mocks.HTTPClient{}
+		svc = service.NewService(
+			service.Config(cfg),
+			service.WithGatewayClient(gatewayClient),
+			service.WithHTTPClient(httpClient),
+		)
+
+		rctx = chi.NewRouteContext()
+		rctx.URLParams.Add("driveID", "storage$space")
+		ctx = context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+		ctx = revactx.ContextSetUser(ctx, &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "user"}})
+	})
+
+	statResponse := func(id *provider.ResourceId, name string, t provider.ResourceType) *provider.StatResponse {
+		return &provider.StatResponse{
+			Status: status.NewOK(ctx),
+			Info: &provider.ResourceInfo{
+				Id:       id,
+				ParentId: &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "space"},
+				Name:     name,
+				Type:     t,
+				Size:     4,
+			},
+		}
+	}
+
+	Describe("GetDriveItem", func() {
+		It("returns the item", func() {
+			rctx.URLParams.Add("itemID", "storage$space!file")
+			gatewayClient.On("Stat", mock.Anything, mock.MatchedBy(func(req *provider.StatRequest) bool {
+				return req.Ref.ResourceId.OpaqueId == "file"
+			})).Return(statResponse(fileID, "file.txt", provider.ResourceType_RESOURCE_TYPE_FILE), nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/items/storage$space!file", nil)
+			rr := httptest.NewRecorder()
+			svc.GetDriveItem(rr, r.WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			item := libregraph.DriveItem{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &item)).To(Succeed())
+			Expect(item.GetId()).To(Equal("storage$space!file"))
+			Expect(item.GetName()).To(Equal("file.txt"))
+			Expect(item.ParentReference.GetId()).To(Equal("storage$space!space"))
+		})
+
+		It("addresses the drive root as 'root'", func() {
+			rctx.URLParams.Add("itemID", "root")
+			gatewayClient.On("Stat", mock.Anything, mock.MatchedBy(func(req *provider.StatRequest) bool {
+				return req.Ref.ResourceId.OpaqueId == "space"
+			})).Return(statResponse(&provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "space"}, "", provider.ResourceType_RESOURCE_TYPE_CONTAINER), nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/items/root", nil)
+			rr := httptest.NewRecorder()
+			svc.GetDriveItem(rr, r.WithContext(ctx))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+		})
+
+		It("rejects items of another drive", func() {
+			rctx.URLParams.Add("itemID", "storage$otherspace!file")
+
+			r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/items/storage$otherspace!file", nil)
+			rr := httptest.NewRecorder()
+			svc.GetDriveItem(rr, r.WithContext(ctx))
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns 404 for unknown items", func() {
+			rctx.URLParams.Add("itemID", "storage$space!unknown")
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&provider.StatResponse{
+				Status: status.NewNotFound(ctx, "not found"),
+			}, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/items/storage$space!unknown", nil)
+			rr := httptest.NewRecorder()
+			svc.GetDriveItem(rr, r.WithContext(ctx))
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	It("lists the children of a folder", func() {
+		rctx.URLParams.Add("itemID", "storage$space!folder")
+		gatewayClient.On("ListContainer", mock.Anything, mock.Anything).Return(&provider.ListContainerResponse{
+			Status: status.NewOK(ctx),
+			Infos: []*provider.ResourceInfo{
+				{Id: fileID, Name: "file.txt", Type: provider.ResourceType_RESOURCE_TYPE_FILE},
+			},
+		}, nil)
+
+		r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/items/storage$space!folder/children", nil)
+		rr := httptest.NewRecorder()
+		svc.GetDriveItemChildren(rr, r.WithContext(ctx))
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		res := struct {
+			Value []libregraph.DriveItem
+		}{}
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+		Expect(len(res.Value)).To(Equal(1))
+		Expect(res.Value[0].GetName()).To(Equal("file.txt"))
+	})
+
+	Describe("CreateDriveItem", func() {
+		It("creates a folder", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			gatewayClient.On("CreateContainer", mock.Anything, mock.MatchedBy(func(req *provider.CreateContainerRequest) bool {
+				return req.Ref.ResourceId.OpaqueId == "folder" && req.Ref.Path == "./new"
+			})).Return(&provider.CreateContainerResponse{Status: status.NewOK(ctx)}, nil)
+			gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(statResponse(folderID, "new", provider.ResourceType_RESOURCE_TYPE_CONTAINER), nil)
+
+			body, _ := json.Marshal(map[string]interface{}{"name": "new", "folder": map[string]interface{}{}})
+			r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!folder/children", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+			svc.CreateDriveItem(rr, r.WithContext(ctx))
+			Expect(rr.Code).To(Equal(http.StatusCreated))
+		})
+
+		It("returns a conflict if the name is taken", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			gatewayClient.On("TouchFile", mock.Anything, mock.Anything).Return(&provider.TouchFileResponse{
+				Status: status.NewAlreadyExists(ctx, nil, "exists"),
+			}, nil)
+
+			body, _ := json.Marshal(map[string]interface{}{"name": "file.txt", "file": map[string]interface{}{}})
+			r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!folder/children", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+			svc.CreateDriveItem(rr, r.WithContext(ctx))
+			Expect(rr.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("rejects invalid names", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			body, _ := json.Marshal(map[string]interface{}{"name": "a/b", "folder": map[string]interface{}{}})
+			r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!folder/children", bytes.NewBuffer(body))
+			rr := httptest.NewRecorder()
+			svc.CreateDriveItem(rr, r.WithContext(ctx))
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	It("renames and moves an item", func() {
+		rctx.URLParams.Add("itemID", "storage$space!file")
+		gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(statResponse(fileID, "file.txt", provider.ResourceType_RESOURCE_TYPE_FILE), nil)
+		gatewayClient.On("Move", mock.Anything, mock.MatchedBy(func(req *provider.MoveRequest) bool {
+			return req.Source.ResourceId.OpaqueId == "file" &&
+				req.Destination.ResourceId.OpaqueId == "folder" &&
+				req.Destination.Path == "./renamed.txt"
+		})).Return(&provider.MoveResponse{Status: status.NewOK(ctx)}, nil)
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":            "renamed.txt",
+			"parentReference": map[string]interface{}{"id": "storage$space!folder"},
+		})
+		r := httptest.NewRequest(http.MethodPatch, "/graph/v1.0/drives/storage$space/items/storage$space!file", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		svc.UpdateDriveItem(rr, r.WithContext(ctx))
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		gatewayClient.AssertNumberOfCalls(GinkgoT(), "Move", 1)
+	})
+
+	It("copies a file", func() {
+		rctx.URLParams.Add("itemID", "storage$space!file")
+		gatewayClient.On("Stat", mock.Anything, mock.MatchedBy(func(req *provider.StatRequest) bool {
+			return req.Ref.ResourceId.OpaqueId == "file"
+		})).Return(statResponse(fileID, "file.txt", provider.ResourceType_RESOURCE_TYPE_FILE), nil)
+		isTarget := mock.MatchedBy(func(req *provider.StatRequest) bool {
+			return req.Ref.ResourceId.OpaqueId == "folder" && req.Ref.Path == "./copy.txt"
+		})
+		gatewayClient.On("Stat", mock.Anything, isTarget).Return(&provider.StatResponse{Status: status.NewNotFound(ctx, "not found")}, nil).Once()
+		gatewayClient.On("Stat", mock.Anything, isTarget).Return(statResponse(&provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "copy"}, "copy.txt", provider.ResourceType_RESOURCE_TYPE_FILE), nil).Once()
+		gatewayClient.On("InitiateFileDownload", mock.Anything, mock.Anything).Return(&gateway.InitiateFileDownloadResponse{
+			Status:    status.NewOK(ctx),
+			Protocols: []*gateway.FileDownloadProtocol{{Protocol: "spaces", DownloadEndpoint: "https://localhost/data/download", Token: "dtoken"}},
+		}, nil)
+		gatewayClient.On("InitiateFileUpload", mock.Anything, mock.Anything).Return(&gateway.InitiateFileUploadResponse{
+			Status:    status.NewOK(ctx),
+			Protocols: []*gateway.FileUploadProtocol{{Protocol: "simple", UploadEndpoint: "https://localhost/data/upload", Token: "utoken"}},
+		}, nil)
+		httpClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodGet && req.Header.Get("X-Reva-Transfer") == "dtoken"
+		})).Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString("data"))}, nil)
+		httpClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == http.MethodPut && req.Header.Get("X-Reva-Transfer") == "utoken"
+		})).Return(&http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(""))}, nil)
+
+		body, _ := json.Marshal(map[string]interface{}{
+			"name":            "copy.txt",
+			"parentReference": map[string]interface{}{"driveId": "storage$space", "id": "storage$space!folder"},
+		})
+		r := httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!file/copy", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		svc.CopyDriveItem(rr, r.WithContext(ctx))
+
+		Expect(rr.Code).To(Equal(http.StatusCreated))
+		item := libregraph.DriveItem{}
+		Expect(json.Unmarshal(rr.Body.Bytes(), &item)).To(Succeed())
+		Expect(item.GetId()).To(Equal("storage$space!copy"))
+		httpClient.AssertNumberOfCalls(GinkgoT(), "Do", 2)
+	})
+
+	It("deletes an item", func() {
+		rctx.URLParams.Add("itemID", "storage$space!file")
+		gatewayClient.On("Delete", mock.Anything, mock.Anything).Return(&provider.DeleteResponse{Status: status.NewOK(ctx)}, nil)
+
+		r := httptest.NewRequest(http.MethodDelete, "/graph/v1.0/drives/storage$space/items/storage$space!file", nil)
+		rr := httptest.NewRecorder()
+		svc.DeleteDriveItem(rr, r.WithContext(ctx))
+		Expect(rr.Code).To(Equal(http.StatusNoContent))
+	})
+})
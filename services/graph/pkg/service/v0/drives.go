@@ -59,6 +59,11 @@ func (g Graph) getDrives(w http.ResponseWriter, r *http.Request, unrestricted bo
 		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	if err := validatePaging(odataReq, g.config.API.MaxSkip); err != nil {
+		logger.Debug().Err(err).Interface("query", r.URL.Query()).Msg("could not get drives: invalid paging")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 	ctx := r.Context()
 
 	filters, err := generateCs3Filters(odataReq)
@@ -97,22 +102,32 @@ func (g Graph) getDrives(w http.ResponseWriter, r *http.Request, unrestricted bo
 		return
 	}
 
-	spaces, err := g.formatDrives(ctx, webDavBaseURL, res.StorageSpaces)
+	total := len(res.StorageSpaces)
+	storageSpaces, next := res.StorageSpaces, 0
+	if odataReq.Query.OrderBy == nil {
+		// without sorting only the spaces of the requested page need to be formatted
+		storageSpaces, next = paginate(odataReq, storageSpaces)
+	}
+
+	spaces, err := g.formatDrives(ctx, webDavBaseURL, storageSpaces)
 	if err != nil {
 		logger.Debug().Err(err).Msg("could not get drives: error parsing grpc response")
 		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	spaces, err = sortSpaces(odataReq, spaces)
-	if err != nil {
-		logger.Debug().Err(err).Msg("could not get drives: error sorting the spaces list according to query")
-		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
-		return
+	if odataReq.Query.OrderBy != nil {
+		spaces, err = sortSpaces(odataReq, spaces)
+		if err != nil {
+			logger.Debug().Err(err).Msg("could not get drives: error sorting the spaces list according to query")
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		spaces, next = paginate(odataReq, spaces)
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &listResponse{Value: spaces})
+	render.JSON(w, r, g.newListResponse(r, odataReq, spaces, total, next))
 }
 
 // GetSingleDrive does a lookup of a single space by spaceId
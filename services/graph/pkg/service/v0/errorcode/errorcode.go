@@ -101,12 +101,25 @@ func (e ErrorCode) Render(w http.ResponseWriter, r *http.Request, status int, ms
 
 func (e Error) Render(w http.ResponseWriter, r *http.Request) {
 	status := http.StatusInternalServerError
-	if e.errorCode == ItemNotFound {
+	switch e.errorCode {
+	case ItemNotFound:
 		status = http.StatusNotFound
+	case InvalidRequest:
+		status = http.StatusBadRequest
 	}
 	e.errorCode.Render(w, r, status, e.msg)
 }
 
+// GetCode returns the ErrorCode of the error
+func (e Error) GetCode() ErrorCode {
+	return e.errorCode
+}
+
+// GetMessage returns the message of the error
+func (e Error) GetMessage() string {
+	return e.msg
+}
+
 func (e ErrorCode) String() string {
 	return errorCodes[e]
 }
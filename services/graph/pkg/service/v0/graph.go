@@ -5,16 +5,24 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/go-chi/chi/v5"
 	"github.com/jellydator/ttlcache/v2"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
 	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/identity"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/scim"
 	mevents "go-micro.dev/v4/events"
 	"google.golang.org/grpc"
 )
@@ -27,6 +35,25 @@ type GatewayClient interface {
 
 	// Authenticates a user.
 	Authenticate(ctx context.Context, in *gateway.AuthenticateRequest, opts ...grpc.CallOption) (*gateway.AuthenticateResponse, error)
+	// Creates a new resource of type container.
+	// MUST return CODE_PRECONDITION_FAILED if the container
+	// cannot be created at the specified reference.
+	CreateContainer(ctx context.Context, in *provider.CreateContainerRequest, opts ...grpc.CallOption) (*provider.CreateContainerResponse, error)
+	// Creates a new resource of type file.
+	TouchFile(ctx context.Context, in *provider.TouchFileRequest, opts ...grpc.CallOption) (*provider.TouchFileResponse, error)
+	// Deletes a resource.
+	// If a resource specifies the non-empty container (directory, ...),
+	// then the entire directory is deleted recursively.
+	// MUST return CODE_NOT_FOUND if the reference does not exist.
+	Delete(ctx context.Context, in *provider.DeleteRequest, opts ...grpc.CallOption) (*provider.DeleteResponse, error)
+	// Moves a resource from one reference to another.
+	// MUST return CODE_NOT_FOUND if any of the references do not exist.
+	// MUST return CODE_FAILED_PRECONDITION if the source reference
+	// cannot be moved to the destination reference.
+	Move(ctx context.Context, in *provider.MoveRequest, opts ...grpc.CallOption) (*provider.MoveResponse, error)
+	// Initiates the upload of a file using an
+	// out-of-band data transfer mechanism.
+	InitiateFileUpload(ctx context.Context, in *provider.InitiateFileUploadRequest, opts ...grpc.CallOption) (*gateway.InitiateFileUploadResponse, error)
 	// Returns the home path for the given authenticated user.
 	// When a user has access to multiple storage providers, one of them is the home.
 	GetHome(ctx context.Context, in *provider.GetHomeRequest, opts ...grpc.CallOption) (*provider.GetHomeResponse, error)
@@ -39,6 +66,12 @@ type GatewayClient interface {
 	// Returns the resource information at the provided reference.
 	// MUST return CODE_NOT_FOUND if the reference does not exist.
 	Stat(ctx context.Context, in *provider.StatRequest, opts ...grpc.CallOption) (*provider.StatResponse, error)
+	// Sets arbitrary metadata into a storage resource.
+	// Arbitrary metadata is returned in a cs3.storage.provider.v1beta1.ResourceInfo.
+	SetArbitraryMetadata(ctx context.Context, in *provider.SetArbitraryMetadataRequest, opts ...grpc.CallOption) (*provider.SetArbitraryMetadataResponse, error)
+	// Unsets arbitrary metdata into a storage resource.
+	// Arbitrary metadata is returned in a cs3.storage.provider.v1beta1.ResourceInfo.
+	UnsetArbitraryMetadata(ctx context.Context, in *provider.UnsetArbitraryMetadataRequest, opts ...grpc.CallOption) (*provider.UnsetArbitraryMetadataResponse, error)
 	// Initiates the download of a file using an
 	// out-of-band data transfer mechanism.
 	InitiateFileDownload(ctx context.Context, in *provider.InitiateFileDownloadRequest, opts ...grpc.CallOption) (*gateway.InitiateFileDownloadResponse, error)
@@ -55,6 +88,39 @@ type GatewayClient interface {
 	// MUST return CODE_NOT_FOUND if the reference does not exist
 	// MUST return CODE_RESOURCE_EXHAUSTED on exceeded quota limits.
 	GetQuota(ctx context.Context, in *gateway.GetQuotaRequest, opts ...grpc.CallOption) (*provider.GetQuotaResponse, error)
+	// Gets the information about a user by the user id.
+	GetUser(ctx context.Context, in *user.GetUserRequest, opts ...grpc.CallOption) (*user.GetUserResponse, error)
+	// Gets the information about a group by the group id.
+	GetGroup(ctx context.Context, in *group.GetGroupRequest, opts ...grpc.CallOption) (*group.GetGroupResponse, error)
+	// Creates a new share.
+	// MUST return CODE_NOT_FOUND if the resource reference does not exist.
+	// MUST return CODE_ALREADY_EXISTS if the share already exists for the 4-tuple consisting of
+	// (owner, shared_resource, grantee).
+	// New shares MUST be created in the state SHARE_STATE_PENDING.
+	CreateShare(ctx context.Context, in *collaboration.CreateShareRequest, opts ...grpc.CallOption) (*collaboration.CreateShareResponse, error)
+	// Removes a share.
+	// MUST return CODE_NOT_FOUND if the share reference does not exist.
+	RemoveShare(ctx context.Context, in *collaboration.RemoveShareRequest, opts ...grpc.CallOption) (*collaboration.RemoveShareResponse, error)
+	// List the shares the authenticated principal has created,
+	// both as owner and creator. If a filter is specified, only
+	// shares satisfying the filter MUST be returned.
+	ListShares(ctx context.Context, in *collaboration.ListSharesRequest, opts ...grpc.CallOption) (*collaboration.ListSharesResponse, error)
+	// Updates a share.
+	// MUST return CODE_NOT_FOUND if the share reference does not exist.
+	UpdateShare(ctx context.Context, in *collaboration.UpdateShareRequest, opts ...grpc.CallOption) (*collaboration.UpdateShareResponse, error)
+	// Creates a new public share.
+	// MUST return CODE_NOT_FOUND if the resource reference does not exist.
+	CreatePublicShare(ctx context.Context, in *link.CreatePublicShareRequest, opts ...grpc.CallOption) (*link.CreatePublicShareResponse, error)
+	// Removes a public share.
+	// MUST return CODE_NOT_FOUND if the public share reference does not exist.
+	RemovePublicShare(ctx context.Context, in *link.RemovePublicShareRequest, opts ...grpc.CallOption) (*link.RemovePublicShareResponse, error)
+	// List the public shares the authenticated principal has created,
+	// both as owner and creator. If a filter is specified, only
+	// public shares satisfying the filter MUST be returned.
+	ListPublicShares(ctx context.Context, in *link.ListPublicSharesRequest, opts ...grpc.CallOption) (*link.ListPublicSharesResponse, error)
+	// Updates a public share.
+	// MUST return CODE_NOT_FOUND if the public share reference does not exist.
+	UpdatePublicShare(ctx context.Context, in *link.UpdatePublicShareRequest, opts ...grpc.CallOption) (*link.UpdatePublicShareResponse, error)
 }
 
 // Publisher is the interface for events publisher
@@ -77,13 +143,22 @@ type Graph struct {
 	logger               *log.Logger
 	identityBackend      identity.Backend
 	gatewayClient        GatewayClient
+	httpClient           HTTPClient
 	roleService          settingssvc.RoleService
+	searchService        searchsvc.SearchProviderService
 	spacePropertiesCache *ttlcache.Cache
 	eventsPublisher      events.Publisher
+	appTokenManager      apptoken.Manager
+	scim                 http.Handler
 }
 
 // ServeHTTP implements the Service interface.
 func (g Graph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the SCIM endpoint uses its own authentication and bypasses the graph middlewares
+	if g.scim != nil && strings.HasPrefix(r.URL.Path, scim.Root) {
+		g.scim.ServeHTTP(w, r)
+		return
+	}
 	g.mux.ServeHTTP(w, r)
 }
 
@@ -112,7 +187,9 @@ func (g Graph) getWebDavBaseURL() (*url.URL, error) {
 }
 
 type listResponse struct {
-	Value interface{} `json:"value,omitempty"`
+	Count    *int        `json:"@odata.count,omitempty"`
+	NextLink string      `json:"@odata.nextLink,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
 }
 
 const (
@@ -200,6 +200,109 @@ var _ = Describe("Graph", func() {
 			}
 			`))
 		})
+		It("can list a page of spaces", func() {
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&provider.ListStorageSpacesResponse{
+				Status: status.NewOK(ctx),
+				StorageSpaces: []*provider.StorageSpace{
+					{
+						Id:        &provider.StorageSpaceId{OpaqueId: "bsameID"},
+						SpaceType: "bspacetype",
+						Root:      &provider.ResourceId{StorageId: "pro-1", SpaceId: "bsameID", OpaqueId: "bsameID"},
+						Name:      "bspacename",
+					},
+					{
+						Id:        &provider.StorageSpaceId{OpaqueId: "asameID"},
+						SpaceType: "aspacetype",
+						Root:      &provider.ResourceId{StorageId: "pro-1", SpaceId: "asameID", OpaqueId: "asameID"},
+						Name:      "aspacename",
+					},
+				},
+			}, nil)
+			gatewayClient.On("InitiateFileDownload", mock.Anything, mock.Anything).Return(&gateway.InitiateFileDownloadResponse{
+				Status: status.NewNotFound(ctx, "not found"),
+			}, nil)
+			gatewayClient.On("GetQuota", mock.Anything, mock.Anything).Return(&provider.GetQuotaResponse{
+				Status: status.NewUnimplemented(ctx, fmt.Errorf("not supported"), "not supported"),
+			}, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/me/drives?$orderby=name%20asc&$top=1&$count=true", nil)
+			rr := httptest.NewRecorder()
+			svc.GetDrives(rr, r)
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			res := struct {
+				Count    int    `json:"@odata.count"`
+				NextLink string `json:"@odata.nextLink"`
+				Value    []struct {
+					Name string
+				}
+			}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(res.Count).To(Equal(2))
+			Expect(len(res.Value)).To(Equal(1))
+			Expect(res.Value[0].Name).To(Equal("aspacename"))
+			next, err := url.Parse(res.NextLink)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(next.Path).To(Equal("/graph/v1.0/me/drives"))
+			Expect(next.Query().Get("$skip")).To(Equal("1"))
+			Expect(next.Query().Get("$top")).To(Equal("1"))
+		})
+		It("does not link to a next page for an empty page size", func() {
+			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&provider.ListStorageSpacesResponse{
+				Status: status.NewOK(ctx),
+				StorageSpaces: []*provider.StorageSpace{
+					{
+						Id:        &provider.StorageSpaceId{OpaqueId: "bsameID"},
+						SpaceType: "bspacetype",
+						Root:      &provider.ResourceId{StorageId: "pro-1", SpaceId: "bsameID", OpaqueId: "bsameID"},
+						Name:      "bspacename",
+					},
+					{
+						Id:        &provider.StorageSpaceId{OpaqueId: "asameID"},
+						SpaceType: "aspacetype",
+						Root:      &provider.ResourceId{StorageId: "pro-1", SpaceId: "asameID", OpaqueId: "asameID"},
+						Name:      "aspacename",
+					},
+				},
+			}, nil)
+			gatewayClient.On("InitiateFileDownload", mock.Anything, mock.Anything).Return(&gateway.InitiateFileDownloadResponse{
+				Status: status.NewNotFound(ctx, "not found"),
+			}, nil)
+			gatewayClient.On("GetQuota", mock.Anything, mock.Anything).Return(&provider.GetQuotaResponse{
+				Status: status.NewUnimplemented(ctx, fmt.Errorf("not supported"), "not supported"),
+			}, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/me/drives?$top=0&$skip=1&$count=true", nil)
+			rr := httptest.NewRecorder()
+			svc.GetDrives(rr, r)
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			res := struct {
+				Count    int    `json:"@odata.count"`
+				NextLink string `json:"@odata.nextLink"`
+				Value    []struct {
+					Name string
+				}
+			}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(res.Count).To(Equal(2))
+			Expect(len(res.Value)).To(Equal(0))
+			Expect(res.NextLink).To(BeEmpty())
+		})
+		It("can not list spaces with a negative page size", func() {
+			r := httptest.NewRequest(http.MethodGet, "/graph/v1.0/me/drives?$top=-1", nil)
+			rr := httptest.NewRecorder()
+			svc.GetDrives(rr, r)
+
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+		It("can not skip more spaces than allowed", func() {
+			r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/graph/v1.0/me/drives?$skip=%d", cfg.API.MaxSkip+1), nil)
+			rr := httptest.NewRecorder()
+			svc.GetDrives(rr, r)
+
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
 		It("can list a spaces type mountpoint", func() {
 			gatewayClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&provider.ListStorageSpacesResponse{
 				Status: status.NewOK(ctx),
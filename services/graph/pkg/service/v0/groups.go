@@ -32,6 +32,11 @@ func (g Graph) GetGroups(w http.ResponseWriter, r *http.Request) {
 		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	if err := validatePaging(odataReq, g.config.API.MaxSkip); err != nil {
+		logger.Debug().Err(err).Interface("query", r.URL.Query()).Msg("could not get groups: invalid paging")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	groups, err := g.identityBackend.GetGroups(r.Context(), r.URL.Query())
 	if err != nil {
@@ -42,6 +47,7 @@ func (g Graph) GetGroups(w http.ResponseWriter, r *http.Request) {
 		} else {
 			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
 		}
+		return
 	}
 
 	groups, err = sortGroups(odataReq, groups)
@@ -50,8 +56,9 @@ func (g Graph) GetGroups(w http.ResponseWriter, r *http.Request) {
 		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	page, next := paginate(odataReq, groups)
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &listResponse{Value: groups})
+	render.JSON(w, r, g.newListResponse(r, odataReq, page, len(groups), next))
 }
 
 // PostGroup implements the Service interface.
@@ -261,8 +268,19 @@ func (g Graph) GetGroupMembers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sanitizedPath := strings.TrimPrefix(r.URL.Path, "/graph/v1.0/")
+	odataReq, err := godata.ParseRequest(r.Context(), sanitizedPath, r.URL.Query())
+	if err == nil {
+		err = validatePaging(odataReq, g.config.API.MaxSkip)
+	}
+	if err != nil {
+		logger.Debug().Err(err).Interface("query", r.URL.Query()).Msg("could not get group members: query error")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	logger.Debug().Str("id", groupID).Msg("calling get group members on backend")
-	members, err := g.identityBackend.GetGroupMembers(r.Context(), groupID)
+	members, err := g.identityBackend.GetGroupMembers(r.Context(), groupID, r.URL.Query())
 	if err != nil {
 		logger.Debug().Err(err).Msg("could not get group members: backend error")
 		var errcode errorcode.Error
@@ -275,7 +293,13 @@ func (g Graph) GetGroupMembers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, members)
+	// unpaged requests keep returning a plain list of members for compatibility
+	if !isPaged(odataReq) {
+		render.JSON(w, r, members)
+		return
+	}
+	page, next := paginate(odataReq, members)
+	render.JSON(w, r, g.newListResponse(r, odataReq, page, len(members), next))
 }
 
 // PostGroupMember implements the Service interface.
@@ -59,6 +59,21 @@ func (i instrument) ChangeOwnPassword(w http.ResponseWriter, r *http.Request) {
 	i.next.ChangeOwnPassword(w, r)
 }
 
+// ListAppTokens implements the Service interface.
+func (i instrument) ListAppTokens(w http.ResponseWriter, r *http.Request) {
+	i.next.ListAppTokens(w, r)
+}
+
+// CreateAppToken implements the Service interface.
+func (i instrument) CreateAppToken(w http.ResponseWriter, r *http.Request) {
+	i.next.CreateAppToken(w, r)
+}
+
+// DeleteAppToken implements the Service interface.
+func (i instrument) DeleteAppToken(w http.ResponseWriter, r *http.Request) {
+	i.next.DeleteAppToken(w, r)
+}
+
 // GetGroups implements the Service interface.
 func (i instrument) GetGroups(w http.ResponseWriter, r *http.Request) {
 	i.next.GetGroups(w, r)
@@ -103,3 +118,88 @@ func (i instrument) DeleteGroupMember(w http.ResponseWriter, r *http.Request) {
 func (i instrument) GetDrives(w http.ResponseWriter, r *http.Request) {
 	i.next.GetDrives(w, r)
 }
+
+// GetDriveItem implements the Service interface.
+func (i instrument) GetDriveItem(w http.ResponseWriter, r *http.Request) {
+	i.next.GetDriveItem(w, r)
+}
+
+// GetDriveItemChildren implements the Service interface.
+func (i instrument) GetDriveItemChildren(w http.ResponseWriter, r *http.Request) {
+	i.next.GetDriveItemChildren(w, r)
+}
+
+// CreateDriveItem implements the Service interface.
+func (i instrument) CreateDriveItem(w http.ResponseWriter, r *http.Request) {
+	i.next.CreateDriveItem(w, r)
+}
+
+// UpdateDriveItem implements the Service interface.
+func (i instrument) UpdateDriveItem(w http.ResponseWriter, r *http.Request) {
+	i.next.UpdateDriveItem(w, r)
+}
+
+// CopyDriveItem implements the Service interface.
+func (i instrument) CopyDriveItem(w http.ResponseWriter, r *http.Request) {
+	i.next.CopyDriveItem(w, r)
+}
+
+// DeleteDriveItem implements the Service interface.
+func (i instrument) DeleteDriveItem(w http.ResponseWriter, r *http.Request) {
+	i.next.DeleteDriveItem(w, r)
+}
+
+// GetDriveItemTags implements the Service interface.
+func (i instrument) GetDriveItemTags(w http.ResponseWriter, r *http.Request) {
+	i.next.GetDriveItemTags(w, r)
+}
+
+// TagDriveItem implements the Service interface.
+func (i instrument) TagDriveItem(w http.ResponseWriter, r *http.Request) {
+	i.next.TagDriveItem(w, r)
+}
+
+// UntagDriveItem implements the Service interface.
+func (i instrument) UntagDriveItem(w http.ResponseWriter, r *http.Request) {
+	i.next.UntagDriveItem(w, r)
+}
+
+// SearchDriveTrash implements the Service interface.
+func (i instrument) SearchDriveTrash(w http.ResponseWriter, r *http.Request) {
+	i.next.SearchDriveTrash(w, r)
+}
+
+// Invite implements the Service interface.
+func (i instrument) Invite(w http.ResponseWriter, r *http.Request) {
+	i.next.Invite(w, r)
+}
+
+// CreateLink implements the Service interface.
+func (i instrument) CreateLink(w http.ResponseWriter, r *http.Request) {
+	i.next.CreateLink(w, r)
+}
+
+// ListPermissions implements the Service interface.
+func (i instrument) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	i.next.ListPermissions(w, r)
+}
+
+// GetPermission implements the Service interface.
+func (i instrument) GetPermission(w http.ResponseWriter, r *http.Request) {
+	i.next.GetPermission(w, r)
+}
+
+// UpdatePermission implements the Service interface.
+func (i instrument) UpdatePermission(w http.ResponseWriter, r *http.Request) {
+	i.next.UpdatePermission(w, r)
+}
+
+// DeletePermission implements the Service interface.
+func (i instrument) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	i.next.DeletePermission(w, r)
+}
+
+// Batch implements the Service interface.
+func (i instrument) Batch(w http.ResponseWriter, r *http.Request) {
+	i.next.Batch(w, r)
+}
@@ -59,6 +59,21 @@ func (l logging) ChangeOwnPassword(w http.ResponseWriter, r *http.Request) {
 	l.next.ChangeOwnPassword(w, r)
 }
 
+// ListAppTokens implements the Service interface.
+func (l logging) ListAppTokens(w http.ResponseWriter, r *http.Request) {
+	l.next.ListAppTokens(w, r)
+}
+
+// CreateAppToken implements the Service interface.
+func (l logging) CreateAppToken(w http.ResponseWriter, r *http.Request) {
+	l.next.CreateAppToken(w, r)
+}
+
+// DeleteAppToken implements the Service interface.
+func (l logging) DeleteAppToken(w http.ResponseWriter, r *http.Request) {
+	l.next.DeleteAppToken(w, r)
+}
+
 // GetGroups implements the Service interface.
 func (l logging) GetGroups(w http.ResponseWriter, r *http.Request) {
 	l.next.GetGroups(w, r)
@@ -103,3 +118,88 @@ func (l logging) DeleteGroupMember(w http.ResponseWriter, r *http.Request) {
 func (l logging) GetDrives(w http.ResponseWriter, r *http.Request) {
 	l.next.GetDrives(w, r)
 }
+
+// GetDriveItem implements the Service interface.
+func (l logging) GetDriveItem(w http.ResponseWriter, r *http.Request) {
+	l.next.GetDriveItem(w, r)
+}
+
+// GetDriveItemChildren implements the Service interface.
+func (l logging) GetDriveItemChildren(w http.ResponseWriter, r *http.Request) {
+	l.next.GetDriveItemChildren(w, r)
+}
+
+// CreateDriveItem implements the Service interface.
+func (l logging) CreateDriveItem(w http.ResponseWriter, r *http.Request) {
+	l.next.CreateDriveItem(w, r)
+}
+
+// UpdateDriveItem implements the Service interface.
+func (l logging) UpdateDriveItem(w http.ResponseWriter, r *http.Request) {
+	l.next.UpdateDriveItem(w, r)
+}
+
+// CopyDriveItem implements the Service interface.
+func (l logging) CopyDriveItem(w http.ResponseWriter, r *http.Request) {
+	l.next.CopyDriveItem(w, r)
+}
+
+// DeleteDriveItem implements the Service interface.
+func (l logging) DeleteDriveItem(w http.ResponseWriter, r *http.Request) {
+	l.next.DeleteDriveItem(w, r)
+}
+
+// GetDriveItemTags implements the Service interface.
+func (l logging) GetDriveItemTags(w http.ResponseWriter, r *http.Request) {
+	l.next.GetDriveItemTags(w, r)
+}
+
+// TagDriveItem implements the Service interface.
+func (l logging) TagDriveItem(w http.ResponseWriter, r *http.Request) {
+	l.next.TagDriveItem(w, r)
+}
+
+// UntagDriveItem implements the Service interface.
+func (l logging) UntagDriveItem(w http.ResponseWriter, r *http.Request) {
+	l.next.UntagDriveItem(w, r)
+}
+
+// SearchDriveTrash implements the Service interface.
+func (l logging) SearchDriveTrash(w http.ResponseWriter, r *http.Request) {
+	l.next.SearchDriveTrash(w, r)
+}
+
+// Invite implements the Service interface.
+func (l logging) Invite(w http.ResponseWriter, r *http.Request) {
+	l.next.Invite(w, r)
+}
+
+// CreateLink implements the Service interface.
+func (l logging) CreateLink(w http.ResponseWriter, r *http.Request) {
+	l.next.CreateLink(w, r)
+}
+
+// ListPermissions implements the Service interface.
+func (l logging) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	l.next.ListPermissions(w, r)
+}
+
+// GetPermission implements the Service interface.
+func (l logging) GetPermission(w http.ResponseWriter, r *http.Request) {
+	l.next.GetPermission(w, r)
+}
+
+// UpdatePermission implements the Service interface.
+func (l logging) UpdatePermission(w http.ResponseWriter, r *http.Request) {
+	l.next.UpdatePermission(w, r)
+}
+
+// DeletePermission implements the Service interface.
+func (l logging) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	l.next.DeletePermission(w, r)
+}
+
+// Batch implements the Service interface.
+func (l logging) Batch(w http.ResponseWriter, r *http.Request) {
+	l.next.Batch(w, r)
+}
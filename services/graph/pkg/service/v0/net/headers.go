@@ -6,4 +6,6 @@ const (
 	HeaderTokenTransport = "X-Reva-Transfer"
 	// HeaderIfModifiedSince is used to mimic/pass on caching headers when using grpc
 	HeaderIfModifiedSince = "If-Modified-Since"
+	// HeaderUploadLength holds the size of an upload initiated via grpc
+	HeaderUploadLength = "Upload-Length"
 )
@@ -4,11 +4,14 @@ import (
 	"net/http"
 
 	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/ocis-pkg/roles"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
 	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/identity"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/metrics"
 )
 
 // Option defines a single option function.
@@ -20,10 +23,14 @@ type Options struct {
 	Config          *config.Config
 	Middleware      []func(http.Handler) http.Handler
 	GatewayClient   GatewayClient
+	HTTPClient      HTTPClient
 	IdentityBackend identity.Backend
 	RoleService     settingssvc.RoleService
+	SearchService   searchsvc.SearchProviderService
 	RoleManager     *roles.Manager
 	EventsPublisher events.Publisher
+	AppTokenManager apptoken.Manager
+	Metrics         *metrics.Metrics
 }
 
 // newOptions initializes the available default options.
@@ -65,6 +72,13 @@ func WithGatewayClient(val GatewayClient) Option {
 	}
 }
 
+// WithHTTPClient provides a function to set the http client option.
+func WithHTTPClient(val HTTPClient) Option {
+	return func(o *Options) {
+		o.HTTPClient = val
+	}
+}
+
 // WithIdentityBackend provides a function to set the IdentityBackend option.
 func WithIdentityBackend(val identity.Backend) Option {
 	return func(o *Options) {
@@ -79,6 +93,13 @@ func RoleService(val settingssvc.RoleService) Option {
 	}
 }
 
+// SearchService provides a function to set the SearchService option.
+func SearchService(val searchsvc.SearchProviderService) Option {
+	return func(o *Options) {
+		o.SearchService = val
+	}
+}
+
 // RoleManager provides a function to set the RoleManager option.
 func RoleManager(val *roles.Manager) Option {
 	return func(o *Options) {
@@ -92,3 +113,17 @@ func EventsPublisher(val events.Publisher) Option {
 		o.EventsPublisher = val
 	}
 }
+
+// AppTokenManager provides a function to set the AppTokenManager option.
+func AppTokenManager(val apptoken.Manager) Option {
+	return func(o *Options) {
+		o.AppTokenManager = val
+	}
+}
+
+// Metrics provides a function to set the metrics option.
+func Metrics(val *metrics.Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = val
+	}
+}
@@ -0,0 +1,79 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/CiscoM31/godata"
+)
+
+// validatePaging rejects negative $top and $skip values and $skip values above maxSkip. The
+// LDAP identity backend can't resume a search at an offset, every page reads the entries it
+// skips again. A maxSkip of 0 allows any $skip value.
+func validatePaging(req *godata.GoDataRequest, maxSkip int) error {
+	if req.Query.Top != nil && *req.Query.Top < 0 {
+		return errors.New("$top must not be negative")
+	}
+	if req.Query.Skip != nil && *req.Query.Skip < 0 {
+		return errors.New("$skip must not be negative")
+	}
+	if req.Query.Skip != nil && maxSkip > 0 && int(*req.Query.Skip) > maxSkip {
+		return fmt.Errorf("$skip must not be larger than %d, use $filter or $search to narrow down the collection", maxSkip)
+	}
+	return nil
+}
+
+// isPaged returns true if the request asks for a page of a collection or for its size
+func isPaged(req *godata.GoDataRequest) bool {
+	return req.Query.Top != nil || req.Query.Skip != nil || req.Query.Count != nil
+}
+
+// paginate returns the items of the page requested with $skip and $top. next is the $skip value
+// of the following page, or 0 if there are no more items or the page is empty.
+func paginate[T any](req *godata.GoDataRequest, items []T) (page []T, next int) {
+	skip := 0
+	if req.Query.Skip != nil {
+		skip = int(*req.Query.Skip)
+	}
+	if skip > len(items) {
+		skip = len(items)
+	}
+	page = items[skip:]
+	if req.Query.Top != nil && int(*req.Query.Top) < len(page) {
+		top := int(*req.Query.Top)
+		page = page[:top]
+		if top > 0 {
+			next = skip + top
+		}
+	}
+	return page, next
+}
+
+// newListResponse returns the response for a page of a collection. The size of the whole
+// collection is only rendered when it was requested with $count.
+func (g Graph) newListResponse(r *http.Request, req *godata.GoDataRequest, value interface{}, total, next int) *listResponse {
+	res := &listResponse{Value: value}
+	if req.Query.Count != nil && bool(*req.Query.Count) {
+		res.Count = &total
+	}
+	if next > 0 {
+		res.NextLink = g.nextLink(r, next)
+	}
+	return res
+}
+
+// nextLink returns the public url of the request with $skip pointing to the next page
+func (g Graph) nextLink(r *http.Request, skip int) string {
+	q := r.URL.Query()
+	q.Set("$skip", strconv.Itoa(skip))
+	u, err := url.Parse(g.config.Spaces.WebDavBase)
+	if err != nil {
+		u = &url.URL{}
+	}
+	u.Path = r.URL.Path
+	u.RawQuery = q.Encode()
+	return u.String()
+}
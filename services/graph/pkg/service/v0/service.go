@@ -6,20 +6,27 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/cs3org/reva/v2/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/v2/pkg/rhttp"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	goldap "github.com/go-ldap/ldap/v3"
 	"github.com/jellydator/ttlcache/v2"
 
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
 	ocisldap "github.com/owncloud/ocis/v2/ocis-pkg/ldap"
 	"github.com/owncloud/ocis/v2/ocis-pkg/roles"
 	"github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
 	"github.com/owncloud/ocis/v2/ocis-pkg/store"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
 	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	storesvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/store/v0"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/identity"
 	"github.com/owncloud/ocis/v2/services/graph/pkg/identity/ldap"
 	graphm "github.com/owncloud/ocis/v2/services/graph/pkg/middleware"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/scim"
 )
 
 const (
@@ -37,6 +44,9 @@ type Service interface {
 	DeleteUser(http.ResponseWriter, *http.Request)
 	PatchUser(http.ResponseWriter, *http.Request)
 	ChangeOwnPassword(http.ResponseWriter, *http.Request)
+	ListAppTokens(http.ResponseWriter, *http.Request)
+	CreateAppToken(http.ResponseWriter, *http.Request)
+	DeleteAppToken(http.ResponseWriter, *http.Request)
 
 	GetGroups(http.ResponseWriter, *http.Request)
 	GetGroup(http.ResponseWriter, *http.Request)
@@ -48,6 +58,26 @@ type Service interface {
 	DeleteGroupMember(http.ResponseWriter, *http.Request)
 
 	GetDrives(w http.ResponseWriter, r *http.Request)
+
+	GetDriveItem(http.ResponseWriter, *http.Request)
+	GetDriveItemChildren(http.ResponseWriter, *http.Request)
+	CreateDriveItem(http.ResponseWriter, *http.Request)
+	UpdateDriveItem(http.ResponseWriter, *http.Request)
+	CopyDriveItem(http.ResponseWriter, *http.Request)
+	DeleteDriveItem(http.ResponseWriter, *http.Request)
+	GetDriveItemTags(http.ResponseWriter, *http.Request)
+	TagDriveItem(http.ResponseWriter, *http.Request)
+	UntagDriveItem(http.ResponseWriter, *http.Request)
+	SearchDriveTrash(http.ResponseWriter, *http.Request)
+
+	Invite(http.ResponseWriter, *http.Request)
+	CreateLink(http.ResponseWriter, *http.Request)
+	ListPermissions(http.ResponseWriter, *http.Request)
+	GetPermission(http.ResponseWriter, *http.Request)
+	UpdatePermission(http.ResponseWriter, *http.Request)
+	DeletePermission(http.ResponseWriter, *http.Request)
+
+	Batch(http.ResponseWriter, *http.Request)
 }
 
 // NewService returns a service implementation for Service.
@@ -74,6 +104,11 @@ func NewService(opts ...Option) Service {
 	} else {
 		svc.gatewayClient = options.GatewayClient
 	}
+	if options.HTTPClient == nil {
+		svc.httpClient = rhttp.GetHTTPClient(rhttp.Insecure(options.Config.Spaces.Insecure))
+	} else {
+		svc.httpClient = options.HTTPClient
+	}
 	if options.IdentityBackend == nil {
 		switch options.Config.Identity.Backend {
 		case "cs3":
@@ -116,14 +151,20 @@ func NewService(opts ...Option) Service {
 				tlsConf.RootCAs = certs
 			}
 
-			conn := ldap.NewLDAPWithReconnect(&options.Logger,
+			ldapConfig := options.Config.Identity.LDAP
+			var conn goldap.Client = ldap.NewConnPool(&options.Logger,
 				ldap.Config{
-					URI:          options.Config.Identity.LDAP.URI,
-					BindDN:       options.Config.Identity.LDAP.BindDN,
-					BindPassword: options.Config.Identity.LDAP.BindPassword,
+					URI:          ldapConfig.URI,
+					BindDN:       ldapConfig.BindDN,
+					BindPassword: ldapConfig.BindPassword,
 					TLSConfig:    tlsConf,
 				},
+				ldapConfig.PoolSize,
+				time.Duration(ldapConfig.HealthCheckInterval)*time.Second,
 			)
+			if ldapConfig.CacheTTL > 0 {
+				conn = ldap.NewCachingClient(conn, time.Duration(ldapConfig.CacheTTL)*time.Second, options.Metrics)
+			}
 			if svc.identityBackend, err = identity.NewLDAPBackend(conn, options.Config.Identity.LDAP, &options.Logger); err != nil {
 				options.Logger.Error().Msgf("Error initializing LDAP Backend: '%s'", err)
 				return nil
@@ -142,6 +183,18 @@ func NewService(opts ...Option) Service {
 		svc.roleService = options.RoleService
 	}
 
+	if options.SearchService == nil {
+		svc.searchService = searchsvc.NewSearchProviderService("com.owncloud.api.search", grpc.DefaultClient())
+	} else {
+		svc.searchService = options.SearchService
+	}
+
+	if options.AppTokenManager == nil {
+		svc.appTokenManager = apptoken.NewStoreManager(storesvc.NewStoreService("com.owncloud.api.store", grpc.DefaultClient()))
+	} else {
+		svc.appTokenManager = options.AppTokenManager
+	}
+
 	roleManager := options.RoleManager
 	if roleManager == nil {
 		storeOptions := store.OcisStoreOptions{
@@ -157,16 +210,33 @@ func NewService(opts ...Option) Service {
 		roleManager = &m
 	}
 
+	if options.Config.SCIM.Enabled {
+		svc.scim = scim.NewHandler(
+			scim.Logger(options.Logger),
+			scim.Token(options.Config.SCIM.Token),
+			scim.BaseURL(options.Config.Spaces.WebDavBase),
+			scim.IdentityBackend(svc.identityBackend),
+			scim.EventsPublisher(options.EventsPublisher),
+			scim.PersonalSpaceDeleter(svc.deletePersonalSpaceAsAdmin),
+		)
+	}
+
 	requireAdmin := graphm.RequireAdmin(roleManager, options.Logger)
 
 	m.Route(options.Config.HTTP.Root, func(r chi.Router) {
 		r.Use(middleware.StripSlashes)
 		r.Route("/v1.0", func(r chi.Router) {
+			r.Post("/$batch", svc.Batch)
 			r.Route("/me", func(r chi.Router) {
 				r.Get("/", svc.GetMe)
 				r.Get("/drives", svc.GetDrives)
 				r.Get("/drive/root/children", svc.GetRootDriveChildren)
 				r.Post("/changePassword", svc.ChangeOwnPassword)
+				r.Route("/appTokens", func(r chi.Router) {
+					r.Get("/", svc.ListAppTokens)
+					r.Post("/", svc.CreateAppToken)
+					r.Delete("/{tokenID}", svc.DeleteAppToken)
+				})
 			})
 			r.Route("/users", func(r chi.Router) {
 				r.With(requireAdmin).Get("/", svc.GetUsers)
@@ -198,6 +268,26 @@ func NewService(opts ...Option) Service {
 					r.Patch("/", svc.UpdateDrive)
 					r.Get("/", svc.GetSingleDrive)
 					r.Delete("/", svc.DeleteDrive)
+					r.Get("/trash/search", svc.SearchDriveTrash)
+					r.Route("/items/{itemID}", func(r chi.Router) {
+						r.Get("/", svc.GetDriveItem)
+						r.Patch("/", svc.UpdateDriveItem)
+						r.Delete("/", svc.DeleteDriveItem)
+						r.Get("/children", svc.GetDriveItemChildren)
+						r.Post("/children", svc.CreateDriveItem)
+						r.Post("/copy", svc.CopyDriveItem)
+						r.Get("/tags", svc.GetDriveItemTags)
+						r.Post("/tag", svc.TagDriveItem)
+						r.Post("/untag", svc.UntagDriveItem)
+						r.Post("/invite", svc.Invite)
+						r.Post("/createLink", svc.CreateLink)
+						r.Route("/permissions", func(r chi.Router) {
+							r.Get("/", svc.ListPermissions)
+							r.Get("/{permissionID}", svc.GetPermission)
+							r.Patch("/{permissionID}", svc.UpdatePermission)
+							r.Delete("/{permissionID}", svc.DeletePermission)
+						})
+					})
 				})
 			})
 		})
@@ -0,0 +1,709 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	cs3rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/cs3org/reva/v2/pkg/publicshare"
+	"github.com/cs3org/reva/v2/pkg/share"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	libregraph "github.com/owncloud/libre-graph-api-go"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+)
+
+const (
+	// roleViewer allows to read a shared item
+	roleViewer = "viewer"
+	// roleEditor allows to read and change a shared item
+	roleEditor = "editor"
+
+	// linkTypeView creates a read only link
+	linkTypeView = "view"
+	// linkTypeEdit creates a link allowing to read and change the item
+	linkTypeEdit = "edit"
+	// linkTypeUpload creates a link to a folder that only allows to upload files
+	linkTypeUpload = "upload"
+
+	recipientTypeUser  = "user"
+	recipientTypeGroup = "group"
+)
+
+// driveRecipient identifies the user or group a drive item is shared with
+type driveRecipient struct {
+	ObjectID string `json:"objectId"`
+	Type     string `json:"@libre.graph.recipient.type,omitempty"`
+}
+
+type inviteRequest struct {
+	Recipients []driveRecipient `json:"recipients"`
+	Roles      []string         `json:"roles"`
+}
+
+type createLinkRequest struct {
+	Type               string     `json:"type"`
+	Password           string     `json:"password,omitempty"`
+	ExpirationDateTime *time.Time `json:"expirationDateTime,omitempty"`
+}
+
+type updatePermissionRequest struct {
+	Roles              []string     `json:"roles,omitempty"`
+	Link               *sharingLink `json:"link,omitempty"`
+	Password           *string      `json:"password,omitempty"`
+	ExpirationDateTime *time.Time   `json:"expirationDateTime,omitempty"`
+}
+
+// sharingIdentitySet is the user or group a permission is granted to
+type sharingIdentitySet struct {
+	User  *libregraph.Identity `json:"user,omitempty"`
+	Group *libregraph.Identity `json:"group,omitempty"`
+}
+
+type sharingLink struct {
+	Type        string `json:"type"`
+	WebURL      string `json:"webUrl,omitempty"`
+	DisplayName string `json:"@libre.graph.displayName,omitempty"`
+}
+
+// permission is the representation of a share or a public link in the API. Shares carry
+// the grantee and the roles, links carry the link type and url.
+type permission struct {
+	ID                 string              `json:"id"`
+	Roles              []string            `json:"roles,omitempty"`
+	GrantedTo          *sharingIdentitySet `json:"grantedToV2,omitempty"`
+	Link               *sharingLink        `json:"link,omitempty"`
+	HasPassword        bool                `json:"hasPassword,omitempty"`
+	ExpirationDateTime *time.Time          `json:"expirationDateTime,omitempty"`
+}
+
+// Invite implements the Service interface. It shares a drive item with users and groups.
+func (g Graph) Invite(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling invite")
+	ctx := r.Context()
+
+	currentUser, ok := revactx.ContextGetUser(ctx)
+	if !ok {
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, "user not in context")
+		return
+	}
+
+	req := inviteRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Debug().Err(err).Msg("could not invite: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Recipients) == 0 {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "missing recipients")
+		return
+	}
+	if len(req.Roles) != 1 || !validRole(req.Roles[0]) {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "exactly one role is required, possible values are 'viewer' and 'editor'")
+		return
+	}
+
+	info, ok := g.statDriveItem(w, r)
+	if !ok {
+		return
+	}
+
+	grantees := make([]*storageprovider.Grantee, 0, len(req.Recipients))
+	identities := make([]*sharingIdentitySet, 0, len(req.Recipients))
+	for _, recipient := range req.Recipients {
+		grantee, identity, err := g.resolveRecipient(ctx, recipient)
+		if err != nil {
+			logger.Debug().Err(err).Str("recipient", recipient.ObjectID).Msg("could not invite: invalid recipient")
+			var errcode errorcode.Error
+			if errors.As(err, &errcode) {
+				errcode.Render(w, r)
+			} else {
+				errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+			}
+			return
+		}
+		grantees = append(grantees, grantee)
+		identities = append(identities, identity)
+	}
+
+	permissions := make([]*permission, 0, len(grantees))
+	for i, grantee := range grantees {
+		res, err := g.GetGatewayClient().CreateShare(ctx, &collaboration.CreateShareRequest{
+			ResourceInfo: info,
+			Grant: &collaboration.ShareGrant{
+				Grantee: grantee,
+				Permissions: &collaboration.SharePermissions{
+					Permissions: rolePermissions(req.Roles[0], info.Type == storageprovider.ResourceType_RESOURCE_TYPE_CONTAINER),
+				},
+			},
+		})
+		switch {
+		case err != nil:
+			logger.Error().Err(err).Msg("error sending create share grpc request")
+			errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		case res.Status.Code != cs3rpc.Code_CODE_OK:
+			renderDriveItemStatus(w, r, res.Status)
+			return
+		}
+
+		g.publishEvent(events.ShareCreated{
+			Executant:      currentUser.GetId(),
+			Sharer:         res.Share.GetCreator(),
+			GranteeUserID:  res.Share.GetGrantee().GetUserId(),
+			GranteeGroupID: res.Share.GetGrantee().GetGroupId(),
+			ItemID:         res.Share.GetResourceId(),
+			Permissions:    res.Share.GetPermissions(),
+			CTime:          res.Share.GetCtime(),
+		})
+
+		p := shareToPermission(res.Share)
+		p.GrantedTo = identities[i]
+		permissions = append(permissions, p)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &listResponse{Value: permissions})
+}
+
+// CreateLink implements the Service interface. It creates a public link for a drive item.
+func (g Graph) CreateLink(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling create link")
+	ctx := r.Context()
+
+	currentUser, ok := revactx.ContextGetUser(ctx)
+	if !ok {
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, "user not in context")
+		return
+	}
+
+	req := createLinkRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Debug().Err(err).Msg("could not create link: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ExpirationDateTime != nil && req.ExpirationDateTime.Before(time.Now()) {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "expirationDateTime must be in the future")
+		return
+	}
+
+	info, ok := g.statDriveItem(w, r)
+	if !ok {
+		return
+	}
+	perms, err := linkPermissions(req.Type, info.Type == storageprovider.ResourceType_RESOURCE_TYPE_CONTAINER)
+	if err != nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	grant := &link.Grant{
+		Permissions: &link.PublicSharePermissions{Permissions: perms},
+		Password:    req.Password,
+	}
+	if req.ExpirationDateTime != nil {
+		grant.Expiration = utils.TimeToTS(*req.ExpirationDateTime)
+	}
+	res, err := g.GetGatewayClient().CreatePublicShare(ctx, &link.CreatePublicShareRequest{
+		ResourceInfo: info,
+		Grant:        grant,
+	})
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error sending create public share grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case res.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, res.Status)
+		return
+	}
+
+	g.publishEvent(events.LinkCreated{
+		Executant:         currentUser.GetId(),
+		ShareID:           res.Share.GetId(),
+		Sharer:            res.Share.GetCreator(),
+		ItemID:            res.Share.GetResourceId(),
+		Permissions:       res.Share.GetPermissions(),
+		DisplayName:       res.Share.GetDisplayName(),
+		Expiration:        res.Share.GetExpiration(),
+		PasswordProtected: res.Share.GetPasswordProtected(),
+		CTime:             res.Share.GetCtime(),
+		Token:             res.Share.GetToken(),
+	})
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, g.linkToPermission(res.Share))
+}
+
+// ListPermissions implements the Service interface. It lists the shares and links of a drive item.
+func (g Graph) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling list permissions")
+
+	info, ok := g.statDriveItem(w, r)
+	if !ok {
+		return
+	}
+
+	shares, links, status, err := g.listItemShares(r.Context(), info.Id)
+	switch {
+	case err != nil:
+		logger.Error().Err(err).Msg("error listing shares")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	case status.GetCode() != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, status)
+		return
+	}
+
+	permissions := make([]*permission, 0, len(shares)+len(links))
+	for _, s := range shares {
+		permissions = append(permissions, shareToPermission(s))
+	}
+	for _, l := range links {
+		permissions = append(permissions, g.linkToPermission(l))
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &listResponse{Value: permissions})
+}
+
+// GetPermission implements the Service interface. It returns a single share or link of a drive item.
+func (g Graph) GetPermission(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling get permission")
+
+	_, s, l, ok := g.findPermission(w, r)
+	if !ok {
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	if s != nil {
+		render.JSON(w, r, shareToPermission(s))
+		return
+	}
+	render.JSON(w, r, g.linkToPermission(l))
+}
+
+// UpdatePermission implements the Service interface. It changes the roles of a share or the
+// type, password or expiration of a link.
+func (g Graph) UpdatePermission(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling update permission")
+	ctx := r.Context()
+
+	req := updatePermissionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Debug().Err(err).Msg("could not update permission: invalid request body")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	info, s, l, ok := g.findPermission(w, r)
+	if !ok {
+		return
+	}
+	isDir := info.Type == storageprovider.ResourceType_RESOURCE_TYPE_CONTAINER
+
+	if s != nil {
+		if req.Link != nil || req.Password != nil || req.ExpirationDateTime != nil {
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "only the roles of a share can be changed")
+			return
+		}
+		if len(req.Roles) != 1 || !validRole(req.Roles[0]) {
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "exactly one role is required, possible values are 'viewer' and 'editor'")
+			return
+		}
+		res, err := g.GetGatewayClient().UpdateShare(ctx, &collaboration.UpdateShareRequest{
+			Ref: &collaboration.ShareReference{Spec: &collaboration.ShareReference_Id{Id: s.Id}},
+			Field: &collaboration.UpdateShareRequest_UpdateField{
+				Field: &collaboration.UpdateShareRequest_UpdateField_Permissions{
+					Permissions: &collaboration.SharePermissions{
+						Permissions: rolePermissions(req.Roles[0], isDir),
+					},
+				},
+			},
+		})
+		switch {
+		case err != nil:
+			logger.Error().Err(err).Msg("error sending update share grpc request")
+			errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		case res.Status.Code != cs3rpc.Code_CODE_OK:
+			renderDriveItemStatus(w, r, res.Status)
+			return
+		}
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, shareToPermission(res.Share))
+		return
+	}
+
+	if len(req.Roles) != 0 {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "the roles of a link cannot be changed, change the link type instead")
+		return
+	}
+	if req.ExpirationDateTime != nil && req.ExpirationDateTime.Before(time.Now()) {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "expirationDateTime must be in the future")
+		return
+	}
+
+	updates := []*link.UpdatePublicShareRequest_Update{}
+	if req.Link != nil && req.Link.Type != "" {
+		perms, err := linkPermissions(req.Link.Type, isDir)
+		if err != nil {
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		updates = append(updates, &link.UpdatePublicShareRequest_Update{
+			Type:  link.UpdatePublicShareRequest_Update_TYPE_PERMISSIONS,
+			Grant: &link.Grant{Permissions: &link.PublicSharePermissions{Permissions: perms}},
+		})
+	}
+	if req.Link != nil && req.Link.DisplayName != "" {
+		updates = append(updates, &link.UpdatePublicShareRequest_Update{
+			Type:        link.UpdatePublicShareRequest_Update_TYPE_DISPLAYNAME,
+			DisplayName: req.Link.DisplayName,
+		})
+	}
+	if req.Password != nil {
+		updates = append(updates, &link.UpdatePublicShareRequest_Update{
+			Type:  link.UpdatePublicShareRequest_Update_TYPE_PASSWORD,
+			Grant: &link.Grant{Password: *req.Password},
+		})
+	}
+	if req.ExpirationDateTime != nil {
+		updates = append(updates, &link.UpdatePublicShareRequest_Update{
+			Type:  link.UpdatePublicShareRequest_Update_TYPE_EXPIRATION,
+			Grant: &link.Grant{Expiration: utils.TimeToTS(*req.ExpirationDateTime)},
+		})
+	}
+
+	// the public share API only allows to change one property per request
+	for _, update := range updates {
+		res, err := g.GetGatewayClient().UpdatePublicShare(ctx, &link.UpdatePublicShareRequest{
+			Ref:    &link.PublicShareReference{Spec: &link.PublicShareReference_Id{Id: l.Id}},
+			Update: update,
+		})
+		switch {
+		case err != nil:
+			logger.Error().Err(err).Msg("error sending update public share grpc request")
+			errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		case res.Status.Code != cs3rpc.Code_CODE_OK:
+			renderDriveItemStatus(w, r, res.Status)
+			return
+		}
+		l = res.Share
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, g.linkToPermission(l))
+}
+
+// DeletePermission implements the Service interface. It removes a share or a link of a drive item.
+func (g Graph) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling delete permission")
+	ctx := r.Context()
+
+	_, s, l, ok := g.findPermission(w, r)
+	if !ok {
+		return
+	}
+
+	var status *cs3rpc.Status
+	if s != nil {
+		res, err := g.GetGatewayClient().RemoveShare(ctx, &collaboration.RemoveShareRequest{
+			Ref: &collaboration.ShareReference{Spec: &collaboration.ShareReference_Id{Id: s.Id}},
+		})
+		if err != nil {
+			logger.Error().Err(err).Msg("error sending remove share grpc request")
+			errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		status = res.Status
+	} else {
+		res, err := g.GetGatewayClient().RemovePublicShare(ctx, &link.RemovePublicShareRequest{
+			Ref: &link.PublicShareReference{Spec: &link.PublicShareReference_Id{Id: l.Id}},
+		})
+		if err != nil {
+			logger.Error().Err(err).Msg("error sending remove public share grpc request")
+			errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		status = res.Status
+	}
+	if status.GetCode() != cs3rpc.Code_CODE_OK {
+		renderDriveItemStatus(w, r, status)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+	render.NoContent(w, r)
+}
+
+// statDriveItem stats the drive item addressed by the request. It renders the error and returns
+// false if the item cannot be found.
+func (g Graph) statDriveItem(w http.ResponseWriter, r *http.Request) (*storageprovider.ResourceInfo, bool) {
+	ref, err := driveItemRef(r)
+	if err != nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	res, err := g.GetGatewayClient().Stat(r.Context(), &storageprovider.StatRequest{Ref: ref})
+	switch {
+	case err != nil:
+		g.logger.Error().Err(err).Msg("error sending stat grpc request")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return nil, false
+	case res.Status.Code != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, res.Status)
+		return nil, false
+	}
+	return res.Info, true
+}
+
+// listItemShares lists the shares and public links of a resource
+func (g Graph) listItemShares(ctx context.Context, id *storageprovider.ResourceId) ([]*collaboration.Share, []*link.PublicShare, *cs3rpc.Status, error) {
+	sRes, err := g.GetGatewayClient().ListShares(ctx, &collaboration.ListSharesRequest{
+		Filters: []*collaboration.Filter{share.ResourceIDFilter(id)},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if sRes.Status.Code != cs3rpc.Code_CODE_OK {
+		return nil, nil, sRes.Status, nil
+	}
+	lRes, err := g.GetGatewayClient().ListPublicShares(ctx, &link.ListPublicSharesRequest{
+		Filters: []*link.ListPublicSharesRequest_Filter{publicshare.ResourceIDFilter(id)},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if lRes.Status.Code != cs3rpc.Code_CODE_OK {
+		return nil, nil, lRes.Status, nil
+	}
+	return sRes.Shares, lRes.Share, lRes.Status, nil
+}
+
+// findPermission looks up the share or link addressed by the request among the permissions of
+// the drive item. It renders the error and returns false if there is no such permission.
+func (g Graph) findPermission(w http.ResponseWriter, r *http.Request) (*storageprovider.ResourceInfo, *collaboration.Share, *link.PublicShare, bool) {
+	permissionID, err := url.PathUnescape(chi.URLParam(r, "permissionID"))
+	if err != nil || permissionID == "" {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "missing or invalid permission id")
+		return nil, nil, nil, false
+	}
+
+	info, ok := g.statDriveItem(w, r)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	shares, links, status, err := g.listItemShares(r.Context(), info.Id)
+	switch {
+	case err != nil:
+		g.logger.Error().Err(err).Msg("error listing shares")
+		errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+		return nil, nil, nil, false
+	case status.GetCode() != cs3rpc.Code_CODE_OK:
+		renderDriveItemStatus(w, r, status)
+		return nil, nil, nil, false
+	}
+	for _, s := range shares {
+		if s.GetId().GetOpaqueId() == permissionID {
+			return info, s, nil, true
+		}
+	}
+	for _, l := range links {
+		if l.GetId().GetOpaqueId() == permissionID {
+			return info, nil, l, true
+		}
+	}
+	errorcode.ItemNotFound.Render(w, r, http.StatusNotFound, "permission not found")
+	return nil, nil, nil, false
+}
+
+// resolveRecipient looks up the recipient of an invite
+func (g Graph) resolveRecipient(ctx context.Context, recipient driveRecipient) (*storageprovider.Grantee, *sharingIdentitySet, error) {
+	if recipient.ObjectID == "" {
+		return nil, nil, errors.New("missing recipient objectId")
+	}
+	switch recipient.Type {
+	case "", recipientTypeUser:
+		res, err := g.GetGatewayClient().GetUser(ctx, &user.GetUserRequest{UserId: &user.UserId{OpaqueId: recipient.ObjectID}})
+		switch {
+		case err != nil:
+			return nil, nil, errorcode.New(errorcode.ServiceNotAvailable, err.Error())
+		case res.Status.Code == cs3rpc.Code_CODE_NOT_FOUND:
+			return nil, nil, fmt.Errorf("user '%s' not found", recipient.ObjectID)
+		case res.Status.Code != cs3rpc.Code_CODE_OK:
+			return nil, nil, errorcode.New(errorcode.GeneralException, res.Status.Message)
+		}
+		return &storageprovider.Grantee{
+			Type: storageprovider.GranteeType_GRANTEE_TYPE_USER,
+			Id:   &storageprovider.Grantee_UserId{UserId: res.User.Id},
+		}, &sharingIdentitySet{
+			User: &libregraph.Identity{Id: libregraph.PtrString(res.User.Id.OpaqueId), DisplayName: libregraph.PtrString(res.User.DisplayName)},
+		}, nil
+	case recipientTypeGroup:
+		res, err := g.GetGatewayClient().GetGroup(ctx, &group.GetGroupRequest{GroupId: &group.GroupId{OpaqueId: recipient.ObjectID}})
+		switch {
+		case err != nil:
+			return nil, nil, errorcode.New(errorcode.ServiceNotAvailable, err.Error())
+		case res.Status.Code == cs3rpc.Code_CODE_NOT_FOUND:
+			return nil, nil, fmt.Errorf("group '%s' not found", recipient.ObjectID)
+		case res.Status.Code != cs3rpc.Code_CODE_OK:
+			return nil, nil, errorcode.New(errorcode.GeneralException, res.Status.Message)
+		}
+		return &storageprovider.Grantee{
+			Type: storageprovider.GranteeType_GRANTEE_TYPE_GROUP,
+			Id:   &storageprovider.Grantee_GroupId{GroupId: res.Group.Id},
+		}, &sharingIdentitySet{
+			Group: &libregraph.Identity{Id: libregraph.PtrString(res.Group.Id.OpaqueId), DisplayName: libregraph.PtrString(res.Group.DisplayName)},
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("invalid recipient type '%s', possible values are 'user' and 'group'", recipient.Type)
+	}
+}
+
+func shareToPermission(s *collaboration.Share) *permission {
+	p := &permission{
+		ID:    s.GetId().GetOpaqueId(),
+		Roles: []string{permissionsRole(s.GetPermissions().GetPermissions())},
+	}
+	switch {
+	case s.GetGrantee().GetUserId() != nil:
+		p.GrantedTo = &sharingIdentitySet{User: &libregraph.Identity{Id: libregraph.PtrString(s.GetGrantee().GetUserId().GetOpaqueId())}}
+	case s.GetGrantee().GetGroupId() != nil:
+		p.GrantedTo = &sharingIdentitySet{Group: &libregraph.Identity{Id: libregraph.PtrString(s.GetGrantee().GetGroupId().GetOpaqueId())}}
+	}
+	return p
+}
+
+func (g Graph) linkToPermission(l *link.PublicShare) *permission {
+	p := &permission{
+		ID: l.GetId().GetOpaqueId(),
+		Link: &sharingLink{
+			Type:        permissionsLinkType(l.GetPermissions().GetPermissions()),
+			WebURL:      strings.TrimRight(g.config.Spaces.WebDavBase, "/") + "/s/" + l.GetToken(),
+			DisplayName: l.GetDisplayName(),
+		},
+		HasPassword: l.GetPasswordProtected(),
+	}
+	if l.GetExpiration() != nil {
+		expiration := utils.TSToTime(l.GetExpiration()).UTC()
+		p.ExpirationDateTime = &expiration
+	}
+	return p
+}
+
+func validRole(role string) bool {
+	return role == roleViewer || role == roleEditor
+}
+
+// rolePermissions returns the resource permissions granted by a share role. Editors of a single
+// file can't create, move or delete anything.
+func rolePermissions(role string, isDir bool) *storageprovider.ResourcePermissions {
+	p := &storageprovider.ResourcePermissions{
+		AddGrant:             true,
+		GetPath:              true,
+		GetQuota:             true,
+		InitiateFileDownload: true,
+		ListContainer:        true,
+		ListFileVersions:     true,
+		ListRecycle:          true,
+		Stat:                 true,
+	}
+	if role != roleEditor {
+		return p
+	}
+	p.InitiateFileUpload = true
+	p.RestoreFileVersion = true
+	if isDir {
+		p.CreateContainer = true
+		p.Delete = true
+		p.Move = true
+		p.PurgeRecycle = true
+		p.RestoreRecycleItem = true
+	}
+	return p
+}
+
+// permissionsRole maps the resource permissions of a share to a role
+func permissionsRole(p *storageprovider.ResourcePermissions) string {
+	if p.GetInitiateFileUpload() {
+		return roleEditor
+	}
+	return roleViewer
+}
+
+// linkPermissions returns the resource permissions granted by a link type
+func linkPermissions(linkType string, isDir bool) (*storageprovider.ResourcePermissions, error) {
+	switch linkType {
+	case linkTypeView:
+		return &storageprovider.ResourcePermissions{
+			GetPath:              true,
+			GetQuota:             true,
+			InitiateFileDownload: true,
+			ListContainer:        true,
+			Stat:                 true,
+		}, nil
+	case linkTypeEdit:
+		p := &storageprovider.ResourcePermissions{
+			GetPath:              true,
+			GetQuota:             true,
+			InitiateFileDownload: true,
+			InitiateFileUpload:   true,
+			ListContainer:        true,
+			Stat:                 true,
+		}
+		if isDir {
+			p.CreateContainer = true
+			p.Delete = true
+			p.Move = true
+		}
+		return p, nil
+	case linkTypeUpload:
+		if !isDir {
+			return nil, errors.New("upload links can only be created for folders")
+		}
+		return &storageprovider.ResourcePermissions{
+			CreateContainer:    true,
+			InitiateFileUpload: true,
+			Stat:               true,
+		}, nil
+	default:
+		return nil, fmt.Errorf("invalid link type '%s', possible values are 'view', 'edit' and 'upload'", linkType)
+	}
+}
+
+// permissionsLinkType maps the resource permissions of a link to a link type
+func permissionsLinkType(p *storageprovider.ResourcePermissions) string {
+	switch {
+	case p.GetInitiateFileUpload() && !p.GetInitiateFileDownload():
+		return linkTypeUpload
+	case p.GetInitiateFileUpload():
+		return linkTypeEdit
+	default:
+		return linkTypeView
+	}
+}
@@ -0,0 +1,319 @@
+package svc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config/defaults"
+	service "github.com/owncloud/ocis/v2/services/graph/pkg/service/v0"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Sharing", func() {
+	var (
+		svc             service.Service
+		gatewayClient   *mocks.GatewayClient
+		eventsPublisher mocks.Publisher
+		ctx             context.Context
+		cfg             *config.Config
+		rctx            *chi.Context
+
+		folderID   = &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "folder"}
+		fileID     = &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}
+		currentUID = &userv1beta1.UserId{OpaqueId: "user"}
+	)
+
+	JustBeforeEach(func() {
+		cfg = defaults.FullDefaultConfig()
+		cfg.Identity.LDAP.CACert = "" // skip the startup checks, we don't use LDAP at all in this tests
+		cfg.TokenManager.JWTSecret = "loremipsum"
+		cfg.Commons = &shared.Commons{}
+		cfg.Spaces.WebDavBase = "https://localhost:9200/"
+
+		gatewayClient = &mocks.GatewayClient{}
+		eventsPublisher = mocks.Publisher{}
+		svc = service.NewService(
+			service.Config(cfg),
+			service.WithGatewayClient(gatewayClient),
+			service.EventsPublisher(&eventsPublisher),
+		)
+
+		rctx = chi.NewRouteContext()
+		rctx.URLParams.Add("driveID", "storage$space")
+		ctx = context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+		ctx = revactx.ContextSetUser(ctx, &userv1beta1.User{Id: currentUID})
+	})
+
+	stat := func(id *provider.ResourceId, t provider.ResourceType) {
+		gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&provider.StatResponse{
+			Status: status.NewOK(ctx),
+			Info:   &provider.ResourceInfo{Id: id, Type: t, Name: "item"},
+		}, nil)
+	}
+
+	listShares := func(shares []*collaboration.Share, links []*link.PublicShare) {
+		gatewayClient.On("ListShares", mock.Anything, mock.Anything).Return(&collaboration.ListSharesResponse{
+			Status: status.NewOK(ctx),
+			Shares: shares,
+		}, nil)
+		gatewayClient.On("ListPublicShares", mock.Anything, mock.Anything).Return(&link.ListPublicSharesResponse{
+			Status: status.NewOK(ctx),
+			Share:  links,
+		}, nil)
+	}
+
+	userShare := &collaboration.Share{
+		Id:         &collaboration.ShareId{OpaqueId: "share1"},
+		ResourceId: folderID,
+		Grantee: &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_USER,
+			Id:   &provider.Grantee_UserId{UserId: &userv1beta1.UserId{OpaqueId: "einstein"}},
+		},
+		Permissions: &collaboration.SharePermissions{Permissions: &provider.ResourcePermissions{Stat: true}},
+	}
+	publicLink := &link.PublicShare{
+		Id:          &link.PublicShareId{OpaqueId: "link1"},
+		Token:       "token1",
+		ResourceId:  folderID,
+		Permissions: &link.PublicSharePermissions{Permissions: &provider.ResourcePermissions{Stat: true, InitiateFileDownload: true}},
+	}
+
+	Describe("Invite", func() {
+		It("shares the item with a user", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			stat(folderID, provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			gatewayClient.On("GetUser", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserResponse{
+				Status: status.NewOK(ctx),
+				User:   &userv1beta1.User{Id: &userv1beta1.UserId{Idp: "idp", OpaqueId: "einstein"}, DisplayName: "Albert Einstein"},
+			}, nil)
+			gatewayClient.On("CreateShare", mock.Anything, mock.MatchedBy(func(req *collaboration.CreateShareRequest) bool {
+				return req.Grant.Grantee.GetUserId().GetIdp() == "idp" &&
+					req.Grant.Permissions.Permissions.InitiateFileUpload &&
+					req.Grant.Permissions.Permissions.CreateContainer
+			})).Return(&collaboration.CreateShareResponse{
+				Status: status.NewOK(ctx),
+				Share: &collaboration.Share{
+					Id:          &collaboration.ShareId{OpaqueId: "share1"},
+					ResourceId:  folderID,
+					Grantee:     &provider.Grantee{Id: &provider.Grantee_UserId{UserId: &userv1beta1.UserId{OpaqueId: "einstein"}}},
+					Permissions: &collaboration.SharePermissions{Permissions: &provider.ResourcePermissions{InitiateFileUpload: true}},
+				},
+			}, nil)
+			eventsPublisher.On("Publish", mock.Anything, mock.MatchedBy(func(ev events.ShareCreated) bool {
+				return ev.GranteeUserID.GetOpaqueId() == "einstein" && ev.Executant.GetOpaqueId() == "user"
+			}), mock.Anything).Return(nil)
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"recipients": []map[string]string{{"objectId": "einstein"}},
+				"roles":      []string{"editor"},
+			})
+			rr := httptest.NewRecorder()
+			svc.Invite(rr, httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!folder/invite", bytes.NewBuffer(body)).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			res := struct {
+				Value []map[string]interface{}
+			}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(len(res.Value)).To(Equal(1))
+			Expect(res.Value[0]["id"]).To(Equal("share1"))
+			Expect(res.Value[0]["roles"]).To(ConsistOf("editor"))
+			eventsPublisher.AssertNumberOfCalls(GinkgoT(), "Publish", 1)
+		})
+
+		It("rejects unknown recipients", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			stat(folderID, provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			gatewayClient.On("GetUser", mock.Anything, mock.Anything).Return(&userv1beta1.GetUserResponse{
+				Status: status.NewNotFound(ctx, "not found"),
+			}, nil)
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"recipients": []map[string]string{{"objectId": "unknown"}},
+				"roles":      []string{"viewer"},
+			})
+			rr := httptest.NewRecorder()
+			svc.Invite(rr, httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!folder/invite", bytes.NewBuffer(body)).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			gatewayClient.AssertNotCalled(GinkgoT(), "CreateShare", mock.Anything, mock.Anything)
+		})
+
+		It("rejects unknown roles", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			body, _ := json.Marshal(map[string]interface{}{
+				"recipients": []map[string]string{{"objectId": "einstein"}},
+				"roles":      []string{"owner"},
+			})
+			rr := httptest.NewRecorder()
+			svc.Invite(rr, httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!folder/invite", bytes.NewBuffer(body)).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("CreateLink", func() {
+		It("creates a password protected link", func() {
+			rctx.URLParams.Add("itemID", "storage$space!file")
+			stat(fileID, provider.ResourceType_RESOURCE_TYPE_FILE)
+			expiration := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+			gatewayClient.On("CreatePublicShare", mock.Anything, mock.MatchedBy(func(req *link.CreatePublicShareRequest) bool {
+				return req.Grant.Password == "secret" &&
+					req.Grant.Expiration.GetSeconds() == uint64(expiration.Unix()) &&
+					!req.Grant.Permissions.Permissions.InitiateFileUpload
+			})).Return(&link.CreatePublicShareResponse{
+				Status: status.NewOK(ctx),
+				Share: &link.PublicShare{
+					Id:                &link.PublicShareId{OpaqueId: "link1"},
+					Token:             "token1",
+					ResourceId:        fileID,
+					PasswordProtected: true,
+					Permissions:       &link.PublicSharePermissions{Permissions: &provider.ResourcePermissions{Stat: true, InitiateFileDownload: true}},
+				},
+			}, nil)
+			eventsPublisher.On("Publish", mock.Anything, mock.MatchedBy(func(ev events.LinkCreated) bool {
+				return ev.Token == "token1" && ev.PasswordProtected
+			}), mock.Anything).Return(nil)
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"type":               "view",
+				"password":           "secret",
+				"expirationDateTime": expiration,
+			})
+			rr := httptest.NewRecorder()
+			svc.CreateLink(rr, httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!file/createLink", bytes.NewBuffer(body)).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusCreated))
+			res := map[string]interface{}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(res["id"]).To(Equal("link1"))
+			Expect(res["hasPassword"]).To(BeTrue())
+			Expect(res["link"]).To(HaveKeyWithValue("type", "view"))
+			Expect(res["link"]).To(HaveKeyWithValue("webUrl", "https://localhost:9200/s/token1"))
+			eventsPublisher.AssertNumberOfCalls(GinkgoT(), "Publish", 1)
+		})
+
+		It("does not create upload links for files", func() {
+			rctx.URLParams.Add("itemID", "storage$space!file")
+			stat(fileID, provider.ResourceType_RESOURCE_TYPE_FILE)
+
+			body, _ := json.Marshal(map[string]interface{}{"type": "upload"})
+			rr := httptest.NewRecorder()
+			svc.CreateLink(rr, httptest.NewRequest(http.MethodPost, "/graph/v1.0/drives/storage$space/items/storage$space!file/createLink", bytes.NewBuffer(body)).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			gatewayClient.AssertNotCalled(GinkgoT(), "CreatePublicShare", mock.Anything, mock.Anything)
+		})
+	})
+
+	Describe("Permissions", func() {
+		It("lists the shares and links of an item", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			stat(folderID, provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			listShares([]*collaboration.Share{userShare}, []*link.PublicShare{publicLink})
+
+			rr := httptest.NewRecorder()
+			svc.ListPermissions(rr, httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/items/storage$space!folder/permissions", nil).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			res := struct {
+				Value []map[string]interface{}
+			}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(len(res.Value)).To(Equal(2))
+			Expect(res.Value[0]["id"]).To(Equal("share1"))
+			Expect(res.Value[0]["roles"]).To(ConsistOf("viewer"))
+			Expect(res.Value[1]["id"]).To(Equal("link1"))
+		})
+
+		It("returns 404 for unknown permissions", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			rctx.URLParams.Add("permissionID", "unknown")
+			stat(folderID, provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			listShares([]*collaboration.Share{userShare}, []*link.PublicShare{publicLink})
+
+			rr := httptest.NewRecorder()
+			svc.GetPermission(rr, httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/items/storage$space!folder/permissions/unknown", nil).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("changes the role of a share", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			rctx.URLParams.Add("permissionID", "share1")
+			stat(folderID, provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			listShares([]*collaboration.Share{userShare}, nil)
+			gatewayClient.On("UpdateShare", mock.Anything, mock.MatchedBy(func(req *collaboration.UpdateShareRequest) bool {
+				return req.Ref.GetId().GetOpaqueId() == "share1" && req.Field.GetPermissions().GetPermissions().GetInitiateFileUpload()
+			})).Return(&collaboration.UpdateShareResponse{
+				Status: status.NewOK(ctx),
+				Share: &collaboration.Share{
+					Id:          &collaboration.ShareId{OpaqueId: "share1"},
+					Permissions: &collaboration.SharePermissions{Permissions: &provider.ResourcePermissions{InitiateFileUpload: true}},
+				},
+			}, nil)
+
+			body, _ := json.Marshal(map[string]interface{}{"roles": []string{"editor"}})
+			rr := httptest.NewRecorder()
+			svc.UpdatePermission(rr, httptest.NewRequest(http.MethodPatch, "/graph/v1.0/drives/storage$space/items/storage$space!folder/permissions/share1", bytes.NewBuffer(body)).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			res := map[string]interface{}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(res["roles"]).To(ConsistOf("editor"))
+		})
+
+		It("changes the expiration of a link", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			rctx.URLParams.Add("permissionID", "link1")
+			stat(folderID, provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			listShares(nil, []*link.PublicShare{publicLink})
+			gatewayClient.On("UpdatePublicShare", mock.Anything, mock.MatchedBy(func(req *link.UpdatePublicShareRequest) bool {
+				return req.Update.Type == link.UpdatePublicShareRequest_Update_TYPE_EXPIRATION
+			})).Return(&link.UpdatePublicShareResponse{
+				Status: status.NewOK(ctx),
+				Share:  publicLink,
+			}, nil)
+
+			body, _ := json.Marshal(map[string]interface{}{"expirationDateTime": time.Now().Add(time.Hour)})
+			rr := httptest.NewRecorder()
+			svc.UpdatePermission(rr, httptest.NewRequest(http.MethodPatch, "/graph/v1.0/drives/storage$space/items/storage$space!folder/permissions/link1", bytes.NewBuffer(body)).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			gatewayClient.AssertNumberOfCalls(GinkgoT(), "UpdatePublicShare", 1)
+		})
+
+		It("removes a link", func() {
+			rctx.URLParams.Add("itemID", "storage$space!folder")
+			rctx.URLParams.Add("permissionID", "link1")
+			stat(folderID, provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+			listShares([]*collaboration.Share{userShare}, []*link.PublicShare{publicLink})
+			gatewayClient.On("RemovePublicShare", mock.Anything, mock.MatchedBy(func(req *link.RemovePublicShareRequest) bool {
+				return req.Ref.GetId().GetOpaqueId() == "link1"
+			})).Return(&link.RemovePublicShareResponse{Status: status.NewOK(ctx)}, nil)
+
+			rr := httptest.NewRecorder()
+			svc.DeletePermission(rr, httptest.NewRequest(http.MethodDelete, "/graph/v1.0/drives/storage$space/items/storage$space!folder/permissions/link1", nil).WithContext(ctx))
+
+			Expect(rr.Code).To(Equal(http.StatusNoContent))
+			gatewayClient.AssertNotCalled(GinkgoT(), "RemoveShare", mock.Anything, mock.Anything)
+		})
+	})
+})
@@ -0,0 +1,159 @@
+package svc
+
+import (
+	"encoding/json"
+	"net/http"
+
+	cs3rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/go-chi/render"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
+	"github.com/owncloud/ocis/v2/ocis-pkg/tags"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+)
+
+// driveItemTags is the request and response body of the tag operations
+type driveItemTags struct {
+	Tags []string `json:"tags"`
+}
+
+// GetDriveItemTags implements the Service interface. It lists the tags of a drive item.
+func (g Graph) GetDriveItemTags(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling get drive item tags")
+
+	info, ok := g.statDriveItem(w, r)
+	if !ok {
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, driveItemTags{Tags: resourceTags(info)})
+}
+
+// TagDriveItem implements the Service interface. It adds tags to a drive item.
+func (g Graph) TagDriveItem(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling tag drive item")
+
+	req, ok := decodeTagsRequest(w, r)
+	if !ok {
+		return
+	}
+	info, ok := g.statDriveItem(w, r)
+	if !ok {
+		return
+	}
+
+	current, changed := tags.Add(resourceTags(info), req.Tags)
+	if changed {
+		res, err := g.GetGatewayClient().SetArbitraryMetadata(r.Context(), &storageprovider.SetArbitraryMetadataRequest{
+			Ref: &storageprovider.Reference{ResourceId: info.Id},
+			ArbitraryMetadata: &storageprovider.ArbitraryMetadata{
+				Metadata: map[string]string{tags.MetadataKey: tags.Format(current)},
+			},
+		})
+		switch {
+		case err != nil:
+			logger.Error().Err(err).Msg("error sending set arbitrary metadata grpc request")
+			errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		case res.Status.Code != cs3rpc.Code_CODE_OK:
+			renderDriveItemStatus(w, r, res.Status)
+			return
+		}
+
+		currentUser, _ := revactx.ContextGetUser(r.Context())
+		g.publishEvent(ocisevents.TagsAdded{
+			SpaceOwner: info.GetOwner(),
+			Executant:  currentUser.GetId(),
+			Ref:        &storageprovider.Reference{ResourceId: info.Id},
+			Tags:       req.Tags,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, driveItemTags{Tags: current})
+}
+
+// UntagDriveItem implements the Service interface. It removes tags from a drive item.
+func (g Graph) UntagDriveItem(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling untag drive item")
+
+	req, ok := decodeTagsRequest(w, r)
+	if !ok {
+		return
+	}
+	info, ok := g.statDriveItem(w, r)
+	if !ok {
+		return
+	}
+
+	current, changed := tags.Remove(resourceTags(info), req.Tags)
+	if changed {
+		ref := &storageprovider.Reference{ResourceId: info.Id}
+		var status *cs3rpc.Status
+		var err error
+		if len(current) == 0 {
+			var res *storageprovider.UnsetArbitraryMetadataResponse
+			res, err = g.GetGatewayClient().UnsetArbitraryMetadata(r.Context(), &storageprovider.UnsetArbitraryMetadataRequest{
+				Ref:                   ref,
+				ArbitraryMetadataKeys: []string{tags.MetadataKey},
+			})
+			status = res.GetStatus()
+		} else {
+			var res *storageprovider.SetArbitraryMetadataResponse
+			res, err = g.GetGatewayClient().SetArbitraryMetadata(r.Context(), &storageprovider.SetArbitraryMetadataRequest{
+				Ref: ref,
+				ArbitraryMetadata: &storageprovider.ArbitraryMetadata{
+					Metadata: map[string]string{tags.MetadataKey: tags.Format(current)},
+				},
+			})
+			status = res.GetStatus()
+		}
+		switch {
+		case err != nil:
+			logger.Error().Err(err).Msg("error sending arbitrary metadata grpc request")
+			errorcode.ServiceNotAvailable.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		case status.GetCode() != cs3rpc.Code_CODE_OK:
+			renderDriveItemStatus(w, r, status)
+			return
+		}
+
+		currentUser, _ := revactx.ContextGetUser(r.Context())
+		g.publishEvent(ocisevents.TagsRemoved{
+			SpaceOwner: info.GetOwner(),
+			Executant:  currentUser.GetId(),
+			Ref:        ref,
+			Tags:       req.Tags,
+		})
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, driveItemTags{Tags: current})
+}
+
+func decodeTagsRequest(w http.ResponseWriter, r *http.Request) (driveItemTags, bool) {
+	req := driveItemTags{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid request body")
+		return req, false
+	}
+	if err := tags.Validate(req.Tags); err != nil {
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	return req, true
+}
+
+// resourceTags returns the tags stored in the arbitrary metadata of the resource
+func resourceTags(info *storageprovider.ResourceInfo) []string {
+	t := tags.Parse(info.GetArbitraryMetadata().GetMetadata()[tags.MetadataKey])
+	if t == nil {
+		return []string{}
+	}
+	return t
+}
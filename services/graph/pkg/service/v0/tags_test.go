@@ -0,0 +1,179 @@
+package svc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config/defaults"
+	service "github.com/owncloud/ocis/v2/services/graph/pkg/service/v0"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Tags", func() {
+	var (
+		svc             service.Service
+		gatewayClient   *mocks.GatewayClient
+		eventsPublisher mocks.Publisher
+		ctx             context.Context
+		cfg             *config.Config
+
+		fileID     = &provider.ResourceId{StorageId: "storage", SpaceId: "space", OpaqueId: "file"}
+		currentUID = &userv1beta1.UserId{OpaqueId: "user"}
+	)
+
+	JustBeforeEach(func() {
+		cfg = defaults.FullDefaultConfig()
+		cfg.Identity.LDAP.CACert = "" // skip the startup checks, we don't use LDAP at all in this tests
+		cfg.TokenManager.JWTSecret = "loremipsum"
+		cfg.Commons = &shared.Commons{}
+
+		gatewayClient = &mocks.GatewayClient{}
+		eventsPublisher = mocks.Publisher{}
+		svc = service.NewService(
+			service.Config(cfg),
+			service.WithGatewayClient(gatewayClient),
+			service.EventsPublisher(&eventsPublisher),
+		)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("driveID", "storage$space")
+		rctx.URLParams.Add("itemID", "storage$space!file")
+		ctx = context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+		ctx = revactx.ContextSetUser(ctx, &userv1beta1.User{Id: currentUID})
+	})
+
+	stat := func(tags string) {
+		info := &provider.ResourceInfo{Id: fileID, Type: provider.ResourceType_RESOURCE_TYPE_FILE, Name: "file"}
+		if tags != "" {
+			info.ArbitraryMetadata = &provider.ArbitraryMetadata{Metadata: map[string]string{"tags": tags}}
+		}
+		gatewayClient.On("Stat", mock.Anything, mock.Anything).Return(&provider.StatResponse{
+			Status: status.NewOK(ctx),
+			Info:   info,
+		}, nil)
+	}
+
+	request := func(handler func(http.ResponseWriter, *http.Request), method, action string, tags ...string) (*httptest.ResponseRecorder, []string) {
+		var body []byte
+		if tags != nil {
+			body, _ = json.Marshal(map[string]interface{}{"tags": tags})
+		}
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(method, "/graph/v1.0/drives/storage$space/items/storage$space!file/"+action, bytes.NewBuffer(body)).WithContext(ctx))
+
+		res := struct {
+			Tags []string
+		}{}
+		if rr.Code == http.StatusOK {
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+		}
+		return rr, res.Tags
+	}
+
+	Describe("GetDriveItemTags", func() {
+		It("lists the tags of the item", func() {
+			stat("invoice,2026")
+
+			rr, tags := request(svc.GetDriveItemTags, http.MethodGet, "tags")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(tags).To(Equal([]string{"invoice", "2026"}))
+		})
+
+		It("returns an empty list for items without tags", func() {
+			stat("")
+
+			rr, tags := request(svc.GetDriveItemTags, http.MethodGet, "tags")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(tags).To(BeEmpty())
+		})
+	})
+
+	Describe("TagDriveItem", func() {
+		It("adds the tags to the metadata", func() {
+			stat("invoice")
+			gatewayClient.On("SetArbitraryMetadata", mock.Anything, mock.MatchedBy(func(req *provider.SetArbitraryMetadataRequest) bool {
+				return req.ArbitraryMetadata.Metadata["tags"] == "invoice,2026"
+			})).Return(&provider.SetArbitraryMetadataResponse{Status: status.NewOK(ctx)}, nil)
+			eventsPublisher.On("Publish", mock.Anything, mock.MatchedBy(func(ev ocisevents.TagsAdded) bool {
+				return ev.Executant.GetOpaqueId() == "user" && ev.Ref.GetResourceId().GetOpaqueId() == "file"
+			}), mock.Anything).Return(nil)
+
+			rr, tags := request(svc.TagDriveItem, http.MethodPost, "tag", "Invoice", "2026")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(tags).To(Equal([]string{"invoice", "2026"}))
+			eventsPublisher.AssertNumberOfCalls(GinkgoT(), "Publish", 1)
+		})
+
+		It("does not change the metadata when all tags are present", func() {
+			stat("invoice")
+
+			rr, tags := request(svc.TagDriveItem, http.MethodPost, "tag", "invoice")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(tags).To(Equal([]string{"invoice"}))
+			gatewayClient.AssertNotCalled(GinkgoT(), "SetArbitraryMetadata", mock.Anything, mock.Anything)
+			eventsPublisher.AssertNotCalled(GinkgoT(), "Publish", mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		It("rejects invalid tags", func() {
+			stat("")
+
+			rr, _ := request(svc.TagDriveItem, http.MethodPost, "tag", "a,b")
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+
+			rr, _ = request(svc.TagDriveItem, http.MethodPost, "tag")
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("UntagDriveItem", func() {
+		It("removes the tags from the metadata", func() {
+			stat("invoice,2026,draft")
+			gatewayClient.On("SetArbitraryMetadata", mock.Anything, mock.MatchedBy(func(req *provider.SetArbitraryMetadataRequest) bool {
+				return req.ArbitraryMetadata.Metadata["tags"] == "invoice,2026"
+			})).Return(&provider.SetArbitraryMetadataResponse{Status: status.NewOK(ctx)}, nil)
+			eventsPublisher.On("Publish", mock.Anything, mock.AnythingOfType("events.TagsRemoved"), mock.Anything).Return(nil)
+
+			rr, tags := request(svc.UntagDriveItem, http.MethodPost, "untag", "draft")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(tags).To(Equal([]string{"invoice", "2026"}))
+			eventsPublisher.AssertNumberOfCalls(GinkgoT(), "Publish", 1)
+		})
+
+		It("unsets the metadata when the last tag is removed", func() {
+			stat("draft")
+			gatewayClient.On("UnsetArbitraryMetadata", mock.Anything, mock.MatchedBy(func(req *provider.UnsetArbitraryMetadataRequest) bool {
+				return len(req.ArbitraryMetadataKeys) == 1 && req.ArbitraryMetadataKeys[0] == "tags"
+			})).Return(&provider.UnsetArbitraryMetadataResponse{Status: status.NewOK(ctx)}, nil)
+			eventsPublisher.On("Publish", mock.Anything, mock.AnythingOfType("events.TagsRemoved"), mock.Anything).Return(nil)
+
+			rr, tags := request(svc.UntagDriveItem, http.MethodPost, "untag", "draft")
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(tags).To(BeEmpty())
+		})
+
+		It("renders errors of the storage", func() {
+			stat("draft")
+			gatewayClient.On("UnsetArbitraryMetadata", mock.Anything, mock.Anything).Return(&provider.UnsetArbitraryMetadataResponse{
+				Status: status.NewPermissionDenied(ctx, nil, "denied"),
+			}, nil)
+
+			rr, _ := request(svc.UntagDriveItem, http.MethodPost, "untag", "draft")
+			Expect(rr.Code).To(Equal(http.StatusForbidden))
+			eventsPublisher.AssertNotCalled(GinkgoT(), "Publish", mock.Anything, mock.Anything, mock.Anything)
+		})
+	})
+})
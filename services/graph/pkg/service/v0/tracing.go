@@ -55,6 +55,21 @@ func (t tracing) ChangeOwnPassword(w http.ResponseWriter, r *http.Request) {
 	t.next.ChangeOwnPassword(w, r)
 }
 
+// ListAppTokens implements the Service interface.
+func (t tracing) ListAppTokens(w http.ResponseWriter, r *http.Request) {
+	t.next.ListAppTokens(w, r)
+}
+
+// CreateAppToken implements the Service interface.
+func (t tracing) CreateAppToken(w http.ResponseWriter, r *http.Request) {
+	t.next.CreateAppToken(w, r)
+}
+
+// DeleteAppToken implements the Service interface.
+func (t tracing) DeleteAppToken(w http.ResponseWriter, r *http.Request) {
+	t.next.DeleteAppToken(w, r)
+}
+
 // GetGroups implements the Service interface.
 func (t tracing) GetGroups(w http.ResponseWriter, r *http.Request) {
 	t.next.GetGroups(w, r)
@@ -99,3 +114,88 @@ func (t tracing) DeleteGroupMember(w http.ResponseWriter, r *http.Request) {
 func (t tracing) GetDrives(w http.ResponseWriter, r *http.Request) {
 	t.next.GetDrives(w, r)
 }
+
+// GetDriveItem implements the Service interface.
+func (t tracing) GetDriveItem(w http.ResponseWriter, r *http.Request) {
+	t.next.GetDriveItem(w, r)
+}
+
+// GetDriveItemChildren implements the Service interface.
+func (t tracing) GetDriveItemChildren(w http.ResponseWriter, r *http.Request) {
+	t.next.GetDriveItemChildren(w, r)
+}
+
+// CreateDriveItem implements the Service interface.
+func (t tracing) CreateDriveItem(w http.ResponseWriter, r *http.Request) {
+	t.next.CreateDriveItem(w, r)
+}
+
+// UpdateDriveItem implements the Service interface.
+func (t tracing) UpdateDriveItem(w http.ResponseWriter, r *http.Request) {
+	t.next.UpdateDriveItem(w, r)
+}
+
+// CopyDriveItem implements the Service interface.
+func (t tracing) CopyDriveItem(w http.ResponseWriter, r *http.Request) {
+	t.next.CopyDriveItem(w, r)
+}
+
+// DeleteDriveItem implements the Service interface.
+func (t tracing) DeleteDriveItem(w http.ResponseWriter, r *http.Request) {
+	t.next.DeleteDriveItem(w, r)
+}
+
+// GetDriveItemTags implements the Service interface.
+func (t tracing) GetDriveItemTags(w http.ResponseWriter, r *http.Request) {
+	t.next.GetDriveItemTags(w, r)
+}
+
+// TagDriveItem implements the Service interface.
+func (t tracing) TagDriveItem(w http.ResponseWriter, r *http.Request) {
+	t.next.TagDriveItem(w, r)
+}
+
+// UntagDriveItem implements the Service interface.
+func (t tracing) UntagDriveItem(w http.ResponseWriter, r *http.Request) {
+	t.next.UntagDriveItem(w, r)
+}
+
+// SearchDriveTrash implements the Service interface.
+func (t tracing) SearchDriveTrash(w http.ResponseWriter, r *http.Request) {
+	t.next.SearchDriveTrash(w, r)
+}
+
+// Invite implements the Service interface.
+func (t tracing) Invite(w http.ResponseWriter, r *http.Request) {
+	t.next.Invite(w, r)
+}
+
+// CreateLink implements the Service interface.
+func (t tracing) CreateLink(w http.ResponseWriter, r *http.Request) {
+	t.next.CreateLink(w, r)
+}
+
+// ListPermissions implements the Service interface.
+func (t tracing) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	t.next.ListPermissions(w, r)
+}
+
+// GetPermission implements the Service interface.
+func (t tracing) GetPermission(w http.ResponseWriter, r *http.Request) {
+	t.next.GetPermission(w, r)
+}
+
+// UpdatePermission implements the Service interface.
+func (t tracing) UpdatePermission(w http.ResponseWriter, r *http.Request) {
+	t.next.UpdatePermission(w, r)
+}
+
+// DeletePermission implements the Service interface.
+func (t tracing) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	t.next.DeletePermission(w, r)
+}
+
+// Batch implements the Service interface.
+func (t tracing) Batch(w http.ResponseWriter, r *http.Request) {
+	t.next.Batch(w, r)
+}
@@ -0,0 +1,146 @@
+package svc
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CiscoM31/godata"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+	merrors "go-micro.dev/v4/errors"
+	"go-micro.dev/v4/metadata"
+)
+
+// defaultTrashSearchLimit is the number of trashed items returned when no $top is requested
+const defaultTrashSearchLimit = 200
+
+// trashItem is an item in the trash bin of a drive. It can be restored or purged with WebDAV
+// using the drive id and its trash key.
+type trashItem struct {
+	ID                   string     `json:"id"`
+	Name                 string     `json:"name"`
+	Size                 uint64     `json:"size"`
+	MimeType             string     `json:"mimeType,omitempty"`
+	OriginalLocation     string     `json:"originalLocation"`
+	TrashKey             string     `json:"trashKey"`
+	DeletedDateTime      *time.Time `json:"deletedDateTime,omitempty"`
+	LastModifiedDateTime *time.Time `json:"lastModifiedDateTime,omitempty"`
+}
+
+// SearchDriveTrash implements the Service interface. It searches the trash bin of a drive
+// with the query language of the search service, e.g. `?q=report deleted:"last month"`.
+func (g Graph) SearchDriveTrash(w http.ResponseWriter, r *http.Request) {
+	logger := g.logger.SubloggerWithRequestID(r.Context())
+	logger.Info().Msg("calling search drive trash")
+
+	driveID, err := url.PathUnescape(chi.URLParam(r, "driveID"))
+	if err != nil {
+		logger.Debug().Err(err).Str("driveID", chi.URLParam(r, "driveID")).Msg("could not search trash: unescaping drive id failed")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "unescaping drive id failed")
+		return
+	}
+	rid, err := storagespace.ParseID(driveID)
+	if err != nil {
+		logger.Debug().Err(err).Str("driveID", driveID).Msg("could not search trash: invalid drive id")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "invalid drive id")
+		return
+	}
+
+	// the query is not passed as $search, the query language is no valid OData search syntax
+	query := r.URL.Query()
+	q := query.Get("q")
+	if q == "" {
+		logger.Debug().Msg("could not search trash: missing query")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "missing search query")
+		return
+	}
+	query.Del("q")
+	odataReq, err := godata.ParseRequest(r.Context(), strings.TrimPrefix(r.URL.Path, "/graph/v1.0/"), query)
+	if err != nil {
+		logger.Debug().Err(err).Interface("query", r.URL.Query()).Msg("could not search trash: query error")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validatePaging(odataReq, g.config.API.MaxSkip); err != nil {
+		logger.Debug().Err(err).Interface("query", r.URL.Query()).Msg("could not search trash: invalid paging")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// fetch one more match than requested to know if there is a next page
+	limit := defaultTrashSearchLimit
+	if odataReq.Query.Top != nil {
+		limit = int(*odataReq.Query.Top) + 1
+	}
+	if odataReq.Query.Skip != nil {
+		limit += int(*odataReq.Query.Skip)
+	}
+
+	t, _ := revactx.ContextGetToken(r.Context())
+	ctx := metadata.Set(r.Context(), revactx.TokenHeader, t)
+	res, err := g.searchService.Search(ctx, &searchsvc.SearchRequest{
+		Query:    q,
+		PageSize: int32(limit),
+		Trash:    true,
+		Ref: &searchmsg.Reference{
+			ResourceId: &searchmsg.ResourceID{
+				StorageId: rid.StorageId,
+				SpaceId:   rid.SpaceId,
+				OpaqueId:  rid.SpaceId,
+			},
+		},
+	})
+	if err != nil {
+		e := merrors.Parse(err.Error())
+		if e.Code == http.StatusBadRequest {
+			logger.Debug().Err(err).Msg("could not search trash: invalid query")
+			errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, e.Detail)
+			return
+		}
+		logger.Error().Err(err).Msg("could not search trash: search error")
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	items := make([]trashItem, 0, len(res.Matches))
+	for _, match := range res.Matches {
+		items = append(items, matchToTrashItem(match))
+	}
+	page, next := paginate(odataReq, items)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, g.newListResponse(r, odataReq, page, int(res.TotalMatches), next))
+}
+
+func matchToTrashItem(match *searchmsg.Match) trashItem {
+	e := match.GetEntity()
+	item := trashItem{
+		ID: storagespace.FormatResourceID(provider.ResourceId{
+			StorageId: e.GetId().GetStorageId(),
+			SpaceId:   e.GetId().GetSpaceId(),
+			OpaqueId:  e.GetId().GetOpaqueId(),
+		}),
+		Name:             e.GetName(),
+		Size:             e.GetSize(),
+		MimeType:         e.GetMimeType(),
+		OriginalLocation: strings.TrimPrefix(e.GetRef().GetPath(), "./"),
+		TrashKey:         e.GetTrashKey(),
+	}
+	if e.GetDeletionTime() != nil {
+		deleted := e.GetDeletionTime().AsTime()
+		item.DeletedDateTime = &deleted
+	}
+	if e.GetLastModifiedTime() != nil {
+		modified := e.GetLastModifiedTime().AsTime()
+		item.LastModifiedDateTime = &modified
+	}
+	return item
+}
@@ -0,0 +1,140 @@
+package svc_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
+	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config/defaults"
+	service "github.com/owncloud/ocis/v2/services/graph/pkg/service/v0"
+	"github.com/stretchr/testify/mock"
+	merrors "go-micro.dev/v4/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("Trash", func() {
+	var (
+		svc           service.Service
+		gatewayClient *mocks.GatewayClient
+		searchService *mocks.SearchProviderService
+		ctx           context.Context
+		cfg           *config.Config
+
+		deletionTime = time.Date(2026, time.September, 14, 10, 0, 0, 0, time.UTC)
+	)
+
+	JustBeforeEach(func() {
+		cfg = defaults.FullDefaultConfig()
+		cfg.Identity.LDAP.CACert = "" // skip the startup checks, we don't use LDAP at all in this tests
+		cfg.TokenManager.JWTSecret = "loremipsum"
+		cfg.Commons = &shared.Commons{}
+
+		gatewayClient = &mocks.GatewayClient{}
+		searchService = &mocks.SearchProviderService{}
+		svc = service.NewService(
+			service.Config(cfg),
+			service.WithGatewayClient(gatewayClient),
+			service.SearchService(searchService),
+		)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("driveID", "storage$space")
+		ctx = context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+		ctx = revactx.ContextSetUser(ctx, &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "user"}})
+	})
+
+	match := func(name, key string) *searchmsg.Match {
+		return &searchmsg.Match{
+			Entity: &searchmsg.Entity{
+				Ref:          &searchmsg.Reference{ResourceId: &searchmsg.ResourceID{StorageId: "storage", SpaceId: "space", OpaqueId: "space"}, Path: "./reports/" + name},
+				Id:           &searchmsg.ResourceID{StorageId: "storage", SpaceId: "space", OpaqueId: name + "-id"},
+				Name:         name,
+				Size:         42,
+				Deleted:      true,
+				TrashKey:     key,
+				DeletionTime: timestamppb.New(deletionTime),
+			},
+		}
+	}
+
+	request := func(query url.Values) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		svc.SearchDriveTrash(rr, httptest.NewRequest(http.MethodGet, "/graph/v1.0/drives/storage$space/trash/search?"+query.Encode(), nil).WithContext(ctx))
+		return rr
+	}
+
+	Describe("SearchDriveTrash", func() {
+		It("searches the trash bin of the drive", func() {
+			searchService.On("Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchRequest) bool {
+				return req.Trash && req.Query == "report" &&
+					req.Ref.GetResourceId().GetStorageId() == "storage" && req.Ref.GetResourceId().GetSpaceId() == "space"
+			})).Return(&searchsvc.SearchResponse{
+				Matches:      []*searchmsg.Match{match("report.pdf", "reports-id/report.pdf")},
+				TotalMatches: 1,
+			}, nil)
+
+			rr := request(url.Values{"q": {"report"}})
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			res := struct {
+				Value []map[string]interface{}
+			}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(len(res.Value)).To(Equal(1))
+			Expect(res.Value[0]["id"]).To(Equal("storage$space!report.pdf-id"))
+			Expect(res.Value[0]["name"]).To(Equal("report.pdf"))
+			Expect(res.Value[0]["originalLocation"]).To(Equal("reports/report.pdf"))
+			Expect(res.Value[0]["trashKey"]).To(Equal("reports-id/report.pdf"))
+			Expect(res.Value[0]["deletedDateTime"]).To(Equal("2026-09-14T10:00:00Z"))
+		})
+
+		It("pages the matches", func() {
+			searchService.On("Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchRequest) bool {
+				return req.PageSize == 3
+			})).Return(&searchsvc.SearchResponse{
+				Matches:      []*searchmsg.Match{match("a.pdf", "a"), match("b.pdf", "b"), match("c.pdf", "c")},
+				TotalMatches: 5,
+			}, nil)
+
+			rr := request(url.Values{"q": {"*.pdf"}, "$top": {"1"}, "$skip": {"1"}, "$count": {"true"}})
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			res := struct {
+				Count    int    `json:"@odata.count"`
+				NextLink string `json:"@odata.nextLink"`
+				Value    []map[string]interface{}
+			}{}
+			Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+			Expect(res.Count).To(Equal(5))
+			Expect(res.NextLink).To(ContainSubstring("%24skip=2"))
+			Expect(len(res.Value)).To(Equal(1))
+			Expect(res.Value[0]["name"]).To(Equal("b.pdf"))
+		})
+
+		It("requires a query", func() {
+			rr := request(url.Values{})
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			searchService.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+		})
+
+		It("rejects invalid queries", func() {
+			searchService.On("Search", mock.Anything, mock.Anything).Return(nil, merrors.BadRequest("com.owncloud.api.search", "invalid query: missing closing quote"))
+
+			rr := request(url.Values{"q": {`"report`}})
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
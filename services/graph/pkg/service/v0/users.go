@@ -1,16 +1,20 @@
 package svc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/CiscoM31/godata"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	cs3rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	ctxpkg "github.com/cs3org/reva/v2/pkg/ctx"
@@ -26,6 +30,7 @@ import (
 	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
 	settingssvc "github.com/owncloud/ocis/v2/services/settings/pkg/service/v0"
 	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/metadata"
 )
 
 // GetMe implements the Service interface.
@@ -59,8 +64,49 @@ func (g Graph) GetMe(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	res, err := g.withAccountStatus(r.Context(), me)
+	if err != nil {
+		logger.Debug().Err(err).Interface("user", u).Msg("could not get account status from backend")
+		var errcode errorcode.Error
+		if errors.As(err, &errcode) {
+			errcode.Render(w, r)
+		} else {
+			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, me)
+	render.JSON(w, r, res)
+}
+
+// userWithAccountStatus adds the accountEnabled property, which the libregraph user model
+// lacks, to the JSON representation of a user
+type userWithAccountStatus struct {
+	*libregraph.User
+	AccountEnabled bool
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (u userWithAccountStatus) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(u.User)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	m["accountEnabled"] = u.AccountEnabled
+	return json.Marshal(m)
+}
+
+// withAccountStatus looks up whether the account of the user is enabled
+func (g Graph) withAccountStatus(ctx context.Context, u *libregraph.User) (userWithAccountStatus, error) {
+	enabled, err := g.identityBackend.GetAccountEnabled(ctx, u.GetId())
+	if err != nil {
+		return userWithAccountStatus{}, err
+	}
+	return userWithAccountStatus{User: u, AccountEnabled: enabled}, nil
 }
 
 // GetUsers implements the Service interface.
@@ -74,6 +120,11 @@ func (g Graph) GetUsers(w http.ResponseWriter, r *http.Request) {
 		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	if err := validatePaging(odataReq, g.config.API.MaxSkip); err != nil {
+		logger.Debug().Err(err).Interface("query", r.URL.Query()).Msg("could not get users: invalid paging")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	logger.Debug().Interface("query", r.URL.Query()).Msg("calling get users on backend")
 	users, err := g.identityBackend.GetUsers(r.Context(), r.URL.Query())
@@ -94,8 +145,9 @@ func (g Graph) GetUsers(w http.ResponseWriter, r *http.Request) {
 		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	page, next := paginate(odataReq, users)
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, &listResponse{Value: users})
+	render.JSON(w, r, g.newListResponse(r, odataReq, page, len(users), next))
 }
 
 func (g Graph) PostUser(w http.ResponseWriter, r *http.Request) {
@@ -215,6 +267,17 @@ func (g Graph) GetUser(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	res, err := g.withAccountStatus(r.Context(), user)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not get user: error fetching account status from backend")
+		var errcode errorcode.Error
+		if errors.As(err, &errcode) {
+			errcode.Render(w, r)
+		} else {
+			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
 	sel := strings.Split(r.URL.Query().Get("$select"), ",")
 	exp := strings.Split(r.URL.Query().Get("$expand"), ",")
 	if slices.Contains(sel, "drive") || slices.Contains(sel, "drives") || slices.Contains(exp, "drive") || slices.Contains(exp, "drives") {
@@ -242,14 +305,14 @@ func (g Graph) GetUser(w http.ResponseWriter, r *http.Request) {
 			// transport error, needs to be fixed by admin
 			logger.Error().Err(err).Interface("query", r.URL.Query()).Msg("error getting storages: transport error")
 			render.Status(r, http.StatusInternalServerError)
-			render.JSON(w, r, user)
+			render.JSON(w, r, res)
 			return
 		}
 		if lspr.GetStatus().GetCode() != cs3rpc.Code_CODE_OK {
 			logger.Debug().Str("grpc", lspr.GetStatus().GetMessage()).Msg("could not get drive for user")
 			// in case of NOT_OK, we can just return the user object with empty drives
 			render.Status(r, status.HTTPStatusFromCode(http.StatusOK))
-			render.JSON(w, r, user)
+			render.JSON(w, r, res)
 			return
 		}
 		drives := []libregraph.Drive{}
@@ -275,7 +338,7 @@ func (g Graph) GetUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, user)
+	render.JSON(w, r, res)
 }
 
 func (g Graph) DeleteUser(w http.ResponseWriter, r *http.Request) {
@@ -315,23 +378,51 @@ func (g Graph) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := g.deletePersonalSpace(r.Context(), user.GetId()); err != nil {
+		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logger.Debug().Str("id", user.GetId()).Msg("calling delete user on backend")
+	err = g.identityBackend.DeleteUser(r.Context(), user.GetId())
+
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not delete user: backend error")
+		var errcode errorcode.Error
+		if errors.As(err, &errcode) {
+			errcode.Render(w, r)
+		} else {
+			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	g.publishEvent(events.UserDeleted{Executant: currentUser.Id, UserID: user.GetId()})
+
+	render.Status(r, http.StatusNoContent)
+	render.NoContent(w, r)
+}
+
+// deletePersonalSpace disables and purges the personal space of a user. It is called before
+// users are deleted through the graph api and the SCIM endpoint.
+func (g Graph) deletePersonalSpace(ctx context.Context, userID string) error {
+	logger := g.logger.SubloggerWithRequestID(ctx)
 	logger.Debug().
-		Str("user", user.GetId()).
+		Str("user", userID).
 		Msg("calling list spaces with user filter to fetch the personal space for deletion")
 	opaque := utils.AppendPlainToOpaque(nil, "unrestricted", "T")
-	f := listStorageSpacesUserFilter(user.GetId())
-	lspr, err := g.gatewayClient.ListStorageSpaces(r.Context(), &storageprovider.ListStorageSpacesRequest{
+	f := listStorageSpacesUserFilter(userID)
+	lspr, err := g.gatewayClient.ListStorageSpaces(ctx, &storageprovider.ListStorageSpacesRequest{
 		Opaque:  opaque,
 		Filters: []*storageprovider.ListStorageSpacesRequest_Filter{f},
 	})
 	if err != nil {
 		// transport error, log as error
 		logger.Error().Err(err).Msg("could not fetch spaces: transport error")
-		errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, "could not fetch spaces for deletion, aborting")
-		return
+		return errors.New("could not fetch spaces for deletion, aborting")
 	}
 	for _, sp := range lspr.GetStorageSpaces() {
-		if !(sp.SpaceType == "personal" && sp.Owner.Id.OpaqueId == user.GetId()) {
+		if !(sp.SpaceType == "personal" && sp.Owner.Id.OpaqueId == userID) {
 			continue
 		}
 		// TODO: check if request contains a homespace and if, check if requesting user has the privilege to
@@ -341,19 +432,18 @@ func (g Graph) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		// Deleting a space a two step process (1. disabling/trashing, 2. purging)
 		// Do the "disable/trash" step only if the space is not marked as trashed yet:
 		if _, ok := sp.Opaque.Map["trashed"]; !ok {
-			_, err := g.gatewayClient.DeleteStorageSpace(r.Context(), &storageprovider.DeleteStorageSpaceRequest{
+			_, err := g.gatewayClient.DeleteStorageSpace(ctx, &storageprovider.DeleteStorageSpaceRequest{
 				Id: &storageprovider.StorageSpaceId{
 					OpaqueId: sp.Id.OpaqueId,
 				},
 			})
 			if err != nil {
 				logger.Error().Err(err).Msg("could not disable homespace: transport error")
-				errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, "could not disable homespace, aborting")
-				return
+				return errors.New("could not disable homespace, aborting")
 			}
 		}
 		purgeFlag := utils.AppendPlainToOpaque(nil, "purge", "")
-		_, err := g.gatewayClient.DeleteStorageSpace(r.Context(), &storageprovider.DeleteStorageSpaceRequest{
+		_, err := g.gatewayClient.DeleteStorageSpace(ctx, &storageprovider.DeleteStorageSpaceRequest{
 			Opaque: purgeFlag,
 			Id: &storageprovider.StorageSpaceId{
 				OpaqueId: sp.Id.OpaqueId,
@@ -362,30 +452,28 @@ func (g Graph) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			// transport error, log as error
 			logger.Error().Err(err).Msg("could not delete homespace: transport error")
-			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, "could not delete homespace, aborting")
-			return
+			return errors.New("could not delete homespace, aborting")
 		}
 		break
 	}
+	return nil
+}
 
-	logger.Debug().Str("id", user.GetId()).Msg("calling delete user on backend")
-	err = g.identityBackend.DeleteUser(r.Context(), user.GetId())
-
+// deletePersonalSpaceAsAdmin deletes the personal space of a user on behalf of the admin user
+// configured for the SCIM endpoint, whose requests aren't made by an oCIS user
+func (g Graph) deletePersonalSpaceAsAdmin(ctx context.Context, userID string) error {
+	res, err := g.gatewayClient.Authenticate(ctx, &gateway.AuthenticateRequest{
+		Type:         "machine",
+		ClientId:     "userid:" + g.config.SCIM.AdminUserID,
+		ClientSecret: g.config.MachineAuthAPIKey,
+	})
 	if err != nil {
-		logger.Debug().Err(err).Msg("could not delete user: backend error")
-		var errcode errorcode.Error
-		if errors.As(err, &errcode) {
-			errcode.Render(w, r)
-		} else {
-			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
-			return
-		}
+		return err
 	}
-
-	g.publishEvent(events.UserDeleted{Executant: currentUser.Id, UserID: user.GetId()})
-
-	render.Status(r, http.StatusNoContent)
-	render.NoContent(w, r)
+	if res.GetStatus().GetCode() != cs3rpc.Code_CODE_OK {
+		return fmt.Errorf("could not authenticate as the admin user: %s", res.GetStatus().GetMessage())
+	}
+	return g.deletePersonalSpace(metadata.AppendToOutgoingContext(ctx, revactx.TokenHeader, res.GetToken()), userID)
 }
 
 // PatchUser implements the Service Interface. Updates the specified attributes of an
@@ -406,10 +494,22 @@ func (g Graph) PatchUser(w http.ResponseWriter, r *http.Request) {
 		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "missing user id")
 		return
 	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.Debug().Err(err).Msg("could not update user: reading request body failed")
+		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest, "reading request body failed")
+		return
+	}
 	changes := libregraph.NewUser()
-	err = json.NewDecoder(r.Body).Decode(changes)
+	// the libregraph user model has no accountEnabled property, it is decoded separately
+	var status struct {
+		AccountEnabled *bool `json:"accountEnabled"`
+	}
+	if err = json.Unmarshal(body, changes); err == nil {
+		err = json.Unmarshal(body, &status)
+	}
 	if err != nil {
-		logger.Debug().Err(err).Interface("body", r.Body).Msg("could not update user: invalid request body")
+		logger.Debug().Err(err).Str("body", string(body)).Msg("could not update user: invalid request body")
 		errorcode.InvalidRequest.Render(w, r, http.StatusBadRequest,
 			fmt.Sprintf("invalid request body: %s", err.Error()))
 		return
@@ -430,10 +530,49 @@ func (g Graph) PatchUser(w http.ResponseWriter, r *http.Request) {
 		features = append(features, events.UserFeature{Name: "displayname", Value: *name})
 	}
 
+	// the account status is changed first, backends without support for it reject the
+	// request before the other changes are saved. It is rolled back if they can't be saved.
+	var previouslyEnabled bool
+	if status.AccountEnabled != nil {
+		previouslyEnabled, err = g.identityBackend.GetAccountEnabled(r.Context(), nameOrID)
+		if err == nil {
+			logger.Debug().Str("nameid", nameOrID).Bool("enabled", *status.AccountEnabled).Msg("calling set account enabled on backend")
+			err = g.identityBackend.SetAccountEnabled(r.Context(), nameOrID, *status.AccountEnabled)
+		}
+		if err != nil {
+			logger.Debug().Err(err).Str("id", nameOrID).Msg("could not update account status: backend error")
+			var errcode errorcode.Error
+			if errors.As(err, &errcode) {
+				errcode.Render(w, r)
+			} else {
+				errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+		features = append(features, events.UserFeature{Name: "accountEnabled", Value: strconv.FormatBool(*status.AccountEnabled)})
+	}
+
 	logger.Debug().Str("nameid", nameOrID).Interface("changes", *changes).Msg("calling update user on backend")
 	u, err := g.identityBackend.UpdateUser(r.Context(), nameOrID, *changes)
 	if err != nil {
 		logger.Debug().Err(err).Str("id", nameOrID).Msg("could not update user: backend error")
+		if status.AccountEnabled != nil && *status.AccountEnabled != previouslyEnabled {
+			if rerr := g.identityBackend.SetAccountEnabled(r.Context(), nameOrID, previouslyEnabled); rerr != nil {
+				logger.Error().Err(rerr).Str("id", nameOrID).Msg("could not roll back the account status")
+			}
+		}
+		var errcode errorcode.Error
+		if errors.As(err, &errcode) {
+			errcode.Render(w, r)
+		} else {
+			errorcode.GeneralException.Render(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	res, err := g.withAccountStatus(r.Context(), u)
+	if err != nil {
+		logger.Debug().Err(err).Str("id", nameOrID).Msg("could not get account status: backend error")
 		var errcode errorcode.Error
 		if errors.As(err, &errcode) {
 			errcode.Render(w, r)
@@ -452,7 +591,7 @@ func (g Graph) PatchUser(w http.ResponseWriter, r *http.Request) {
 		},
 	)
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, u)
+	render.JSON(w, r, res)
 
 }
 
@@ -0,0 +1,121 @@
+package svc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/events"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	libregraph "github.com/owncloud/libre-graph-api-go"
+	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
+	"github.com/owncloud/ocis/v2/services/graph/mocks"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/config/defaults"
+	service "github.com/owncloud/ocis/v2/services/graph/pkg/service/v0"
+	"github.com/owncloud/ocis/v2/services/graph/pkg/service/v0/errorcode"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ = Describe("Users", func() {
+	var (
+		svc             service.Service
+		identityBackend *mocks.IdentityBackend
+		eventsPublisher *mocks.Publisher
+		ctx             context.Context
+		cfg             *config.Config
+		user            *userv1beta1.User
+	)
+
+	JustBeforeEach(func() {
+		cfg = defaults.FullDefaultConfig()
+		cfg.Identity.LDAP.CACert = "" // skip the startup checks, we don't use LDAP at all in this tests
+		cfg.TokenManager.JWTSecret = "loremipsum"
+		cfg.Commons = &shared.Commons{}
+
+		identityBackend = &mocks.IdentityBackend{}
+		eventsPublisher = &mocks.Publisher{}
+		svc = service.NewService(
+			service.Config(cfg),
+			service.WithGatewayClient(&mocks.GatewayClient{}),
+			service.WithIdentityBackend(identityBackend),
+			service.EventsPublisher(eventsPublisher),
+		)
+		user = &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: "admin"}}
+		ctx = revactx.ContextSetUser(context.Background(), user)
+	})
+
+	patchUser := func(userID string, body string) *httptest.ResponseRecorder {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("userID", userID)
+		r := httptest.NewRequest(http.MethodPatch, "/graph/v1.0/users/"+userID, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		svc.PatchUser(rr, r.WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx)))
+		return rr
+	}
+
+	It("disables an account", func() {
+		identityBackend.On("UpdateUser", mock.Anything, "user1", mock.Anything).Return(&libregraph.User{Id: libregraph.PtrString("user1")}, nil)
+		identityBackend.On("SetAccountEnabled", mock.Anything, "user1", false).Return(nil)
+		identityBackend.On("GetAccountEnabled", mock.Anything, "user1").Return(false, nil)
+		eventsPublisher.On("Publish", mock.Anything, events.UserFeatureChanged{
+			Executant: user.Id,
+			UserID:    "user1",
+			Features:  []events.UserFeature{{Name: "accountEnabled", Value: "false"}},
+		}, mock.Anything).Return(nil)
+
+		rr := patchUser("user1", `{"accountEnabled": false}`)
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		res := map[string]interface{}{}
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).To(Succeed())
+		Expect(res["id"]).To(Equal("user1"))
+		Expect(res["accountEnabled"]).To(Equal(false))
+		identityBackend.AssertExpectations(GinkgoT())
+		eventsPublisher.AssertExpectations(GinkgoT())
+	})
+
+	It("fails if the backend can't disable accounts", func() {
+		identityBackend.On("GetAccountEnabled", mock.Anything, "user1").Return(true, nil)
+		identityBackend.On("SetAccountEnabled", mock.Anything, "user1", false).
+			Return(errorcode.New(errorcode.NotSupported, "disabling users is not supported"))
+
+		rr := patchUser("user1", `{"displayName": "User One", "accountEnabled": false}`)
+
+		Expect(rr.Code).To(Equal(http.StatusInternalServerError))
+		Expect(rr.Body.String()).To(ContainSubstring("notSupported"))
+		identityBackend.AssertNotCalled(GinkgoT(), "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+		eventsPublisher.AssertNotCalled(GinkgoT(), "Publish", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("rolls back the account status if the user can't be updated", func() {
+		identityBackend.On("GetAccountEnabled", mock.Anything, "user1").Return(true, nil)
+		identityBackend.On("SetAccountEnabled", mock.Anything, "user1", false).Return(nil)
+		identityBackend.On("UpdateUser", mock.Anything, "user1", mock.Anything).
+			Return(nil, errorcode.New(errorcode.InvalidRequest, "invalid display name"))
+		identityBackend.On("SetAccountEnabled", mock.Anything, "user1", true).Return(nil)
+
+		rr := patchUser("user1", `{"displayName": "User One", "accountEnabled": false}`)
+
+		Expect(rr.Code).To(Equal(http.StatusBadRequest))
+		identityBackend.AssertExpectations(GinkgoT())
+		eventsPublisher.AssertNotCalled(GinkgoT(), "Publish", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	It("doesn't change the account status unless requested", func() {
+		identityBackend.On("UpdateUser", mock.Anything, "user1", mock.Anything).Return(&libregraph.User{Id: libregraph.PtrString("user1")}, nil)
+		identityBackend.On("GetAccountEnabled", mock.Anything, "user1").Return(true, nil)
+		eventsPublisher.On("Publish", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		rr := patchUser("user1", `{"displayName": "User One"}`)
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+		identityBackend.AssertNotCalled(GinkgoT(), "SetAccountEnabled", mock.Anything, mock.Anything, mock.Anything)
+	})
+})
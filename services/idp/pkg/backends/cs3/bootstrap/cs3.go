@@ -25,6 +25,7 @@ import (
 	"github.com/libregraph/lico/identifier"
 	"github.com/libregraph/lico/identity"
 	"github.com/libregraph/lico/identity/managers"
+	"github.com/owncloud/ocis/v2/ocis-pkg/accountstatus"
 	cs3 "github.com/owncloud/ocis/v2/services/idp/pkg/backends/cs3/identifier"
 )
 
@@ -33,20 +34,23 @@ const (
 	identityManagerName = "cs3"
 )
 
-// Register adds the CS3 identity manager to the lico bootstrap
-func Register() error {
-	return bootstrap.RegisterIdentityManager(identityManagerName, NewIdentityManager)
+// Register adds the CS3 identity manager to the lico bootstrap. The options configure the
+// account status checker of the identity manager.
+func Register(opts ...accountstatus.Option) error {
+	return bootstrap.RegisterIdentityManager(identityManagerName, func(bs bootstrap.Bootstrap) (identity.Manager, error) {
+		return NewIdentityManager(bs, opts...)
+	})
 }
 
 // MustRegister adds the CS3 identity manager to the lico bootstrap or panics
-func MustRegister() {
-	if err := Register(); err != nil {
+func MustRegister(opts ...accountstatus.Option) {
+	if err := Register(opts...); err != nil {
 		panic(err)
 	}
 }
 
 // NewIdentityManager produces a CS3 backed identity manager instance for the idp
-func NewIdentityManager(bs bootstrap.Bootstrap) (identity.Manager, error) {
+func NewIdentityManager(bs bootstrap.Bootstrap, opts ...accountstatus.Option) (identity.Manager, error) {
 	config := bs.Config()
 
 	logger := config.Config.Logger
@@ -76,6 +80,7 @@ func NewIdentityManager(bs bootstrap.Bootstrap) (identity.Manager, error) {
 		os.Getenv("CS3_GATEWAY"),
 		os.Getenv("CS3_MACHINE_AUTH_API_KEY"),
 		config.Settings.Insecure,
+		accountstatus.NewChecker(opts...),
 	)
 	if identifierErr != nil {
 		return nil, fmt.Errorf("failed to create identifier backend: %v", identifierErr)
@@ -3,6 +3,7 @@ package cs3
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 
 	cs3gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -13,6 +14,7 @@ import (
 	"github.com/libregraph/lico/identifier/meta/scopes"
 	"github.com/libregraph/lico/identity"
 	cmap "github.com/orcaman/concurrent-map"
+	"github.com/owncloud/ocis/v2/ocis-pkg/accountstatus"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -38,6 +40,7 @@ type CS3Backend struct {
 	gatewayURI        string
 	machineAuthAPIKey string
 	insecure          bool
+	accountStatus     *accountstatus.Checker
 
 	sessions cmap.ConcurrentMap
 
@@ -51,6 +54,7 @@ func NewCS3Backend(
 	gatewayURI string,
 	machineAuthAPIKey string,
 	insecure bool,
+	accountStatus *accountstatus.Checker,
 ) (*CS3Backend, error) {
 
 	// Build supported scopes based on default scopes.
@@ -65,6 +69,7 @@ func NewCS3Backend(
 		gatewayURI:        gatewayURI,
 		machineAuthAPIKey: machineAuthAPIKey,
 		insecure:          insecure,
+		accountStatus:     accountStatus,
 
 		sessions: cmap.New(),
 	}
@@ -103,6 +108,17 @@ func (b *CS3Backend) Logon(ctx context.Context, audience, username, password str
 		return false, nil, nil, nil, fmt.Errorf("cs3 backend basic authenticate failed with code %s: %s", res.Status.Code.String(), res.Status.Message)
 	}
 
+	if b.accountStatus != nil {
+		err := b.accountStatus.Check(ctx, res.User.GetId().GetOpaqueId(), res.Token)
+		switch {
+		case errors.Is(err, accountstatus.ErrDisabled):
+			b.logger.WithField("username", username).Debugln("cs3 backend logon rejected, account is disabled")
+			return false, nil, nil, nil, nil
+		case err != nil:
+			return false, nil, nil, nil, fmt.Errorf("cs3 backend account status error: %v", err)
+		}
+	}
+
 	session := createSession(ctx, res.User)
 
 	user, err := newCS3User(res.User)
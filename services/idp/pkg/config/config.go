@@ -27,6 +27,8 @@ type Config struct {
 	Clients []Client `yaml:"clients"`
 	Ldap    Ldap     `yaml:"ldap"`
 
+	AccountStatus AccountStatus `yaml:"account_status"`
+
 	Context context.Context `yaml:"-"`
 }
 
@@ -51,6 +53,12 @@ type Ldap struct {
 	ObjectClass string `yaml:"objectclass" env:"LDAP_USER_OBJECTCLASS;IDP_LDAP_OBJECTCLASS" desc:"LDAP User ObjectClass like 'inetOrgPerson'."`
 }
 
+// AccountStatus configures how the cs3 backend reads the account status of users from the graph service.
+type AccountStatus struct {
+	Timeout  int  `yaml:"timeout" env:"OCIS_ACCOUNT_STATUS_TIMEOUT;IDP_ACCOUNT_STATUS_TIMEOUT" desc:"Timeout in seconds for reading the account status of a user from the graph service."`
+	FailOpen bool `yaml:"fail_open" env:"OCIS_ACCOUNT_STATUS_FAIL_OPEN;IDP_ACCOUNT_STATUS_FAIL_OPEN" desc:"Set this to 'true' to let users sign in when their account status can't be read from the graph service. By default the sign-in is rejected."`
+}
+
 // Asset defines the available asset configuration.
 type Asset struct {
 	Path string `yaml:"asset" env:"IDP_ASSET_PATH" desc:"Serve IDP assets from a path on the filesystem instead of the builtin assets."`
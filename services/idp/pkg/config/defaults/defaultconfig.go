@@ -125,6 +125,10 @@ func DefaultConfig() *config.Config {
 			Filter:            "",
 			ObjectClass:       "inetOrgPerson",
 		},
+		AccountStatus: config.AccountStatus{
+			Timeout:  5,
+			FailOpen: false,
+		},
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/mux"
@@ -19,6 +20,7 @@ import (
 	libreGraphBackendSupport "github.com/libregraph/lico/bootstrap/backends/libregraph"
 	licoconfig "github.com/libregraph/lico/config"
 	"github.com/libregraph/lico/server"
+	"github.com/owncloud/ocis/v2/ocis-pkg/accountstatus"
 	"github.com/owncloud/ocis/v2/ocis-pkg/ldap"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/idp/pkg/assets"
@@ -54,7 +56,11 @@ func NewService(opts ...Option) Service {
 
 	switch options.Config.IDP.IdentityManager {
 	case "cs3":
-		cs3BackendSupport.MustRegister()
+		cs3BackendSupport.MustRegister(
+			accountstatus.Logger(options.Logger),
+			accountstatus.Timeout(time.Duration(options.Config.AccountStatus.Timeout)*time.Second),
+			accountstatus.FailOpen(options.Config.AccountStatus.FailOpen),
+		)
 		if err := initCS3EnvVars(options.Config.Reva.Address, options.Config.MachineAuthAPIKey); err != nil {
 			logger.Fatal().Err(err).Msg("could not initialize cs3 backend env vars")
 		}
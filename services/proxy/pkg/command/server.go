@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
@@ -13,7 +14,10 @@ import (
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/justinas/alice"
 	"github.com/oklog/run"
+	"github.com/owncloud/ocis/v2/ocis-pkg/accountstatus"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/configlog"
+	pkgcrypto "github.com/owncloud/ocis/v2/ocis-pkg/crypto"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	pkgmiddleware "github.com/owncloud/ocis/v2/ocis-pkg/middleware"
 	"github.com/owncloud/ocis/v2/ocis-pkg/service/grpc"
@@ -26,6 +30,7 @@ import (
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/metrics"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/middleware"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/proxy"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/proxy/upstream"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/router"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/server/debug"
 	proxyHTTP "github.com/owncloud/ocis/v2/services/proxy/pkg/server/http"
@@ -67,9 +72,25 @@ func Server(cfg *config.Config) *cli.Command {
 
 			m.BuildInfo.WithLabelValues(version.GetString()).Set(1)
 
+			tracker := upstream.NewTracker(
+				logger,
+				cfg.BackendHealth.FailureThreshold,
+				time.Duration(cfg.BackendHealth.ProbeInterval)*time.Second,
+				cfg.BackendHealth.ProbePath,
+				&http.Client{
+					Transport: &http.Transport{
+						TLSClientConfig: &tls.Config{
+							InsecureSkipVerify: cfg.InsecureBackends, //nolint:gosec
+						},
+					},
+					Timeout: time.Second * 5,
+				},
+			)
+
 			rp := proxy.NewMultiHostReverseProxy(
 				proxy.Logger(logger),
 				proxy.Config(cfg),
+				proxy.Tracker(tracker),
 			)
 
 			{
@@ -79,7 +100,7 @@ func Server(cfg *config.Config) *cli.Command {
 					proxyHTTP.Context(ctx),
 					proxyHTTP.Config(cfg),
 					proxyHTTP.Metrics(metrics.New()),
-					proxyHTTP.Middlewares(loadMiddlewares(ctx, logger, cfg)),
+					proxyHTTP.Middlewares(loadMiddlewares(ctx, logger, cfg, tracker, m)),
 				)
 
 				if err != nil {
@@ -103,11 +124,18 @@ func Server(cfg *config.Config) *cli.Command {
 				})
 			}
 
+			gr.Add(func() error {
+				return tracker.Run(ctx)
+			}, func(_ error) {
+				cancel()
+			})
+
 			{
 				server, err := debug.Server(
 					debug.Logger(logger),
 					debug.Context(ctx),
 					debug.Config(cfg),
+					debug.Tracker(tracker),
 				)
 
 				if err != nil {
@@ -126,7 +154,7 @@ func Server(cfg *config.Config) *cli.Command {
 	}
 }
 
-func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config) alice.Chain {
+func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config, tracker *upstream.Tracker, m *metrics.Metrics) alice.Chain {
 	rolesClient := settingssvc.NewRoleService("com.owncloud.api.settings", grpc.DefaultClient())
 	revaClient, err := pool.GetGatewayServiceClient(cfg.Reva.Address)
 	var userProvider backend.UserBackend
@@ -140,11 +168,21 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 				Msg("Failed to create token manager")
 		}
 
-		userProvider = backend.NewCS3UserBackend(rolesClient, revaClient, cfg.MachineAuthAPIKey, cfg.OIDC.Issuer, tokenManager, logger)
+		accountStatus := accountstatus.NewChecker(
+			accountstatus.Logger(logger),
+			accountstatus.Timeout(time.Duration(cfg.AccountStatus.Timeout)*time.Second),
+			accountstatus.FailOpen(cfg.AccountStatus.FailOpen),
+		)
+		userProvider = backend.NewCS3UserBackend(rolesClient, revaClient, cfg.MachineAuthAPIKey, cfg.OIDC.Issuer, tokenManager, accountStatus, logger)
 	default:
 		logger.Fatal().Msgf("Invalid accounts backend type '%s'", cfg.AccountBackend)
 	}
 
+	var roleAssigner backend.UserRoleAssigner
+	if cfg.RoleAssignment.ClaimName != "" {
+		roleAssigner = backend.NewClaimsRoleAssigner(rolesClient, cfg.RoleAssignment, time.Duration(cfg.OIDC.UserinfoCache.TTL)*time.Second, logger)
+	}
+
 	storeClient := storesvc.NewStoreService("com.owncloud.api.store", grpc.DefaultClient())
 	if err != nil {
 		logger.Error().Err(err).
@@ -163,12 +201,19 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 	}
 
 	var authenticators []middleware.Authenticator
-	if cfg.EnableBasicAuth {
-		logger.Warn().Msg("basic auth enabled, use only for testing or development")
-		authenticators = append(authenticators, middleware.BasicAuthenticator{
-			Logger:       logger,
-			UserProvider: userProvider,
-		})
+	if cfg.EnableBasicAuth || cfg.EnableAppTokens {
+		basicAuthenticator := middleware.BasicAuthenticator{
+			Logger:        logger,
+			UserProvider:  userProvider,
+			AppTokensOnly: !cfg.EnableBasicAuth,
+		}
+		if cfg.EnableBasicAuth {
+			logger.Warn().Msg("basic auth enabled, use only for testing or development")
+		}
+		if cfg.EnableAppTokens {
+			basicAuthenticator.AppTokens = apptoken.NewStoreManager(storeClient)
+		}
+		authenticators = append(authenticators, basicAuthenticator)
 	}
 	authenticators = append(authenticators, middleware.NewOIDCAuthenticator(
 		logger,
@@ -185,6 +230,7 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 			)
 		},
 		cfg.OIDC.JWKS,
+		cfg.OIDC.Introspection,
 		cfg.OIDC.AccessTokenVerifyMethod,
 	))
 	authenticators = append(authenticators, middleware.PublicShareAuthenticator{
@@ -199,6 +245,25 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 		Store:              storeClient,
 	})
 
+	if cfg.ClientCertAuth.Enabled {
+		f, err := os.Open(cfg.ClientCertAuth.CABundle)
+		if err != nil {
+			logger.Fatal().Err(err).Str("file", cfg.ClientCertAuth.CABundle).Msg("Could not read client certificate CA bundle")
+		}
+		pool, err := pkgcrypto.NewCertPoolFromPEM(f)
+		f.Close()
+		if err != nil {
+			logger.Fatal().Err(err).Str("file", cfg.ClientCertAuth.CABundle).Msg("Could not load client certificate CA bundle")
+		}
+		authenticators = append(authenticators, middleware.ClientCertAuthenticator{
+			Logger:       logger,
+			UserProvider: userProvider,
+			CertPool:     pool,
+			UserField:    cfg.ClientCertAuth.UserField,
+			UserClaim:    cfg.ClientCertAuth.UserClaim,
+		})
+	}
+
 	return alice.New(
 		// first make sure we log all requests and redirect to https if necessary
 		pkgmiddleware.TraceContext,
@@ -212,14 +277,14 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 			oidcHTTPClient,
 		),
 
-		router.Middleware(cfg.PolicySelector, cfg.Policies, logger),
+		router.Middleware(cfg.PolicySelector, cfg.Policies, tracker, logger),
 
 		middleware.Authentication(
 			authenticators,
 			middleware.CredentialsByUserAgent(cfg.AuthMiddleware.CredentialsByUserAgent),
 			middleware.Logger(logger),
 			middleware.OIDCIss(cfg.OIDC.Issuer),
-			middleware.EnableBasicAuth(cfg.EnableBasicAuth),
+			middleware.EnableBasicAuth(cfg.EnableBasicAuth || cfg.EnableAppTokens),
 		),
 		middleware.AccountResolver(
 			middleware.Logger(logger),
@@ -228,6 +293,12 @@ func loadMiddlewares(ctx context.Context, logger log.Logger, cfg *config.Config)
 			middleware.UserOIDCClaim(cfg.UserOIDCClaim),
 			middleware.UserCS3Claim(cfg.UserCS3Claim),
 			middleware.AutoprovisionAccounts(cfg.AutoprovisionAccounts),
+			middleware.UserRoleAssigner(roleAssigner),
+		),
+		middleware.RateLimiter(
+			middleware.Logger(logger),
+			middleware.RateLimitConfig(cfg.RateLimit),
+			middleware.Metrics(m),
 		),
 
 		middleware.SelectorCookie(
@@ -30,9 +30,15 @@ type Config struct {
 	UserCS3Claim          string          `yaml:"user_cs3_claim" env:"PROXY_USER_CS3_CLAIM" desc:"The name of a CS3 user attribute (claim) that should be mapped to the 'user_oidc_claim'. Supported values are 'username', 'mail' and 'userid'."`
 	MachineAuthAPIKey     string          `mask:"password" yaml:"machine_auth_api_key" env:"OCIS_MACHINE_AUTH_API_KEY;PROXY_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to validate internal requests necessary to access resources from other services."`
 	AutoprovisionAccounts bool            `yaml:"auto_provision_accounts" env:"PROXY_AUTOPROVISION_ACCOUNTS" desc:"Set this to 'true' to automatically provision users that do not yet exist in the users service on-demand upon first sign-in. To use this a write-enabled libregraph user backend needs to be setup an running."`
+	RoleAssignment        RoleAssignment  `yaml:"role_assignment"`
 	EnableBasicAuth       bool            `yaml:"enable_basic_auth" env:"PROXY_ENABLE_BASIC_AUTH" desc:"Set this to true to enable 'basic' (username/password) authentication."`
+	EnableAppTokens       bool            `yaml:"enable_app_tokens" env:"PROXY_ENABLE_APP_TOKENS" desc:"Set this to true to accept app tokens created by the users in the graph service in place of their password with 'basic' authentication. This works independently of 'enable_basic_auth'."`
+	ClientCertAuth        ClientCertAuth  `yaml:"client_cert_auth"`
 	InsecureBackends      bool            `yaml:"insecure_backends" env:"PROXY_INSECURE_BACKENDS" desc:"Disable TLS certificate validation for all HTTP backend connections."`
+	BackendHealth         BackendHealth   `yaml:"backend_health"`
+	RateLimit             RateLimit       `yaml:"rate_limit"`
 	AuthMiddleware        AuthMiddleware  `yaml:"auth_middleware"`
+	AccountStatus         AccountStatus   `yaml:"account_status"`
 
 	Context context.Context `yaml:"-" json:"-"`
 }
@@ -41,6 +47,8 @@ type Config struct {
 type Policy struct {
 	Name   string  `yaml:"name"`
 	Routes []Route `yaml:"routes"`
+	// RateLimits apply to all routes of the policy which don't configure their own
+	RateLimits []RateLimitRule `yaml:"rate_limits,omitempty"`
 }
 
 // Route defines forwarding routes
@@ -51,10 +59,31 @@ type Route struct {
 	Endpoint string `yaml:"endpoint,omitempty"`
 	// Backend is a static URL to forward the request to
 	Backend string `yaml:"backend,omitempty"`
+	// Backends is a list of weighted static URLs to balance the requests between
+	Backends []WeightedBackend `yaml:"backends,omitempty"`
 	// Service name to look up in the registry
 	Service     string `yaml:"service,omitempty"`
 	ApacheVHost bool   `yaml:"apache_vhost,omitempty"`
 	Unprotected bool   `yaml:"unprotected,omitempty"`
+	// RateLimits take precedence over the rate limits of the policy for this route
+	RateLimits []RateLimitRule `yaml:"rate_limits,omitempty"`
+}
+
+// RateLimitRule configures a token bucket per client for a policy or route
+type RateLimitRule struct {
+	// Method optionally limits the rule to this HTTP method
+	Method string `yaml:"method,omitempty"`
+	// Rate is the number of requests per second a client may send on average
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of requests a client may send at once
+	Burst int `yaml:"burst"`
+}
+
+// WeightedBackend is a static backend URL with a relative weight
+type WeightedBackend struct {
+	URL string `yaml:"url"`
+	// Weight is the relative share of requests the backend receives. Defaults to 1.
+	Weight int `yaml:"weight,omitempty"`
 }
 
 // RouteType defines the type of a route
@@ -76,16 +105,56 @@ var (
 	RouteTypes = []RouteType{QueryRoute, RegexRoute, PrefixRoute}
 )
 
+// BackendHealth configures the passive health tracking of static backends.
+type BackendHealth struct {
+	FailureThreshold int    `yaml:"failure_threshold" env:"PROXY_BACKEND_HEALTH_FAILURE_THRESHOLD" desc:"Number of consecutive 5xx responses or connection errors after which a backend is marked as down. Set to '0' to disable health tracking."`
+	ProbeInterval    int    `yaml:"probe_interval" env:"PROXY_BACKEND_HEALTH_PROBE_INTERVAL" desc:"Interval in seconds in which backends that are marked as down are probed."`
+	ProbePath        string `yaml:"probe_path" env:"PROXY_BACKEND_HEALTH_PROBE_PATH" desc:"Path that is requested on a backend that is marked as down to check if it is back up. Any response other than a 5xx marks the backend as up again."`
+}
+
+// AccountStatus configures how the proxy reads the account status of users from the graph service.
+type AccountStatus struct {
+	Timeout  int  `yaml:"timeout" env:"OCIS_ACCOUNT_STATUS_TIMEOUT;PROXY_ACCOUNT_STATUS_TIMEOUT" desc:"Timeout in seconds for reading the account status of a user from the graph service."`
+	FailOpen bool `yaml:"fail_open" env:"OCIS_ACCOUNT_STATUS_FAIL_OPEN;PROXY_ACCOUNT_STATUS_FAIL_OPEN" desc:"Set this to 'true' to let users sign in when their account status can't be read from the graph service. By default the sign-in is rejected."`
+}
+
+// RateLimit configures the per client rate limiting of the proxy.
+type RateLimit struct {
+	Enabled bool    `yaml:"enabled" env:"PROXY_RATE_LIMIT_ENABLED" desc:"Set this to 'true' to throttle clients that send too many requests. Clients are identified by their user ID or by their remote IP address for unauthenticated requests."`
+	Rate    float64 `yaml:"rate" env:"PROXY_RATE_LIMIT_RATE" desc:"The number of requests per second a client may send on average to routes that do not configure their own rate limits. Set to '0' to only limit routes with explicit rate limits."`
+	Burst   int     `yaml:"burst" env:"PROXY_RATE_LIMIT_BURST" desc:"The number of requests a client may send at once to routes that do not configure their own rate limits."`
+}
+
+// RoleAssignment configures how settings roles are assigned to users based on their OIDC claims.
+type RoleAssignment struct {
+	ClaimName string `yaml:"claim_name" env:"PROXY_ROLE_ASSIGNMENT_CLAIM" desc:"The name of the OIDC claim holding the role or group names of the user. When set, the role of a user is (re)assigned on every sign-in according to the 'mapping'. Users without a matching claim value get the default 'user' role. Leave empty to keep role assignments managed in oCIS."`
+	// Mapping maps claim values to role ids. The first matching entry wins.
+	Mapping []RoleMapping `yaml:"mapping"`
+}
+
+// RoleMapping maps a value of the role claim to a settings role id
+type RoleMapping struct {
+	ClaimValue string `yaml:"claim_value"`
+	RoleID     string `yaml:"role_id"`
+}
+
+// ClientCertAuth configures the authentication of clients with X.509 client certificates.
+type ClientCertAuth struct {
+	Enabled   bool   `yaml:"enabled" env:"PROXY_CLIENT_CERT_AUTH_ENABLED" desc:"Set this to 'true' to authenticate clients presenting a TLS client certificate. Requires the HTTPS server of the proxy, TLS must not be terminated in front of it."`
+	CABundle  string `yaml:"ca_bundle" env:"PROXY_CLIENT_CERT_AUTH_CA_BUNDLE" desc:"File name of a PEM encoded bundle of the CA certificates that issue client certificates accepted for authentication."`
+	UserField string `yaml:"user_field" env:"PROXY_CLIENT_CERT_AUTH_USER_FIELD" desc:"The certificate field used to look up the user. Supported values are 'subject_cn', 'san_email', 'san_dns' and 'san_uri'."`
+	UserClaim string `yaml:"user_claim" env:"PROXY_CLIENT_CERT_AUTH_USER_CLAIM" desc:"The name of a CS3 user attribute (claim) the value of the 'user_field' is matched against. Supported values are 'username', 'mail' and 'userid'."`
+}
+
 // AuthMiddleware configures the proxy http auth middleware.
 type AuthMiddleware struct {
 	CredentialsByUserAgent map[string]string `yaml:"credentials_by_user_agent"`
 }
 
 const (
-	AccessTokenVerificationNone = "none"
-	AccessTokenVerificationJWT  = "jwt"
-	// tdb:
-	// AccessTokenVerificationIntrospect = "introspect"
+	AccessTokenVerificationNone       = "none"
+	AccessTokenVerificationJWT        = "jwt"
+	AccessTokenVerificationIntrospect = "introspect"
 )
 
 // OIDC is the config for the OpenID-Connect middleware. If set the proxy will try to authenticate every request
@@ -93,9 +162,10 @@ const (
 type OIDC struct {
 	Issuer                  string        `yaml:"issuer" env:"OCIS_URL;OCIS_OIDC_ISSUER;PROXY_OIDC_ISSUER" desc:"URL of the OIDC issuer. It defaults to URL of the builtin IDP."`
 	Insecure                bool          `yaml:"insecure" env:"OCIS_INSECURE;PROXY_OIDC_INSECURE" desc:"Disable TLS certificate validation for connections to the IDP. Note that this is not recommended for production environments."`
-	AccessTokenVerifyMethod string        `yaml:"access_token_verify_method" env:"PROXY_OIDC_ACCESS_TOKEN_VERIFY_METHOD" desc:"Sets how OIDC access tokens should be verified. Possible values are 'none' and 'jwt'. When using 'none', no special validation apart from using it for accessing the IPD's userinfo endpoint will be done. When using 'jwt', it tries to parse the access token as a jwt token and verifies the signature using the keys published on the IDP's 'jwks_uri'."`
+	AccessTokenVerifyMethod string        `yaml:"access_token_verify_method" env:"PROXY_OIDC_ACCESS_TOKEN_VERIFY_METHOD" desc:"Sets how OIDC access tokens should be verified. Possible values are 'none', 'jwt' and 'introspect'. When using 'none', no special validation apart from using it for accessing the IPD's userinfo endpoint will be done. When using 'jwt', it tries to parse the access token as a jwt token and verifies the signature using the keys published on the IDP's 'jwks_uri'. When using 'introspect', the access token is validated by calling the IDP's 'introspection_endpoint' (RFC 7662), which also works for opaque access tokens."`
 	UserinfoCache           UserinfoCache `yaml:"user_info_cache"`
 	JWKS                    JWKS          `yaml:"jwks"`
+	Introspection           Introspection `mask:"struct" yaml:"introspection"`
 	RewriteWellKnown        bool          `yaml:"rewrite_well_known" env:"PROXY_OIDC_REWRITE_WELLKNOWN" desc:"Enables rewriting the /.well-known/openid-configuration to the configured OIDC issuer. Needed by the Desktop Client, Android Client and iOS Client to discover the OIDC provider."`
 }
 
@@ -106,6 +176,12 @@ type JWKS struct {
 	RefreshUnknownKID bool   `yaml:"refresh_unknown_kid" env:"PROXY_OIDC_JWKS_REFRESH_UNKNOWN_KID" desc:"If set to 'true', the JWKS refresh request will occur every time an unknown KEY ID (KID) is seen. Always set a 'refresh_limit' when enabling this."`
 }
 
+// Introspection configures how the proxy authenticates at the IDP's token introspection endpoint.
+type Introspection struct {
+	ClientID     string `yaml:"client_id" env:"PROXY_OIDC_INTROSPECTION_CLIENT_ID" desc:"The client ID the proxy uses to authenticate at the IDP's introspection endpoint. Only used when 'access_token_verify_method' is set to 'introspect'."`
+	ClientSecret string `mask:"password" yaml:"client_secret" env:"PROXY_OIDC_INTROSPECTION_CLIENT_SECRET" desc:"The client secret the proxy uses to authenticate at the IDP's introspection endpoint. Only used when 'access_token_verify_method' is set to 'introspect'."`
+}
+
 // UserinfoCache is a TTL cache configuration.
 type UserinfoCache struct {
 	Size int `yaml:"size" env:"PROXY_OIDC_USERINFO_CACHE_SIZE" desc:"Cache size for OIDC user info."`
@@ -114,9 +190,10 @@ type UserinfoCache struct {
 
 // PolicySelector is the toplevel-configuration for different selectors
 type PolicySelector struct {
-	Static *StaticSelectorConf `yaml:"static"`
-	Claims *ClaimsSelectorConf `yaml:"claims"`
-	Regex  *RegexSelectorConf  `yaml:"regex"`
+	Static  *StaticSelectorConf  `yaml:"static"`
+	Claims  *ClaimsSelectorConf  `yaml:"claims"`
+	Regex   *RegexSelectorConf   `yaml:"regex"`
+	Request *RequestSelectorConf `yaml:"request"`
 }
 
 // StaticSelectorConf is the config for the static-policy-selector
@@ -156,3 +233,22 @@ type RegexRuleConf struct {
 	Match    string `yaml:"match"`
 	Policy   string `yaml:"policy"`
 }
+
+// RequestSelectorConf is the config for the request-selector
+type RequestSelectorConf struct {
+	DefaultPolicy         string            `yaml:"default_policy"`
+	MatchesPolicies       []RequestRuleConf `yaml:"matches_policies"`
+	UnauthenticatedPolicy string            `yaml:"unauthenticated_policy"`
+	SelectorCookieName    string            `yaml:"selector_cookie_name"`
+}
+
+// RequestRuleConf matches a property of the request against a regular expression.
+// Possible sources are "host", "header", "client_cert_subject" and "client_cert_san".
+type RequestRuleConf struct {
+	Priority int    `yaml:"priority"`
+	Source   string `yaml:"source"`
+	// Header is the name of the header to match when the source is "header"
+	Header string `yaml:"header,omitempty"`
+	Match  string `yaml:"match"`
+	Policy string `yaml:"policy"`
+}
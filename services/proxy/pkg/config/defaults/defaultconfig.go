@@ -60,7 +60,27 @@ func DefaultConfig() *config.Config {
 		UserCS3Claim:          "username",
 		AutoprovisionAccounts: false,
 		EnableBasicAuth:       false,
-		InsecureBackends:      false,
+		EnableAppTokens:       false,
+		ClientCertAuth: config.ClientCertAuth{
+			Enabled:   false,
+			UserField: "subject_cn",
+			UserClaim: "username",
+		},
+		InsecureBackends: false,
+		BackendHealth: config.BackendHealth{
+			FailureThreshold: 5,
+			ProbeInterval:    10,
+			ProbePath:        "/",
+		},
+		RateLimit: config.RateLimit{
+			Enabled: false,
+			Rate:    50,
+			Burst:   100,
+		},
+		AccountStatus: config.AccountStatus{
+			Timeout:  5,
+			FailOpen: false,
+		},
 	}
 }
 
@@ -190,6 +210,12 @@ func DefaultPolicies() []config.Policy {
 					Endpoint: "/graph/",
 					Backend:  "http://localhost:9120",
 				},
+				{
+					// the SCIM endpoint authenticates requests with its own bearer token
+					Endpoint:    "/scim/v2/",
+					Backend:     "http://localhost:9120",
+					Unprotected: true,
+				},
 				{
 					Endpoint: "/api/v0/settings",
 					Backend:  "http://localhost:9190",
@@ -43,13 +43,28 @@ func Validate(cfg *config.Config) error {
 	}
 
 	if cfg.OIDC.AccessTokenVerifyMethod != config.AccessTokenVerificationNone &&
-		cfg.OIDC.AccessTokenVerifyMethod != config.AccessTokenVerificationJWT {
+		cfg.OIDC.AccessTokenVerifyMethod != config.AccessTokenVerificationJWT &&
+		cfg.OIDC.AccessTokenVerifyMethod != config.AccessTokenVerificationIntrospect {
 		return fmt.Errorf(
-			"Invalid value '%s' for 'access_token_verify_method' in service %s. Possible values are: '%s' or '%s'.",
+			"Invalid value '%s' for 'access_token_verify_method' in service %s. Possible values are: '%s', '%s' or '%s'.",
 			cfg.OIDC.AccessTokenVerifyMethod, cfg.Service.Name,
-			config.AccessTokenVerificationJWT, config.AccessTokenVerificationNone,
+			config.AccessTokenVerificationJWT, config.AccessTokenVerificationIntrospect, config.AccessTokenVerificationNone,
 		)
 	}
 
+	if cfg.ClientCertAuth.Enabled {
+		if cfg.ClientCertAuth.CABundle == "" {
+			return fmt.Errorf("The client certificate authentication of service %s requires a 'ca_bundle'.", cfg.Service.Name)
+		}
+		switch cfg.ClientCertAuth.UserField {
+		case "subject_cn", "san_email", "san_dns", "san_uri":
+		default:
+			return fmt.Errorf(
+				"Invalid value '%s' for 'client_cert_auth.user_field' in service %s. Possible values are: 'subject_cn', 'san_email', 'san_dns' or 'san_uri'.",
+				cfg.ClientCertAuth.UserField, cfg.Service.Name,
+			)
+		}
+	}
+
 	return nil
 }
@@ -14,10 +14,11 @@ var (
 
 // Metrics defines the available metrics of this service.
 type Metrics struct {
-	Counter   *prometheus.CounterVec
-	Latency   *prometheus.SummaryVec
-	Duration  *prometheus.HistogramVec
-	BuildInfo *prometheus.GaugeVec
+	Counter     *prometheus.CounterVec
+	Latency     *prometheus.SummaryVec
+	Duration    *prometheus.HistogramVec
+	BuildInfo   *prometheus.GaugeVec
+	RateLimited *prometheus.CounterVec
 }
 
 // New initializes the available metrics.
@@ -47,11 +48,18 @@ func New() *Metrics {
 			Name:      "build_info",
 			Help:      "Build Information",
 		}, []string{"versions"}),
+		RateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "rate_limited_total",
+			Help:      "How many requests were rejected by the rate limiter",
+		}, []string{"endpoint", "method", "client"}),
 	}
 
 	_ = prometheus.Register(m.Counter)
 	_ = prometheus.Register(m.Latency)
 	_ = prometheus.Register(m.Duration)
 	_ = prometheus.Register(m.BuildInfo)
+	_ = prometheus.Register(m.RateLimited)
 	return m
 }
@@ -22,6 +22,7 @@ func AccountResolver(optionSetters ...Option) func(next http.Handler) http.Handl
 			next:                  next,
 			logger:                logger,
 			userProvider:          options.UserProvider,
+			userRoleAssigner:      options.UserRoleAssigner,
 			userOIDCClaim:         options.UserOIDCClaim,
 			userCS3Claim:          options.UserCS3Claim,
 			autoProvisionAccounts: options.AutoprovisionAccounts,
@@ -33,6 +34,7 @@ type accountResolver struct {
 	next                  http.Handler
 	logger                log.Logger
 	userProvider          backend.UserBackend
+	userRoleAssigner      backend.UserRoleAssigner
 	autoProvisionAccounts bool
 	userOIDCClaim         string
 	userCS3Claim          string
@@ -94,6 +96,25 @@ func (m accountResolver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 
+		if m.userRoleAssigner != nil {
+			// re-evaluate the role assignment so that role changes in the IDP take effect on the next sign-in
+			var changed bool
+			if user, changed, err = m.userRoleAssigner.UpdateUserRoleAssignment(ctx, user, claims); err != nil {
+				m.logger.Error().Err(err).Msg("Could not update the role assignment from claims")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if changed {
+				// the token was minted with the previous role
+				userID := user.Id.OpaqueId
+				if user, token, err = m.userProvider.GetUserByClaims(req.Context(), "userid", userID, true); err != nil {
+					m.logger.Error().Err(err).Str("userid", userID).Msg("Could not get a token with the updated role")
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
 		// add user to context for selectors
 		ctx = revactx.ContextSetUser(ctx, user)
 		req = req.WithContext(ctx)
@@ -107,6 +107,82 @@ func TestInternalServerErrorOnMissingMailAndUsername(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rw.Code)
 }
 
+func TestRoleAssignmentIsUpdatedFromClaims(t *testing.T) {
+	user := &userv1beta1.User{
+		Id:       &userv1beta1.UserId{Idp: "https://idx.example.com", OpaqueId: "123"},
+		Username: "foo",
+	}
+	mock := &test.UserBackendMock{
+		GetUserByClaimsFunc: func(ctx context.Context, claim string, value string, withRoles bool) (*userv1beta1.User, string, error) {
+			return user, "", nil
+		},
+	}
+	var gotClaims map[string]interface{}
+	sut := AccountResolver(
+		Logger(log.NewLogger()),
+		UserProvider(mock),
+		UserRoleAssigner(mockRoleAssigner(func(ctx context.Context, u *userv1beta1.User, claims map[string]interface{}) (*userv1beta1.User, bool, error) {
+			gotClaims = claims
+			return u, false, nil
+		})),
+		UserOIDCClaim(oidc.PreferredUsername),
+		UserCS3Claim("username"),
+	)(mockHandler{})
+
+	claims := map[string]interface{}{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "foo",
+		"roles":                []interface{}{"admin"},
+	}
+	req, rw := mockRequest(claims)
+	sut.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, claims, gotClaims)
+}
+
+func TestTokenIsMintedAgainAfterRoleChange(t *testing.T) {
+	user := &userv1beta1.User{
+		Id:       &userv1beta1.UserId{Idp: "https://idx.example.com", OpaqueId: "123"},
+		Username: "foo",
+	}
+	var lookups []string
+	mock := &test.UserBackendMock{
+		GetUserByClaimsFunc: func(ctx context.Context, claim string, value string, withRoles bool) (*userv1beta1.User, string, error) {
+			lookups = append(lookups, claim)
+			if claim == "userid" {
+				return user, "new-token", nil
+			}
+			return user, "old-token", nil
+		},
+	}
+	sut := AccountResolver(
+		Logger(log.NewLogger()),
+		UserProvider(mock),
+		UserRoleAssigner(mockRoleAssigner(func(ctx context.Context, u *userv1beta1.User, claims map[string]interface{}) (*userv1beta1.User, bool, error) {
+			return u, true, nil
+		})),
+		UserOIDCClaim(oidc.PreferredUsername),
+		UserCS3Claim("username"),
+	)(mockHandler{})
+
+	req, rw := mockRequest(map[string]interface{}{
+		oidc.Iss:               "https://idx.example.com",
+		oidc.PreferredUsername: "foo",
+	})
+	sut.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, []string{"username", "userid"}, lookups)
+	assert.Equal(t, "new-token", req.Header.Get(revactx.TokenHeader))
+}
+
+type mockRoleAssigner func(ctx context.Context, user *userv1beta1.User, claims map[string]interface{}) (*userv1beta1.User, bool, error)
+
+func (f mockRoleAssigner) UpdateUserRoleAssignment(ctx context.Context, user *userv1beta1.User, claims map[string]interface{}) (*userv1beta1.User, bool, error) {
+	return f(ctx, user, claims)
+}
+
 func newMockAccountResolver(userBackendResult *userv1beta1.User, userBackendErr error, oidcclaim, cs3claim string) http.Handler {
 	tokenManager, _ := jwt.New(map[string]interface{}{
 		"secret":  "change-me",
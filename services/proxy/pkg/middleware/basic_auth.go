@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/ocis-pkg/oidc"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/user/backend"
@@ -14,6 +17,10 @@ type BasicAuthenticator struct {
 	UserProvider  backend.UserBackend
 	UserCS3Claim  string
 	UserOIDCClaim string
+	// AppTokens is used to authenticate requests with app tokens in place of the password, can be nil
+	AppTokens apptoken.Manager
+	// AppTokensOnly disables the authentication with the user's password
+	AppTokensOnly bool
 }
 
 // Authenticate implements the authenticator interface to authenticate requests via basic auth.
@@ -30,7 +37,10 @@ func (m BasicAuthenticator) Authenticate(r *http.Request) (*http.Request, bool)
 		return nil, false
 	}
 
-	user, _, err := m.UserProvider.Authenticate(r.Context(), login, password)
+	user, err := m.authenticateAppToken(r, login, password)
+	if errors.Is(err, apptoken.ErrNotFound) && !m.AppTokensOnly {
+		user, _, err = m.UserProvider.Authenticate(r.Context(), login, password)
+	}
 	if err != nil {
 		m.Logger.Error().
 			Err(err).
@@ -60,3 +70,28 @@ func (m BasicAuthenticator) Authenticate(r *http.Request) (*http.Request, bool)
 		Msg("successfully authenticated request")
 	return r.WithContext(oidc.NewContext(r.Context(), claims)), true
 }
+
+// authenticateAppToken returns the user owning the app token used as password. It returns
+// apptoken.ErrNotFound if the password is no app token.
+func (m BasicAuthenticator) authenticateAppToken(r *http.Request, login, password string) (*userv1beta1.User, error) {
+	if m.AppTokens == nil {
+		return nil, apptoken.ErrNotFound
+	}
+	token, err := m.AppTokens.Authenticate(r.Context(), password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, _, err := m.UserProvider.GetUserByClaims(r.Context(), "username", login, false)
+	if err != nil {
+		return nil, err
+	}
+	if user.GetId().GetOpaqueId() != token.UserID {
+		return nil, errors.New("app token belongs to another user")
+	}
+	// read-only tokens fail the authentication of modifying requests
+	if !token.Allows(r.Method) {
+		return nil, errors.New("app token scope does not permit the request method")
+	}
+	return user, nil
+}
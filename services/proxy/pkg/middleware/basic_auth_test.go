@@ -4,11 +4,13 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	. "github.com/onsi/ginkgo/v2"
 
 	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/apptoken"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/ocis-pkg/oidc"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/user/backend"
@@ -65,4 +67,89 @@ var _ = Describe("Authenticating requests", Label("BasicAuthenticator"), func()
 			Expect(claims[oidc.OwncloudUUID]).To(Equal("OpaqueId"))
 		})
 	})
+
+	When("app tokens are enabled", func() {
+		BeforeEach(func() {
+			ba := authenticator.(BasicAuthenticator)
+			ba.UserProvider.(*test.UserBackendMock).GetUserByClaimsFunc = func(ctx context.Context, claim string, value string, withRoles bool) (*userv1beta1.User, string, error) {
+				if claim == "username" && value == "testuser" {
+					return &userv1beta1.User{
+						Id:       &userv1beta1.UserId{Idp: "IdpId", OpaqueId: "OpaqueId"},
+						Username: "testuser",
+					}, "", nil
+				}
+				return nil, "", backend.ErrAccountNotFound
+			}
+			ba.AppTokens = fakeAppTokens{
+				"readwrite-token": {UserID: "OpaqueId", Scope: apptoken.ScopeReadWrite},
+				"readonly-token":  {UserID: "OpaqueId", Scope: apptoken.ScopeReadOnly},
+				"other-token":     {UserID: "OtherId", Scope: apptoken.ScopeReadWrite},
+			}
+			authenticator = ba
+		})
+		It("accepts an app token in place of the password", func() {
+			req := httptest.NewRequest(http.MethodPut, "http://example.com/example/path", http.NoBody)
+			req.SetBasicAuth("testuser", "readwrite-token")
+
+			req2, valid := authenticator.Authenticate(req)
+			Expect(valid).To(Equal(true))
+			Expect(oidc.FromContext(req2.Context())[oidc.OwncloudUUID]).To(Equal("OpaqueId"))
+		})
+		It("still accepts the password", func() {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/example/path", http.NoBody)
+			req.SetBasicAuth("testuser", "testpassword")
+
+			_, valid := authenticator.Authenticate(req)
+			Expect(valid).To(Equal(true))
+		})
+		It("rejects the password if only app tokens are allowed", func() {
+			ba := authenticator.(BasicAuthenticator)
+			ba.AppTokensOnly = true
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/example/path", http.NoBody)
+			req.SetBasicAuth("testuser", "testpassword")
+
+			_, valid := ba.Authenticate(req)
+			Expect(valid).To(Equal(false))
+		})
+		It("rejects modifying requests with a read-only token", func() {
+			req := httptest.NewRequest("PROPFIND", "http://example.com/example/path", http.NoBody)
+			req.SetBasicAuth("testuser", "readonly-token")
+			_, valid := authenticator.Authenticate(req)
+			Expect(valid).To(Equal(true))
+
+			req = httptest.NewRequest(http.MethodPut, "http://example.com/example/path", http.NoBody)
+			req.SetBasicAuth("testuser", "readonly-token")
+			_, valid = authenticator.Authenticate(req)
+			Expect(valid).To(Equal(false))
+		})
+		It("rejects tokens of other users", func() {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/example/path", http.NoBody)
+			req.SetBasicAuth("testuser", "other-token")
+
+			_, valid := authenticator.Authenticate(req)
+			Expect(valid).To(Equal(false))
+		})
+	})
 })
+
+// fakeAppTokens maps token secrets to tokens
+type fakeAppTokens map[string]*apptoken.Token
+
+func (f fakeAppTokens) Create(ctx context.Context, userID, label, scope string, expiresAt time.Time) (*apptoken.Token, string, error) {
+	return nil, "", nil
+}
+
+func (f fakeAppTokens) List(ctx context.Context, userID string) ([]*apptoken.Token, error) {
+	return nil, nil
+}
+
+func (f fakeAppTokens) Delete(ctx context.Context, userID, tokenID string) error {
+	return nil
+}
+
+func (f fakeAppTokens) Authenticate(ctx context.Context, secret string) (*apptoken.Token, error) {
+	if t, ok := f[secret]; ok {
+		return t, nil
+	}
+	return nil, apptoken.ErrNotFound
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/user/backend"
+)
+
+// Supported certificate fields to identify the user by.
+const (
+	ClientCertFieldSubjectCN = "subject_cn"
+	ClientCertFieldSANEmail  = "san_email"
+	ClientCertFieldSANDNS    = "san_dns"
+	ClientCertFieldSANURI    = "san_uri"
+)
+
+// ClientCertAuthenticator is the authenticator responsible for authenticating requests with a TLS client certificate.
+type ClientCertAuthenticator struct {
+	Logger       log.Logger
+	UserProvider backend.UserBackend
+	// CertPool contains the CAs which issue the client certificates accepted for authentication.
+	CertPool *x509.CertPool
+	// UserField is the certificate field holding the value to look up the user by.
+	UserField string
+	// UserClaim is the CS3 user attribute the value of the UserField is matched against.
+	UserClaim string
+}
+
+// Authenticate implements the authenticator interface to authenticate requests via client certificates.
+func (m ClientCertAuthenticator) Authenticate(r *http.Request) (*http.Request, bool) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, false
+	}
+
+	cert, err := m.verify(r.TLS.PeerCertificates)
+	if err != nil {
+		m.Logger.Warn().
+			Err(err).
+			Str("authenticator", "client_cert").
+			Str("subject", r.TLS.PeerCertificates[0].Subject.String()).
+			Str("path", r.URL.Path).
+			Msg("invalid client certificate")
+		return nil, false
+	}
+
+	value, err := m.userValue(cert)
+	if err != nil {
+		m.Logger.Warn().
+			Err(err).
+			Str("authenticator", "client_cert").
+			Str("subject", cert.Subject.String()).
+			Str("path", r.URL.Path).
+			Msg("could not get user value from client certificate")
+		return nil, false
+	}
+
+	user, _, err := m.UserProvider.GetUserByClaims(r.Context(), m.UserClaim, value, true)
+	if err != nil {
+		m.Logger.Error().
+			Err(err).
+			Str("authenticator", "client_cert").
+			Str("subject", cert.Subject.String()).
+			Str("path", r.URL.Path).
+			Msg("Could not get user by claim")
+		return nil, false
+	}
+
+	m.Logger.Debug().
+		Str("authenticator", "client_cert").
+		Str("path", r.URL.Path).
+		Msg("successfully authenticated request")
+	return r.WithContext(revactx.ContextSetUser(r.Context(), user)), true
+}
+
+// verify checks that the leaf certificate was issued for client authentication by one of the configured CAs.
+// The remaining peer certificates are used as intermediates.
+func (m ClientCertAuthenticator) verify(peerCertificates []*x509.Certificate) (*x509.Certificate, error) {
+	if m.CertPool == nil {
+		return nil, errors.New("no client certificate CAs configured")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range peerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	leaf := peerCertificates[0]
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         m.CertPool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return leaf, nil
+}
+
+// userValue returns the value of the configured certificate field. For SAN fields the first entry is used.
+func (m ClientCertAuthenticator) userValue(cert *x509.Certificate) (string, error) {
+	var value string
+	switch m.UserField {
+	case ClientCertFieldSubjectCN, "":
+		value = cert.Subject.CommonName
+	case ClientCertFieldSANEmail:
+		if len(cert.EmailAddresses) > 0 {
+			value = cert.EmailAddresses[0]
+		}
+	case ClientCertFieldSANDNS:
+		if len(cert.DNSNames) > 0 {
+			value = cert.DNSNames[0]
+		}
+	case ClientCertFieldSANURI:
+		if len(cert.URIs) > 0 {
+			value = cert.URIs[0].String()
+		}
+	default:
+		return "", fmt.Errorf("unsupported certificate field '%s'", m.UserField)
+	}
+	if value == "" {
+		return "", fmt.Errorf("certificate field '%s' is empty", m.UserField)
+	}
+	return value, nil
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/user/backend"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/user/backend/test"
+)
+
+var _ = Describe("Authenticating requests", Label("ClientCertAuthenticator"), func() {
+	var (
+		authenticator ClientCertAuthenticator
+		caCert        *x509.Certificate
+		caKey         *ecdsa.PrivateKey
+	)
+
+	newCertificate := func(template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		Expect(err).ToNot(HaveOccurred())
+		cert, err := x509.ParseCertificate(der)
+		Expect(err).ToNot(HaveOccurred())
+		return cert, key
+	}
+
+	clientTemplate := func(cn string, usage x509.ExtKeyUsage) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:   big.NewInt(2),
+			Subject:        pkix.Name{CommonName: cn},
+			EmailAddresses: []string{cn + "@example.org"},
+			NotBefore:      time.Now().Add(-time.Hour),
+			NotAfter:       time.Now().Add(time.Hour),
+			KeyUsage:       x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:    []x509.ExtKeyUsage{usage},
+		}
+	}
+
+	requestWithCertificate := func(cert *x509.Certificate) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/remote.php/dav/spaces", http.NoBody)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		return req
+	}
+
+	BeforeEach(func() {
+		caCert, caKey = newCertificate(&x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "client CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, nil, nil)
+		pool := x509.NewCertPool()
+		pool.AddCert(caCert)
+
+		authenticator = ClientCertAuthenticator{
+			Logger:    log.NewLogger(),
+			CertPool:  pool,
+			UserField: ClientCertFieldSubjectCN,
+			UserClaim: "username",
+			UserProvider: &test.UserBackendMock{
+				GetUserByClaimsFunc: func(ctx context.Context, claim string, value string, withRoles bool) (*userv1beta1.User, string, error) {
+					if (claim == "username" && value == "einstein") || (claim == "mail" && value == "einstein@example.org") {
+						return &userv1beta1.User{
+							Id:       &userv1beta1.UserId{OpaqueId: "einstein-id"},
+							Username: "einstein",
+							Mail:     "einstein@example.org",
+						}, "", nil
+					}
+					return nil, "", backend.ErrAccountNotFound
+				},
+			},
+		}
+	})
+
+	It("ignores requests without a client certificate", func() {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/remote.php/dav/spaces", http.NoBody)
+		_, valid := authenticator.Authenticate(req)
+		Expect(valid).To(BeFalse())
+	})
+
+	It("authenticates the user from the subject common name", func() {
+		cert, _ := newCertificate(clientTemplate("einstein", x509.ExtKeyUsageClientAuth), caCert, caKey)
+
+		req, valid := authenticator.Authenticate(requestWithCertificate(cert))
+		Expect(valid).To(BeTrue())
+		user, ok := revactx.ContextGetUser(req.Context())
+		Expect(ok).To(BeTrue())
+		Expect(user.Id.OpaqueId).To(Equal("einstein-id"))
+	})
+
+	It("authenticates the user from the email SAN", func() {
+		authenticator.UserField = ClientCertFieldSANEmail
+		authenticator.UserClaim = "mail"
+		cert, _ := newCertificate(clientTemplate("einstein", x509.ExtKeyUsageClientAuth), caCert, caKey)
+
+		_, valid := authenticator.Authenticate(requestWithCertificate(cert))
+		Expect(valid).To(BeTrue())
+	})
+
+	It("rejects certificates of an unknown CA", func() {
+		otherCA, otherKey := newCertificate(&x509.Certificate{
+			SerialNumber:          big.NewInt(3),
+			Subject:               pkix.Name{CommonName: "other CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}, nil, nil)
+		cert, _ := newCertificate(clientTemplate("einstein", x509.ExtKeyUsageClientAuth), otherCA, otherKey)
+
+		_, valid := authenticator.Authenticate(requestWithCertificate(cert))
+		Expect(valid).To(BeFalse())
+	})
+
+	It("rejects certificates which are not issued for client authentication", func() {
+		cert, _ := newCertificate(clientTemplate("einstein", x509.ExtKeyUsageServerAuth), caCert, caKey)
+
+		_, valid := authenticator.Authenticate(requestWithCertificate(cert))
+		Expect(valid).To(BeFalse())
+	})
+
+	It("rejects certificates of unknown users", func() {
+		cert, _ := newCertificate(clientTemplate("marie", x509.ExtKeyUsageClientAuth), caCert, caKey)
+
+		_, valid := authenticator.Authenticate(requestWithCertificate(cert))
+		Expect(valid).To(BeFalse())
+	})
+})
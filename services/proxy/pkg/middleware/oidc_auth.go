@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -32,7 +33,7 @@ type OIDCProvider interface {
 
 // NewOIDCAuthenticator returns a ready to use authenticator which can handle OIDC authentication.
 func NewOIDCAuthenticator(logger log.Logger, tokenCacheTTL int, oidcHTTPClient *http.Client, oidcIss string, providerFunc func() (OIDCProvider, error),
-	jwksOptions config.JWKS, accessTokenVerifyMethod string) *OIDCAuthenticator {
+	jwksOptions config.JWKS, introspectionOptions config.Introspection, accessTokenVerifyMethod string) *OIDCAuthenticator {
 	tokenCache := osync.NewCache(tokenCacheTTL)
 	return &OIDCAuthenticator{
 		Logger:                  logger,
@@ -42,9 +43,11 @@ func NewOIDCAuthenticator(logger log.Logger, tokenCacheTTL int, oidcHTTPClient *
 		OIDCIss:                 oidcIss,
 		ProviderFunc:            providerFunc,
 		JWKSOptions:             jwksOptions,
+		IntrospectionOptions:    introspectionOptions,
 		AccessTokenVerifyMethod: accessTokenVerifyMethod,
 		providerLock:            &sync.Mutex{},
 		jwksLock:                &sync.Mutex{},
+		introspectionLock:       &sync.Mutex{},
 	}
 }
 
@@ -58,12 +61,16 @@ type OIDCAuthenticator struct {
 	ProviderFunc            func() (OIDCProvider, error)
 	AccessTokenVerifyMethod string
 	JWKSOptions             config.JWKS
+	IntrospectionOptions    config.Introspection
 
 	providerLock *sync.Mutex
 	provider     OIDCProvider
 
 	jwksLock *sync.Mutex
 	JWKS     *keyfunc.JWKS
+
+	introspectionLock     *sync.Mutex
+	introspectionEndpoint string
 }
 
 func (m *OIDCAuthenticator) getClaims(token string, req *http.Request) (map[string]interface{}, error) {
@@ -105,10 +112,12 @@ func (m *OIDCAuthenticator) getClaims(token string, req *http.Request) (map[stri
 	return claims, nil
 }
 
-func (m OIDCAuthenticator) verifyAccessToken(token string) (jwt.RegisteredClaims, error) {
+func (m *OIDCAuthenticator) verifyAccessToken(token string) (jwt.RegisteredClaims, error) {
 	switch m.AccessTokenVerifyMethod {
 	case config.AccessTokenVerificationJWT:
 		return m.verifyAccessTokenJWT(token)
+	case config.AccessTokenVerificationIntrospect:
+		return m.verifyAccessTokenIntrospect(token)
 	case config.AccessTokenVerificationNone:
 		m.Logger.Debug().Msg("Access Token verification disabled")
 		return jwt.RegisteredClaims{}, nil
@@ -143,6 +152,75 @@ func (m OIDCAuthenticator) verifyAccessTokenJWT(token string) (jwt.RegisteredCla
 	return claims, nil
 }
 
+// introspectionResponse is the subset of the RFC 7662 introspection response we evaluate.
+type introspectionResponse struct {
+	Active bool   `json:"active"`
+	Exp    int64  `json:"exp,omitempty"`
+	Iss    string `json:"iss,omitempty"`
+	Sub    string `json:"sub,omitempty"`
+}
+
+// verifyAccessTokenIntrospect validates the access token by calling the introspection endpoint
+// of the IDP (RFC 7662). This also works for opaque (non-JWT) access tokens.
+func (m *OIDCAuthenticator) verifyAccessTokenIntrospect(token string) (jwt.RegisteredClaims, error) {
+	var claims jwt.RegisteredClaims
+	endpoint := m.getIntrospectionEndpoint()
+	if endpoint == "" {
+		return claims, errors.New("Error discovering the introspection endpoint")
+	}
+
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", "access_token")
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return claims, errors.Wrap(err, "failed to create introspection request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if m.IntrospectionOptions.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(m.IntrospectionOptions.ClientID), url.QueryEscape(m.IntrospectionOptions.ClientSecret))
+	}
+
+	resp, err := m.HTTPClient.Do(req)
+	if err != nil {
+		return claims, errors.Wrap(err, "failed to call introspection endpoint")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return claims, errors.Wrap(err, "unable to read introspection response body")
+	}
+	if resp.StatusCode != http.StatusOK {
+		m.Logger.Error().Str("status", resp.Status).Str("body", string(body)).Msg("error requesting token introspection")
+		return claims, errors.Errorf("introspection endpoint returned status %s", resp.Status)
+	}
+
+	var ir introspectionResponse
+	if err := json.Unmarshal(body, &ir); err != nil {
+		return claims, errors.Wrap(err, "failed to decode introspection response")
+	}
+	m.Logger.Debug().Interface("introspection", ir).Msg("introspected access token")
+
+	if !ir.Active {
+		return claims, errors.New("access token is not active")
+	}
+	if ir.Iss != "" && strings.TrimSuffix(ir.Iss, "/") != strings.TrimSuffix(m.OIDCIss, "/") {
+		return claims, jwt.ErrTokenInvalidIssuer
+	}
+
+	claims.Issuer = ir.Iss
+	claims.Subject = ir.Sub
+	if ir.Exp != 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Unix(ir.Exp, 0))
+		if !claims.VerifyExpiresAt(time.Now(), true) {
+			return claims, jwt.ErrTokenExpired
+		}
+	}
+	return claims, nil
+}
+
 // extractExpiration tries to extract the expriration time from the access token
 // If the access token does not have an exp claim it will fallback to the configured
 // default expiration
@@ -164,41 +242,64 @@ func (m OIDCAuthenticator) shouldServe(req *http.Request) bool {
 	return strings.HasPrefix(header, _bearerPrefix)
 }
 
-type jwksJSON struct {
-	JWKSURL string `json:"jwks_uri"`
-}
+// getProviderMetadata fetches the discovery document from the issuer's .well-known endpoint.
+func (m *OIDCAuthenticator) getProviderMetadata() *oidc.ProviderMetadata {
+	wellKnown := strings.TrimSuffix(m.OIDCIss, "/") + "/.well-known/openid-configuration"
 
-func (m *OIDCAuthenticator) getKeyfunc() *keyfunc.JWKS {
-	m.jwksLock.Lock()
-	defer m.jwksLock.Unlock()
-	if m.JWKS == nil {
-		wellKnown := strings.TrimSuffix(m.OIDCIss, "/") + "/.well-known/openid-configuration"
+	resp, err := m.HTTPClient.Get(wellKnown)
+	if err != nil {
+		m.Logger.Error().Err(err).Msg("Failed to set request for .well-known/openid-configuration")
+		return nil
+	}
+	defer resp.Body.Close()
 
-		resp, err := m.HTTPClient.Get(wellKnown)
-		if err != nil {
-			m.Logger.Error().Err(err).Msg("Failed to set request for .well-known/openid-configuration")
-			return nil
-		}
-		defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		m.Logger.Error().Err(err).Msg("unable to read discovery response body")
+		return nil
+	}
 
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			m.Logger.Error().Err(err).Msg("unable to read discovery response body")
-			return nil
-		}
+	if resp.StatusCode != http.StatusOK {
+		m.Logger.Error().Str("status", resp.Status).Str("body", string(body)).Msg("error requesting openid-configuration")
+		return nil
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			m.Logger.Error().Str("status", resp.Status).Str("body", string(body)).Msg("error requesting openid-configuration")
-			return nil
+	var md oidc.ProviderMetadata
+	err = json.Unmarshal(body, &md)
+	if err != nil {
+		m.Logger.Error().Err(err).Msg("failed to decode provider openid-configuration")
+		return nil
+	}
+	return &md
+}
+
+func (m *OIDCAuthenticator) getIntrospectionEndpoint() string {
+	m.introspectionLock.Lock()
+	defer m.introspectionLock.Unlock()
+	if m.introspectionEndpoint == "" {
+		md := m.getProviderMetadata()
+		if md == nil {
+			return ""
 		}
+		if md.IntrospectionEndpoint == "" {
+			m.Logger.Error().Msg("the IDP does not advertise an introspection_endpoint")
+			return ""
+		}
+		m.Logger.Debug().Str("introspection_endpoint", md.IntrospectionEndpoint).Msg("discovered introspection endpoint")
+		m.introspectionEndpoint = md.IntrospectionEndpoint
+	}
+	return m.introspectionEndpoint
+}
 
-		var j jwksJSON
-		err = json.Unmarshal(body, &j)
-		if err != nil {
-			m.Logger.Error().Err(err).Msg("failed to decode provider openid-configuration")
+func (m *OIDCAuthenticator) getKeyfunc() *keyfunc.JWKS {
+	m.jwksLock.Lock()
+	defer m.jwksLock.Unlock()
+	if m.JWKS == nil {
+		md := m.getProviderMetadata()
+		if md == nil {
 			return nil
 		}
-		m.Logger.Debug().Str("jwks", j.JWKSURL).Msg("discovered jwks endpoint")
+		m.Logger.Debug().Str("jwks", md.JwksURI).Msg("discovered jwks endpoint")
 		options := keyfunc.Options{
 			Client: m.HTTPClient,
 			RefreshErrorHandler: func(err error) {
@@ -209,7 +310,8 @@ func (m *OIDCAuthenticator) getKeyfunc() *keyfunc.JWKS {
 			RefreshTimeout:    time.Second * time.Duration(m.JWKSOptions.RefreshTimeout),
 			RefreshUnknownKID: m.JWKSOptions.RefreshUnknownKID,
 		}
-		m.JWKS, err = keyfunc.Get(j.JWKSURL, options)
+		var err error
+		m.JWKS, err = keyfunc.Get(md.JwksURI, options)
 		if err != nil {
 			m.JWKS = nil
 			m.Logger.Error().Err(err).Msg("Failed to create JWKS from resource at the given URL.")
@@ -257,6 +359,10 @@ func (m *OIDCAuthenticator) Authenticate(r *http.Request) (*http.Request, bool)
 	if m.AccessTokenVerifyMethod == config.AccessTokenVerificationJWT && m.getKeyfunc() == nil {
 		return nil, false
 	}
+	// Force discovery of the introspection endpoint if needed (contacts the .well-known endpoint on first call)
+	if m.AccessTokenVerifyMethod == config.AccessTokenVerificationIntrospect && m.getIntrospectionEndpoint() == "" {
+		return nil, false
+	}
 	token := strings.TrimPrefix(r.Header.Get(_headerAuthorization), _bearerPrefix)
 
 	claims, err := m.getClaims(token, r)
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	gOidc "github.com/coreos/go-oidc/v3/oidc"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/ocis-pkg/oidc"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
+	"golang.org/x/oauth2"
+)
+
+// introspectionServer is a stand-in IDP that serves a discovery document,
+// a userinfo endpoint and an RFC 7662 introspection endpoint.
+type introspectionServer struct {
+	*httptest.Server
+	tokens         map[string]map[string]interface{}
+	introspections int32
+}
+
+func newIntrospectionServer() *introspectionServer {
+	s := &introspectionServer{tokens: map[string]map[string]interface{}{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidc.ProviderMetadata{
+			Issuer:                s.URL,
+			AuthorizationEndpoint: s.URL + "/authorize",
+			TokenEndpoint:         s.URL + "/token",
+			UserinfoEndpoint:      s.URL + "/userinfo",
+			JwksURI:               s.URL + "/jwks",
+			IntrospectionEndpoint: s.URL + "/introspect",
+		})
+	})
+	mux.HandleFunc("/introspect", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.introspections, 1)
+		if id, secret, ok := r.BasicAuth(); !ok || id != "proxy" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp, ok := s.tokens[r.PostForm.Get("token")]
+		if !ok {
+			resp = map[string]interface{}{"active": false}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			oidc.Sub:               "einstein",
+			oidc.PreferredUsername: "einstein",
+			oidc.Email:             "einstein@example.org",
+		})
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+var _ = Describe("Authenticating requests", Label("OIDCAuthenticator"), func() {
+	var (
+		idp           *introspectionServer
+		authenticator *OIDCAuthenticator
+	)
+
+	BeforeEach(func() {
+		idp = newIntrospectionServer()
+		idp.tokens["active-token"] = map[string]interface{}{
+			"active": true,
+			"iss":    idp.URL,
+			"sub":    "einstein",
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		}
+		idp.tokens["expired-token"] = map[string]interface{}{
+			"active": true,
+			"iss":    idp.URL,
+			"exp":    time.Now().Add(-time.Hour).Unix(),
+		}
+		idp.tokens["foreign-token"] = map[string]interface{}{
+			"active": true,
+			"iss":    "https://other.example.org",
+		}
+
+		client := idp.Client()
+		authenticator = NewOIDCAuthenticator(
+			log.NewLogger(),
+			10,
+			client,
+			idp.URL,
+			func() (OIDCProvider, error) {
+				return gOidc.NewProvider(context.WithValue(context.Background(), oauth2.HTTPClient, client), idp.URL)
+			},
+			config.JWKS{},
+			config.Introspection{ClientID: "proxy", ClientSecret: "secret"},
+			config.AccessTokenVerificationIntrospect,
+		)
+	})
+
+	AfterEach(func() {
+		idp.Close()
+	})
+
+	newRequest := func(token string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/example/path", http.NoBody)
+		req.Header.Set(_headerAuthorization, _bearerPrefix+token)
+		return req
+	}
+
+	When("the access token is active", func() {
+		It("should successfully authenticate and add the userinfo claims to the context", func() {
+			req, valid := authenticator.Authenticate(newRequest("active-token"))
+			Expect(valid).To(BeTrue())
+			Expect(req).ToNot(BeNil())
+
+			claims := oidc.FromContext(req.Context())
+			Expect(claims[oidc.PreferredUsername]).To(Equal("einstein"))
+		})
+		It("should cache the introspection result", func() {
+			_, valid := authenticator.Authenticate(newRequest("active-token"))
+			Expect(valid).To(BeTrue())
+			_, valid = authenticator.Authenticate(newRequest("active-token"))
+			Expect(valid).To(BeTrue())
+			Expect(atomic.LoadInt32(&idp.introspections)).To(Equal(int32(1)))
+		})
+	})
+
+	When("the access token is not valid", func() {
+		It("should reject unknown tokens", func() {
+			_, valid := authenticator.Authenticate(newRequest("unknown-token"))
+			Expect(valid).To(BeFalse())
+		})
+		It("should reject expired tokens", func() {
+			_, valid := authenticator.Authenticate(newRequest("expired-token"))
+			Expect(valid).To(BeFalse())
+		})
+		It("should reject tokens of a different issuer", func() {
+			_, valid := authenticator.Authenticate(newRequest("foreign-token"))
+			Expect(valid).To(BeFalse())
+		})
+		It("should not cache rejected tokens", func() {
+			_, _ = authenticator.Authenticate(newRequest("unknown-token"))
+			_, _ = authenticator.Authenticate(newRequest("unknown-token"))
+			Expect(atomic.LoadInt32(&idp.introspections)).To(Equal(int32(2)))
+		})
+	})
+
+	When("the proxy uses wrong client credentials", func() {
+		It("should not authenticate", func() {
+			authenticator.IntrospectionOptions.ClientSecret = "wrong"
+			_, valid := authenticator.Authenticate(newRequest("active-token"))
+			Expect(valid).To(BeFalse())
+		})
+	})
+})
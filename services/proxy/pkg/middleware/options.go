@@ -12,6 +12,7 @@ import (
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/metrics"
 )
 
 // Option defines a single option function.
@@ -29,6 +30,8 @@ type Options struct {
 	HTTPClient *http.Client
 	// UP
 	UserProvider backend.UserBackend
+	// UserRoleAssigner to keep the role of a user in sync with the claims, may be nil
+	UserRoleAssigner backend.UserRoleAssigner
 	// SettingsRoleService for the roles API in settings
 	SettingsRoleService settingssvc.RoleService
 	// OIDCProviderFunc to lazily initialize an oidc provider, must be set for the oidc_auth middleware
@@ -60,6 +63,10 @@ type Options struct {
 	AccessTokenVerifyMethod string
 	// JWKS sets the options for fetching the JWKS from the IDP
 	JWKS config.JWKS
+	// RateLimit configures the default rate limit of the rate_limit middleware
+	RateLimit config.RateLimit
+	// Metrics to record e.g. rejected requests
+	Metrics *metrics.Metrics
 }
 
 // newOptions initializes the available default options.
@@ -199,6 +206,13 @@ func UserProvider(up backend.UserBackend) Option {
 	}
 }
 
+// UserRoleAssigner sets the role assigner which keeps the role of a user in sync with the claims
+func UserRoleAssigner(ra backend.UserRoleAssigner) Option {
+	return func(o *Options) {
+		o.UserRoleAssigner = ra
+	}
+}
+
 // AccessTokenVerifyMethod set the mechanism for access token verification
 func AccessTokenVerifyMethod(method string) Option {
 	return func(o *Options) {
@@ -212,3 +226,17 @@ func JWKSOptions(jo config.JWKS) Option {
 		o.JWKS = jo
 	}
 }
+
+// RateLimitConfig provides a function to set the RateLimit option.
+func RateLimitConfig(cfg config.RateLimit) Option {
+	return func(o *Options) {
+		o.RateLimit = cfg
+	}
+}
+
+// Metrics provides a function to set the Metrics option.
+func Metrics(m *metrics.Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = m
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/metrics"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/router"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// limiters which have not been used for this duration are evicted
+	_rateLimiterIdleTimeout = 10 * time.Minute
+	_clientTypeUser         = "user"
+	_clientTypeIP           = "ip"
+)
+
+// RateLimiter provides a middleware which throttles clients using a token bucket per client.
+// Clients are identified by their user id or by their remote address for unauthenticated requests.
+// It needs to run after the authentication and the account resolver middlewares.
+func RateLimiter(optionSetters ...Option) func(next http.Handler) http.Handler {
+	options := newOptions(optionSetters...)
+
+	return func(next http.Handler) http.Handler {
+		if !options.RateLimit.Enabled {
+			return next
+		}
+		return &rateLimiter{
+			next:     next,
+			logger:   options.Logger,
+			metrics:  options.Metrics,
+			defaults: options.RateLimit,
+			limiters: make(map[string]*limiterEntry),
+			lastGC:   time.Now(),
+		}
+	}
+}
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type rateLimiter struct {
+	next     http.Handler
+	logger   log.Logger
+	metrics  *metrics.Metrics
+	defaults config.RateLimit
+
+	mu       sync.Mutex
+	limiters map[string]*limiterEntry
+	lastGC   time.Time
+}
+
+func (m *rateLimiter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ri := router.ContextRoutingInfo(req.Context())
+	rule, ok := ri.RateLimit(req.Method)
+	if !ok {
+		if m.defaults.Rate <= 0 {
+			m.next.ServeHTTP(w, req)
+			return
+		}
+		rule = config.RateLimitRule{Rate: m.defaults.Rate, Burst: m.defaults.Burst}
+	}
+
+	clientType, client := clientKey(req)
+	key := clientType + ":" + client + "|" + ri.Endpoint() + "|" + rule.Method
+
+	reservation := m.getLimiter(key, rule).Reserve()
+	delay := reservation.Delay()
+	if reservation.OK() && delay == 0 {
+		m.next.ServeHTTP(w, req)
+		return
+	}
+	reservation.Cancel()
+
+	if delay == rate.InfDuration || !reservation.OK() {
+		delay = time.Second
+	}
+	m.logger.Debug().
+		Str("client", client).
+		Str("endpoint", ri.Endpoint()).
+		Str("method", req.Method).
+		Dur("retry_after", delay).
+		Msg("rate limit exceeded")
+	if m.metrics != nil {
+		m.metrics.RateLimited.WithLabelValues(ri.Endpoint(), req.Method, clientType).Inc()
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+}
+
+func (m *rateLimiter) getLimiter(key string, rule config.RateLimitRule) *rate.Limiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(m.lastGC) > _rateLimiterIdleTimeout {
+		for k, e := range m.limiters {
+			if now.Sub(e.lastSeen) > _rateLimiterIdleTimeout {
+				delete(m.limiters, k)
+			}
+		}
+		m.lastGC = now
+	}
+
+	e, ok := m.limiters[key]
+	if !ok {
+		burst := rule.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		e = &limiterEntry{limiter: rate.NewLimiter(rate.Limit(rule.Rate), burst)}
+		m.limiters[key] = e
+	}
+	e.lastSeen = now
+	return e.limiter
+}
+
+// clientKey identifies the client by the user id of the authenticated user or by the remote address.
+func clientKey(req *http.Request) (string, string) {
+	if u, ok := revactx.ContextGetUser(req.Context()); ok && u.GetId().GetOpaqueId() != "" {
+		return _clientTypeUser, u.GetId().GetOpaqueId()
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		// chi's RealIP middleware sets the RemoteAddr without a port
+		host = req.RemoteAddr
+	}
+	return _clientTypeIP, host
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	revactx "github.com/cs3org/reva/v2/pkg/ctx"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/router"
+)
+
+var _ = Describe("Rate limiting requests", Label("RateLimiter"), func() {
+	var (
+		handler http.Handler
+		rt      router.Router
+	)
+
+	BeforeEach(func() {
+		rt = router.New(
+			&config.PolicySelector{Static: &config.StaticSelectorConf{Policy: "ocis"}},
+			[]config.Policy{{
+				Name: "ocis",
+				Routes: []config.Route{
+					{Endpoint: "/", Backend: "http://web"},
+					{Endpoint: "/dav", Backend: "http://ocdav", RateLimits: []config.RateLimitRule{
+						{Method: "PROPFIND", Rate: 0.001, Burst: 2},
+					}},
+					{Endpoint: "/ocs", Backend: "http://ocs"},
+				},
+				RateLimits: []config.RateLimitRule{{Rate: 0.001, Burst: 1}},
+			}},
+			nil,
+			log.NewLogger(),
+		)
+
+		handler = RateLimiter(
+			Logger(log.NewLogger()),
+			RateLimitConfig(config.RateLimit{Enabled: true}),
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+
+	serve := func(method, path, remoteAddr, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://example.com"+path, http.NoBody)
+		req.RemoteAddr = remoteAddr
+		ri, ok := rt.Route(req)
+		Expect(ok).To(BeTrue())
+		ctx := router.SetRoutingInfo(req.Context(), ri)
+		if userID != "" {
+			ctx = revactx.ContextSetUser(ctx, &userv1beta1.User{Id: &userv1beta1.UserId{OpaqueId: userID}})
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req.WithContext(ctx))
+		return rec
+	}
+
+	It("rejects clients that exceed the burst of the route with a Retry-After header", func() {
+		Expect(serve("PROPFIND", "/dav/files", "10.0.0.1:1234", "einstein").Code).To(Equal(http.StatusOK))
+		Expect(serve("PROPFIND", "/dav/files", "10.0.0.1:1234", "einstein").Code).To(Equal(http.StatusOK))
+
+		rec := serve("PROPFIND", "/dav/files", "10.0.0.1:1234", "einstein")
+		Expect(rec.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(rec.Header().Get("Retry-After")).ToNot(BeEmpty())
+	})
+
+	It("keeps a bucket per user", func() {
+		Expect(serve("PROPFIND", "/dav/files", "10.0.0.1:1234", "einstein").Code).To(Equal(http.StatusOK))
+		Expect(serve("PROPFIND", "/dav/files", "10.0.0.1:1234", "einstein").Code).To(Equal(http.StatusOK))
+		Expect(serve("PROPFIND", "/dav/files", "10.0.0.1:1234", "marie").Code).To(Equal(http.StatusOK))
+	})
+
+	It("keeps a bucket per remote address for unauthenticated requests", func() {
+		Expect(serve("GET", "/ocs/config", "10.0.0.1:1234", "").Code).To(Equal(http.StatusOK))
+		Expect(serve("GET", "/ocs/config", "10.0.0.1:4321", "").Code).To(Equal(http.StatusTooManyRequests))
+		Expect(serve("GET", "/ocs/config", "10.0.0.2:1234", "").Code).To(Equal(http.StatusOK))
+	})
+
+	It("falls back to the policy rate limits for other methods and routes", func() {
+		Expect(serve("GET", "/dav/files", "10.0.0.1:1234", "einstein").Code).To(Equal(http.StatusOK))
+		Expect(serve("GET", "/dav/files", "10.0.0.1:1234", "einstein").Code).To(Equal(http.StatusTooManyRequests))
+	})
+
+	It("does not limit anything when disabled", func() {
+		handler = RateLimiter(
+			Logger(log.NewLogger()),
+			RateLimitConfig(config.RateLimit{Enabled: false}),
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		for i := 0; i < 5; i++ {
+			Expect(serve("PROPFIND", "/dav/files", "10.0.0.1:1234", "einstein").Code).To(Equal(http.StatusOK))
+		}
+	})
+})
@@ -1,6 +1,7 @@
 package policy
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -13,9 +14,9 @@ import (
 
 var (
 	// ErrMultipleSelectors in case there is more then one selector configured.
-	ErrMultipleSelectors = fmt.Errorf("only one type of policy-selector (static, migration, claim or regex) can be configured")
+	ErrMultipleSelectors = fmt.Errorf("only one type of policy-selector (static, migration, claim, regex or request) can be configured")
 	// ErrSelectorConfigIncomplete if policy_selector conf is missing
-	ErrSelectorConfigIncomplete = fmt.Errorf("missing either \"static\", \"migration\", \"claim\" or \"regex\" configuration in policy_selector config ")
+	ErrSelectorConfigIncomplete = fmt.Errorf("missing either \"static\", \"migration\", \"claim\", \"regex\" or \"request\" configuration in policy_selector config ")
 	// ErrUnexpectedConfigError unexpected config error
 	ErrUnexpectedConfigError = fmt.Errorf("could not initialize policy-selector for given config")
 )
@@ -64,11 +65,14 @@ func LoadSelector(cfg *config.PolicySelector) (Selector, error) {
 	if cfg.Regex != nil {
 		selCount++
 	}
+	if cfg.Request != nil {
+		selCount++
+	}
 	if selCount > 1 {
 		return nil, ErrMultipleSelectors
 	}
 
-	if cfg.Static == nil && cfg.Claims == nil && cfg.Regex == nil {
+	if cfg.Static == nil && cfg.Claims == nil && cfg.Regex == nil && cfg.Request == nil {
 		return nil, ErrSelectorConfigIncomplete
 	}
 
@@ -90,6 +94,13 @@ func LoadSelector(cfg *config.PolicySelector) (Selector, error) {
 		return NewRegexSelector(cfg.Regex), nil
 	}
 
+	if cfg.Request != nil {
+		if cfg.Request.SelectorCookieName == "" {
+			cfg.Request.SelectorCookieName = SelectorCookieName
+		}
+		return NewRequestSelector(cfg.Request), nil
+	}
+
 	return nil, ErrUnexpectedConfigError
 }
 
@@ -218,3 +229,108 @@ type regexRule struct {
 	rule     *regexp.Regexp
 	policy   string
 }
+
+// NewRequestSelector selects the policy based on properties of the request
+// The policy for each case is configurable:
+// "policy_selector": {
+//    "request": {
+//      "matches_policies": [
+//        {"priority": 10, "source": "host", "match": "^tenant-a\\.example\\.org$", "policy": "tenant-a"},
+//        {"priority": 20, "source": "header", "header": "X-Tenant", "match": "^b$", "policy": "tenant-b"},
+//        {"priority": 30, "source": "client_cert_subject", "match": "O=Tenant C", "policy": "tenant-c"},
+//        {"priority": 40, "source": "client_cert_san", "match": "\\.tenant-d\\.example\\.org$", "policy": "tenant-d"}
+//      ],
+//      "default_policy": "ocis",
+//      "unauthenticated_policy": "ocis"
+//    }
+//  },
+//
+// The rules are evaluated for authenticated and unauthenticated requests. Only client certificates that were
+// verified by the TLS server are taken into account.
+func NewRequestSelector(cfg *config.RequestSelectorConf) Selector {
+	requestRules := []*requestRule{}
+	sort.SliceStable(cfg.MatchesPolicies, func(i, j int) bool {
+		return cfg.MatchesPolicies[i].Priority < cfg.MatchesPolicies[j].Priority
+	})
+	for i := range cfg.MatchesPolicies {
+		requestRules = append(requestRules, &requestRule{
+			source: cfg.MatchesPolicies[i].Source,
+			header: cfg.MatchesPolicies[i].Header,
+			rule:   regexp.MustCompile(cfg.MatchesPolicies[i].Match),
+			policy: cfg.MatchesPolicies[i].Policy,
+		})
+	}
+	return func(r *http.Request) (s string, err error) {
+		// use cookie first if provided
+		selectorCookie, err := r.Cookie(cfg.SelectorCookieName)
+		if err == nil {
+			return selectorCookie.Value, nil
+		}
+
+		for i := range requestRules {
+			if requestRules[i].matches(r) {
+				return requestRules[i].policy, nil
+			}
+		}
+
+		if _, ok := revactx.ContextGetUser(r.Context()); ok {
+			return cfg.DefaultPolicy, nil
+		}
+		return cfg.UnauthenticatedPolicy, nil
+	}
+}
+
+type requestRule struct {
+	source string
+	header string
+	rule   *regexp.Regexp
+	policy string
+}
+
+func (rr requestRule) matches(r *http.Request) bool {
+	switch rr.source {
+	case "host":
+		return rr.rule.MatchString(r.Host)
+	case "header":
+		for _, v := range r.Header.Values(rr.header) {
+			if rr.rule.MatchString(v) {
+				return true
+			}
+		}
+	case "client_cert_subject":
+		if cert := verifiedClientCertificate(r); cert != nil {
+			return rr.rule.MatchString(cert.Subject.String())
+		}
+	case "client_cert_san":
+		if cert := verifiedClientCertificate(r); cert != nil {
+			for _, san := range certificateSANs(cert) {
+				if rr.rule.MatchString(san) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// verifiedClientCertificate returns the leaf certificate of the first verified client certificate chain.
+func verifiedClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// certificateSANs returns all subject alternative names of the certificate as strings.
+func certificateSANs(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.URIs)+len(cert.IPAddresses))
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		sans = append(sans, u.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	return sans
+}
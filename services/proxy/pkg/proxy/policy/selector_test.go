@@ -2,6 +2,9 @@ package policy
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -20,13 +23,16 @@ func TestLoadSelector(t *testing.T) {
 	sCfg := &config.StaticSelectorConf{Policy: "reva"}
 	ccfg := &config.ClaimsSelectorConf{}
 	rcfg := &config.RegexSelectorConf{}
+	reqcfg := &config.RequestSelectorConf{}
 
 	table := []test{
 		{cfg: &config.PolicySelector{Static: sCfg, Claims: ccfg, Regex: rcfg}, expectedErr: ErrMultipleSelectors},
+		{cfg: &config.PolicySelector{Regex: rcfg, Request: reqcfg}, expectedErr: ErrMultipleSelectors},
 		{cfg: &config.PolicySelector{}, expectedErr: ErrSelectorConfigIncomplete},
 		{cfg: &config.PolicySelector{Static: sCfg}, expectedErr: nil},
 		{cfg: &config.PolicySelector{Claims: ccfg}, expectedErr: nil},
 		{cfg: &config.PolicySelector{Regex: rcfg}, expectedErr: nil},
+		{cfg: &config.PolicySelector{Request: reqcfg}, expectedErr: nil},
 	}
 
 	for _, test := range table {
@@ -143,3 +149,65 @@ func TestRegexSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestSelector(t *testing.T) {
+	sel := NewRequestSelector(&config.RequestSelectorConf{
+		DefaultPolicy: "default",
+		MatchesPolicies: []config.RequestRuleConf{
+			{Priority: 40, Source: "client_cert_san", Match: `\.tenant-d\.example\.org$`, Policy: "tenant-d"},
+			{Priority: 10, Source: "host", Match: `^tenant-a\.example\.org$`, Policy: "tenant-a"},
+			{Priority: 20, Source: "header", Header: "X-Tenant", Match: "^b$", Policy: "tenant-b"},
+			{Priority: 30, Source: "client_cert_subject", Match: "O=Tenant C", Policy: "tenant-c"},
+		},
+		UnauthenticatedPolicy: "unauthenticated",
+		SelectorCookieName:    SelectorCookieName,
+	})
+
+	withCert := func(cert *x509.Certificate) func(r *http.Request) {
+		return func(r *http.Request) {
+			r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+	}
+	authenticated := revactx.ContextSetUser(context.Background(), &userv1beta1.User{Username: "einstein"})
+
+	var tests = []struct {
+		name     string
+		ctx      context.Context
+		host     string
+		modify   func(r *http.Request)
+		expected string
+	}{
+		{"unauthenticated", context.Background(), "example.com", nil, "unauthenticated"},
+		{"default", authenticated, "example.com", nil, "default"},
+		{"host-unauthenticated", context.Background(), "tenant-a.example.org", nil, "tenant-a"},
+		{"host-authenticated", authenticated, "tenant-a.example.org", nil, "tenant-a"},
+		{"header", authenticated, "example.com", func(r *http.Request) { r.Header.Set("X-Tenant", "b") }, "tenant-b"},
+		{"host-before-header", authenticated, "tenant-a.example.org", func(r *http.Request) { r.Header.Set("X-Tenant", "b") }, "tenant-a"},
+		{"cert-subject", authenticated, "example.com", withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "backup", Organization: []string{"Tenant C"}}}), "tenant-c"},
+		{"cert-san", authenticated, "example.com", withCert(&x509.Certificate{DNSNames: []string{"robot.tenant-d.example.org"}}), "tenant-d"},
+		{"unverified-cert", authenticated, "example.com", func(r *http.Request) {
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{DNSNames: []string{"robot.tenant-d.example.org"}}}}
+		}, "default"},
+		{"cookie-first", authenticated, "tenant-a.example.org", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: SelectorCookieName, Value: "cookie"})
+		}, "cookie"},
+	}
+
+	for _, tc := range tests {
+		tc := tc // capture range variable
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "https://"+tc.host, nil)
+			if tc.modify != nil {
+				tc.modify(r)
+			}
+			got, err := sel(r.WithContext(tc.ctx))
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if got != tc.expected {
+				t.Errorf("Expected Policy %v got %v", tc.expected, got)
+			}
+		})
+	}
+}
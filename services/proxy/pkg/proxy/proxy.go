@@ -1,7 +1,9 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -16,6 +18,7 @@ import (
 	pkgtrace "github.com/owncloud/ocis/v2/ocis-pkg/tracing"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/proxy/policy"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/proxy/upstream"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/router"
 	proxytracing "github.com/owncloud/ocis/v2/services/proxy/pkg/tracing"
 	"go.opentelemetry.io/otel/propagation"
@@ -30,6 +33,7 @@ type MultiHostReverseProxy struct {
 	PolicySelector policy.Selector
 	logger         log.Logger
 	config         *config.Config
+	tracker        *upstream.Tracker
 }
 
 // NewMultiHostReverseProxy creates a new MultiHostReverseProxy
@@ -40,6 +44,7 @@ func NewMultiHostReverseProxy(opts ...Option) *MultiHostReverseProxy {
 		Directors: make(map[string]map[config.RouteType]map[string]map[string]func(req *http.Request)),
 		logger:    options.Logger,
 		config:    options.Config,
+		tracker:   options.Tracker,
 	}
 
 	rp.Director = func(r *http.Request) {
@@ -47,6 +52,23 @@ func NewMultiHostReverseProxy(opts ...Option) *MultiHostReverseProxy {
 		ri.Director()(r)
 	}
 
+	if rp.tracker != nil {
+		rp.ModifyResponse = func(resp *http.Response) error {
+			rp.tracker.ReportResponse(resp.Request.URL, resp.StatusCode)
+			return nil
+		}
+		rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, context.Canceled) {
+				// the client went away, this is no failure of the backend
+				rp.logger.Debug().Err(err).Str("backend", r.URL.Host).Msg("request canceled by the client")
+				return
+			}
+			rp.tracker.ReportError(r.URL, err)
+			rp.logger.Error().Err(err).Str("backend", r.URL.Host).Msg("error proxying the request")
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}
+
 	// equals http.DefaultTransport except TLSClientConfig
 	rp.Transport = &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
@@ -110,6 +110,16 @@ func TestProxyIntegration(t *testing.T) {
 			},
 		})).withRequest("GET", "https://example.com/user/1234", nil).
 			expectProxyTo("http://users.example.com/user/1234"),
+
+		// Weighted backends
+		test("weighted_backends", withPolicy("ocis", withRoutes{{
+			Type:     config.PrefixRoute,
+			Endpoint: "/api",
+			Backends: []config.WeightedBackend{
+				{URL: "http://api.example.com/service1/", Weight: 10},
+			}},
+		})).withRequest("GET", "https://example.com/api", nil).
+			expectProxyTo("http://api.example.com/service1/api"),
 	}
 
 	for k := range tests {
@@ -117,7 +127,7 @@ func TestProxyIntegration(t *testing.T) {
 			t.Parallel()
 			tc := tests[k]
 
-			rt := router.Middleware(nil, tc.conf, log.NewLogger())
+			rt := router.Middleware(nil, tc.conf, nil, log.NewLogger())
 			rp := newTestProxy(testConfig(tc.conf), func(req *http.Request) *http.Response {
 				if got, want := req.URL.String(), tc.expect.String(); got != want {
 					t.Errorf("Proxied url should be %v got %v", want, got)
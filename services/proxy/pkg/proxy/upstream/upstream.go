@@ -0,0 +1,261 @@
+// Package upstream keeps track of the health of the static backends the proxy forwards to
+// and selects one of several weighted backends of a route.
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+)
+
+// Backend is a static backend of a route.
+type Backend struct {
+	URL    *url.URL
+	Weight int
+}
+
+// State describes the health of a single backend.
+type State struct {
+	URL                 string    `json:"url"`
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Requests            uint64    `json:"requests"`
+	Failures            uint64    `json:"failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastChange          time.Time `json:"last_change"`
+}
+
+// Tracker passively tracks the health of backends based on the responses the proxy receives from them.
+// After a configurable number of consecutive 5xx responses or connection errors a backend is marked as down
+// and is actively probed until it responds again.
+type Tracker struct {
+	logger           log.Logger
+	failureThreshold int
+	probeInterval    time.Duration
+	probePath        string
+	client           *http.Client
+
+	mu     sync.RWMutex
+	states map[string]*State
+}
+
+// NewTracker returns a new backend health tracker. A failureThreshold of 0 disables the health tracking,
+// all backends are considered healthy then.
+func NewTracker(logger log.Logger, failureThreshold int, probeInterval time.Duration, probePath string, client *http.Client) *Tracker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Tracker{
+		logger:           logger,
+		failureThreshold: failureThreshold,
+		probeInterval:    probeInterval,
+		probePath:        probePath,
+		client:           client,
+		states:           make(map[string]*State),
+	}
+}
+
+func key(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+// Register starts tracking the health of the given backend.
+func (t *Tracker) Register(u *url.URL) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	k := key(u)
+	if _, ok := t.states[k]; !ok {
+		t.states[k] = &State{URL: k, Healthy: true, LastChange: time.Now()}
+	}
+}
+
+// Healthy returns false if the backend is currently marked as down. Unknown backends are considered healthy.
+func (t *Tracker) Healthy(u *url.URL) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if s, ok := t.states[key(u)]; ok {
+		return s.Healthy
+	}
+	return true
+}
+
+// ReportResponse records the status code a backend responded with.
+func (t *Tracker) ReportResponse(u *url.URL, status int) {
+	if status >= http.StatusInternalServerError {
+		t.report(u, http.StatusText(status))
+		return
+	}
+	t.report(u, "")
+}
+
+// ReportError records a failed request to a backend, e.g. a connection error.
+func (t *Tracker) ReportError(u *url.URL, err error) {
+	msg := "unknown error"
+	if err != nil {
+		msg = err.Error()
+	}
+	t.report(u, msg)
+}
+
+func (t *Tracker) report(u *url.URL, failure string) {
+	if t == nil || u == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.states[key(u)]
+	if !ok {
+		return
+	}
+	s.Requests++
+	if failure == "" {
+		s.ConsecutiveFailures = 0
+		if !s.Healthy {
+			t.markHealthy(s)
+		}
+		return
+	}
+	s.Failures++
+	s.ConsecutiveFailures++
+	s.LastError = failure
+	if s.Healthy && t.failureThreshold > 0 && s.ConsecutiveFailures >= t.failureThreshold {
+		s.Healthy = false
+		s.LastChange = time.Now()
+		t.logger.Warn().
+			Str("backend", s.URL).
+			Int("consecutive_failures", s.ConsecutiveFailures).
+			Str("last_error", s.LastError).
+			Msg("marking backend as down")
+	}
+}
+
+// markHealthy must be called with the lock held.
+func (t *Tracker) markHealthy(s *State) {
+	s.Healthy = true
+	s.ConsecutiveFailures = 0
+	s.LastChange = time.Now()
+	t.logger.Info().Str("backend", s.URL).Msg("marking backend as up")
+}
+
+// States returns a snapshot of the health of all tracked backends.
+func (t *Tracker) States() []State {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	states := make([]State, 0, len(t.states))
+	for _, s := range t.states {
+		states = append(states, *s)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].URL < states[j].URL })
+	return states
+}
+
+// ServeHTTP writes the health of all tracked backends as JSON.
+func (t *Tracker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	b, err := json.Marshal(t.States())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// Run probes the backends that are marked as down until the context is cancelled.
+func (t *Tracker) Run(ctx context.Context) error {
+	if t.failureThreshold <= 0 || t.probeInterval <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+	ticker := time.NewTicker(t.probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			t.probe(ctx)
+		}
+	}
+}
+
+func (t *Tracker) probe(ctx context.Context) {
+	t.mu.RLock()
+	var down []string
+	for k, s := range t.states {
+		if !s.Healthy {
+			down = append(down, k)
+		}
+	}
+	t.mu.RUnlock()
+
+	for _, k := range down {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, k+t.probePath, http.NoBody)
+		if err != nil {
+			continue
+		}
+		resp, err := t.client.Do(req)
+		if err != nil {
+			t.logger.Debug().Err(err).Str("backend", k).Msg("backend probe failed")
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			t.logger.Debug().Int("status", resp.StatusCode).Str("backend", k).Msg("backend probe failed")
+			continue
+		}
+		t.mu.Lock()
+		if s, ok := t.states[k]; ok && !s.Healthy {
+			t.markHealthy(s)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Select picks one of the backends at random according to their weights. Backends that are marked
+// as down are skipped. If all backends are down it falls back to selecting among all of them.
+func (t *Tracker) Select(backends []Backend) Backend {
+	if len(backends) == 1 {
+		return backends[0]
+	}
+	candidates := make([]Backend, 0, len(backends))
+	for _, b := range backends {
+		if t.Healthy(b.URL) {
+			candidates = append(candidates, b)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = backends
+	}
+
+	total := 0
+	for _, b := range candidates {
+		total += b.Weight
+	}
+	if total <= 0 {
+		return candidates[rand.Intn(len(candidates))] //nolint:gosec
+	}
+	n := rand.Intn(total) //nolint:gosec
+	for _, b := range candidates {
+		if n < b.Weight {
+			return b
+		}
+		n -= b.Weight
+	}
+	return candidates[len(candidates)-1]
+}
@@ -0,0 +1,129 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+)
+
+func mustParse(t *testing.T, s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestTrackerMarksBackendDown(t *testing.T) {
+	tracker := NewTracker(log.NewLogger(), 3, time.Second, "/", nil)
+	u := mustParse(t, "http://web:9100/path")
+	tracker.Register(u)
+
+	tracker.ReportResponse(u, http.StatusBadGateway)
+	tracker.ReportError(u, errors.New("connection refused"))
+	if !tracker.Healthy(u) {
+		t.Fatal("backend should still be healthy below the failure threshold")
+	}
+
+	// a successful response resets the consecutive failures
+	tracker.ReportResponse(u, http.StatusNotFound)
+	tracker.ReportResponse(u, http.StatusInternalServerError)
+	tracker.ReportResponse(u, http.StatusInternalServerError)
+	if !tracker.Healthy(u) {
+		t.Fatal("backend should be healthy after the failure counter was reset")
+	}
+
+	tracker.ReportResponse(u, http.StatusServiceUnavailable)
+	if tracker.Healthy(u) {
+		t.Fatal("backend should be marked as down")
+	}
+
+	states := tracker.States()
+	if len(states) != 1 {
+		t.Fatalf("expected 1 tracked backend got %d", len(states))
+	}
+	if states[0].URL != "http://web:9100" || states[0].Requests != 6 || states[0].Failures != 5 {
+		t.Errorf("unexpected backend state %+v", states[0])
+	}
+}
+
+func TestTrackerDisabled(t *testing.T) {
+	tracker := NewTracker(log.NewLogger(), 0, time.Second, "/", nil)
+	u := mustParse(t, "http://web:9100")
+	tracker.Register(u)
+	for i := 0; i < 10; i++ {
+		tracker.ReportError(u, errors.New("connection refused"))
+	}
+	if !tracker.Healthy(u) {
+		t.Fatal("backend should never be marked as down when health tracking is disabled")
+	}
+}
+
+func TestSelectSkipsUnhealthyBackends(t *testing.T) {
+	tracker := NewTracker(log.NewLogger(), 1, time.Second, "/", nil)
+	stock := Backend{URL: mustParse(t, "http://stock"), Weight: 1}
+	canary := Backend{URL: mustParse(t, "http://canary"), Weight: 1}
+	tracker.Register(stock.URL)
+	tracker.Register(canary.URL)
+
+	tracker.ReportResponse(canary.URL, http.StatusInternalServerError)
+	for i := 0; i < 100; i++ {
+		if b := tracker.Select([]Backend{stock, canary}); b.URL.Host != "stock" {
+			t.Fatalf("selected unhealthy backend %s", b.URL)
+		}
+	}
+
+	// if all backends are down we still have to forward the request somewhere
+	tracker.ReportResponse(stock.URL, http.StatusInternalServerError)
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		seen[tracker.Select([]Backend{stock, canary}).URL.Host] = true
+	}
+	if !seen["stock"] || !seen["canary"] {
+		t.Errorf("expected to fall back to all backends got %v", seen)
+	}
+}
+
+func TestSelectHonoursWeights(t *testing.T) {
+	var tracker *Tracker
+	stock := Backend{URL: mustParse(t, "http://stock"), Weight: 9}
+	canary := Backend{URL: mustParse(t, "http://canary"), Weight: 1}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[tracker.Select([]Backend{stock, canary}).URL.Host]++
+	}
+	if counts["canary"] < 500 || counts["canary"] > 1500 {
+		t.Errorf("expected about 10%% of the requests on the canary got %d of 10000", counts["canary"])
+	}
+}
+
+func TestProbeMarksBackendUp(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer svr.Close()
+
+	tracker := NewTracker(log.NewLogger(), 1, time.Second, "/healthz", svr.Client())
+	u := mustParse(t, svr.URL)
+	tracker.Register(u)
+	tracker.ReportError(u, errors.New("connection refused"))
+
+	tracker.probe(context.Background())
+	if tracker.Healthy(u) {
+		t.Fatal("backend should still be down")
+	}
+
+	status = http.StatusOK
+	tracker.probe(context.Background())
+	if !tracker.Healthy(u) {
+		t.Fatal("backend should be up again")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/owncloud/ocis/v2/ocis-pkg/registry"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/proxy/policy"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/proxy/upstream"
 	"go-micro.dev/v4/selector"
 )
 
@@ -19,8 +20,8 @@ type routingInfoCtxKey struct{}
 var noInfo = RoutingInfo{}
 
 // Middleware returns a HTTP middleware containing the router.
-func Middleware(policySelector *config.PolicySelector, policies []config.Policy, logger log.Logger) func(http.Handler) http.Handler {
-	router := New(policySelector, policies, logger)
+func Middleware(policySelector *config.PolicySelector, policies []config.Policy, tracker *upstream.Tracker, logger log.Logger) func(http.Handler) http.Handler {
+	router := New(policySelector, policies, tracker, logger)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ri, ok := router.Route(r)
@@ -35,7 +36,8 @@ func Middleware(policySelector *config.PolicySelector, policies []config.Policy,
 
 // New creates a new request router.
 // It initializes the routes before returning the router.
-func New(policySelector *config.PolicySelector, policies []config.Policy, logger log.Logger) Router {
+// The tracker is used to skip static backends which are marked as down, it may be nil.
+func New(policySelector *config.PolicySelector, policies []config.Policy, tracker *upstream.Tracker, logger log.Logger) Router {
 	if policySelector == nil {
 		firstPolicy := policies[0].Name
 		logger.Warn().Str("policy", firstPolicy).Msg("policy-selector not configured. Will always use first policy")
@@ -59,25 +61,42 @@ func New(policySelector *config.PolicySelector, policies []config.Policy, logger
 		logger:         logger,
 		directors:      make(map[string]map[config.RouteType]map[string][]RoutingInfo),
 		policySelector: selector,
+		tracker:        tracker,
 	}
 	for _, pol := range policies {
 		for _, route := range pol.Routes {
 			logger.Debug().Str("fwd: ", route.Endpoint)
 
-			if route.Backend == "" && route.Service == "" {
-				logger.Fatal().Interface("route", route).Msg("neither Backend nor Service is set")
+			if route.Backend == "" && route.Service == "" && len(route.Backends) == 0 {
+				logger.Fatal().Interface("route", route).Msg("neither Backend, Backends nor Service is set")
 			}
-			uri, err2 := url.Parse(route.Backend)
-			if err2 != nil {
-				logger.
-					Fatal(). // fail early on misconfiguration
-					Err(err2).
-					Str("backend", route.Backend).
-					Msg("malformed url")
+
+			weighted := route.Backends
+			if len(weighted) == 0 {
+				weighted = []config.WeightedBackend{{URL: route.Backend, Weight: 1}}
+			}
+			backends := make([]upstream.Backend, 0, len(weighted))
+			for _, b := range weighted {
+				uri, err2 := url.Parse(b.URL)
+				if err2 != nil {
+					logger.
+						Fatal(). // fail early on misconfiguration
+						Err(err2).
+						Str("backend", b.URL).
+						Msg("malformed url")
+				}
+				weight := b.Weight
+				if weight <= 0 {
+					weight = 1
+				}
+				if route.Service == "" {
+					tracker.Register(uri)
+				}
+				backends = append(backends, upstream.Backend{URL: uri, Weight: weight})
 			}
 
-			// here the backend is used as a uri
-			r.addHost(pol.Name, uri, route)
+			// here the backends are used as uris
+			r.addHost(pol.Name, backends, pol.RateLimits, route)
 		}
 	}
 	return r
@@ -88,6 +107,8 @@ type RoutingInfo struct {
 	director    func(*http.Request)
 	endpoint    string
 	unprotected bool
+	// rateLimits holds the rate limits of the route followed by the rate limits of the policy
+	rateLimits [][]config.RateLimitRule
 }
 
 // Director returns the proxy director.
@@ -100,15 +121,44 @@ func (r RoutingInfo) IsRouteUnprotected() bool {
 	return r.unprotected
 }
 
+// Endpoint returns the endpoint of the matched route.
+func (r RoutingInfo) Endpoint() string {
+	return r.endpoint
+}
+
+// RateLimit returns the rate limit configured for the route and the given method.
+// Rules of the route take precedence over rules of the policy, and a rule for the
+// specific method takes precedence over a rule without a method.
+func (r RoutingInfo) RateLimit(method string) (config.RateLimitRule, bool) {
+	for _, rules := range r.rateLimits {
+		var (
+			rule  config.RateLimitRule
+			found bool
+		)
+		for _, rl := range rules {
+			switch rl.Method {
+			case method:
+				return rl, true
+			case "":
+				rule, found = rl, true
+			}
+		}
+		if found {
+			return rule, true
+		}
+	}
+	return config.RateLimitRule{}, false
+}
+
 // Router handles the routing of HTTP requests according to the given policies.
 type Router struct {
 	logger         log.Logger
 	directors      map[string]map[config.RouteType]map[string][]RoutingInfo
 	policySelector policy.Selector
+	tracker        *upstream.Tracker
 }
 
-func (rt Router) addHost(policy string, target *url.URL, route config.Route) {
-	targetQuery := target.RawQuery
+func (rt Router) addHost(policy string, backends []upstream.Backend, policyRateLimits []config.RateLimitRule, route config.Route) {
 	if rt.directors[policy] == nil {
 		rt.directors[policy] = make(map[config.RouteType]map[string][]RoutingInfo)
 	}
@@ -129,7 +179,10 @@ func (rt Router) addHost(policy string, target *url.URL, route config.Route) {
 	rt.directors[policy][routeType][route.Method] = append(rt.directors[policy][routeType][route.Method], RoutingInfo{
 		endpoint:    route.Endpoint,
 		unprotected: route.Unprotected,
+		rateLimits:  [][]config.RateLimitRule{route.RateLimits, policyRateLimits},
 		director: func(req *http.Request) {
+			target := rt.tracker.Select(backends).URL
+			targetQuery := target.RawQuery
 			if route.Service != "" {
 				// select next node
 				next, err := sel.Select(route.Service)
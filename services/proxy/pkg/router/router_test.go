@@ -67,7 +67,7 @@ func TestQueryRouteMatcher(t *testing.T) {
 func TestRegexRouteMatcher(t *testing.T) {
 	cfg := defaults.DefaultConfig()
 	cfg.Policies = defaults.DefaultPolicies()
-	rt := New(cfg.PolicySelector, cfg.Policies, log.NewLogger())
+	rt := New(cfg.PolicySelector, cfg.Policies, nil, log.NewLogger())
 
 	table := []matchertest{
 		{endpoint: ".*some\\/url.*parameter=true", target: "/foobar/baz/some/url?parameter=true", matches: true},
@@ -127,7 +127,7 @@ func TestRouter(t *testing.T) {
 		},
 	}
 
-	router := New(selector, policies, log.NewLogger())
+	router := New(selector, policies, nil, log.NewLogger())
 
 	table := []matchertest{
 		{method: "PROPFIND", endpoint: "/dav/files/demo/", target: "ocdav"},
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	masker "github.com/ggwhite/go-masker"
+	"github.com/owncloud/ocis/v2/ocis-pkg/middleware"
 	"github.com/owncloud/ocis/v2/ocis-pkg/service/debug"
 	"github.com/owncloud/ocis/v2/ocis-pkg/version"
 	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
@@ -15,7 +16,7 @@ import (
 func Server(opts ...Option) (*http.Server, error) {
 	options := newOptions(opts...)
 
-	return debug.NewService(
+	server := debug.NewService(
 		debug.Logger(options.Logger),
 		debug.Name(options.Config.Service.Name),
 		debug.Version(version.GetString()),
@@ -26,7 +27,17 @@ func Server(opts ...Option) (*http.Server, error) {
 		debug.Health(health(options.Config)),
 		debug.Ready(ready(options.Config)),
 		debug.ConfigDump(configDump(options.Config)),
-	), nil
+	)
+
+	if options.Tracker != nil {
+		// expose the health of the backends next to the default debug endpoints
+		mux := http.NewServeMux()
+		mux.Handle("/backends", middleware.Token(options.Config.Debug.Token)(options.Tracker))
+		mux.Handle("/", server.Handler)
+		server.Handler = mux
+	}
+
+	return server, nil
 }
 
 // health implements the health check.
@@ -2,6 +2,7 @@ package http
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"os"
 
 	pkgcrypto "github.com/owncloud/ocis/v2/ocis-pkg/crypto"
@@ -39,6 +40,24 @@ func Server(opts ...Option) (svc.Service, error) {
 		}
 
 		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cer}}
+
+		if httpCfg.TLSClientCA != "" {
+			pem, err := os.ReadFile(httpCfg.TLSClientCA)
+			if err != nil {
+				options.Logger.Fatal().Err(err).Str("file", httpCfg.TLSClientCA).Msg("Could not read TLS client CA bundle")
+				os.Exit(1)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				options.Logger.Fatal().Str("file", httpCfg.TLSClientCA).Msg("No certificates found in TLS client CA bundle")
+				os.Exit(1)
+			}
+			tlsConfig.ClientCAs = pool
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		} else if options.Config.ClientCertAuth.Enabled {
+			// the client certificate authenticator verifies the certificates against its own CA bundle
+			tlsConfig.ClientAuth = tls.RequestClientCert
+		}
 	}
 	chain := options.Middlewares.Then(options.Handler)
 
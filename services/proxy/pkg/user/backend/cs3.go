@@ -16,6 +16,7 @@ import (
 	revactx "github.com/cs3org/reva/v2/pkg/ctx"
 	"github.com/cs3org/reva/v2/pkg/token"
 	libregraph "github.com/owncloud/libre-graph-api-go"
+	"github.com/owncloud/ocis/v2/ocis-pkg/accountstatus"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/ocis-pkg/middleware"
 	"github.com/owncloud/ocis/v2/ocis-pkg/oidc"
@@ -35,11 +36,13 @@ type cs3backend struct {
 	oidcISS             string
 	machineAuthAPIKey   string
 	tokenManager        token.Manager
+	accountStatus       *accountstatus.Checker
 	logger              log.Logger
 }
 
-// NewCS3UserBackend creates a user-provider which fetches users from a CS3 UserBackend
-func NewCS3UserBackend(rs settingssvc.RoleService, ap RevaAuthenticator, machineAuthAPIKey string, oidcISS string, tokenManager token.Manager, logger log.Logger) UserBackend {
+// NewCS3UserBackend creates a user-provider which fetches users from a CS3 UserBackend. When
+// accountStatus is not nil users with a disabled account are rejected.
+func NewCS3UserBackend(rs settingssvc.RoleService, ap RevaAuthenticator, machineAuthAPIKey string, oidcISS string, tokenManager token.Manager, accountStatus *accountstatus.Checker, logger log.Logger) UserBackend {
 	reg := registry.GetRegistry()
 	sel := selector.NewSelector(selector.Registry(reg))
 	return &cs3backend{
@@ -49,6 +52,7 @@ func NewCS3UserBackend(rs settingssvc.RoleService, ap RevaAuthenticator, machine
 		oidcISS:             oidcISS,
 		machineAuthAPIKey:   machineAuthAPIKey,
 		tokenManager:        tokenManager,
+		accountStatus:       accountStatus,
 		logger:              logger,
 	}
 }
@@ -72,6 +76,10 @@ func (c *cs3backend) GetUserByClaims(ctx context.Context, claim, value string, w
 
 	user := res.User
 
+	if err := c.checkAccountStatus(ctx, user, res.Token); err != nil {
+		return nil, "", err
+	}
+
 	if !withRoles {
 		return user, res.Token, nil
 	}
@@ -139,9 +147,29 @@ func (c *cs3backend) Authenticate(ctx context.Context, username string, password
 		return nil, "", fmt.Errorf("could not authenticate with username and password user: %s, got code: %d", username, res.Status.Code)
 	}
 
+	if err := c.checkAccountStatus(ctx, res.User, res.Token); err != nil {
+		return nil, "", err
+	}
+
 	return res.User, res.Token, nil
 }
 
+// checkAccountStatus returns ErrAccountDisabled if the account of the user has been disabled
+func (c *cs3backend) checkAccountStatus(ctx context.Context, user *cs3.User, token string) error {
+	if c.accountStatus == nil || user.GetId().GetType() == cs3.UserType_USER_TYPE_LIGHTWEIGHT {
+		return nil
+	}
+	err := c.accountStatus.Check(ctx, user.GetId().GetOpaqueId(), token)
+	switch {
+	case errors.Is(err, accountstatus.ErrDisabled):
+		return ErrAccountDisabled
+	case err != nil:
+		c.logger.Error().Err(err).Str("userid", user.GetId().GetOpaqueId()).Msg("could not check account status")
+		return err
+	}
+	return nil
+}
+
 // CreateUserFromClaims creates a new user via libregraph users API, taking the
 // attributes from the provided `claims` map. On success it returns the new
 // user. If the user already exist this is not considered an error and the
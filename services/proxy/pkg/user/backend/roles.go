@@ -0,0 +1,131 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/jellydator/ttlcache/v2"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/ocis-pkg/middleware"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
+	settingsService "github.com/owncloud/ocis/v2/services/settings/pkg/service/v0"
+	"go-micro.dev/v4/metadata"
+)
+
+// UserRoleAssigner keeps the settings role of a user in sync with the claims sent by the IDP
+type UserRoleAssigner interface {
+	// UpdateUserRoleAssignment assigns the role mapped from the claims to the user. It reports
+	// whether the role was changed, tokens minted for the user before carry the old role.
+	UpdateUserRoleAssignment(ctx context.Context, user *cs3.User, claims map[string]interface{}) (*cs3.User, bool, error)
+}
+
+type claimsRoleAssigner struct {
+	settingsRoleService settingssvc.RoleService
+	claimName           string
+	mapping             []config.RoleMapping
+	logger              log.Logger
+	// synced holds the role ids which were recently checked or assigned per user id
+	synced *ttlcache.Cache
+}
+
+// NewClaimsRoleAssigner returns a UserRoleAssigner which assigns roles according to the configured
+// mapping of claim values to role ids. Once a role is in sync it is not checked again for the
+// ttl, which should match the lifetime of the cached claims.
+func NewClaimsRoleAssigner(rs settingssvc.RoleService, cfg config.RoleAssignment, ttl time.Duration, logger log.Logger) UserRoleAssigner {
+	synced := ttlcache.NewCache()
+	_ = synced.SetTTL(ttl)
+	synced.SkipTTLExtensionOnHit(true)
+	return &claimsRoleAssigner{
+		settingsRoleService: rs,
+		claimName:           cfg.ClaimName,
+		mapping:             cfg.Mapping,
+		logger:              logger,
+		synced:              synced,
+	}
+}
+
+// UpdateUserRoleAssignment assigns the role mapped from the claims to the user if the user currently has a different role.
+func (ra claimsRoleAssigner) UpdateUserRoleAssignment(ctx context.Context, user *cs3.User, claims map[string]interface{}) (*cs3.User, bool, error) {
+	if user.GetId().GetType() != cs3.UserType_USER_TYPE_PRIMARY {
+		return user, false, nil
+	}
+
+	roleID := ra.roleIDFromClaims(claims)
+	if synced, err := ra.synced.Get(user.Id.OpaqueId); err == nil && synced.(string) == roleID {
+		return user, false, nil
+	}
+	current := rolesFromOpaque(user)
+	if len(current) == 1 && current[0] == roleID {
+		_ = ra.synced.Set(user.Id.OpaqueId, roleID)
+		return user, false, nil
+	}
+
+	ra.logger.Info().Str("userid", user.Id.OpaqueId).Str("role", roleID).Strs("current", current).Msg("updating role assignment from claims")
+	// Updating context to have the Account-ID field and suffixing with _init
+	// so that the safety check for setting users' own role doesn't fail
+	ctx = metadata.Set(ctx, middleware.AccountID, user.Id.OpaqueId+"_init")
+	_, err := ra.settingsRoleService.AssignRoleToUser(ctx, &settingssvc.AssignRoleToUserRequest{
+		AccountUuid: user.Id.OpaqueId,
+		RoleId:      roleID,
+	})
+	if err != nil {
+		ra.logger.Error().Err(err).Str("userid", user.Id.OpaqueId).Msg("Could not assign role from claims")
+		return nil, false, err
+	}
+	_ = ra.synced.Set(user.Id.OpaqueId, roleID)
+
+	enc, err := encodeRoleIDs([]string{roleID})
+	if err != nil {
+		ra.logger.Error().Err(err).Msg("Could not encode assigned role")
+		return nil, false, err
+	}
+	if user.Opaque == nil {
+		user.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{}}
+	} else if user.Opaque.Map == nil {
+		user.Opaque.Map = map[string]*types.OpaqueEntry{}
+	}
+	user.Opaque.Map["roles"] = enc
+	return user, true, nil
+}
+
+// roleIDFromClaims returns the role id of the first mapping matching a value of the role claim.
+// The claim may either hold a single string or a list of strings.
+func (ra claimsRoleAssigner) roleIDFromClaims(claims map[string]interface{}) string {
+	var values []string
+	switch v := claims[ra.claimName].(type) {
+	case string:
+		values = []string{v}
+	case []string:
+		values = v
+	case []interface{}:
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	for _, m := range ra.mapping {
+		for _, v := range values {
+			if v == m.ClaimValue {
+				return m.RoleID
+			}
+		}
+	}
+	return settingsService.BundleUUIDRoleUser
+}
+
+// rolesFromOpaque returns the role ids that were loaded into the opaque data of the user
+func rolesFromOpaque(user *cs3.User) []string {
+	var roleIDs []string
+	entry, ok := user.GetOpaque().GetMap()["roles"]
+	if !ok || entry.Decoder != "json" {
+		return roleIDs
+	}
+	_ = json.Unmarshal(entry.Value, &roleIDs)
+	return roleIDs
+}
@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cs3 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	settingssvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/settings/v0"
+	"github.com/owncloud/ocis/v2/services/proxy/pkg/config"
+	settingsService "github.com/owncloud/ocis/v2/services/settings/pkg/service/v0"
+	"github.com/stretchr/testify/assert"
+	"go-micro.dev/v4/client"
+)
+
+func TestClaimsRoleAssigner(t *testing.T) {
+	cfg := config.RoleAssignment{
+		ClaimName: "roles",
+		Mapping: []config.RoleMapping{
+			{ClaimValue: "ocisAdmin", RoleID: settingsService.BundleUUIDRoleAdmin},
+			{ClaimValue: "ocisSpaceAdmin", RoleID: settingsService.BundleUUIDRoleSpaceAdmin},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		current  []string
+		claims   map[string]interface{}
+		expected string
+		assigns  bool
+	}{
+		{
+			name:     "first matching mapping wins",
+			current:  []string{settingsService.BundleUUIDRoleUser},
+			claims:   map[string]interface{}{"roles": []interface{}{"ocisSpaceAdmin", "ocisAdmin"}},
+			expected: settingsService.BundleUUIDRoleAdmin,
+			assigns:  true,
+		},
+		{
+			name:     "single string claim",
+			current:  []string{settingsService.BundleUUIDRoleUser},
+			claims:   map[string]interface{}{"roles": "ocisSpaceAdmin"},
+			expected: settingsService.BundleUUIDRoleSpaceAdmin,
+			assigns:  true,
+		},
+		{
+			name:     "role removed in the IDP falls back to the user role",
+			current:  []string{settingsService.BundleUUIDRoleAdmin},
+			claims:   map[string]interface{}{"roles": []interface{}{"somethingElse"}},
+			expected: settingsService.BundleUUIDRoleUser,
+			assigns:  true,
+		},
+		{
+			name:     "unchanged role is not reassigned",
+			current:  []string{settingsService.BundleUUIDRoleAdmin},
+			claims:   map[string]interface{}{"roles": []interface{}{"ocisAdmin"}},
+			expected: settingsService.BundleUUIDRoleAdmin,
+			assigns:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assigned := ""
+			rs := settingssvc.MockRoleService{
+				AssignRoleToUserFunc: func(ctx context.Context, req *settingssvc.AssignRoleToUserRequest, opts ...client.CallOption) (*settingssvc.AssignRoleToUserResponse, error) {
+					assigned = req.RoleId
+					return &settingssvc.AssignRoleToUserResponse{}, nil
+				},
+			}
+			ra := NewClaimsRoleAssigner(rs, cfg, time.Minute, log.NewLogger())
+
+			enc, err := encodeRoleIDs(tc.current)
+			assert.NoError(t, err)
+			user := &cs3.User{Id: &cs3.UserId{OpaqueId: "einstein", Type: cs3.UserType_USER_TYPE_PRIMARY}}
+			user.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{"roles": enc}}
+
+			user, changed, err := ra.UpdateUserRoleAssignment(context.Background(), user, tc.claims)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.assigns, changed)
+			assert.Equal(t, []string{tc.expected}, rolesFromOpaque(user))
+			if tc.assigns {
+				assert.Equal(t, tc.expected, assigned)
+			} else {
+				assert.Empty(t, assigned)
+			}
+		})
+	}
+}
+
+func TestClaimsRoleAssignerCachesSyncedRoles(t *testing.T) {
+	cfg := config.RoleAssignment{
+		ClaimName: "roles",
+		Mapping:   []config.RoleMapping{{ClaimValue: "ocisAdmin", RoleID: settingsService.BundleUUIDRoleAdmin}},
+	}
+	calls := 0
+	rs := settingssvc.MockRoleService{
+		AssignRoleToUserFunc: func(ctx context.Context, req *settingssvc.AssignRoleToUserRequest, opts ...client.CallOption) (*settingssvc.AssignRoleToUserResponse, error) {
+			calls++
+			return &settingssvc.AssignRoleToUserResponse{}, nil
+		},
+	}
+	ra := NewClaimsRoleAssigner(rs, cfg, time.Minute, log.NewLogger())
+	claims := map[string]interface{}{"roles": "ocisAdmin"}
+
+	// the roles loaded for the user are outdated until the token is minted again
+	for i := 0; i < 3; i++ {
+		enc, err := encodeRoleIDs([]string{settingsService.BundleUUIDRoleUser})
+		assert.NoError(t, err)
+		user := &cs3.User{Id: &cs3.UserId{OpaqueId: "einstein", Type: cs3.UserType_USER_TYPE_PRIMARY}}
+		user.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{"roles": enc}}
+
+		_, changed, err := ra.UpdateUserRoleAssignment(context.Background(), user, claims)
+		assert.NoError(t, err)
+		assert.Equal(t, i == 0, changed)
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -24,16 +25,20 @@ func Index(cfg *config.Config) *cli.Command {
 		Aliases:  []string{"i"},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "space",
-				Aliases:  []string{"s"},
-				Required: true,
-				Usage:    "the id of the space to travers and index the files of",
+				Name:    "space",
+				Aliases: []string{"s"},
+				Usage:   "the id of the space to travers and index the files of. All spaces are indexed in the background if it is not set",
 			},
 			&cli.StringFlag{
 				Name:     "user",
 				Aliases:  []string{"u"},
 				Required: true,
-				Usage:    "the username of the user that shall be used to access the files",
+				Usage:    "the username of the user that shall be used to access the files. It has to be allowed to list all spaces when all spaces are indexed",
+			},
+			&cli.BoolFlag{
+				Name:    "wait",
+				Aliases: []string{"w"},
+				Usage:   "wait until all spaces are indexed and print the progress",
 			},
 		},
 		Before: func(c *cli.Context) error {
@@ -43,6 +48,22 @@ func Index(cfg *config.Config) *cli.Command {
 			grpcClient := grpc.DefaultClient()
 			grpcClient.Options()
 			c := searchsvc.NewSearchProviderService("com.owncloud.api.search", grpcClient)
+
+			if ctx.String("space") == "" {
+				_, err := c.IndexSpace(context.Background(), &searchsvc.IndexSpaceRequest{
+					UserId: ctx.String("user"),
+				})
+				if err != nil {
+					fmt.Println("failed to start indexing all spaces: " + err.Error())
+					return err
+				}
+				if !ctx.Bool("wait") {
+					fmt.Println("indexing all spaces in the background")
+					return nil
+				}
+				return waitForIndex(c)
+			}
+
 			_, err := c.IndexSpace(context.Background(), &searchsvc.IndexSpaceRequest{
 				SpaceId: ctx.String("space"),
 				UserId:  ctx.String("user"),
@@ -55,3 +76,59 @@ func Index(cfg *config.Config) *cli.Command {
 		},
 	}
 }
+
+// IndexStatus is the entrypoint for the index-status command.
+func IndexStatus(cfg *config.Config) *cli.Command {
+	return &cli.Command{
+		Name:     "index-status",
+		Usage:    "print the progress of indexing all spaces",
+		Category: "index management",
+		Before: func(c *cli.Context) error {
+			return configlog.ReturnFatal(parser.ParseConfig(cfg))
+		},
+		Action: func(ctx *cli.Context) error {
+			grpcClient := grpc.DefaultClient()
+			grpcClient.Options()
+			c := searchsvc.NewSearchProviderService("com.owncloud.api.search", grpcClient)
+			res, err := c.GetIndexStatus(context.Background(), &searchsvc.GetIndexStatusRequest{})
+			if err != nil {
+				fmt.Println("failed to get the index status: " + err.Error())
+				return err
+			}
+			printIndexStatus(res)
+			return nil
+		},
+	}
+}
+
+// waitForIndex prints the progress until all spaces are indexed
+func waitForIndex(c searchsvc.SearchProviderService) error {
+	for {
+		res, err := c.GetIndexStatus(context.Background(), &searchsvc.GetIndexStatusRequest{})
+		if err != nil {
+			fmt.Println("failed to get the index status: " + err.Error())
+			return err
+		}
+		printIndexStatus(res)
+		if !res.Running {
+			if res.Errors > 0 {
+				return errors.New("some resources could not be indexed")
+			}
+			return nil
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func printIndexStatus(res *searchsvc.GetIndexStatusResponse) {
+	switch {
+	case res.StartTime == nil:
+		fmt.Println("indexing all spaces has not been started yet")
+		return
+	case res.Running:
+		fmt.Printf("indexing since %s: ", res.StartTime.AsTime().Local().Format(time.RFC1123))
+	default:
+		fmt.Printf("indexed at %s: ", res.EndTime.AsTime().Local().Format(time.RFC1123))
+	}
+	fmt.Printf("%d of %d spaces done, %d documents indexed, %d errors\n", res.SpacesDone, res.SpacesTotal, res.DocumentsIndexed, res.Errors)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 )
@@ -22,6 +23,10 @@ type Config struct {
 	Reva     *Reva  `yaml:"reva"`
 	Events   Events `yaml:"events"`
 
+	Engine    Engine    `yaml:"engine"`
+	Extractor Extractor `yaml:"extractor"`
+	Reindex   Reindex   `yaml:"reindex"`
+
 	MachineAuthAPIKey string `yaml:"machine_auth_api_key" env:"OCIS_MACHINE_AUTH_API_KEY;SEARCH_MACHINE_AUTH_API_KEY" desc:"Machine auth API key used to validate internal requests necessary for the access to resources from other services."`
 
 	Context context.Context `yaml:"-"`
@@ -35,3 +40,37 @@ type Events struct {
 	TLSInsecure          bool   `yaml:"tls_insecure" env:"OCIS_INSECURE;SEARCH_EVENTS_TLS_INSECURE" desc:"Whether to verify the server TLS certificates."`
 	TLSRootCACertificate string `yaml:"tls_root_ca_certificate" env:"SEARCH_EVENTS_TLS_ROOT_CA_CERTIFICATE" desc:"The root CA certificate used to validate the server's TLS certificate. If provided SEARCH_EVENTS_TLS_INSECURE will be seen as false."`
 }
+
+// Engine defines which search index engine is used
+type Engine struct {
+	Type       string           `yaml:"type" env:"SEARCH_ENGINE_TYPE" desc:"Defines the search index engine. Supported values are 'bleve' and 'opensearch'. 'bleve' stores the index in the data path of the service, 'opensearch' uses an OpenSearch compatible server and allows to run several instances of the service."`
+	OpenSearch EngineOpenSearch `yaml:"opensearch"`
+}
+
+// EngineOpenSearch configures the OpenSearch index engine
+type EngineOpenSearch struct {
+	URL      string        `yaml:"url" env:"SEARCH_ENGINE_OPENSEARCH_URL" desc:"URL of the OpenSearch server."`
+	Index    string        `yaml:"index" env:"SEARCH_ENGINE_OPENSEARCH_INDEX" desc:"Name of the OpenSearch index. It is created with the required mapping if it does not exist yet."`
+	Username string        `yaml:"username" env:"SEARCH_ENGINE_OPENSEARCH_USERNAME" desc:"Username for the basic authentication at the OpenSearch server. Leave empty to send no credentials."`
+	Password string        `yaml:"password" env:"SEARCH_ENGINE_OPENSEARCH_PASSWORD" desc:"Password for the basic authentication at the OpenSearch server."`
+	Insecure bool          `yaml:"insecure" env:"OCIS_INSECURE;SEARCH_ENGINE_OPENSEARCH_INSECURE" desc:"Ignore untrusted SSL certificates when connecting to the OpenSearch server."`
+	Timeout  time.Duration `yaml:"timeout" env:"SEARCH_ENGINE_OPENSEARCH_TIMEOUT" desc:"Time limit for requests to the OpenSearch server, e.g. '30s'."`
+}
+
+// Extractor defines which content extraction engine is used
+type Extractor struct {
+	Type             string        `yaml:"type" env:"SEARCH_EXTRACTOR_TYPE" desc:"Defines the content extraction engine. Supported values are 'basic' and 'tika'. 'basic' extracts the content of plain text, Markdown, HTML, PDF and OOXML/ODF documents, 'tika' sends the files to an Apache Tika server."`
+	CS3AllowInsecure bool          `yaml:"cs3_allow_insecure" env:"OCIS_INSECURE;SEARCH_EXTRACTOR_CS3SOURCE_INSECURE" desc:"Ignore untrusted SSL certificates when downloading the files to extract the content from."`
+	MaxFileSize      uint64        `yaml:"max_file_size" env:"SEARCH_EXTRACTOR_MAX_FILE_SIZE" desc:"Maximum size in bytes of the files whose content is extracted. Larger files are only indexed by their metadata. Set to 0 to extract the content of all files."`
+	Tika             ExtractorTika `yaml:"tika"`
+}
+
+// ExtractorTika configures the Tika content extraction engine
+type ExtractorTika struct {
+	TikaURL string `yaml:"tika_url" env:"SEARCH_EXTRACTOR_TIKA_TIKA_URL" desc:"URL of the Tika server."`
+}
+
+// Reindex configures the reindex of all spaces
+type Reindex struct {
+	Throttle time.Duration `yaml:"throttle" env:"SEARCH_REINDEX_THROTTLE" desc:"Time to pause after every resource added to the index while all spaces are reindexed, e.g. '10ms'. It limits the load on the storage and the search index."`
+}
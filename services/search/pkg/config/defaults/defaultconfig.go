@@ -2,6 +2,7 @@ package defaults
 
 import (
 	"path"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/defaults"
 	"github.com/owncloud/ocis/v2/services/search/pkg/config"
@@ -37,6 +38,25 @@ func DefaultConfig() *config.Config {
 			Cluster:       "ocis-cluster",
 			ConsumerGroup: "search",
 		},
+		Engine: config.Engine{
+			Type: "bleve",
+			OpenSearch: config.EngineOpenSearch{
+				URL:     "http://127.0.0.1:9200",
+				Index:   "ocis-search",
+				Timeout: 30 * time.Second,
+			},
+		},
+		Extractor: config.Extractor{
+			Type:             "basic",
+			CS3AllowInsecure: false,
+			MaxFileSize:      20 * 1024 * 1024,
+			Tika: config.ExtractorTika{
+				TikaURL: "http://127.0.0.1:9998",
+			},
+		},
+		Reindex: config.Reindex{
+			Throttle: 10 * time.Millisecond,
+		},
 		MachineAuthAPIKey: "",
 	}
 }
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 
 	ociscfg "github.com/owncloud/ocis/v2/ocis-pkg/config"
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
@@ -37,5 +38,15 @@ func Validate(cfg *config.Config) error {
 	if cfg.MachineAuthAPIKey == "" {
 		return shared.MissingMachineAuthApiKeyError(cfg.Service.Name)
 	}
+	switch cfg.Engine.Type {
+	case "bleve", "opensearch":
+	default:
+		return fmt.Errorf("unsupported search engine type '%s', supported types are 'bleve' and 'opensearch'", cfg.Engine.Type)
+	}
+	switch cfg.Extractor.Type {
+	case "basic", "tika":
+	default:
+		return fmt.Errorf("unsupported content extractor type '%s', supported types are 'basic' and 'tika'", cfg.Extractor.Type)
+	}
 	return nil
 }
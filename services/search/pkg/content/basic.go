@@ -0,0 +1,101 @@
+package content
+
+import (
+	"context"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+)
+
+// textExtractors maps the mime types supported by the Basic extractor to the functions
+// turning the file content into plain text. All other text/* mime types are read as plain
+// text.
+var textExtractors = map[string]func([]byte) (string, error){
+	"text/html":             htmlText,
+	"application/xhtml+xml": htmlText,
+	"application/pdf":       pdfText,
+
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ooxmlText,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ooxmlText,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ooxmlText,
+
+	"application/vnd.oasis.opendocument.text":         odfText,
+	"application/vnd.oasis.opendocument.spreadsheet":  odfText,
+	"application/vnd.oasis.opendocument.presentation": odfText,
+}
+
+// maxDecompressedSize limits the amount of data decompressed from a single document to
+// protect against zip and flate bombs. The text read until the limit is reached is used.
+const maxDecompressedSize = 16 << 20
+
+// Basic extracts the content of plain text, Markdown, HTML, PDF and OOXML/ODF documents
+// without depending on external services
+type Basic struct {
+	retriever   Retriever
+	maxFileSize uint64
+	logger      log.Logger
+}
+
+// NewBasicExtractor returns a new Basic extractor. Files larger than maxFileSize bytes are
+// skipped, a maxFileSize of 0 extracts the content of all files.
+func NewBasicExtractor(retriever Retriever, maxFileSize uint64, logger log.Logger) Basic {
+	return Basic{
+		retriever:   retriever,
+		maxFileSize: maxFileSize,
+		logger:      logger,
+	}
+}
+
+// Extract downloads the file and extracts its text content
+func (b Basic) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document, error) {
+	extract := extractorFor(ri.GetMimeType())
+	if extract == nil || !extractable(ri, b.maxFileSize) {
+		return Document{}, nil
+	}
+
+	rc, err := b.retriever.Retrieve(ctx, &provider.Reference{ResourceId: ri.GetId()})
+	if err != nil {
+		return Document{}, err
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if b.maxFileSize > 0 {
+		r = io.LimitReader(rc, int64(b.maxFileSize))
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, err
+	}
+
+	text, err := extract(data)
+	if err != nil {
+		return Document{}, err
+	}
+	b.logger.Debug().Str("mimetype", ri.GetMimeType()).Int("length", len(text)).Msg("extracted file content")
+	return Document{Content: text}, nil
+}
+
+func extractorFor(mimeType string) func([]byte) (string, error) {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.TrimSpace(strings.ToLower(mimeType))
+	if extract, ok := textExtractors[mimeType]; ok {
+		return extract
+	}
+	if strings.HasPrefix(mimeType, "text/") {
+		// text/plain, text/markdown, text/csv, source code, ...
+		return plainText
+	}
+	return nil
+}
+
+func plainText(data []byte) (string, error) {
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	return strings.ToValidUTF8(string(data), ""), nil
+}
@@ -0,0 +1,181 @@
+package content_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content/mocks"
+)
+
+func zipped(files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, data := range files {
+		f, err := zw.Create(name)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = f.Write([]byte(data))
+		Expect(err).ToNot(HaveOccurred())
+	}
+	Expect(zw.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func pdf(stream string) []byte {
+	compressed := &bytes.Buffer{}
+	zw := zlib.NewWriter(compressed)
+	_, err := zw.Write([]byte(stream))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(zw.Close()).To(Succeed())
+	return []byte(fmt.Sprintf("%%PDF-1.4\n"+
+		"4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n"+
+		"5 0 obj\n<< /Subtype /Image /Length 7 >>\nstream\nBT (no) Tj ET\nendstream\nendobj\n%%%%EOF\n",
+		compressed.Len(), compressed.Bytes()))
+}
+
+var _ = Describe("Basic", func() {
+	var (
+		retriever *mocks.Retriever
+		extractor content.Basic
+		ctx       = context.Background()
+		ri        *sprovider.ResourceInfo
+	)
+
+	BeforeEach(func() {
+		retriever = &mocks.Retriever{}
+		extractor = content.NewBasicExtractor(retriever, 1024, log.NewLogger())
+		ri = &sprovider.ResourceInfo{
+			Id:   &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "opaqueid"},
+			Type: sprovider.ResourceType_RESOURCE_TYPE_FILE,
+			Size: 100,
+		}
+	})
+
+	retrieve := func(data []byte) {
+		retriever.On("Retrieve", mock.Anything, mock.MatchedBy(func(ref *sprovider.Reference) bool {
+			return ref.ResourceId.OpaqueId == "opaqueid"
+		})).Return(io.NopCloser(bytes.NewReader(data)), nil)
+	}
+
+	DescribeTable("extracts the content",
+		func(mimeType string, data []byte, expected []string) {
+			ri.MimeType = mimeType
+			retrieve(data)
+
+			doc, err := extractor.Extract(ctx, ri)
+			Expect(err).ToNot(HaveOccurred())
+			for _, e := range expected {
+				Expect(doc.Content).To(ContainSubstring(e))
+			}
+		},
+		Entry("plain text", "text/plain", []byte("Hello world"), []string{"Hello world"}),
+		Entry("markdown", "text/markdown", []byte("# Title\n\nSome *text*"), []string{"Title", "Some *text*"}),
+		Entry("html", "text/html; charset=utf-8",
+			[]byte(`<html><head><style>p { color: red; }</style><script>var x;</script></head><body><h1>Title</h1><p>Some <b>bo</b>ld text</p></body></html>`),
+			[]string{"Title Some bold text"}),
+		Entry("docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			zipped(map[string]string{
+				"word/document.xml": `<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>Hel</w:t></w:r><w:r><w:t>lo</w:t></w:r></w:p><w:p><w:r><w:t>world</w:t></w:r></w:p></w:body></w:document>`,
+				"word/styles.xml":   `<w:styles xmlns:w="w"><w:t>ignored</w:t></w:styles>`,
+			}),
+			[]string{"Hello\nworld"}),
+		Entry("xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			zipped(map[string]string{
+				"xl/sharedStrings.xml": `<sst><si><t>first</t></si><si><t>second</t></si></sst>`,
+			}),
+			[]string{"first\nsecond"}),
+		Entry("odt", "application/vnd.oasis.opendocument.text",
+			zipped(map[string]string{
+				"content.xml": `<office:document-content xmlns:office="o" xmlns:text="t"><office:body><office:text><text:h>Title</text:h><text:p>Some<text:s/>text</text:p></office:text></office:body></office:document-content>`,
+			}),
+			[]string{"Title\nSome text"}),
+		Entry("pdf", "application/pdf",
+			pdf("BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(wo) -20 (rld) -500 (again)] TJ <FEFF00E4> Tj ET"),
+			[]string{"Hello (PDF)\nworld againä"}),
+	)
+
+	It("ignores the content of images in pdf documents", func() {
+		ri.MimeType = "application/pdf"
+		retrieve(pdf("BT (text) Tj ET"))
+
+		doc, err := extractor.Extract(ctx, ri)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Content).ToNot(ContainSubstring("no"))
+	})
+
+	Describe("decompression bombs", func() {
+		BeforeEach(func() {
+			extractor = content.NewBasicExtractor(retriever, 0, log.NewLogger())
+		})
+
+		It("stops extracting office documents at the limit", func() {
+			ri.MimeType = "application/vnd.oasis.opendocument.text"
+			retrieve(zipped(map[string]string{
+				"content.xml": "<document>" + strings.Repeat("<p>some text</p>", 2<<20) + "</document>",
+			}))
+
+			doc, err := extractor.Extract(ctx, ri)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(doc.Content).To(HavePrefix("some text\n"))
+			Expect(len(doc.Content)).To(BeNumerically("<", 16<<20))
+		})
+
+		It("stops extracting pdf documents at the limit", func() {
+			ri.MimeType = "application/pdf"
+			retrieve(pdf(strings.Repeat("BT (x) Tj ET\n", 3<<20)))
+
+			doc, err := extractor.Extract(ctx, ri)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(doc.Content).To(HavePrefix("x"))
+			Expect(strings.Count(doc.Content, "x")).To(BeNumerically("<", 3<<20))
+		})
+	})
+
+	It("skips unsupported file types", func() {
+		ri.MimeType = "image/png"
+
+		doc, err := extractor.Extract(ctx, ri)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Content).To(BeEmpty())
+		retriever.AssertNotCalled(GinkgoT(), "Retrieve", mock.Anything, mock.Anything)
+	})
+
+	It("skips folders", func() {
+		ri.MimeType = "httpd/unix-directory"
+		ri.Type = sprovider.ResourceType_RESOURCE_TYPE_CONTAINER
+
+		doc, err := extractor.Extract(ctx, ri)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Content).To(BeEmpty())
+		retriever.AssertNotCalled(GinkgoT(), "Retrieve", mock.Anything, mock.Anything)
+	})
+
+	It("skips files exceeding the size limit", func() {
+		ri.MimeType = "text/plain"
+		ri.Size = 2048
+
+		doc, err := extractor.Extract(ctx, ri)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Content).To(BeEmpty())
+		retriever.AssertNotCalled(GinkgoT(), "Retrieve", mock.Anything, mock.Anything)
+	})
+
+	It("returns retrieval errors", func() {
+		ri.MimeType = "text/plain"
+		retriever.On("Retrieve", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("download failed"))
+
+		_, err := extractor.Extract(ctx, ri)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,40 @@
+// Package content extracts the text content of files, so that they can be found by their
+// content and not only by their name.
+package content
+
+import (
+	"context"
+	"io"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+//go:generate mockery --name=Extractor
+//go:generate mockery --name=Retriever
+
+// Document holds the data extracted from a resource
+type Document struct {
+	// Content is the plain text content of the file
+	Content string
+}
+
+// Extractor is the interface to the content extraction engines
+type Extractor interface {
+	// Extract returns the content of the resource. Resources without extractable content, like
+	// folders or unsupported file types, result in an empty Document.
+	Extract(ctx context.Context, ri *provider.ResourceInfo) (Document, error)
+}
+
+// Retriever is the interface to download the files to extract the content from
+type Retriever interface {
+	// Retrieve downloads the file. The caller MUST close the returned ReadCloser.
+	Retrieve(ctx context.Context, ref *provider.Reference) (io.ReadCloser, error)
+}
+
+// extractable reports whether the content of the resource should be extracted
+func extractable(ri *provider.ResourceInfo, maxFileSize uint64) bool {
+	if ri.GetType() != provider.ResourceType_RESOURCE_TYPE_FILE || ri.GetSize() == 0 {
+		return false
+	}
+	return maxFileSize == 0 || ri.GetSize() <= maxFileSize
+}
@@ -0,0 +1,91 @@
+package content
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	ctxpkg "github.com/cs3org/reva/v2/pkg/ctx"
+	"github.com/cs3org/reva/v2/pkg/rhttp"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// "github.com/cs3org/reva/v2/internal/http/services/datagateway" is internal so we redeclare it here
+	// TokenTransportHeader holds the header key for the reva transfer token
+	TokenTransportHeader = "X-Reva-Transfer"
+)
+
+// CS3 downloads files through the cs3 gateway
+type CS3 struct {
+	gwClient gateway.GatewayAPIClient
+	client   *http.Client
+}
+
+// NewCS3Retriever returns a Retriever downloading the files through the cs3 gateway. The
+// context passed to Retrieve has to carry the access token in the outgoing grpc metadata.
+func NewCS3Retriever(gwClient gateway.GatewayAPIClient, insecure bool) CS3 {
+	return CS3{
+		gwClient: gwClient,
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: insecure, //nolint:gosec
+				},
+			},
+		},
+	}
+}
+
+// Retrieve downloads the file from a cs3 service
+// The caller MUST make sure to close the returned ReadCloser
+func (s CS3) Retrieve(ctx context.Context, ref *provider.Reference) (io.ReadCloser, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	auth := md.Get(ctxpkg.TokenHeader)
+	if len(auth) == 0 {
+		return nil, errors.New("cs3retriever: authorization missing")
+	}
+
+	rsp, err := s.gwClient.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	if rsp.Status.Code != rpc.Code_CODE_OK {
+		return nil, fmt.Errorf("could not initiate the download: %s", rsp.Status.Message)
+	}
+
+	var ep, tk string
+	for _, p := range rsp.Protocols {
+		if p.Protocol == "spaces" {
+			ep, tk = p.DownloadEndpoint, p.Token
+			break
+		}
+	}
+	if (ep == "" || tk == "") && len(rsp.Protocols) > 0 {
+		ep, tk = rsp.Protocols[0].DownloadEndpoint, rsp.Protocols[0].Token
+	}
+
+	httpReq, err := rhttp.NewRequest(ctx, http.MethodGet, ep, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set(ctxpkg.TokenHeader, auth[0])
+	httpReq.Header.Set(TokenTransportHeader, tk)
+
+	resp, err := s.client.Do(httpReq) // nolint:bodyclose
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not download the file. Request returned with statuscode %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
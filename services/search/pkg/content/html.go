@@ -0,0 +1,55 @@
+package content
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// inlineElements are the elements that don't separate their text from the surrounding text
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "cite": true, "code": true,
+	"data": true, "dfn": true, "em": true, "i": true, "kbd": true, "mark": true, "q": true,
+	"s": true, "samp": true, "small": true, "span": true, "strong": true, "sub": true,
+	"sup": true, "time": true, "u": true, "var": true,
+}
+
+// htmlText returns the text of the HTML document without the markup, scripts and styles
+func htmlText(data []byte) (string, error) {
+	var sb strings.Builder
+	skip := 0
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return "", err
+			}
+			return sb.String(), nil
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style", "noscript", "template":
+				skip++
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style", "noscript", "template":
+				if skip > 0 {
+					skip--
+				}
+			}
+			if !inlineElements[string(name)] {
+				// block elements separate the words of neighbouring elements
+				sb.WriteString(" ")
+			}
+		case html.TextToken:
+			if skip == 0 {
+				sb.Write(z.Text())
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// xmlBreaks are the local names of the OOXML and ODF elements ending a paragraph, a cell or a
+// shared string, xmlSpaces are the ones standing for whitespace.
+var (
+	xmlBreaks = map[string]bool{"p": true, "h": true, "si": true, "table-cell": true, "tc": true}
+	xmlSpaces = map[string]bool{"tab": true, "br": true, "s": true, "line-break": true}
+)
+
+// ooxmlText returns the text of Office Open XML (docx, xlsx, pptx) documents
+func ooxmlText(data []byte) (string, error) {
+	return zipXMLText(data, func(name string) bool {
+		switch {
+		case name == "word/document.xml", name == "xl/sharedStrings.xml":
+			return true
+		case strings.HasPrefix(name, "word/header"), strings.HasPrefix(name, "word/footer"):
+			return strings.HasSuffix(name, ".xml")
+		case strings.HasPrefix(name, "ppt/slides/slide"), strings.HasPrefix(name, "ppt/notesSlides/notesSlide"):
+			return strings.HasSuffix(name, ".xml")
+		}
+		return false
+	})
+}
+
+// odfText returns the text of OpenDocument (odt, ods, odp) documents
+func odfText(data []byte) (string, error) {
+	return zipXMLText(data, func(name string) bool {
+		return name == "content.xml"
+	})
+}
+
+// zipXMLText concatenates the character data of the XML files in the zip archive selected by
+// the part function
+func zipXMLText(data []byte, part func(name string) bool) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	remaining := int64(maxDecompressedSize)
+	for _, f := range zr.File {
+		if !part(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		lr := &io.LimitedReader{R: rc, N: remaining}
+		err = xmlText(lr, &sb)
+		rc.Close()
+		remaining = lr.N
+		if remaining <= 0 {
+			// the limit cut the part short, keep the text read so far
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return sb.String(), nil
+}
+
+func xmlText(r io.Reader, sb *strings.Builder) error {
+	d := xml.NewDecoder(r)
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := t.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.EndElement:
+			switch {
+			case xmlBreaks[t.Name.Local]:
+				sb.WriteString("\n")
+			case xmlSpaces[t.Name.Local]:
+				sb.WriteString(" ")
+			}
+		}
+	}
+}
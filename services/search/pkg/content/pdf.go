@@ -0,0 +1,186 @@
+package content
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/pdf"
+)
+
+// pdfText returns the text shown by the content streams of the PDF document. Only text using
+// the standard encodings is supported, documents using embedded fonts with custom encodings or
+// CID fonts, as well as encrypted documents, need an external extractor like Tika.
+func pdfText(data []byte) (string, error) {
+	doc, err := pdf.Parse(data, maxDecompressedSize)
+	if err != nil {
+		return "", err
+	}
+
+	w := &textWriter{}
+	for _, s := range doc.Streams() {
+		if !pdfTextStream(doc, s) {
+			continue
+		}
+		content, err := doc.Decode(s)
+		if errors.Is(err, pdf.ErrDecodeLimit) {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		pdfShowText(content, w)
+	}
+	return w.String(), nil
+}
+
+// pdfTextStream tells whether the stream may contain text operators, which excludes images,
+// fonts and the streams holding the structure and metadata of the document
+func pdfTextStream(doc *pdf.Document, s *pdf.Stream) bool {
+	switch doc.Name(s.Dict["Type"]) {
+	case "XRef", "ObjStm", "Metadata":
+		return false
+	}
+	switch doc.Name(s.Dict["Subtype"]) {
+	case "Image", "Type1C", "CIDFontType0C", "OpenType":
+		return false
+	}
+	for _, key := range []pdf.Name{"Length1", "Length2", "Length3"} {
+		if _, ok := s.Dict[key]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// pdfShowText writes the strings shown by the text operators of the content stream
+func pdfShowText(content []byte, w *textWriter) {
+	l := pdf.NewLexer(content)
+	var operands []interface{}
+	inText := false
+	for {
+		obj, err := l.Next()
+		if err != nil {
+			return
+		}
+		op, ok := obj.(pdf.Keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			inText = true
+		case "ET":
+			inText = false
+			w.Break("\n")
+		case "Tj", "TJ":
+			if inText {
+				w.Write(pdfStrings(operands)...)
+			}
+		case "'", `"`:
+			if inText {
+				w.Break("\n")
+				w.Write(pdfStrings(operands)...)
+			}
+		case "T*":
+			w.Break("\n")
+		case "Td", "TD":
+			if n := len(operands); n >= 2 && operands[n-1] != 0.0 {
+				w.Break("\n")
+			} else {
+				w.Break(" ")
+			}
+		case "Tm":
+			w.Break(" ")
+		case "BI":
+			l.SkipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+// pdfStrings returns the strings among the operands of a text showing operator. Large negative
+// kerning in TJ arrays separates words.
+func pdfStrings(operands []interface{}) []string {
+	var strs []string
+	for _, o := range operands {
+		switch v := o.(type) {
+		case pdf.String:
+			strs = append(strs, pdfDecodeText(v))
+		case pdf.Array:
+			for _, item := range v {
+				switch i := item.(type) {
+				case pdf.String:
+					strs = append(strs, pdfDecodeText(i))
+				case float64:
+					if i < -200 {
+						strs = append(strs, " ")
+					}
+				}
+			}
+		}
+	}
+	return strs
+}
+
+// pdfDecodeText decodes UTF-16BE strings starting with a byte order mark and reads all other
+// strings as Latin-1, which matches the standard encodings for the ASCII range
+func pdfDecodeText(s []byte) string {
+	var runes []rune
+	if len(s) >= 2 && s[0] == 0xfe && s[1] == 0xff {
+		u := make([]uint16, 0, len(s)/2)
+		for j := 2; j+1 < len(s); j += 2 {
+			u = append(u, uint16(s[j])<<8|uint16(s[j+1]))
+		}
+		runes = utf16.Decode(u)
+	} else {
+		runes = make([]rune, 0, len(s))
+		for _, b := range s {
+			runes = append(runes, rune(b))
+		}
+	}
+
+	var sb strings.Builder
+	for _, r := range runes {
+		switch {
+		case unicode.IsSpace(r):
+			sb.WriteRune(' ')
+		case unicode.IsPrint(r):
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// textWriter collects text, avoiding repeated separators between the text fragments
+type textWriter struct {
+	sb  strings.Builder
+	sep bool
+}
+
+// Write appends the strings
+func (w *textWriter) Write(strs ...string) {
+	for _, s := range strs {
+		if s == "" {
+			continue
+		}
+		w.sb.WriteString(s)
+		w.sep = strings.HasSuffix(s, " ")
+	}
+}
+
+// Break appends the separator unless the text already ends with one
+func (w *textWriter) Break(sep string) {
+	if w.sep || w.sb.Len() == 0 {
+		return
+	}
+	w.sb.WriteString(sep)
+	w.sep = true
+}
+
+func (w *textWriter) String() string {
+	return w.sb.String()
+}
@@ -0,0 +1,79 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+)
+
+// Tika extracts the content of files by sending them to an Apache Tika server. Tika supports
+// many more file types than the Basic extractor.
+type Tika struct {
+	retriever   Retriever
+	url         string
+	maxFileSize uint64
+	client      *http.Client
+	logger      log.Logger
+}
+
+// NewTikaExtractor returns a new Tika extractor using the Tika server at url. Files larger
+// than maxFileSize bytes are skipped, a maxFileSize of 0 extracts the content of all files.
+func NewTikaExtractor(retriever Retriever, url string, maxFileSize uint64, logger log.Logger) Tika {
+	return Tika{
+		retriever:   retriever,
+		url:         strings.TrimSuffix(url, "/"),
+		maxFileSize: maxFileSize,
+		client:      &http.Client{},
+		logger:      logger,
+	}
+}
+
+// Extract downloads the file and lets Tika extract its text content
+func (t Tika) Extract(ctx context.Context, ri *provider.ResourceInfo) (Document, error) {
+	if !extractable(ri, t.maxFileSize) {
+		return Document{}, nil
+	}
+
+	rc, err := t.retriever.Retrieve(ctx, &provider.Reference{ResourceId: ri.GetId()})
+	if err != nil {
+		return Document{}, err
+	}
+	defer rc.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, t.url+"/tika", rc)
+	if err != nil {
+		return Document{}, err
+	}
+	req.Header.Set("Accept", "text/plain; charset=UTF-8")
+	if ri.GetMimeType() != "" {
+		req.Header.Set("Content-Type", ri.GetMimeType())
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return Document{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+		// no text content, unsupported file type or the file could not be parsed
+		t.logger.Debug().Str("mimetype", ri.GetMimeType()).Int("status", resp.StatusCode).Msg("tika did not extract any content")
+		return Document{}, nil
+	default:
+		return Document{}, fmt.Errorf("tika request returned with statuscode %d", resp.StatusCode)
+	}
+
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Content: strings.TrimSpace(string(text))}, nil
+}
@@ -0,0 +1,73 @@
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content/mocks"
+)
+
+var _ = Describe("Tika", func() {
+	var (
+		retriever *mocks.Retriever
+		server    *httptest.Server
+		status    int
+		ri        = &sprovider.ResourceInfo{
+			Id:       &sprovider.ResourceId{StorageId: "storageid", SpaceId: "spaceid", OpaqueId: "opaqueid"},
+			Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+			MimeType: "application/msword",
+			Size:     100,
+		}
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Method).To(Equal(http.MethodPut))
+			Expect(r.URL.Path).To(Equal("/tika"))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/msword"))
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(body)).To(Equal("binary"))
+
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("\nextracted text\n"))
+		}))
+		retriever = &mocks.Retriever{}
+		retriever.On("Retrieve", mock.Anything, mock.Anything).Return(io.NopCloser(bytes.NewReader([]byte("binary"))), nil)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("extracts the content using tika", func() {
+		doc, err := content.NewTikaExtractor(retriever, server.URL+"/", 0, log.NewLogger()).Extract(context.Background(), ri)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Content).To(Equal("extracted text"))
+	})
+
+	It("returns an empty document for unsupported files", func() {
+		status = http.StatusUnprocessableEntity
+		doc, err := content.NewTikaExtractor(retriever, server.URL, 0, log.NewLogger()).Extract(context.Background(), ri)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(doc.Content).To(BeEmpty())
+	})
+
+	It("returns server errors", func() {
+		status = http.StatusInternalServerError
+		_, err := content.NewTikaExtractor(retriever, server.URL, 0, log.NewLogger()).Extract(context.Background(), ri)
+		Expect(err).To(HaveOccurred())
+	})
+})
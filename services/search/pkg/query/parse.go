@@ -0,0 +1,360 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tEOF tokenType = iota
+	tLeftParen
+	tRightParen
+	tAnd
+	tOr
+	tNot
+	tRequired
+	tText
+)
+
+type token struct {
+	typ tokenType
+	// field and operator of restrictions, they are empty for free text
+	field    string
+	operator string
+	value    string
+	phrase   bool
+}
+
+func (t token) String() string {
+	switch t.typ {
+	case tEOF:
+		return "end of query"
+	case tLeftParen:
+		return `"("`
+	case tRightParen:
+		return `")"`
+	case tAnd:
+		return "AND"
+	case tOr:
+		return "OR"
+	case tNot:
+		return "NOT"
+	case tRequired:
+		return `"+"`
+	}
+	return fmt.Sprintf("%q", t.field+t.operator+t.value)
+}
+
+// operators are the operators of restrictions, longer operators first
+var operators = []string{":>=", ":<=", ":>", ":<", ":", ">=", "<=", ">", "<", "="}
+
+// Parse parses the query into its syntax tree. Relative times like `mtime:today` are resolved
+// using now.
+func Parse(q string, now time.Time) (Node, error) {
+	tokens, err := tokenize(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, now: now}
+	if p.peek().typ == tEOF {
+		return nil, fmt.Errorf("empty query")
+	}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.typ != tEOF {
+		return nil, fmt.Errorf("unexpected %s", t)
+	}
+	return n, nil
+}
+
+// tokenize splits the query into its tokens
+func tokenize(q string) ([]token, error) {
+	var tokens []token
+	r := []rune(q)
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{typ: tLeftParen})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{typ: tRightParen})
+			i++
+		case (c == '-' || c == '+') && i+1 < len(r) && !unicode.IsSpace(r[i+1]):
+			if c == '-' {
+				tokens = append(tokens, token{typ: tNot})
+			} else {
+				tokens = append(tokens, token{typ: tRequired})
+			}
+			i++
+		case c == '"':
+			value, next, err := readPhrase(r, i+1)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{typ: tText, value: value, phrase: true})
+			i = next
+		default:
+			t, next, err := readTerm(r, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = next
+		}
+	}
+	return append(tokens, token{typ: tEOF}), nil
+}
+
+// readTerm reads a word, an operator keyword or a restriction starting at i and returns the
+// token and the position after it
+func readTerm(r []rune, i int) (token, int, error) {
+	start := i
+	for i < len(r) && (unicode.IsLetter(r[i]) || unicode.IsDigit(r[i])) {
+		i++
+	}
+	if _, ok := fieldNames[strings.ToLower(string(r[start:i]))]; ok {
+		for _, op := range operators {
+			if !strings.HasPrefix(string(r[i:]), op) {
+				continue
+			}
+			t := token{typ: tText, field: strings.ToLower(string(r[start:i])), operator: op}
+			i += len(op)
+			if i < len(r) && r[i] == '"' {
+				value, next, err := readPhrase(r, i+1)
+				if err != nil {
+					return t, next, err
+				}
+				t.value, t.phrase = value, true
+				return t, next, nil
+			}
+			t.value, i = readWord(r, i)
+			return t, i, nil
+		}
+	}
+
+	// anything else, including unknown fields, is free text
+	word, next := readWord(r, start)
+	switch word {
+	case "AND", "&&":
+		return token{typ: tAnd}, next, nil
+	case "OR", "||":
+		return token{typ: tOr}, next, nil
+	case "NOT":
+		return token{typ: tNot}, next, nil
+	}
+	return token{typ: tText, value: word}, next, nil
+}
+
+// readWord reads the characters up to the next space or parenthesis
+func readWord(r []rune, i int) (string, int) {
+	start := i
+	for i < len(r) && !unicode.IsSpace(r[i]) && r[i] != '(' && r[i] != ')' {
+		i++
+	}
+	return string(r[start:i]), i
+}
+
+// readPhrase reads a quoted phrase starting after the opening quote and returns the unescaped
+// phrase and the position after the closing quote
+func readPhrase(r []rune, i int) (string, int, error) {
+	var sb strings.Builder
+	for ; i < len(r); i++ {
+		switch r[i] {
+		case '\\':
+			if i+1 < len(r) {
+				i++
+			}
+		case '"':
+			return sb.String(), i + 1, nil
+		}
+		sb.WriteRune(r[i])
+	}
+	return "", i, fmt.Errorf("missing closing quote")
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseOr parses a sequence of conjunctions separated by OR, which binds weakest
+func (p *parser) parseOr() (Node, error) {
+	n, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{n}
+	for p.peek().typ == tOr {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return Or{Nodes: nodes}, nil
+}
+
+// parseAnd parses a sequence of nodes separated by AND or only by spaces
+func (p *parser) parseAnd() (Node, error) {
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{n}
+	for {
+		switch p.peek().typ {
+		case tAnd:
+			p.next()
+		case tText, tLeftParen, tNot, tRequired:
+		default:
+			if len(nodes) == 1 {
+				return nodes[0], nil
+			}
+			return And{Nodes: nodes}, nil
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	switch t := p.next(); t.typ {
+	case tNot:
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: n}, nil
+	case tRequired:
+		// terms are required anyway unless they are combined with OR
+		return p.parseUnary()
+	case tLeftParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.typ != tRightParen {
+			return nil, fmt.Errorf("expected \")\" instead of %s", t)
+		}
+		return n, nil
+	case tText:
+		if t.field == "" {
+			return Match{Value: t.value, Phrase: t.phrase}, nil
+		}
+		return p.restriction(t)
+	default:
+		return nil, fmt.Errorf("unexpected %s", t)
+	}
+}
+
+// restriction returns the node of the restriction of a field
+func (p *parser) restriction(t token) (Node, error) {
+	field := fieldNames[t.field]
+	if t.value == "" {
+		return nil, fmt.Errorf("missing value for %s", t.field)
+	}
+
+	switch field {
+	case FieldSize:
+		min, max, err := parseSize(t.value)
+		if err != nil {
+			return nil, err
+		}
+		min, max, err = applyOperator(t, min, max)
+		if err != nil {
+			return nil, err
+		}
+		return NumericRange{Field: field, Min: min, Max: max}, nil
+	case FieldMtime, FieldDeleted:
+		start, end, err := parseTime(t.value, p.now)
+		if err != nil {
+			return nil, err
+		}
+		startBound, endBound, err := applyOperator(t, &start, &end)
+		if err != nil {
+			return nil, err
+		}
+		r := TimeRange{Field: field}
+		if startBound != nil {
+			r.Start = *startBound
+		}
+		if endBound != nil {
+			r.End = *endBound
+		}
+		return r, nil
+	}
+
+	if !isEquality(t.operator) {
+		return nil, fmt.Errorf("operator %q is not supported for %s", strings.TrimPrefix(t.operator, ":"), t.field)
+	}
+	m := Match{Field: field, Value: t.value, Phrase: t.phrase}
+	switch field {
+	case FieldMimeType:
+		m.Value = strings.ToLower(m.Value)
+	case FieldMediaType:
+		m.Value = strings.ToLower(m.Value)
+		if !isMediaType(m.Value) {
+			return nil, fmt.Errorf("unknown media type %q, known media types are %s", t.value, strings.Join(MediaTypes, ", "))
+		}
+	case FieldType:
+		switch strings.ToLower(m.Value) {
+		case "file":
+			m.Value = TypeFile
+		case "folder", "directory", "dir":
+			m.Value = TypeFolder
+		default:
+			return nil, fmt.Errorf("unknown type %q, known types are file and folder", t.value)
+		}
+	}
+	return m, nil
+}
+
+func isEquality(operator string) bool {
+	return operator == ":" || operator == "="
+}
+
+// applyOperator turns the interval [min, max) of the restriction value into the interval of the
+// values matched by the operator. A nil bound is open.
+func applyOperator[T any](t token, min, max *T) (*T, *T, error) {
+	switch strings.TrimPrefix(t.operator, ":") {
+	case "", "=":
+		return min, max, nil
+	case ">":
+		return max, nil, nil
+	case ">=":
+		return min, nil, nil
+	case "<":
+		return nil, min, nil
+	case "<=":
+		return nil, max, nil
+	}
+	return nil, nil, fmt.Errorf("operator %q is not supported for %s", t.operator, t.field)
+}
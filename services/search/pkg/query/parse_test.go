@@ -0,0 +1,104 @@
+package query_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
+)
+
+var _ = Describe("Parse", func() {
+	// a wednesday
+	now := time.Date(2022, time.October, 12, 15, 30, 0, 0, time.UTC)
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	size := func(n float64) *float64 {
+		return &n
+	}
+
+	DescribeTable("parses valid queries",
+		func(q string, expected query.Node) {
+			n, err := query.Parse(q, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(expected))
+		},
+		Entry("free text", "report", query.Match{Value: "report"}),
+		Entry("phrases", `"annual report"`, query.Match{Value: "annual report", Phrase: true}),
+		Entry("escaped quotes", `"say \"hi\""`, query.Match{Value: `say "hi"`, Phrase: true}),
+		Entry("implicit AND", "annual report", query.And{Nodes: []query.Node{
+			query.Match{Value: "annual"},
+			query.Match{Value: "report"},
+		}}),
+		Entry("OR binding weaker than AND", "a b OR c", query.Or{Nodes: []query.Node{
+			query.And{Nodes: []query.Node{query.Match{Value: "a"}, query.Match{Value: "b"}}},
+			query.Match{Value: "c"},
+		}}),
+		Entry("parentheses", "a AND (b OR c)", query.And{Nodes: []query.Node{
+			query.Match{Value: "a"},
+			query.Or{Nodes: []query.Node{query.Match{Value: "b"}, query.Match{Value: "c"}}},
+		}}),
+		Entry("NOT", "NOT a", query.Not{Node: query.Match{Value: "a"}}),
+		Entry("- and +", "-a +b", query.And{Nodes: []query.Node{
+			query.Not{Node: query.Match{Value: "a"}},
+			query.Match{Value: "b"},
+		}}),
+		Entry("lower case operators as text", "cats and dogs", query.And{Nodes: []query.Node{
+			query.Match{Value: "cats"},
+			query.Match{Value: "and"},
+			query.Match{Value: "dogs"},
+		}}),
+		Entry("names", "name:Report*.pdf", query.Match{Field: query.FieldName, Value: "Report*.pdf"}),
+		Entry("case insensitive field names", `Name:"my report.pdf"`, query.Match{Field: query.FieldName, Value: "my report.pdf", Phrase: true}),
+		Entry("unknown fields as text", "note:important", query.Match{Value: "note:important"}),
+		Entry("mime types", "mimetype:Application/PDF", query.Match{Field: query.FieldMimeType, Value: "application/pdf"}),
+		Entry("media types", "mediatype:image", query.Match{Field: query.FieldMediaType, Value: "image"}),
+		Entry("tags", "tag:invoice", query.Match{Field: query.FieldTags, Value: "invoice"}),
+		Entry("types", "type:directory", query.Match{Field: query.FieldType, Value: query.TypeFolder}),
+		Entry("sizes", "size:100", query.NumericRange{Field: query.FieldSize, Min: size(100), Max: size(101)}),
+		Entry("sizes with units", "size>1.5kb", query.NumericRange{Field: query.FieldSize, Min: size(1537)}),
+		Entry("bleve style comparisons", "size:<=1MiB", query.NumericRange{Field: query.FieldSize, Max: size(1<<20 + 1)}),
+		Entry("dates", "mtime:2022-10-01", query.TimeRange{Field: query.FieldMtime, Start: day(2022, time.October, 1), End: day(2022, time.October, 2)}),
+		Entry("dates after", "mtime>2022-10-01", query.TimeRange{Field: query.FieldMtime, Start: day(2022, time.October, 2)}),
+		Entry("months before", "mtime<2022-10", query.TimeRange{Field: query.FieldMtime, End: day(2022, time.October, 1)}),
+		Entry("times", `mtime>="2022-10-01T10:00:00Z"`, query.TimeRange{Field: query.FieldMtime, Start: time.Date(2022, time.October, 1, 10, 0, 0, 0, time.UTC)}),
+		Entry("today", "mtime:today", query.TimeRange{Field: query.FieldMtime, Start: day(2022, time.October, 12), End: day(2022, time.October, 13)}),
+		Entry("weeks", `mtime:"last week"`, query.TimeRange{Field: query.FieldMtime, Start: day(2022, time.October, 3), End: day(2022, time.October, 10)}),
+		Entry("days", `mtime:"last 7 days"`, query.TimeRange{Field: query.FieldMtime, Start: day(2022, time.October, 6), End: day(2022, time.October, 13)}),
+		Entry("deletion times", `deleted:"last month"`, query.TimeRange{Field: query.FieldDeleted, Start: day(2022, time.September, 1), End: day(2022, time.October, 1)}),
+	)
+
+	DescribeTable("rejects invalid queries",
+		func(q string) {
+			_, err := query.Parse(q, now)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("empty queries", "  "),
+		Entry("missing closing parentheses", "(a OR b"),
+		Entry("unexpected closing parentheses", "a)"),
+		Entry("dangling operators", "a OR"),
+		Entry("missing closing quotes", `"annual report`),
+		Entry("missing values", "name: report"),
+		Entry("invalid sizes", "size>big"),
+		Entry("invalid dates", "mtime>someday"),
+		Entry("unknown types", "type:printer"),
+		Entry("unknown media types", "mediatype:hologram"),
+		Entry("comparisons of names", "name>report"),
+	)
+})
+
+var _ = Describe("MediaType", func() {
+	DescribeTable("groups mime types",
+		func(mimeType, expected string) {
+			Expect(query.MediaType(mimeType)).To(Equal(expected))
+		},
+		Entry("folders", "httpd/unix-directory", "folder"),
+		Entry("text", "text/plain", "document"),
+		Entry("csv", "text/csv", "spreadsheet"),
+		Entry("office documents", "application/vnd.openxmlformats-officedocument.presentationml.presentation", "presentation"),
+		Entry("images", "image/png", "image"),
+		Entry("unknown types", "application/octet-stream", "other"),
+	)
+})
@@ -0,0 +1,117 @@
+// Package query implements the query language of the search service. It is modeled after the
+// Keyword Query Language (KQL): free text and field restrictions like `name:report*`,
+// `mimetype:application/pdf`, `size>10MB`, `mtime>=2022-10-01`, `type:folder`, `tag:invoice` or
+// `deleted:"last month"` are combined with the boolean operators AND, OR and NOT, quoted phrases
+// and parentheses.
+//
+// Queries are parsed into a syntax tree independent of the search engine, the index clients
+// translate the tree into the queries of their engine.
+package query
+
+import (
+	"strings"
+	"time"
+)
+
+// Field is a property of the indexed resources that can be restricted in a query
+type Field string
+
+const (
+	// FieldName is the name of the resource
+	FieldName Field = "name"
+	// FieldContent is the text content of the file
+	FieldContent Field = "content"
+	// FieldMimeType is the mime type of the resource
+	FieldMimeType Field = "mimetype"
+	// FieldMediaType is the group of the mime type, see MediaType
+	FieldMediaType Field = "mediatype"
+	// FieldSize is the size of the resource in bytes
+	FieldSize Field = "size"
+	// FieldMtime is the modification time of the resource
+	FieldMtime Field = "mtime"
+	// FieldType is the type of the resource, either TypeFile or TypeFolder
+	FieldType Field = "type"
+	// FieldTags are the tags of the resource
+	FieldTags Field = "tags"
+	// FieldID is the id of the resource
+	FieldID Field = "id"
+	// FieldDeleted is the time the resource was moved to the trash bin
+	FieldDeleted Field = "deleted"
+)
+
+// fieldNames maps the field names used in queries to the fields
+var fieldNames = map[string]Field{
+	"name":      FieldName,
+	"content":   FieldContent,
+	"mimetype":  FieldMimeType,
+	"mediatype": FieldMediaType,
+	"size":      FieldSize,
+	"mtime":     FieldMtime,
+	"type":      FieldType,
+	"tag":       FieldTags,
+	"tags":      FieldTags,
+	"id":        FieldID,
+	"deleted":   FieldDeleted,
+}
+
+const (
+	// TypeFile is the value of the type field of files
+	TypeFile = "file"
+	// TypeFolder is the value of the type field of folders
+	TypeFolder = "folder"
+)
+
+// Node is a node of the syntax tree of a query
+type Node interface {
+	node()
+}
+
+// And matches the resources matched by all of its nodes
+type And struct {
+	Nodes []Node
+}
+
+// Or matches the resources matched by any of its nodes
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches the resources not matched by its node
+type Not struct {
+	Node Node
+}
+
+// Match matches the resources with the value in the field. Free text has no field and matches
+// the name or the content of the resources.
+type Match struct {
+	Field Field
+	Value string
+	// Phrase is set for quoted values, they never contain wildcards
+	Phrase bool
+}
+
+// NumericRange matches the resources with a value of the field in the interval [Min, Max).
+// Bounds that are nil are open.
+type NumericRange struct {
+	Field    Field
+	Min, Max *float64
+}
+
+// TimeRange matches the resources with a value of the field in the interval [Start, End).
+// Bounds that are zero are open.
+type TimeRange struct {
+	Field      Field
+	Start, End time.Time
+}
+
+func (And) node()          {}
+func (Or) node()           {}
+func (Not) node()          {}
+func (Match) node()        {}
+func (NumericRange) node() {}
+func (TimeRange) node()    {}
+
+// HasWildcard reports whether the value is a pattern containing the wildcards * or ?
+func (m Match) HasWildcard() bool {
+	return !m.Phrase && strings.ContainsAny(m.Value, "*?")
+}
@@ -0,0 +1,159 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// MediaTypes are the values of the mediatype field, which groups the mime types of the
+// resources into the categories offered to filter search results
+var MediaTypes = []string{"folder", "document", "spreadsheet", "presentation", "pdf", "image", "video", "audio", "archive", "other"}
+
+// mediaTypePrefixes maps the prefixes of mime types to their media type, more specific
+// prefixes win
+var mediaTypePrefixes = map[string]string{
+	"httpd/unix-directory": "folder",
+	"application/pdf":      "pdf",
+	"image/":               "image",
+	"video/":               "video",
+	"audio/":               "audio",
+
+	"text/":              "document",
+	"application/msword": "document",
+	"application/rtf":    "document",
+	"application/vnd.oasis.opendocument.text":                        "document",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml": "document",
+
+	"text/csv":                  "spreadsheet",
+	"text/tab-separated-values": "spreadsheet",
+	"application/vnd.ms-excel":  "spreadsheet",
+	"application/vnd.oasis.opendocument.spreadsheet":              "spreadsheet",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml": "spreadsheet",
+
+	"application/vnd.ms-powerpoint":                                "presentation",
+	"application/vnd.oasis.opendocument.presentation":              "presentation",
+	"application/vnd.openxmlformats-officedocument.presentationml": "presentation",
+
+	"application/zip":              "archive",
+	"application/gzip":             "archive",
+	"application/x-gzip":           "archive",
+	"application/x-tar":            "archive",
+	"application/x-bzip2":          "archive",
+	"application/x-7z-compressed":  "archive",
+	"application/x-rar-compressed": "archive",
+	"application/vnd.rar":          "archive",
+}
+
+// MediaType returns the media type of the mime type
+func MediaType(mimeType string) string {
+	mimeType = strings.ToLower(mimeType)
+	mediaType, matched := "other", ""
+	for prefix, t := range mediaTypePrefixes {
+		if strings.HasPrefix(mimeType, prefix) && len(prefix) > len(matched) {
+			mediaType, matched = t, prefix
+		}
+	}
+	return mediaType
+}
+
+func isMediaType(value string) bool {
+	return slices.Contains(MediaTypes, value)
+}
+
+// MtimeRanges are the names of the relative time ranges offered as facet of the modification
+// time. They can be used as values of the mtime field, e.g. `mtime:"last 7 days"`.
+var MtimeRanges = []string{"today", "yesterday", "last 7 days", "last 30 days", "this year", "last year"}
+
+var (
+	sizePattern     = regexp.MustCompile(`^(?i)(\d+(?:\.\d+)?)\s*([kmgt]i?b?|b)?$`)
+	lastDaysPattern = regexp.MustCompile(`^last (\d+) days$`)
+)
+
+// sizeUnits are the multipliers of the size units, they are binary like the sizes shown by
+// the clients
+var sizeUnits = map[string]float64{"": 1, "b": 1, "k": 1 << 10, "m": 1 << 20, "g": 1 << 30, "t": 1 << 40}
+
+// parseSize returns the interval [min, max) matching the size, e.g. 1.5MB or 100
+func parseSize(value string) (*float64, *float64, error) {
+	m := sizePattern.FindStringSubmatch(value)
+	if m == nil {
+		return nil, nil, fmt.Errorf("invalid size %q", value)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid size %q", value)
+	}
+	unit := strings.ToLower(m[2])
+	if unit != "b" {
+		unit = strings.TrimRight(unit, "ib")
+	}
+	min := math.Ceil(n * sizeUnits[unit])
+	max := min + 1
+	return &min, &max, nil
+}
+
+// parseTime returns the interval [start, end) matching the time. Dates match the whole day,
+// e.g. 2022-10-01, names of time ranges like "today" or "last 30 days" match the range.
+func parseTime(value string, now time.Time) (time.Time, time.Time, error) {
+	if start, end, ok := TimeRangeByName(value, now); ok {
+		return start, end, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, t.Add(time.Nanosecond), nil
+	}
+	for _, layout := range []struct {
+		format              string
+		years, months, days int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	} {
+		if t, err := time.ParseInLocation(layout.format, value, now.Location()); err == nil {
+			return t, t.AddDate(layout.years, layout.months, layout.days), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid time %q, use a date like 2022-10-01 or one of %s", value, strings.Join(MtimeRanges, ", "))
+}
+
+// TimeRangeByName returns the interval [start, end) of the named time range relative to now
+func TimeRangeByName(name string, now time.Time) (time.Time, time.Time, bool) {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// weeks start on monday
+	week := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	year := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+
+	switch name {
+	case "today":
+		return today, today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, true
+	case "this week":
+		return week, week.AddDate(0, 0, 7), true
+	case "last week":
+		return week.AddDate(0, 0, -7), week, true
+	case "this month":
+		return month, month.AddDate(0, 1, 0), true
+	case "last month":
+		return month.AddDate(0, -1, 0), month, true
+	case "this year":
+		return year, year.AddDate(1, 0, 0), true
+	case "last year":
+		return year.AddDate(-1, 0, 0), year, true
+	}
+	if m := lastDaysPattern.FindStringSubmatch(name); m != nil {
+		days, err := strconv.Atoi(m[1])
+		if err == nil {
+			return today.AddDate(0, 0, 1-days), today.AddDate(0, 0, 1), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
@@ -21,6 +21,7 @@ package index
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"path"
 	"regexp"
@@ -30,6 +31,7 @@ import (
 	bleve "github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
 	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
 	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/mapping"
@@ -39,8 +41,11 @@ import (
 
 	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/v2/pkg/utils"
+	"github.com/owncloud/ocis/v2/ocis-pkg/tags"
 	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
 	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
 )
 
 type indexDocument struct {
@@ -49,15 +54,26 @@ type indexDocument struct {
 	ID       string
 	ParentID string
 
-	Name     string
-	Size     uint64
-	Mtime    string
-	MimeType string
-	Type     uint64
+	Name      string
+	Size      uint64
+	Mtime     string
+	MimeType  string
+	MediaType string
+	Type      uint64
+	Tags      []string
+	Content   string
 
 	Deleted bool
+	// TrashKey is the key of the deleted resource in the trash bin. Children of a deleted
+	// container use the key of the container followed by their path relative to it.
+	TrashKey     string
+	DeletionTime string
 }
 
+// resultFields are the stored fields loaded for search results. The content is only needed
+// for highlighting, loading it for every match would be expensive.
+var resultFields = []string{"RootID", "Path", "ID", "ParentID", "Name", "Size", "Mtime", "MimeType", "Type", "Tags", "Deleted", "TrashKey", "DeletionTime"}
+
 // Index represents a bleve based search index
 type Index struct {
 	bleveIndex bleve.Index
@@ -89,53 +105,103 @@ func (i *Index) DocCount() (uint64, error) {
 }
 
 // Add adds a new entity to the Index
-func (i *Index) Add(ref *sprovider.Reference, ri *sprovider.ResourceInfo) error {
+func (i *Index) Add(ref *sprovider.Reference, ri *sprovider.ResourceInfo, doc content.Document) error {
 	entity := toEntity(ref, ri)
+	entity.Content = doc.Content
 	return i.bleveIndex.Index(idToBleveId(ri.Id), entity)
 }
 
-// Delete marks an entity from the index as deleten (still keeping it around)
+// Delete marks an entity from the index as deleted (still keeping it around). The entity is
+// stored with its key in the trash bin, which is the opaque id of the trashed resource.
 func (i *Index) Delete(id *sprovider.ResourceId) error {
-	return i.markAsDeleted(idToBleveId(id), true)
+	deletionTime := time.Now().UTC().Format(time.RFC3339Nano)
+	doc, err := i.updateEntity(idToBleveId(id), func(doc *indexDocument) {
+		doc.Deleted = true
+		doc.TrashKey = id.GetOpaqueId()
+		doc.DeletionTime = deletionTime
+	})
+	if err != nil {
+		return err
+	}
+
+	children, err := i.children(doc)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		// children deleted before have their own item in the trash bin
+		if child.Deleted {
+			continue
+		}
+		_, err := i.updateEntity(child.ID, func(child *indexDocument) {
+			child.Deleted = true
+			child.TrashKey = doc.TrashKey + "/" + strings.TrimPrefix(child.Path, doc.Path+"/")
+			child.DeletionTime = deletionTime
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Restore marks an entity from the index as not being deleted
 func (i *Index) Restore(id *sprovider.ResourceId) error {
-	return i.markAsDeleted(idToBleveId(id), false)
-}
-
-func (i *Index) markAsDeleted(id string, deleted bool) error {
-	doc, err := i.updateEntity(id, func(doc *indexDocument) {
-		doc.Deleted = deleted
+	var trashKey string
+	doc, err := i.updateEntity(idToBleveId(id), func(doc *indexDocument) {
+		trashKey = doc.TrashKey
+		doc.Deleted = false
+		doc.TrashKey = ""
+		doc.DeletionTime = ""
 	})
 	if err != nil {
 		return err
 	}
 
-	if doc.Type == uint64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER) {
-		query := bleve.NewConjunctionQuery(
-			bleve.NewQueryStringQuery("RootID:"+doc.RootID),
-			bleve.NewQueryStringQuery("Path:"+queryEscape(doc.Path+"/*")),
-		)
-		bleveReq := bleve.NewSearchRequest(query)
-		bleveReq.Size = math.MaxInt
-		bleveReq.Fields = []string{"*"}
-		res, err := i.bleveIndex.Search(bleveReq)
+	children, err := i.children(doc)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		// children deleted before the container stay in the trash bin, documents indexed
+		// without trash keys are restored with the container
+		if !child.Deleted || (child.TrashKey != "" && !strings.HasPrefix(child.TrashKey, trashKey+"/")) {
+			continue
+		}
+		_, err := i.updateEntity(child.ID, func(child *indexDocument) {
+			child.Deleted = false
+			child.TrashKey = ""
+			child.DeletionTime = ""
+		})
 		if err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		for _, h := range res.Hits {
-			_, err := i.updateEntity(h.ID, func(doc *indexDocument) {
-				doc.Deleted = deleted
-			})
-			if err != nil {
-				return err
-			}
-		}
+// children returns the documents below the container, it returns nothing for files
+func (i *Index) children(doc *indexDocument) ([]*indexDocument, error) {
+	if doc.Type != uint64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+		return nil, nil
+	}
+	query := bleve.NewConjunctionQuery(
+		bleve.NewQueryStringQuery("RootID:"+doc.RootID),
+		bleve.NewQueryStringQuery("Path:"+queryEscape(doc.Path+"/*")),
+	)
+	bleveReq := bleve.NewSearchRequest(query)
+	bleveReq.Size = math.MaxInt
+	bleveReq.Fields = []string{"*"}
+	res, err := i.bleveIndex.Search(bleveReq)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	children := make([]*indexDocument, 0, len(res.Hits))
+	for _, h := range res.Hits {
+		children = append(children, fieldsToEntity(h.Fields))
+	}
+	return children, nil
 }
 
 func (i *Index) updateEntity(id string, mutateFunc func(doc *indexDocument)) (*indexDocument, error) {
@@ -217,11 +283,21 @@ func (i *Index) Move(id, newParentID *sprovider.ResourceId, fullPath string) err
 
 // Search searches the index according to the criteria specified in the given SearchIndexRequest
 func (i *Index) Search(ctx context.Context, req *searchsvc.SearchIndexRequest) (*searchsvc.SearchIndexResponse, error) {
-	deletedQuery := bleve.NewBoolFieldQuery(false)
+	now := time.Now()
+	ast, err := query.Parse(req.Query, now)
+	if err != nil {
+		return nil, err
+	}
+	q, err := compileQuery(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	deletedQuery := bleve.NewBoolFieldQuery(req.Trash)
 	deletedQuery.SetField("Deleted")
 	query := bleve.NewConjunctionQuery(
-		bleve.NewQueryStringQuery(req.Query),
-		deletedQuery, // Skip documents that have been marked as deleted
+		q,
+		deletedQuery, // Only search the documents in the trash bin or skip them
 	)
 	if req.Ref != nil {
 		query = bleve.NewConjunctionQuery(
@@ -239,7 +315,16 @@ func (i *Index) Search(ctx context.Context, req *searchsvc.SearchIndexRequest) (
 	if req.PageSize > 0 {
 		bleveReq.Size = int(req.PageSize)
 	}
-	bleveReq.Fields = []string{"*"}
+	bleveReq.Fields = resultFields
+	bleveReq.Highlight = bleve.NewHighlight()
+	bleveReq.Highlight.AddField("Content")
+	for _, name := range req.Facets {
+		facet, err := facetRequest(name, now)
+		if err != nil {
+			return nil, err
+		}
+		bleveReq.AddFacet(name, facet)
+	}
 	res, err := i.bleveIndex.Search(bleveReq)
 	if err != nil {
 		return nil, err
@@ -257,16 +342,69 @@ func (i *Index) Search(ctx context.Context, req *searchsvc.SearchIndexRequest) (
 	return &searchsvc.SearchIndexResponse{
 		Matches:      matches,
 		TotalMatches: int32(res.Total),
+		Facets:       fromFacetResults(req.Facets, res.Facets),
 	}, nil
 }
 
+// facetRequest returns the request of the named facet. The values of the facets can be used
+// as values of the according fields in queries.
+func facetRequest(name string, now time.Time) (*bleve.FacetRequest, error) {
+	switch query.Field(name) {
+	case query.FieldMediaType:
+		return bleve.NewFacetRequest("MediaType", len(query.MediaTypes)), nil
+	case query.FieldMtime:
+		facet := bleve.NewFacetRequest("Mtime", len(query.MtimeRanges))
+		for _, r := range query.MtimeRanges {
+			start, end, _ := query.TimeRangeByName(r, now)
+			facet.AddDateTimeRange(r, start, end)
+		}
+		return facet, nil
+	}
+	return nil, fmt.Errorf("unknown facet %q", name)
+}
+
+func fromFacetResults(names []string, results search.FacetResults) []*searchmsg.Facet {
+	facets := make([]*searchmsg.Facet, 0, len(names))
+	for _, name := range names {
+		result, ok := results[name]
+		if !ok {
+			continue
+		}
+		facet := &searchmsg.Facet{Name: name}
+		for _, term := range result.Terms.Terms() {
+			facet.Values = append(facet.Values, &searchmsg.FacetValue{Value: term.Term, Count: int32(term.Count)})
+		}
+		// the date ranges are sorted by their count, keep them in the order of the ranges instead
+		for _, r := range query.MtimeRanges {
+			for _, dateRange := range result.DateRanges {
+				if dateRange.Name == r && dateRange.Count > 0 {
+					facet.Values = append(facet.Values, &searchmsg.FacetValue{Value: r, Count: int32(dateRange.Count)})
+				}
+			}
+		}
+		facets = append(facets, facet)
+	}
+	return facets
+}
+
 // BuildMapping builds a bleve index mapping which can be used for indexing
 func BuildMapping() (mapping.IndexMapping, error) {
 	nameMapping := bleve.NewTextFieldMapping()
 	nameMapping.Analyzer = "lowercaseKeyword"
 
+	// the content is split into words, it is stored for highlighting the matches
+	contentMapping := bleve.NewTextFieldMapping()
+	contentMapping.Analyzer = standard.Name
+	contentMapping.IncludeInAll = false
+
+	// tags are matched case insensitively
+	tagsMapping := bleve.NewTextFieldMapping()
+	tagsMapping.Analyzer = "lowercaseKeyword"
+
 	docMapping := bleve.NewDocumentMapping()
 	docMapping.AddFieldMappingsAt("Name", nameMapping)
+	docMapping.AddFieldMappingsAt("Tags", tagsMapping)
+	docMapping.AddFieldMappingsAt("Content", contentMapping)
 
 	indexMapping := bleve.NewIndexMapping()
 	indexMapping.DefaultAnalyzer = keyword.Name
@@ -288,15 +426,17 @@ func BuildMapping() (mapping.IndexMapping, error) {
 
 func toEntity(ref *sprovider.Reference, ri *sprovider.ResourceInfo) *indexDocument {
 	doc := &indexDocument{
-		RootID:   idToBleveId(ref.ResourceId),
-		Path:     ref.Path,
-		ID:       idToBleveId(ri.Id),
-		ParentID: idToBleveId(ri.ParentId),
-		Name:     ri.Path,
-		Size:     ri.Size,
-		MimeType: ri.MimeType,
-		Type:     uint64(ri.Type),
-		Deleted:  false,
+		RootID:    idToBleveId(ref.ResourceId),
+		Path:      ref.Path,
+		ID:        idToBleveId(ri.Id),
+		ParentID:  idToBleveId(ri.ParentId),
+		Name:      ri.Path,
+		Size:      ri.Size,
+		MimeType:  ri.MimeType,
+		MediaType: query.MediaType(ri.MimeType),
+		Type:      uint64(ri.Type),
+		Tags:      tags.Parse(ri.GetArbitraryMetadata().GetMetadata()[tags.MetadataKey]),
+		Deleted:   false,
 	}
 
 	if ri.Mtime != nil {
@@ -308,17 +448,25 @@ func toEntity(ref *sprovider.Reference, ri *sprovider.ResourceInfo) *indexDocume
 
 func fieldsToEntity(fields map[string]interface{}) *indexDocument {
 	doc := &indexDocument{
-		RootID:   fields["RootID"].(string),
-		Path:     fields["Path"].(string),
-		ID:       fields["ID"].(string),
-		ParentID: fields["ParentID"].(string),
-		Name:     fields["Name"].(string),
-		Size:     uint64(fields["Size"].(float64)),
-		Mtime:    fields["Mtime"].(string),
-		MimeType: fields["MimeType"].(string),
-		Type:     uint64(fields["Type"].(float64)),
-		Deleted:  fields["Deleted"].(bool),
+		RootID:    fields["RootID"].(string),
+		Path:      fields["Path"].(string),
+		ID:        fields["ID"].(string),
+		ParentID:  fields["ParentID"].(string),
+		Name:      fields["Name"].(string),
+		Size:      uint64(fields["Size"].(float64)),
+		Mtime:     fields["Mtime"].(string),
+		MimeType:  fields["MimeType"].(string),
+		MediaType: query.MediaType(fields["MimeType"].(string)),
+		Type:      uint64(fields["Type"].(float64)),
+		Tags:      fieldToStrings(fields["Tags"]),
+		Deleted:   fields["Deleted"].(bool),
 	}
+	if c, ok := fields["Content"].(string); ok {
+		doc.Content = c
+	}
+	// documents indexed before the trash bin was searchable have no trash keys
+	doc.TrashKey, _ = fields["TrashKey"].(string)
+	doc.DeletionTime, _ = fields["DeletionTime"].(string)
 	return doc
 }
 
@@ -333,7 +481,8 @@ func fromDocumentMatch(hit *search.DocumentMatch) (*searchmsg.Match, error) {
 	}
 
 	match := &searchmsg.Match{
-		Score: float32(hit.Score),
+		Score:      float32(hit.Score),
+		Highlights: hit.Fragments["Content"],
 		Entity: &searchmsg.Entity{
 			Ref: &searchmsg.Reference{
 				ResourceId: resourceIDtoSearchID(rootID),
@@ -344,6 +493,7 @@ func fromDocumentMatch(hit *search.DocumentMatch) (*searchmsg.Match, error) {
 			Size:     uint64(hit.Fields["Size"].(float64)),
 			Type:     uint64(hit.Fields["Type"].(float64)),
 			MimeType: hit.Fields["MimeType"].(string),
+			Tags:     fieldToStrings(hit.Fields["Tags"]),
 			Deleted:  hit.Fields["Deleted"].(bool),
 		},
 	}
@@ -359,9 +509,36 @@ func fromDocumentMatch(hit *search.DocumentMatch) (*searchmsg.Match, error) {
 		match.Entity.LastModifiedTime = &timestamppb.Timestamp{Seconds: mtime.Unix(), Nanos: int32(mtime.Nanosecond())}
 	}
 
+	if match.Entity.Deleted {
+		match.Entity.TrashKey, _ = hit.Fields["TrashKey"].(string)
+		if deletionTime, ok := hit.Fields["DeletionTime"].(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, deletionTime); err == nil {
+				match.Entity.DeletionTime = timestamppb.New(t)
+			}
+		}
+	}
+
 	return match, nil
 }
 
+// fieldToStrings returns the values of a stored field with multiple values. bleve returns
+// fields with a single value as plain value instead of a slice.
+func fieldToStrings(field interface{}) []string {
+	switch v := field.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, value := range v {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
 func idToBleveId(id *sprovider.ResourceId) string {
 	if id == nil {
 		return ""
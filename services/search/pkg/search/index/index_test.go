@@ -2,12 +2,14 @@ package index_test
 
 import (
 	"context"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
 	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
 	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
 	"github.com/owncloud/ocis/v2/services/search/pkg/search/index"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -142,7 +144,7 @@ var _ = Describe("Index", func() {
 	Describe("Search", func() {
 		Context("by other fields than filename", func() {
 			JustBeforeEach(func() {
-				err := i.Add(ref, ri)
+				err := i.Add(ref, ri, content.Document{})
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -157,20 +159,154 @@ var _ = Describe("Index", func() {
 			})
 		})
 
+		Context("with the query language", func() {
+			JustBeforeEach(func() {
+				err := i.Add(ref, ri, content.Document{Content: "The quick brown fox"})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("finds files by their properties", func() {
+				assertDocCount(ref.ResourceId, `size>10KB`, 1)
+				assertDocCount(ref.ResourceId, `size<=12345`, 1)
+				assertDocCount(ref.ResourceId, `type:file`, 1)
+				assertDocCount(ref.ResourceId, `mimetype:application/pdf`, 1)
+				assertDocCount(ref.ResourceId, `mimetype:application/*`, 1)
+				assertDocCount(ref.ResourceId, `mediatype:pdf`, 1)
+				assertDocCount(ref.ResourceId, `mtime<2000-01-01`, 1)
+				assertDocCount(ref.ResourceId, `mtime:1970-01-01`, 1)
+
+				assertDocCount(ref.ResourceId, `size<12345`, 0)
+				assertDocCount(ref.ResourceId, `type:folder`, 0)
+				assertDocCount(ref.ResourceId, `mediatype:image`, 0)
+				assertDocCount(ref.ResourceId, `mtime:today`, 0)
+			})
+
+			It("combines the restrictions", func() {
+				assertDocCount(ref.ResourceId, `foo fox`, 1)
+				assertDocCount(ref.ResourceId, `bar OR fox`, 1)
+				assertDocCount(ref.ResourceId, `(name:bar.pdf OR name:foo.pdf) AND type:file`, 1)
+				assertDocCount(ref.ResourceId, `NOT cat`, 1)
+
+				assertDocCount(ref.ResourceId, `foo cat`, 0)
+				assertDocCount(ref.ResourceId, `-fox`, 0)
+				assertDocCount(ref.ResourceId, `foo AND NOT mediatype:pdf`, 0)
+			})
+
+			It("rejects invalid queries", func() {
+				_, err := i.Search(ctx, &searchsvc.SearchIndexRequest{Query: `size>lots`})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("with facets", func() {
+			JustBeforeEach(func() {
+				err := i.Add(ref, ri, content.Document{})
+				Expect(err).ToNot(HaveOccurred())
+
+				ri.Id.OpaqueId = "imageopaqueid"
+				ri.Path = "foo.png"
+				ri.MimeType = "image/png"
+				ri.Mtime = &typesv1beta1.Timestamp{Seconds: uint64(time.Now().Unix())}
+				ref.Path = "./" + ri.Path
+				err = i.Add(ref, ri, content.Document{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("counts the matches by media type and modification time", func() {
+				res, err := i.Search(ctx, &searchsvc.SearchIndexRequest{
+					Query:  "foo",
+					Facets: []string{"mediatype", "mtime"},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Facets).To(HaveLen(2))
+
+				Expect(res.Facets[0].Name).To(Equal("mediatype"))
+				Expect(res.Facets[0].Values).To(ConsistOf(
+					&searchmsg.FacetValue{Value: "pdf", Count: 1},
+					&searchmsg.FacetValue{Value: "image", Count: 1},
+				))
+
+				Expect(res.Facets[1].Name).To(Equal("mtime"))
+				Expect(res.Facets[1].Values[0]).To(Equal(&searchmsg.FacetValue{Value: "today", Count: 1}))
+			})
+
+			It("rejects unknown facets", func() {
+				_, err := i.Search(ctx, &searchsvc.SearchIndexRequest{Query: "foo", Facets: []string{"color"}})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("by tags", func() {
+			JustBeforeEach(func() {
+				ri.ArbitraryMetadata = &sprovider.ArbitraryMetadata{
+					Metadata: map[string]string{"tags": "Invoice,2026"},
+				}
+				err := i.Add(ref, ri, content.Document{})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("finds files by their tags", func() {
+				assertDocCount(ref.ResourceId, `Tags:invoice`, 1)
+				assertDocCount(ref.ResourceId, `Tags:INVOICE`, 1)
+				assertDocCount(ref.ResourceId, `Tags:2026`, 1)
+				assertDocCount(ref.ResourceId, `+Tags:invoice +Tags:2026`, 1)
+
+				assertDocCount(ref.ResourceId, `Tags:draft`, 0)
+				assertDocCount(ref.ResourceId, `Tags:invo`, 0)
+			})
+
+			It("returns the tags", func() {
+				matches := assertDocCount(ref.ResourceId, `Tags:invoice`, 1)
+				Expect(matches[0].Entity.Tags).To(Equal([]string{"Invoice", "2026"}))
+			})
+		})
+
+		Context("by content", func() {
+			JustBeforeEach(func() {
+				err := i.Add(ref, ri, content.Document{Content: "The quick brown fox jumps over the lazy dog"})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("finds files by the words of their content", func() {
+				assertDocCount(ref.ResourceId, `Content:fox`, 1)
+				assertDocCount(ref.ResourceId, `Content:Quick`, 1)
+				assertDocCount(ref.ResourceId, `Content:"lazy dog"`, 1)
+
+				assertDocCount(ref.ResourceId, `Content:cat`, 0)
+				assertDocCount(ref.ResourceId, `Content:"dog lazy"`, 0)
+			})
+
+			It("highlights the matches", func() {
+				matches := assertDocCount(ref.ResourceId, `Content:fox`, 1)
+				Expect(matches[0].Highlights).To(HaveLen(1))
+				Expect(matches[0].Highlights[0]).To(ContainSubstring("<mark>fox</mark>"))
+			})
+
+			It("keeps the content when the document is updated", func() {
+				err := i.Delete(ri.Id)
+				Expect(err).ToNot(HaveOccurred())
+				err = i.Restore(ri.Id)
+				Expect(err).ToNot(HaveOccurred())
+
+				assertDocCount(ref.ResourceId, `Content:fox`, 1)
+			})
+		})
+
 		Context("by filename", func() {
 			It("finds files with spaces in the filename", func() {
 				ri.Path = "Foo oo.pdf"
 				ref.Path = "./" + ri.Path
-				err := i.Add(ref, ri)
+				err := i.Add(ref, ri, content.Document{})
 				Expect(err).ToNot(HaveOccurred())
 
-				assertDocCount(ref.ResourceId, `Name:foo\ o*`, 1)
+				assertDocCount(ref.ResourceId, `"foo o"`, 1)
+				assertDocCount(ref.ResourceId, `name:"foo oo.pdf"`, 1)
 			})
 
 			It("finds files by digits in the filename", func() {
 				ri.Path = "12345.pdf"
 				ref.Path = "./" + ri.Path
-				err := i.Add(ref, ri)
+				err := i.Add(ref, ri, content.Document{})
 				Expect(err).ToNot(HaveOccurred())
 
 				assertDocCount(ref.ResourceId, `Name:1234*`, 1)
@@ -178,7 +314,7 @@ var _ = Describe("Index", func() {
 
 			Context("with a file in the root of the space", func() {
 				JustBeforeEach(func() {
-					err := i.Add(ref, ri)
+					err := i.Add(ref, ri, content.Document{})
 					Expect(err).ToNot(HaveOccurred())
 				})
 
@@ -236,9 +372,9 @@ var _ = Describe("Index", func() {
 					}
 				})
 
-				It("uses a lower-case index", func() {
-					assertDocCount(ref.ResourceId, "Name:foo*", 1)
-					assertDocCount(ref.ResourceId, "Name:Foo*", 0)
+				It("matches the name case insensitively", func() {
+					assertDocCount(ref.ResourceId, "name:foo*", 1)
+					assertDocCount(ref.ResourceId, "name:FOO.PDF", 1)
 				})
 
 				Context("and an additional file in a subdirectory", func() {
@@ -265,7 +401,7 @@ var _ = Describe("Index", func() {
 							Path: "nestedpdf.pdf",
 							Size: 12345,
 						}
-						err := i.Add(nestedRef, nestedRI)
+						err := i.Add(nestedRef, nestedRI, content.Document{})
 						Expect(err).ToNot(HaveOccurred())
 					})
 
@@ -299,7 +435,7 @@ var _ = Describe("Index", func() {
 
 	Describe("Add", func() {
 		It("adds a resourceInfo to the index", func() {
-			err := i.Add(ref, ri)
+			err := i.Add(ref, ri, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
 
 			count, err := bleveIndex.DocCount()
@@ -313,12 +449,12 @@ var _ = Describe("Index", func() {
 		})
 
 		It("updates an existing resource in the index", func() {
-			err := i.Add(ref, ri)
+			err := i.Add(ref, ri, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
 			count, _ := bleveIndex.DocCount()
 			Expect(count).To(Equal(uint64(1)))
 
-			err = i.Add(ref, ri)
+			err = i.Add(ref, ri, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
 			count, _ = bleveIndex.DocCount()
 			Expect(count).To(Equal(uint64(1)))
@@ -327,65 +463,111 @@ var _ = Describe("Index", func() {
 
 	Describe("Delete", func() {
 		It("marks a resource as deleted", func() {
-			err := i.Add(parentRef, parentRi)
+			err := i.Add(parentRef, parentRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
-			assertDocCount(rootId, `sub\ d!r`, 1)
+			assertDocCount(rootId, `"sub d!r"`, 1)
 
 			err = i.Delete(parentRi.Id)
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootId, `sub\ d!r`, 0)
+			assertDocCount(rootId, `"sub d!r"`, 0)
 		})
 
 		It("also marks child resources as deleted", func() {
-			err := i.Add(parentRef, parentRi)
+			err := i.Add(parentRef, parentRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
-			err = i.Add(childRef, childRi)
+			err = i.Add(childRef, childRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootId, `sub\ d\!r`, 1)
+			assertDocCount(rootId, `"sub d!r"`, 1)
 			assertDocCount(rootId, "child.pdf", 1)
 
 			err = i.Delete(parentRi.Id)
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootId, `sub\ d\!r`, 0)
+			assertDocCount(rootId, `"sub d!r"`, 0)
 			assertDocCount(rootId, "child.pdf", 0)
 		})
+
+		It("stores the trash keys of the resources", func() {
+			err := i.Add(parentRef, parentRi, content.Document{})
+			Expect(err).ToNot(HaveOccurred())
+			err = i.Add(childRef, childRi, content.Document{})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = i.Delete(parentRi.Id)
+			Expect(err).ToNot(HaveOccurred())
+
+			res, err := i.Search(ctx, &searchsvc.SearchIndexRequest{Query: "deleted:today", Trash: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(res.Matches)).To(Equal(2))
+			keys := map[string]string{}
+			for _, match := range res.Matches {
+				Expect(match.Entity.Deleted).To(BeTrue())
+				Expect(match.Entity.DeletionTime).ToNot(BeNil())
+				keys[match.Entity.Name] = match.Entity.TrashKey
+			}
+			Expect(keys).To(Equal(map[string]string{
+				"sub d!r":   "parentopaqueid",
+				"child.pdf": "parentopaqueid/child.pdf",
+			}))
+		})
 	})
 
 	Describe("Restore", func() {
 		It("also marks child resources as restored", func() {
-			err := i.Add(parentRef, parentRi)
+			err := i.Add(parentRef, parentRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
-			err = i.Add(childRef, childRi)
+			err = i.Add(childRef, childRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
 			err = i.Delete(parentRi.Id)
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootId, `sub\ d!r`, 0)
+			assertDocCount(rootId, `"sub d!r"`, 0)
 			assertDocCount(rootId, "child.pdf", 0)
 
 			err = i.Restore(parentRi.Id)
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootId, `sub\ d!r`, 1)
+			assertDocCount(rootId, `"sub d!r"`, 1)
 			assertDocCount(rootId, "child.pdf", 1)
 		})
+
+		It("keeps child resources deleted before the parent in the trash bin", func() {
+			err := i.Add(parentRef, parentRi, content.Document{})
+			Expect(err).ToNot(HaveOccurred())
+			err = i.Add(childRef, childRi, content.Document{})
+			Expect(err).ToNot(HaveOccurred())
+			err = i.Delete(childRi.Id)
+			Expect(err).ToNot(HaveOccurred())
+			err = i.Delete(parentRi.Id)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = i.Restore(parentRi.Id)
+			Expect(err).ToNot(HaveOccurred())
+
+			assertDocCount(rootId, `"sub d!r"`, 1)
+			assertDocCount(rootId, "child.pdf", 0)
+
+			res, err := i.Search(ctx, &searchsvc.SearchIndexRequest{Query: "child.pdf", Trash: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(res.Matches)).To(Equal(1))
+			Expect(res.Matches[0].Entity.TrashKey).To(Equal("childopaqueid"))
+		})
 	})
 
 	Describe("Move", func() {
 		It("renames the parent and its child resources", func() {
-			err := i.Add(parentRef, parentRi)
+			err := i.Add(parentRef, parentRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
-			err = i.Add(childRef, childRi)
+			err = i.Add(childRef, childRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
 
 			parentRi.Path = "newname"
 			err = i.Move(parentRi.Id, parentRi.ParentId, "./my/newname")
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootId, `sub\ d!r`, 0)
+			assertDocCount(rootId, `"sub d!r"`, 0)
 
 			matches := assertDocCount(rootId, "Name:child.pdf", 1)
 			Expect(matches[0].Entity.ParentId.OpaqueId).To(Equal("parentopaqueid"))
@@ -393,9 +575,9 @@ var _ = Describe("Index", func() {
 		})
 
 		It("moves the parent and its child resources", func() {
-			err := i.Add(parentRef, parentRi)
+			err := i.Add(parentRef, parentRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
-			err = i.Add(childRef, childRi)
+			err = i.Add(childRef, childRi, content.Document{})
 			Expect(err).ToNot(HaveOccurred())
 
 			parentRi.Path = " "
@@ -407,7 +589,7 @@ var _ = Describe("Index", func() {
 			err = i.Move(parentRi.Id, parentRi.ParentId, "./somewhere/else/newname")
 			Expect(err).ToNot(HaveOccurred())
 
-			assertDocCount(rootId, `sub\ d!r`, 0)
+			assertDocCount(rootId, `"sub d!r"`, 0)
 
 			matches := assertDocCount(rootId, "Name:child.pdf", 1)
 			Expect(matches[0].Entity.ParentId.OpaqueId).To(Equal("parentopaqueid"))
@@ -0,0 +1,127 @@
+package index
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	bleve "github.com/blevesearch/bleve/v2"
+	blevequery "github.com/blevesearch/bleve/v2/search/query"
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
+)
+
+// indexFields maps the fields of the query language to the fields of the index documents
+var indexFields = map[query.Field]string{
+	query.FieldName:      "Name",
+	query.FieldContent:   "Content",
+	query.FieldMimeType:  "MimeType",
+	query.FieldMediaType: "MediaType",
+	query.FieldSize:      "Size",
+	query.FieldMtime:     "Mtime",
+	query.FieldType:      "Type",
+	query.FieldTags:      "Tags",
+	query.FieldID:        "ID",
+	query.FieldDeleted:   "DeletionTime",
+}
+
+// compileQuery translates the syntax tree of a search query into a bleve query
+func compileQuery(n query.Node) (blevequery.Query, error) {
+	switch n := n.(type) {
+	case query.And:
+		queries, err := compileQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return bleve.NewConjunctionQuery(queries...), nil
+	case query.Or:
+		queries, err := compileQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return bleve.NewDisjunctionQuery(queries...), nil
+	case query.Not:
+		q, err := compileQuery(n.Node)
+		if err != nil {
+			return nil, err
+		}
+		// a boolean query without must and should clauses matches all other documents
+		not := bleve.NewBooleanQuery()
+		not.AddMustNot(q)
+		return not, nil
+	case query.Match:
+		return compileMatch(n), nil
+	case query.NumericRange:
+		q := bleve.NewNumericRangeQuery(n.Min, n.Max)
+		q.SetField(indexFields[n.Field])
+		return q, nil
+	case query.TimeRange:
+		q := bleve.NewDateRangeQuery(n.Start, n.End)
+		q.SetField(indexFields[n.Field])
+		return q, nil
+	}
+	return nil, fmt.Errorf("unsupported query node %T", n)
+}
+
+func compileQueries(nodes []query.Node) ([]blevequery.Query, error) {
+	queries := make([]blevequery.Query, 0, len(nodes))
+	for _, n := range nodes {
+		q, err := compileQuery(n)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+func compileMatch(m query.Match) blevequery.Query {
+	field := indexFields[m.Field]
+	switch m.Field {
+	case "":
+		// free text finds the resources by a part of their name or by their content
+		if m.HasWildcard() {
+			return fieldQuery(bleve.NewWildcardQuery(strings.ToLower(m.Value)), "Name")
+		}
+		return bleve.NewDisjunctionQuery(
+			fieldQuery(bleve.NewRegexpQuery(".*"+regexp.QuoteMeta(strings.ToLower(m.Value))+".*"), "Name"),
+			compileMatch(query.Match{Field: query.FieldContent, Value: m.Value, Phrase: m.Phrase}),
+		)
+	case query.FieldContent:
+		switch {
+		case m.Phrase:
+			return fieldQuery(bleve.NewMatchPhraseQuery(m.Value), field)
+		case m.HasWildcard():
+			return fieldQuery(bleve.NewWildcardQuery(strings.ToLower(m.Value)), field)
+		}
+		q := bleve.NewMatchQuery(m.Value)
+		q.SetOperator(blevequery.MatchQueryOperatorAnd)
+		return fieldQuery(q, field)
+	case query.FieldType:
+		t := float64(sprovider.ResourceType_RESOURCE_TYPE_FILE)
+		if m.Value == query.TypeFolder {
+			t = float64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER)
+		}
+		inclusive := true
+		return fieldQuery(bleve.NewNumericRangeInclusiveQuery(&t, &t, &inclusive, &inclusive), field)
+	}
+
+	// the name and the tags are indexed in lower case, the other fields are matched exactly
+	if m.HasWildcard() {
+		value := m.Value
+		if m.Field == query.FieldName || m.Field == query.FieldTags {
+			value = strings.ToLower(value)
+		}
+		return fieldQuery(bleve.NewWildcardQuery(value), field)
+	}
+	if m.Field == query.FieldName || m.Field == query.FieldTags {
+		return fieldQuery(bleve.NewMatchQuery(m.Value), field)
+	}
+	return fieldQuery(bleve.NewTermQuery(m.Value), field)
+}
+
+// fieldQuery limits the query to the field
+func fieldQuery(q blevequery.FieldableQuery, field string) blevequery.Query {
+	q.SetField(field)
+	return q
+}
@@ -5,6 +5,8 @@ package mocks
 import (
 	context "context"
 
+	content "github.com/owncloud/ocis/v2/services/search/pkg/content"
+
 	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	mock "github.com/stretchr/testify/mock"
 
@@ -16,13 +18,13 @@ type IndexClient struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: ref, ri
-func (_m *IndexClient) Add(ref *providerv1beta1.Reference, ri *providerv1beta1.ResourceInfo) error {
-	ret := _m.Called(ref, ri)
+// Add provides a mock function with given fields: ref, ri, doc
+func (_m *IndexClient) Add(ref *providerv1beta1.Reference, ri *providerv1beta1.ResourceInfo, doc content.Document) error {
+	ret := _m.Called(ref, ri, doc)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*providerv1beta1.Reference, *providerv1beta1.ResourceInfo) error); ok {
-		r0 = rf(ref, ri)
+	if rf, ok := ret.Get(0).(func(*providerv1beta1.Reference, *providerv1beta1.ResourceInfo, content.Document) error); ok {
+		r0 = rf(ref, ri, doc)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -15,6 +15,29 @@ type ProviderClient struct {
 	mock.Mock
 }
 
+// GetIndexStatus provides a mock function with given fields: ctx, req
+func (_m *ProviderClient) GetIndexStatus(ctx context.Context, req *v0.GetIndexStatusRequest) (*v0.GetIndexStatusResponse, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *v0.GetIndexStatusResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *v0.GetIndexStatusRequest) *v0.GetIndexStatusResponse); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v0.GetIndexStatusResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v0.GetIndexStatusRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // IndexSpace provides a mock function with given fields: ctx, req
 func (_m *ProviderClient) IndexSpace(ctx context.Context, req *v0.IndexSpaceRequest) (*v0.IndexSpaceResponse, error) {
 	ret := _m.Called(ctx, req)
@@ -0,0 +1,647 @@
+// Package opensearch implements a search index stored in an OpenSearch or Elasticsearch
+// cluster. Unlike the bleve index, which is a local directory, the index can be shared by
+// multiple instances of the search service.
+//
+// The documents have the same fields and the same semantics as the documents of the bleve
+// index: deleted resources are only flagged and keep their trash keys, and moves, deletions
+// and restores update the whole subtree of a container.
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/tags"
+	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
+)
+
+// childrenPageSize is the number of children of a container loaded at once when updating a
+// subtree
+const childrenPageSize = 1000
+
+type document struct {
+	RootID   string
+	Path     string
+	ID       string
+	ParentID string
+
+	Name      string
+	Size      uint64
+	Mtime     string `json:",omitempty"`
+	MimeType  string
+	MediaType string
+	Type      uint64
+	Tags      []string
+	Content   string `json:",omitempty"`
+
+	Deleted bool
+	// TrashKey is the key of the deleted resource in the trash bin. Children of a deleted
+	// container use the key of the container followed by their path relative to it.
+	TrashKey     string `json:",omitempty"`
+	DeletionTime string `json:",omitempty"`
+}
+
+// resultFields are the fields loaded for search results. The content is only needed for
+// highlighting, loading it for every match would be expensive.
+var resultFields = []string{"RootID", "Path", "ID", "ParentID", "Name", "Size", "Mtime", "MimeType", "Type", "Tags", "Deleted", "TrashKey", "DeletionTime"}
+
+// indexDefinition are the settings and the mapping of the index. The name and the tags are
+// matched case insensitively, the content is split into words.
+var indexDefinition = object{
+	"settings": object{
+		"analysis": object{
+			"normalizer": object{
+				"lowercase_keyword": object{"type": "custom", "filter": []string{"lowercase"}},
+			},
+		},
+	},
+	"mappings": object{
+		"properties": object{
+			"RootID":       object{"type": "keyword"},
+			"Path":         object{"type": "keyword"},
+			"ID":           object{"type": "keyword"},
+			"ParentID":     object{"type": "keyword"},
+			"Name":         object{"type": "keyword", "normalizer": "lowercase_keyword"},
+			"Size":         object{"type": "long"},
+			"Mtime":        object{"type": "date"},
+			"MimeType":     object{"type": "keyword"},
+			"MediaType":    object{"type": "keyword"},
+			"Type":         object{"type": "long"},
+			"Tags":         object{"type": "keyword", "normalizer": "lowercase_keyword"},
+			"Content":      object{"type": "text", "analyzer": "standard"},
+			"Deleted":      object{"type": "boolean"},
+			"TrashKey":     object{"type": "keyword"},
+			"DeletionTime": object{"type": "date"},
+		},
+	},
+}
+
+// Index represents a search index stored in an OpenSearch cluster
+type Index struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+// New returns a new Index storing the documents in the index named indexName of the cluster
+// at url. The index is created if it does not exist yet. The credentials are only sent if
+// username is not empty.
+func New(url, indexName, username, password string, client *http.Client) (*Index, error) {
+	i := &Index{
+		url:      strings.TrimSuffix(url, "/") + "/" + indexName,
+		username: username,
+		password: password,
+		client:   client,
+	}
+	if err := i.ensureIndex(); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// ensureIndex creates the index unless it exists
+func (i *Index) ensureIndex() error {
+	err := i.do(context.Background(), http.MethodHead, "", nil, nil)
+	if !isStatus(err, http.StatusNotFound) {
+		return err
+	}
+	err = i.do(context.Background(), http.MethodPut, "", indexDefinition, nil)
+	// another instance of the search service might have created the index in the meantime
+	var respErr *responseError
+	if errors.As(err, &respErr) && respErr.Type == "resource_already_exists_exception" {
+		return nil
+	}
+	return err
+}
+
+// DocCount returns the number of elements in the index
+func (i *Index) DocCount() (uint64, error) {
+	res := struct {
+		Count uint64 `json:"count"`
+	}{}
+	if err := i.do(context.Background(), http.MethodGet, "/_count", nil, &res); err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// Add adds a new entity to the Index
+func (i *Index) Add(ref *sprovider.Reference, ri *sprovider.ResourceInfo, doc content.Document) error {
+	entity := toDocument(ref, ri)
+	entity.Content = doc.Content
+	return i.putDocument(entity)
+}
+
+// Delete marks an entity from the index as deleted (still keeping it around). The entity is
+// stored with its key in the trash bin, which is the opaque id of the trashed resource.
+func (i *Index) Delete(id *sprovider.ResourceId) error {
+	deletionTime := time.Now().UTC().Format(time.RFC3339Nano)
+	doc, err := i.updateDocument(idToString(id), func(doc *document) {
+		doc.Deleted = true
+		doc.TrashKey = id.GetOpaqueId()
+		doc.DeletionTime = deletionTime
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.updateChildren(doc, func(child *document) bool {
+		// children deleted before have their own item in the trash bin
+		if child.Deleted {
+			return false
+		}
+		child.Deleted = true
+		child.TrashKey = doc.TrashKey + "/" + strings.TrimPrefix(child.Path, doc.Path+"/")
+		child.DeletionTime = deletionTime
+		return true
+	})
+}
+
+// Restore marks an entity from the index as not being deleted
+func (i *Index) Restore(id *sprovider.ResourceId) error {
+	var trashKey string
+	doc, err := i.updateDocument(idToString(id), func(doc *document) {
+		trashKey = doc.TrashKey
+		doc.Deleted = false
+		doc.TrashKey = ""
+		doc.DeletionTime = ""
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.updateChildren(doc, func(child *document) bool {
+		// children deleted before the container stay in the trash bin
+		if !child.Deleted || (child.TrashKey != "" && !strings.HasPrefix(child.TrashKey, trashKey+"/")) {
+			return false
+		}
+		child.Deleted = false
+		child.TrashKey = ""
+		child.DeletionTime = ""
+		return true
+	})
+}
+
+// Purge removes an entity from the index
+func (i *Index) Purge(id *sprovider.ResourceId) error {
+	err := i.do(context.Background(), http.MethodDelete, "/_doc/"+url.PathEscape(idToString(id)), nil, nil)
+	if isStatus(err, http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Move update the path of an entry and all its children
+func (i *Index) Move(id, newParentID *sprovider.ResourceId, fullPath string) error {
+	doc, err := i.getDocument(idToString(id))
+	if err != nil {
+		return err
+	}
+	oldDoc := *doc
+	newName := utils.MakeRelativePath(fullPath)
+
+	_, err = i.updateDocument(doc.ID, func(doc *document) {
+		doc.Path = newName
+		doc.Name = path.Base(newName)
+		doc.ParentID = idToString(newParentID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.updateChildren(&oldDoc, func(child *document) bool {
+		child.Path = strings.Replace(child.Path, oldDoc.Path, newName, 1)
+		return true
+	})
+}
+
+// Search searches the index according to the criteria specified in the given SearchIndexRequest
+func (i *Index) Search(ctx context.Context, req *searchsvc.SearchIndexRequest) (*searchsvc.SearchIndexResponse, error) {
+	now := time.Now()
+	ast, err := query.Parse(req.Query, now)
+	if err != nil {
+		return nil, err
+	}
+	q, err := compileQuery(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only search the documents in the trash bin or skip them
+	filters := []object{term("Deleted", req.Trash)}
+	if req.Ref != nil {
+		filters = append(filters,
+			term("RootID", idToString(&sprovider.ResourceId{
+				StorageId: req.Ref.GetResourceId().GetStorageId(),
+				SpaceId:   req.Ref.GetResourceId().GetSpaceId(),
+				OpaqueId:  req.Ref.GetResourceId().GetOpaqueId(),
+			})), // Limit search to the space
+			prefix("Path", utils.MakeRelativePath(path.Join(req.Ref.Path, "/"))), // Limit search to this directory in the space
+		)
+	}
+	size := 200
+	if req.PageSize > 0 {
+		size = int(req.PageSize)
+	}
+	body := object{
+		"query":            object{"bool": object{"must": []object{q}, "filter": filters}},
+		"size":             size,
+		"track_total_hits": true,
+		"_source":          resultFields,
+		"highlight": object{
+			"pre_tags":  []string{"<mark>"},
+			"post_tags": []string{"</mark>"},
+			"fields":    object{"Content": object{}},
+		},
+	}
+	if len(req.Facets) > 0 {
+		aggs := object{}
+		for _, name := range req.Facets {
+			agg, err := aggregation(name, now)
+			if err != nil {
+				return nil, err
+			}
+			aggs[name] = agg
+		}
+		body["aggs"] = aggs
+	}
+
+	res := searchResponse{}
+	if err := i.do(ctx, http.MethodPost, "/_search", body, &res); err != nil {
+		return nil, err
+	}
+
+	matches := []*searchmsg.Match{}
+	for _, h := range res.Hits.Hits {
+		match, err := fromHit(h)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	return &searchsvc.SearchIndexResponse{
+		Matches:      matches,
+		TotalMatches: int32(res.Hits.Total.Value),
+		Facets:       fromAggregations(req.Facets, res.Aggregations),
+	}, nil
+}
+
+// aggregation returns the aggregation computing the named facet. The values of the facets can
+// be used as values of the according fields in queries.
+func aggregation(name string, now time.Time) (object, error) {
+	switch query.Field(name) {
+	case query.FieldMediaType:
+		return object{"terms": object{"field": "MediaType", "size": len(query.MediaTypes)}}, nil
+	case query.FieldMtime:
+		ranges := make([]object, 0, len(query.MtimeRanges))
+		for _, r := range query.MtimeRanges {
+			start, end, _ := query.TimeRangeByName(r, now)
+			ranges = append(ranges, object{
+				"key":  r,
+				"from": start.UTC().Format(time.RFC3339Nano),
+				"to":   end.UTC().Format(time.RFC3339Nano),
+			})
+		}
+		return object{"date_range": object{"field": "Mtime", "ranges": ranges}}, nil
+	}
+	return nil, fmt.Errorf("unknown facet %q", name)
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []hit `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]aggregationResult `json:"aggregations"`
+}
+
+type hit struct {
+	ID        string              `json:"_id"`
+	Score     float64             `json:"_score"`
+	Source    document            `json:"_source"`
+	Highlight map[string][]string `json:"highlight"`
+	Sort      []interface{}       `json:"sort"`
+}
+
+type aggregationResult struct {
+	Buckets []struct {
+		Key      string `json:"key"`
+		DocCount int    `json:"doc_count"`
+	} `json:"buckets"`
+}
+
+func fromAggregations(names []string, results map[string]aggregationResult) []*searchmsg.Facet {
+	facets := make([]*searchmsg.Facet, 0, len(names))
+	for _, name := range names {
+		result, ok := results[name]
+		if !ok {
+			continue
+		}
+		// the buckets of terms are sorted by their count, the buckets of date ranges are kept
+		// in the order of the ranges
+		facet := &searchmsg.Facet{Name: name}
+		for _, bucket := range result.Buckets {
+			if bucket.DocCount > 0 {
+				facet.Values = append(facet.Values, &searchmsg.FacetValue{Value: bucket.Key, Count: int32(bucket.DocCount)})
+			}
+		}
+		facets = append(facets, facet)
+	}
+	return facets
+}
+
+func (i *Index) getDocument(id string) (*document, error) {
+	res := struct {
+		Found  bool     `json:"found"`
+		Source document `json:"_source"`
+	}{}
+	err := i.do(context.Background(), http.MethodGet, "/_doc/"+url.PathEscape(id), nil, &res)
+	if isStatus(err, http.StatusNotFound) || err == nil && !res.Found {
+		return nil, errors.New("entity not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res.Source, nil
+}
+
+func (i *Index) putDocument(doc *document) error {
+	return i.do(context.Background(), http.MethodPut, "/_doc/"+url.PathEscape(doc.ID), doc, nil)
+}
+
+func (i *Index) updateDocument(id string, mutateFunc func(doc *document)) (*document, error) {
+	doc, err := i.getDocument(id)
+	if err != nil {
+		return nil, err
+	}
+	mutateFunc(doc)
+	if err := i.putDocument(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// updateChildren applies mutateFunc to the documents below the container and writes the
+// changed documents. mutateFunc returns false for documents it did not change.
+func (i *Index) updateChildren(doc *document, mutateFunc func(child *document) bool) error {
+	if doc.Type != uint64(sprovider.ResourceType_RESOURCE_TYPE_CONTAINER) {
+		return nil
+	}
+	// make the recent changes of the subtree visible to the search
+	if err := i.do(context.Background(), http.MethodPost, "/_refresh", nil, nil); err != nil {
+		return err
+	}
+
+	var after []interface{}
+	for {
+		body := object{
+			"query": object{"bool": object{"filter": []object{
+				term("RootID", doc.RootID),
+				prefix("Path", doc.Path+"/"),
+			}}},
+			"size": childrenPageSize,
+			"sort": []object{{"ID": "asc"}},
+		}
+		if after != nil {
+			body["search_after"] = after
+		}
+		res := searchResponse{}
+		if err := i.do(context.Background(), http.MethodPost, "/_search", body, &res); err != nil {
+			return err
+		}
+		if len(res.Hits.Hits) == 0 {
+			return nil
+		}
+
+		changed := make([]*document, 0, len(res.Hits.Hits))
+		for _, h := range res.Hits.Hits {
+			child := h.Source
+			if mutateFunc(&child) {
+				changed = append(changed, &child)
+			}
+		}
+		if err := i.putDocuments(changed); err != nil {
+			return err
+		}
+		after = res.Hits.Hits[len(res.Hits.Hits)-1].Sort
+	}
+}
+
+// putDocuments writes the documents with a single bulk request
+func (i *Index) putDocuments(docs []*document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	body := &bytes.Buffer{}
+	enc := json.NewEncoder(body)
+	for _, doc := range docs {
+		if err := enc.Encode(object{"index": object{"_id": doc.ID}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+
+	res := struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}{}
+	if err := i.do(context.Background(), http.MethodPost, "/_bulk", body, &res); err != nil {
+		return err
+	}
+	if res.Errors {
+		for _, item := range res.Items {
+			for _, result := range item {
+				if result.Status >= http.StatusMultipleChoices {
+					return &responseError{Status: result.Status, Type: result.Error.Type, Reason: result.Error.Reason}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// responseError is the error returned by the cluster
+type responseError struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("opensearch request returned with statuscode %d: %s %s", e.Status, e.Type, e.Reason)
+}
+
+func isStatus(err error, status int) bool {
+	var respErr *responseError
+	return errors.As(err, &respErr) && respErr.Status == status
+}
+
+// do sends a request to the index. The body is encoded as JSON unless it is a buffer with a
+// bulk request, the response is decoded into result if it is not nil.
+func (i *Index) do(ctx context.Context, method, endpoint string, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case *bytes.Buffer:
+		reqBody = b
+		contentType = "application/x-ndjson"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, i.url+endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if i.username != "" {
+		req.SetBasicAuth(i.username, i.password)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		respErr := &responseError{Status: resp.StatusCode}
+		errBody := struct {
+			Error struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}{}
+		if json.NewDecoder(resp.Body).Decode(&errBody) == nil {
+			respErr.Type, respErr.Reason = errBody.Error.Type, errBody.Error.Reason
+		}
+		return respErr
+	}
+	if result == nil || method == http.MethodHead {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func toDocument(ref *sprovider.Reference, ri *sprovider.ResourceInfo) *document {
+	doc := &document{
+		RootID:    idToString(ref.ResourceId),
+		Path:      ref.Path,
+		ID:        idToString(ri.Id),
+		ParentID:  idToString(ri.ParentId),
+		Name:      ri.Path,
+		Size:      ri.Size,
+		MimeType:  ri.MimeType,
+		MediaType: query.MediaType(ri.MimeType),
+		Type:      uint64(ri.Type),
+		Tags:      tags.Parse(ri.GetArbitraryMetadata().GetMetadata()[tags.MetadataKey]),
+		Deleted:   false,
+	}
+
+	if ri.Mtime != nil {
+		doc.Mtime = time.Unix(int64(ri.Mtime.Seconds), int64(ri.Mtime.Nanos)).UTC().Format(time.RFC3339Nano)
+	}
+
+	return doc
+}
+
+func fromHit(h hit) (*searchmsg.Match, error) {
+	doc := h.Source
+	rootID, err := storagespace.ParseID(doc.RootID)
+	if err != nil {
+		return nil, err
+	}
+	rID, err := storagespace.ParseID(doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := &searchmsg.Match{
+		Score:      float32(h.Score),
+		Highlights: h.Highlight["Content"],
+		Entity: &searchmsg.Entity{
+			Ref: &searchmsg.Reference{
+				ResourceId: resourceIDtoSearchID(rootID),
+				Path:       doc.Path,
+			},
+			Id:       resourceIDtoSearchID(rID),
+			Name:     doc.Name,
+			Size:     doc.Size,
+			Type:     doc.Type,
+			MimeType: doc.MimeType,
+			Tags:     doc.Tags,
+			Deleted:  doc.Deleted,
+		},
+	}
+	if doc.ParentID != "" {
+		parentID, err := storagespace.ParseID(doc.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		match.Entity.ParentId = resourceIDtoSearchID(parentID)
+	}
+
+	if mtime, err := time.Parse(time.RFC3339Nano, doc.Mtime); err == nil {
+		match.Entity.LastModifiedTime = timestamppb.New(mtime)
+	}
+
+	if doc.Deleted {
+		match.Entity.TrashKey = doc.TrashKey
+		if deletionTime, err := time.Parse(time.RFC3339Nano, doc.DeletionTime); err == nil {
+			match.Entity.DeletionTime = timestamppb.New(deletionTime)
+		}
+	}
+
+	return match, nil
+}
+
+func idToString(id *sprovider.ResourceId) string {
+	if id == nil {
+		return ""
+	}
+	return storagespace.FormatResourceID(*id)
+}
+
+func resourceIDtoSearchID(id sprovider.ResourceId) *searchmsg.ResourceID {
+	return &searchmsg.ResourceID{
+		StorageId: id.GetStorageId(),
+		SpaceId:   id.GetSpaceId(),
+		OpaqueId:  id.GetOpaqueId(),
+	}
+}
@@ -0,0 +1,13 @@
+package opensearch_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOpensearch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Opensearch Suite")
+}
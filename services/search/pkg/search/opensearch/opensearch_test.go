@@ -0,0 +1,356 @@
+package opensearch_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/search/opensearch"
+)
+
+var _ = Describe("Opensearch", func() {
+	var (
+		i      *opensearch.Index
+		fake   *fakeServer
+		server *httptest.Server
+		ctx    context.Context
+
+		rootID = &sprovider.ResourceId{
+			StorageId: "provider-1",
+			SpaceId:   "spaceid",
+			OpaqueId:  "rootopaqueid",
+		}
+		ref = &sprovider.Reference{
+			ResourceId: rootID,
+			Path:       "./Foo.pdf",
+		}
+		ri = &sprovider.ResourceInfo{
+			Id:       &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "opaqueid"},
+			ParentId: &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "rootopaqueid"},
+			Path:     "Foo.pdf",
+			Size:     12345,
+			Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+			MimeType: "application/pdf",
+			Mtime:    &typesv1beta1.Timestamp{Seconds: 4000},
+		}
+		parentRef = &sprovider.Reference{
+			ResourceId: rootID,
+			Path:       "./my/sub d!r",
+		}
+		parentRi = &sprovider.ResourceInfo{
+			Id:       &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "parentopaqueid"},
+			ParentId: &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "myopaqueid"},
+			Path:     "sub d!r",
+			Type:     sprovider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			MimeType: "httpd/unix-directory",
+			Mtime:    &typesv1beta1.Timestamp{Seconds: 4000},
+		}
+		childRef = &sprovider.Reference{
+			ResourceId: rootID,
+			Path:       "./my/sub d!r/child.pdf",
+		}
+		childRi = &sprovider.ResourceInfo{
+			Id:       &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "childopaqueid"},
+			ParentId: &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "parentopaqueid"},
+			Path:     "child.pdf",
+			Size:     12345,
+			Type:     sprovider.ResourceType_RESOURCE_TYPE_FILE,
+			MimeType: "application/pdf",
+			Mtime:    &typesv1beta1.Timestamp{Seconds: 4000},
+		}
+
+		search = func(req *searchsvc.SearchIndexRequest) []*searchmsg.Match {
+			res, err := i.Search(ctx, req)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			return res.Matches
+		}
+		assertDocCount = func(query string, expectedCount int) []*searchmsg.Match {
+			matches := search(&searchsvc.SearchIndexRequest{
+				Query: query,
+				Ref: &searchmsg.Reference{
+					ResourceId: &searchmsg.ResourceID{
+						StorageId: rootID.StorageId,
+						SpaceId:   rootID.SpaceId,
+						OpaqueId:  rootID.OpaqueId,
+					},
+				},
+			})
+			ExpectWithOffset(1, matches).To(HaveLen(expectedCount), "query returned unexpected number of results: "+query)
+			return matches
+		}
+		addTree = func() {
+			Expect(i.Add(parentRef, parentRi, content.Document{})).To(Succeed())
+			Expect(i.Add(childRef, childRi, content.Document{})).To(Succeed())
+		}
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fake = newFakeServer("ocis-search")
+		server = httptest.NewServer(fake)
+
+		var err error
+		i, err = opensearch.New(server.URL, "ocis-search", "", "", &http.Client{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("New", func() {
+		It("creates the index with its mapping", func() {
+			Expect(fake.properties).To(HaveKey("Name"))
+			Expect(fake.properties["Content"]["type"]).To(Equal("text"))
+		})
+
+		It("uses an existing index", func() {
+			Expect(i.Add(ref, ri, content.Document{})).To(Succeed())
+
+			i, err := opensearch.New(server.URL+"/", "ocis-search", "", "", &http.Client{})
+			Expect(err).ToNot(HaveOccurred())
+			count, err := i.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(1)))
+		})
+
+		It("sends the credentials", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fake.ServeHTTP(w, r)
+			})
+
+			_, err := opensearch.New(server.URL, "ocis-search", "admin", "wrong", &http.Client{})
+			Expect(err).To(HaveOccurred())
+			_, err = opensearch.New(server.URL, "ocis-search", "admin", "secret", &http.Client{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Add", func() {
+		It("adds and updates a resource in the index", func() {
+			Expect(i.Add(ref, ri, content.Document{})).To(Succeed())
+			Expect(i.Add(ref, ri, content.Document{})).To(Succeed())
+
+			count, err := i.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(1)))
+		})
+	})
+
+	Describe("Search", func() {
+		JustBeforeEach(func() {
+			ri.ArbitraryMetadata = &sprovider.ArbitraryMetadata{Metadata: map[string]string{"tags": "Invoice,2026"}}
+			Expect(i.Add(ref, ri, content.Document{Content: "The quick brown fox"})).To(Succeed())
+			ri.ArbitraryMetadata = nil
+		})
+
+		It("returns all desired fields", func() {
+			matches := assertDocCount("foo.pdf", 1)
+			match := matches[0]
+			Expect(match.Entity.Ref.ResourceId.OpaqueId).To(Equal(rootID.OpaqueId))
+			Expect(match.Entity.Ref.Path).To(Equal("./Foo.pdf"))
+			Expect(match.Entity.Id.OpaqueId).To(Equal("opaqueid"))
+			Expect(match.Entity.ParentId.OpaqueId).To(Equal("rootopaqueid"))
+			Expect(match.Entity.Name).To(Equal("Foo.pdf"))
+			Expect(match.Entity.Size).To(Equal(uint64(12345)))
+			Expect(match.Entity.MimeType).To(Equal("application/pdf"))
+			Expect(match.Entity.Tags).To(Equal([]string{"Invoice", "2026"}))
+			Expect(match.Entity.LastModifiedTime.AsTime().Unix()).To(Equal(int64(4000)))
+		})
+
+		It("finds files by name, prefix or substring match", func() {
+			assertDocCount("foo.pdf", 1)
+			assertDocCount("Foo*", 1)
+			assertDocCount("oo.p", 1)
+			assertDocCount("name:FOO.PDF", 1)
+
+			assertDocCount("bar.pdf", 0)
+			assertDocCount("name:foo", 0)
+		})
+
+		It("finds files by their properties", func() {
+			assertDocCount(`size>10KB`, 1)
+			assertDocCount(`size<=12345`, 1)
+			assertDocCount(`type:file`, 1)
+			assertDocCount(`mimetype:application/*`, 1)
+			assertDocCount(`mediatype:pdf`, 1)
+			assertDocCount(`mtime:1970-01-01`, 1)
+			assertDocCount(`tag:invoice`, 1)
+
+			assertDocCount(`size<12345`, 0)
+			assertDocCount(`type:folder`, 0)
+			assertDocCount(`mtime:today`, 0)
+			assertDocCount(`tag:draft`, 0)
+		})
+
+		It("combines the restrictions", func() {
+			assertDocCount(`foo fox`, 1)
+			assertDocCount(`bar OR fox`, 1)
+			assertDocCount(`(name:bar.pdf OR name:foo.pdf) AND type:file`, 1)
+			assertDocCount(`NOT cat`, 1)
+
+			assertDocCount(`foo cat`, 0)
+			assertDocCount(`-fox`, 0)
+		})
+
+		It("finds files by their content and highlights the matches", func() {
+			matches := assertDocCount(`content:"brown fox"`, 1)
+			Expect(matches[0].Highlights).To(Equal([]string{"The quick <mark>brown</mark> <mark>fox</mark>"}))
+
+			assertDocCount(`content:"fox brown"`, 0)
+		})
+
+		It("scopes the search to the specified space and directory", func() {
+			addTree()
+
+			matches := search(&searchsvc.SearchIndexRequest{
+				Query: "*.pdf",
+				Ref: &searchmsg.Reference{
+					ResourceId: &searchmsg.ResourceID{StorageId: rootID.StorageId, SpaceId: rootID.SpaceId, OpaqueId: rootID.OpaqueId},
+					Path:       "./my",
+				},
+			})
+			Expect(matches).To(HaveLen(1))
+			Expect(matches[0].Entity.Name).To(Equal("child.pdf"))
+
+			matches = search(&searchsvc.SearchIndexRequest{
+				Query: "*.pdf",
+				Ref: &searchmsg.Reference{
+					ResourceId: &searchmsg.ResourceID{StorageId: rootID.StorageId, SpaceId: rootID.SpaceId, OpaqueId: "otherspace"},
+				},
+			})
+			Expect(matches).To(BeEmpty())
+		})
+
+		It("limits the number of matches and returns the total", func() {
+			addTree()
+
+			res, err := i.Search(ctx, &searchsvc.SearchIndexRequest{Query: "*.pdf", PageSize: 1})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Matches).To(HaveLen(1))
+			Expect(res.TotalMatches).To(Equal(int32(2)))
+		})
+
+		It("counts the matches by media type and modification time", func() {
+			image := *ri
+			image.Id = &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "imageopaqueid"}
+			image.Path = "foo.png"
+			image.MimeType = "image/png"
+			image.Mtime = &typesv1beta1.Timestamp{Seconds: uint64(time.Now().Unix())}
+			Expect(i.Add(&sprovider.Reference{ResourceId: rootID, Path: "./foo.png"}, &image, content.Document{})).To(Succeed())
+
+			res, err := i.Search(ctx, &searchsvc.SearchIndexRequest{Query: "foo", Facets: []string{"mediatype", "mtime"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Facets).To(HaveLen(2))
+			Expect(res.Facets[0].Name).To(Equal("mediatype"))
+			Expect(res.Facets[0].Values).To(ConsistOf(
+				&searchmsg.FacetValue{Value: "pdf", Count: 1},
+				&searchmsg.FacetValue{Value: "image", Count: 1},
+			))
+			Expect(res.Facets[1].Name).To(Equal("mtime"))
+			Expect(res.Facets[1].Values[0]).To(Equal(&searchmsg.FacetValue{Value: "today", Count: 1}))
+		})
+
+		It("rejects invalid queries and unknown facets", func() {
+			_, err := i.Search(ctx, &searchsvc.SearchIndexRequest{Query: `size>lots`})
+			Expect(err).To(HaveOccurred())
+			_, err = i.Search(ctx, &searchsvc.SearchIndexRequest{Query: "foo", Facets: []string{"color"}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Delete", func() {
+		It("marks the resource and its children as deleted and stores their trash keys", func() {
+			addTree()
+
+			Expect(i.Delete(parentRi.Id)).To(Succeed())
+
+			assertDocCount(`"sub d!r"`, 0)
+			assertDocCount("child.pdf", 0)
+
+			matches := search(&searchsvc.SearchIndexRequest{Query: "deleted:today", Trash: true})
+			Expect(matches).To(HaveLen(2))
+			keys := map[string]string{}
+			for _, match := range matches {
+				Expect(match.Entity.Deleted).To(BeTrue())
+				Expect(match.Entity.DeletionTime).ToNot(BeNil())
+				keys[match.Entity.Name] = match.Entity.TrashKey
+			}
+			Expect(keys).To(Equal(map[string]string{
+				"sub d!r":   "parentopaqueid",
+				"child.pdf": "parentopaqueid/child.pdf",
+			}))
+		})
+	})
+
+	Describe("Restore", func() {
+		It("also marks child resources as restored", func() {
+			addTree()
+			Expect(i.Delete(parentRi.Id)).To(Succeed())
+
+			Expect(i.Restore(parentRi.Id)).To(Succeed())
+
+			assertDocCount(`"sub d!r"`, 1)
+			assertDocCount("child.pdf", 1)
+		})
+
+		It("keeps child resources deleted before the parent in the trash bin", func() {
+			addTree()
+			Expect(i.Delete(childRi.Id)).To(Succeed())
+			Expect(i.Delete(parentRi.Id)).To(Succeed())
+
+			Expect(i.Restore(parentRi.Id)).To(Succeed())
+
+			assertDocCount(`"sub d!r"`, 1)
+			assertDocCount("child.pdf", 0)
+			matches := search(&searchsvc.SearchIndexRequest{Query: "child.pdf", Trash: true})
+			Expect(matches).To(HaveLen(1))
+			Expect(matches[0].Entity.TrashKey).To(Equal("childopaqueid"))
+		})
+	})
+
+	Describe("Purge", func() {
+		It("removes the resource from the index", func() {
+			Expect(i.Add(ref, ri, content.Document{})).To(Succeed())
+
+			Expect(i.Purge(ri.Id)).To(Succeed())
+			Expect(i.Purge(ri.Id)).To(Succeed())
+
+			count, err := i.DocCount()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(uint64(0)))
+		})
+	})
+
+	Describe("Move", func() {
+		It("moves the parent and its child resources", func() {
+			addTree()
+			newParentID := &sprovider.ResourceId{StorageId: "provider-1", SpaceId: "spaceid", OpaqueId: "somewhereopaqueid"}
+
+			Expect(i.Move(parentRi.Id, newParentID, "./somewhere/else/newname")).To(Succeed())
+
+			assertDocCount(`"sub d!r"`, 0)
+
+			matches := assertDocCount("name:child.pdf", 1)
+			Expect(matches[0].Entity.ParentId.OpaqueId).To(Equal("parentopaqueid"))
+			Expect(matches[0].Entity.Ref.Path).To(Equal("./somewhere/else/newname/child.pdf"))
+
+			matches = assertDocCount("newname", 1)
+			Expect(matches[0].Entity.ParentId.OpaqueId).To(Equal("somewhereopaqueid"))
+			Expect(matches[0].Entity.Ref.Path).To(Equal("./somewhere/else/newname"))
+		})
+	})
+})
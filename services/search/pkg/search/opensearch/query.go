@@ -0,0 +1,144 @@
+package opensearch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
+)
+
+// object is a JSON object of a request or a response
+type object = map[string]interface{}
+
+// indexFields maps the fields of the query language to the fields of the index documents
+var indexFields = map[query.Field]string{
+	query.FieldName:      "Name",
+	query.FieldContent:   "Content",
+	query.FieldMimeType:  "MimeType",
+	query.FieldMediaType: "MediaType",
+	query.FieldSize:      "Size",
+	query.FieldMtime:     "Mtime",
+	query.FieldType:      "Type",
+	query.FieldTags:      "Tags",
+	query.FieldID:        "ID",
+	query.FieldDeleted:   "DeletionTime",
+}
+
+// compileQuery translates the syntax tree of a search query into the query DSL
+func compileQuery(n query.Node) (object, error) {
+	switch n := n.(type) {
+	case query.And:
+		queries, err := compileQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return object{"bool": object{"must": queries}}, nil
+	case query.Or:
+		queries, err := compileQueries(n.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		return object{"bool": object{"should": queries, "minimum_should_match": 1}}, nil
+	case query.Not:
+		q, err := compileQuery(n.Node)
+		if err != nil {
+			return nil, err
+		}
+		// a boolean query without must and should clauses matches all other documents
+		return object{"bool": object{"must_not": []object{q}}}, nil
+	case query.Match:
+		return compileMatch(n), nil
+	case query.NumericRange:
+		r := object{}
+		if n.Min != nil {
+			r["gte"] = *n.Min
+		}
+		if n.Max != nil {
+			r["lt"] = *n.Max
+		}
+		return object{"range": object{indexFields[n.Field]: r}}, nil
+	case query.TimeRange:
+		r := object{}
+		if !n.Start.IsZero() {
+			r["gte"] = n.Start.UTC().Format(time.RFC3339Nano)
+		}
+		if !n.End.IsZero() {
+			r["lt"] = n.End.UTC().Format(time.RFC3339Nano)
+		}
+		return object{"range": object{indexFields[n.Field]: r}}, nil
+	}
+	return nil, fmt.Errorf("unsupported query node %T", n)
+}
+
+func compileQueries(nodes []query.Node) ([]object, error) {
+	queries := make([]object, 0, len(nodes))
+	for _, n := range nodes {
+		q, err := compileQuery(n)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+func compileMatch(m query.Match) object {
+	field := indexFields[m.Field]
+	switch m.Field {
+	case "":
+		// free text finds the resources by a part of their name or by their content
+		if m.HasWildcard() {
+			return wildcard("Name", strings.ToLower(m.Value))
+		}
+		return object{"bool": object{
+			"should": []object{
+				wildcard("Name", "*"+escapeWildcard(strings.ToLower(m.Value))+"*"),
+				compileMatch(query.Match{Field: query.FieldContent, Value: m.Value, Phrase: m.Phrase}),
+			},
+			"minimum_should_match": 1,
+		}}
+	case query.FieldContent:
+		switch {
+		case m.Phrase:
+			return object{"match_phrase": object{field: object{"query": m.Value}}}
+		case m.HasWildcard():
+			return wildcard(field, strings.ToLower(m.Value))
+		}
+		return object{"match": object{field: object{"query": m.Value, "operator": "and"}}}
+	case query.FieldType:
+		t := sprovider.ResourceType_RESOURCE_TYPE_FILE
+		if m.Value == query.TypeFolder {
+			t = sprovider.ResourceType_RESOURCE_TYPE_CONTAINER
+		}
+		return term(field, int(t))
+	}
+
+	// the name and the tags are normalized to lower case, the other fields are matched exactly
+	value := m.Value
+	if m.Field == query.FieldName || m.Field == query.FieldTags {
+		value = strings.ToLower(value)
+	}
+	if m.HasWildcard() {
+		return wildcard(field, value)
+	}
+	return term(field, value)
+}
+
+func term(field string, value interface{}) object {
+	return object{"term": object{field: object{"value": value}}}
+}
+
+func prefix(field, value string) object {
+	return object{"prefix": object{field: object{"value": value}}}
+}
+
+func wildcard(field, pattern string) object {
+	return object{"wildcard": object{field: object{"value": pattern}}}
+}
+
+// escapeWildcard escapes the characters with a special meaning in wildcard patterns
+func escapeWildcard(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(s)
+}
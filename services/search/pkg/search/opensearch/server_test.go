@@ -0,0 +1,502 @@
+package opensearch_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+type object = map[string]interface{}
+
+// fakeServer is an in-process fake of the OpenSearch API. It implements the endpoints and the
+// subset of the query DSL used by the index client, the fields are interpreted according to
+// the mapping sent when creating the index.
+type fakeServer struct {
+	mutex      sync.Mutex
+	index      string
+	properties map[string]object
+	docs       map[string]object
+}
+
+func newFakeServer(index string) *fakeServer {
+	return &fakeServer{index: index, docs: map[string]object{}}
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if parts[0] != s.index {
+		writeError(w, http.StatusNotFound, "index_not_found_exception", "no such index")
+		return
+	}
+	endpoint := ""
+	if len(parts) > 1 {
+		endpoint = parts[1]
+	}
+	if endpoint != "" && s.properties == nil {
+		writeError(w, http.StatusNotFound, "index_not_found_exception", "no such index")
+		return
+	}
+
+	switch {
+	case endpoint == "" && r.Method == http.MethodHead:
+		if s.properties == nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case endpoint == "" && r.Method == http.MethodPut:
+		if s.properties != nil {
+			writeError(w, http.StatusBadRequest, "resource_already_exists_exception", "index already exists")
+			return
+		}
+		definition := struct {
+			Mappings struct {
+				Properties map[string]object `json:"properties"`
+			} `json:"mappings"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+			writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+			return
+		}
+		s.properties = definition.Mappings.Properties
+		writeJSON(w, object{"acknowledged": true})
+	case endpoint == "_doc" && len(parts) == 3:
+		s.serveDocument(w, r, parts[2])
+	case endpoint == "_bulk":
+		s.serveBulk(w, r)
+	case endpoint == "_refresh":
+		writeJSON(w, object{})
+	case endpoint == "_count":
+		writeJSON(w, object{"count": len(s.docs)})
+	case endpoint == "_search":
+		s.serveSearch(w, r)
+	default:
+		writeError(w, http.StatusBadRequest, "illegal_argument_exception", "unsupported request "+r.Method+" "+r.URL.Path)
+	}
+}
+
+func (s *fakeServer) serveDocument(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		doc, ok := s.docs[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, object{"_id": id, "found": false})
+			return
+		}
+		writeJSON(w, object{"_id": id, "found": true, "_source": doc})
+	case http.MethodPut:
+		doc := object{}
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+			return
+		}
+		if err := s.put(id, doc); err != nil {
+			writeError(w, http.StatusBadRequest, "mapper_parsing_exception", err.Error())
+			return
+		}
+		writeJSON(w, object{"_id": id, "result": "updated"})
+	case http.MethodDelete:
+		if _, ok := s.docs[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, object{"_id": id, "result": "not_found"})
+			return
+		}
+		delete(s.docs, id)
+		writeJSON(w, object{"_id": id, "result": "deleted"})
+	}
+}
+
+func (s *fakeServer) serveBulk(w http.ResponseWriter, r *http.Request) {
+	items := []object{}
+	errors := false
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for scanner.Scan() {
+		action := struct {
+			Index struct {
+				ID string `json:"_id"`
+			} `json:"index"`
+		}{}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+			writeError(w, http.StatusBadRequest, "parse_exception", "invalid bulk request")
+			return
+		}
+		doc := object{}
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+			return
+		}
+		result := object{"_id": action.Index.ID, "status": http.StatusOK}
+		if err := s.put(action.Index.ID, doc); err != nil {
+			errors = true
+			result["status"] = http.StatusBadRequest
+			result["error"] = object{"type": "mapper_parsing_exception", "reason": err.Error()}
+		}
+		items = append(items, object{"index": result})
+	}
+	writeJSON(w, object{"errors": errors, "items": items})
+}
+
+// put stores the document after checking its fields against the mapping
+func (s *fakeServer) put(id string, doc object) error {
+	for field, value := range doc {
+		property, ok := s.properties[field]
+		if !ok {
+			return fmt.Errorf("unknown field %s", field)
+		}
+		if property["type"] == "date" {
+			if _, err := time.Parse(time.RFC3339Nano, value.(string)); err != nil {
+				return fmt.Errorf("failed to parse field %s of type date", field)
+			}
+		}
+	}
+	s.docs[id] = doc
+	return nil
+}
+
+type searchRequest struct {
+	Query       object              `json:"query"`
+	Size        *int                `json:"size"`
+	Sort        []map[string]string `json:"sort"`
+	SearchAfter []interface{}       `json:"search_after"`
+	Highlight   *struct {
+		PreTags  []string `json:"pre_tags"`
+		PostTags []string `json:"post_tags"`
+	} `json:"highlight"`
+	Aggs map[string]object `json:"aggs"`
+}
+
+func (s *fakeServer) serveSearch(w http.ResponseWriter, r *http.Request) {
+	req := searchRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+
+	ids := make([]string, 0, len(s.docs))
+	for id, doc := range s.docs {
+		matched, err := s.matches(req.Query, doc)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+			return
+		}
+		if matched {
+			ids = append(ids, id)
+		}
+	}
+
+	sortField := "_id"
+	for _, sortBy := range req.Sort {
+		for field := range sortBy {
+			sortField = field
+		}
+	}
+	sortValue := func(id string) string {
+		if sortField == "_id" {
+			return id
+		}
+		return fmt.Sprint(s.docs[id][sortField])
+	}
+	sort.Slice(ids, func(i, j int) bool { return sortValue(ids[i]) < sortValue(ids[j]) })
+
+	aggregations := object{}
+	for name, agg := range req.Aggs {
+		aggregations[name] = s.aggregate(agg, ids)
+	}
+
+	total := len(ids)
+	if len(req.SearchAfter) > 0 {
+		after := fmt.Sprint(req.SearchAfter[0])
+		i := sort.Search(len(ids), func(i int) bool { return sortValue(ids[i]) > after })
+		ids = ids[i:]
+	}
+	if req.Size != nil && *req.Size < len(ids) {
+		ids = ids[:*req.Size]
+	}
+
+	hits := make([]object, 0, len(ids))
+	for _, id := range ids {
+		hit := object{"_id": id, "_score": 1.0, "_source": s.docs[id]}
+		if len(req.Sort) > 0 {
+			hit["sort"] = []interface{}{sortValue(id)}
+		}
+		if req.Highlight != nil {
+			if fragment := s.highlight(req.Query, s.docs[id], req.Highlight.PreTags[0], req.Highlight.PostTags[0]); fragment != "" {
+				hit["highlight"] = object{"Content": []string{fragment}}
+			}
+		}
+		hits = append(hits, hit)
+	}
+	writeJSON(w, object{
+		"hits":         object{"total": object{"value": total, "relation": "eq"}, "hits": hits},
+		"aggregations": aggregations,
+	})
+}
+
+// matches evaluates the query for the document
+func (s *fakeServer) matches(q object, doc object) (bool, error) {
+	for typ, v := range q {
+		switch typ {
+		case "bool":
+			return s.matchesBool(v.(object), doc)
+		case "term", "prefix", "wildcard", "match", "match_phrase", "range":
+			for field, params := range v.(object) {
+				return s.matchesField(typ, field, params, doc)
+			}
+		}
+		return false, fmt.Errorf("unsupported query %s", typ)
+	}
+	return true, nil
+}
+
+func (s *fakeServer) matchesBool(q object, doc object) (bool, error) {
+	for _, clause := range []string{"must", "filter"} {
+		for _, sub := range clauses(q[clause]) {
+			if ok, err := s.matches(sub, doc); err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+	for _, sub := range clauses(q["must_not"]) {
+		if ok, err := s.matches(sub, doc); err != nil || ok {
+			return false, err
+		}
+	}
+	should := clauses(q["should"])
+	if len(should) == 0 {
+		return true, nil
+	}
+	for _, sub := range should {
+		if ok, err := s.matches(sub, doc); err != nil || ok {
+			return ok, err
+		}
+	}
+	return q["minimum_should_match"] == nil && (q["must"] != nil || q["filter"] != nil), nil
+}
+
+func clauses(v interface{}) []object {
+	list, _ := v.([]interface{})
+	objects := make([]object, 0, len(list))
+	for _, item := range list {
+		objects = append(objects, item.(object))
+	}
+	return objects
+}
+
+func (s *fakeServer) matchesField(typ, field string, params interface{}, doc object) (bool, error) {
+	property, ok := s.properties[field]
+	if !ok {
+		return false, fmt.Errorf("unknown field %s", field)
+	}
+	p, ok := params.(object)
+	if !ok {
+		p = object{"value": params, "query": params}
+	}
+
+	for _, value := range fieldValues(doc[field]) {
+		var matched bool
+		switch typ {
+		case "term":
+			matched = s.normalize(property, value) == s.normalize(property, p["value"])
+		case "prefix":
+			matched = strings.HasPrefix(s.normalize(property, value), s.normalize(property, p["value"]))
+		case "wildcard":
+			if property["type"] == "text" {
+				for _, token := range tokenize(fmt.Sprint(value)) {
+					matched = matched || wildcardPattern(p["value"].(string)).MatchString(token)
+				}
+			} else {
+				matched = wildcardPattern(p["value"].(string)).MatchString(s.normalize(property, value))
+			}
+		case "match":
+			tokens := tokenize(fmt.Sprint(value))
+			matched = true
+			for _, token := range tokenize(fmt.Sprint(p["query"])) {
+				matched = matched && slices.Contains(tokens, token)
+			}
+		case "match_phrase":
+			matched = strings.Contains(" "+strings.Join(tokenize(fmt.Sprint(value)), " ")+" ", " "+strings.Join(tokenize(fmt.Sprint(p["query"])), " ")+" ")
+		case "range":
+			matched = inRange(property, value, p)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// normalize returns the value as string, keywords with a normalizer are lower cased
+func (s *fakeServer) normalize(property object, value interface{}) string {
+	if property["normalizer"] != nil {
+		return strings.ToLower(fmt.Sprint(value))
+	}
+	return fmt.Sprint(value)
+}
+
+func fieldValues(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	}
+	return []interface{}{v}
+}
+
+func inRange(property object, value interface{}, bounds object) bool {
+	compare := func(bound interface{}) int {
+		if property["type"] == "date" {
+			v, _ := time.Parse(time.RFC3339Nano, value.(string))
+			b, _ := time.Parse(time.RFC3339Nano, bound.(string))
+			switch {
+			case v.Before(b):
+				return -1
+			case v.After(b):
+				return 1
+			}
+			return 0
+		}
+		v, b := value.(float64), bound.(float64)
+		switch {
+		case v < b:
+			return -1
+		case v > b:
+			return 1
+		}
+		return 0
+	}
+	return (bounds["gte"] == nil || compare(bounds["gte"]) >= 0) &&
+		(bounds["gt"] == nil || compare(bounds["gt"]) > 0) &&
+		(bounds["lte"] == nil || compare(bounds["lte"]) <= 0) &&
+		(bounds["lt"] == nil || compare(bounds["lt"]) < 0)
+}
+
+// tokenize splits text into lower case words like the standard analyzer
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func wildcardPattern(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+func (s *fakeServer) aggregate(agg object, ids []string) object {
+	if terms, ok := agg["terms"].(object); ok {
+		counts := map[string]int{}
+		for _, id := range ids {
+			for _, value := range fieldValues(s.docs[id][terms["field"].(string)]) {
+				counts[fmt.Sprint(value)]++
+			}
+		}
+		buckets := []object{}
+		for key, count := range counts {
+			buckets = append(buckets, object{"key": key, "doc_count": count})
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i]["doc_count"] == buckets[j]["doc_count"] {
+				return buckets[i]["key"].(string) < buckets[j]["key"].(string)
+			}
+			return buckets[i]["doc_count"].(int) > buckets[j]["doc_count"].(int)
+		})
+		return object{"buckets": buckets}
+	}
+
+	dateRange := agg["date_range"].(object)
+	field := dateRange["field"].(string)
+	buckets := []object{}
+	for _, r := range clauses(dateRange["ranges"]) {
+		count := 0
+		for _, id := range ids {
+			if value, ok := s.docs[id][field]; ok && inRange(s.properties[field], value, object{"gte": r["from"], "lt": r["to"]}) {
+				count++
+			}
+		}
+		buckets = append(buckets, object{"key": r["key"], "from_as_string": r["from"], "to_as_string": r["to"], "doc_count": count})
+	}
+	return object{"buckets": buckets}
+}
+
+// highlight marks the words of the content matched by match queries
+func (s *fakeServer) highlight(q object, doc object, preTag, postTag string) string {
+	content, ok := doc["Content"].(string)
+	if !ok {
+		return ""
+	}
+	words := map[string]bool{}
+	var collect func(q object)
+	collect = func(q object) {
+		for typ, v := range q {
+			switch typ {
+			case "bool":
+				for _, clause := range []string{"must", "should", "filter"} {
+					for _, sub := range clauses(v.(object)[clause]) {
+						collect(sub)
+					}
+				}
+			case "match", "match_phrase":
+				if p, ok := v.(object)["Content"].(object); ok {
+					for _, token := range tokenize(fmt.Sprint(p["query"])) {
+						words[token] = true
+					}
+				}
+			}
+		}
+	}
+	collect(q)
+
+	highlighted := false
+	fragment := regexp.MustCompile(`[\p{L}\p{N}]+`).ReplaceAllStringFunc(content, func(word string) string {
+		if !words[strings.ToLower(word)] {
+			return word
+		}
+		highlighted = true
+		return preTag + word + postTag
+	})
+	if !highlighted {
+		return ""
+	}
+	return fragment
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, typ, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(object{"error": object{"type": typ, "reason": reason}, "status": status})
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"path"
 	"sync"
 	"time"
 
@@ -14,7 +15,10 @@ import (
 	"github.com/cs3org/reva/v2/pkg/errtypes"
 	"github.com/cs3org/reva/v2/pkg/events"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
+	"github.com/cs3org/reva/v2/pkg/utils"
 	"google.golang.org/grpc/metadata"
+
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 )
 
 // SpaceDebouncer debounces operations on spaces for a configurable amount of time
@@ -142,6 +146,10 @@ func (p *Provider) handleEvent(ev interface{}) {
 		p.reindexSpace(ev, e.Ref, e.Executant, e.SpaceOwner)
 	case events.FileVersionRestored:
 		p.reindexSpace(ev, e.Ref, e.Executant, e.SpaceOwner)
+	case ocisevents.TagsAdded:
+		p.reindexResource(ev, e.Ref, e.Executant)
+	case ocisevents.TagsRemoved:
+		p.reindexResource(ev, e.Ref, e.Executant)
 	default:
 		// Not sure what to do here. Skip.
 		return
@@ -164,6 +172,54 @@ func (p *Provider) reindexSpace(ev interface{}, ref *provider.Reference, executa
 	}
 }
 
+// reindexResource updates the document of a resource. It is used for changes of the metadata
+// which don't change the mtime, the space resync would skip them.
+func (p *Provider) reindexResource(ev interface{}, ref *provider.Reference, executant *user.UserId) {
+	p.logger.Debug().Interface("event", ev).Msg("resource metadata has been changed, updating the document")
+	owner := &user.User{
+		Id: executant,
+	}
+
+	ownerCtx, err := p.getAuthContext(owner)
+	if err != nil {
+		return
+	}
+	statRes, err := p.statResource(ownerCtx, ref, owner)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to stat the changed resource")
+		return
+	}
+	if statRes.Status.Code != rpc.Code_CODE_OK {
+		p.logger.Error().Interface("statRes", statRes).Msg("failed to stat the changed resource")
+		return
+	}
+	info := statRes.GetInfo()
+
+	gpRes, err := p.getPath(ownerCtx, info.GetId(), owner)
+	if err != nil {
+		p.logger.Error().Err(err).Interface("ref", ref).Msg("failed to get path for changed resource")
+		return
+	}
+	if gpRes.Status.Code != rpcv1beta1.Code_CODE_OK {
+		p.logger.Error().Interface("status", gpRes.Status).Interface("ref", ref).Msg("failed to get path for changed resource")
+		return
+	}
+
+	spaceRef := &provider.Reference{
+		ResourceId: &provider.ResourceId{
+			StorageId: info.GetId().GetStorageId(),
+			SpaceId:   info.GetId().GetSpaceId(),
+			OpaqueId:  info.GetId().GetSpaceId(),
+		},
+		Path: utils.MakeRelativePath(gpRes.Path),
+	}
+	info.Path = path.Base(gpRes.Path)
+	err = p.indexClient.Add(spaceRef, info, p.extractContent(ownerCtx, info))
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to update the changed resource in the index")
+	}
+}
+
 func (p *Provider) statResource(ctx context.Context, ref *provider.Reference, owner *user.User) (*provider.StatResponse, error) {
 	return p.gwClient.Stat(ctx, &provider.StatRequest{Ref: ref})
 }
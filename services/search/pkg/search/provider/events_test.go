@@ -16,6 +16,7 @@ import (
 	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
 	"github.com/cs3org/reva/v2/pkg/utils"
 	cs3mocks "github.com/cs3org/reva/v2/tests/cs3mocks/mocks"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/search/pkg/search/mocks"
 	provider "github.com/owncloud/ocis/v2/services/search/pkg/search/provider"
@@ -69,7 +70,7 @@ var _ = Describe("Searchprovider", func() {
 			debouncedIndexCalls += 1
 		})
 
-		p = provider.NewWithDebouncer(gwClient, indexClient, "", eventsChan, logger, debouncer)
+		p = provider.NewWithDebouncer(gwClient, indexClient, nil, "", eventsChan, logger, debouncer)
 
 		gwClient.On("Authenticate", mock.Anything, mock.Anything).Return(&gateway.AuthenticateResponse{
 			Status: status.NewOK(ctx),
@@ -84,7 +85,7 @@ var _ = Describe("Searchprovider", func() {
 
 	Describe("New", func() {
 		It("returns a new instance", func() {
-			p = provider.New(gwClient, indexClient, "", eventsChan, logger)
+			p = provider.New(gwClient, indexClient, nil, "", eventsChan, logger)
 			Expect(p).ToNot(BeNil())
 		})
 	})
@@ -160,6 +161,26 @@ var _ = Describe("Searchprovider", func() {
 				}, "2s").Should(BeTrue())
 			})
 
+			It("updates the document when the tags have changed", func() {
+				called := false
+				indexClient.On("Add", mock.MatchedBy(func(ref *sprovider.Reference) bool {
+					return ref.ResourceId.OpaqueId == "rootopaqueid" && ref.Path == "./foo.pdf"
+				}), mock.MatchedBy(func(riToIndex *sprovider.ResourceInfo) bool {
+					return riToIndex.Id.OpaqueId == ri.Id.OpaqueId
+				}), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+					called = true
+				})
+				eventsChan <- ocisevents.TagsAdded{
+					Ref:       ref,
+					Executant: user.Id,
+					Tags:      []string{"invoice"},
+				}
+
+				Eventually(func() bool {
+					return called
+				}, "2s").Should(BeTrue())
+			})
+
 			It("indexes items when a version has been restored", func() {
 				eventsChan <- events.FileVersionRestored{
 					Ref:       ref,
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+
+	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
+	"golang.org/x/exp/slices"
+)
+
+// facetSpace is the name of the facet counting the matches per space. The other facets are
+// computed by the index.
+const facetSpace = "space"
+
+// indexFacets are the facets computed by the index
+var indexFacets = []string{string(query.FieldMediaType), string(query.FieldMtime)}
+
+// facetCounter sums up the facets of the searched spaces
+type facetCounter struct {
+	facets []*searchmsg.Facet
+}
+
+func newFacetCounter(names []string) (*facetCounter, error) {
+	c := &facetCounter{}
+	for _, name := range names {
+		if name != facetSpace && !slices.Contains(indexFacets, name) {
+			return nil, fmt.Errorf("unknown facet %q", name)
+		}
+		if c.facet(name) == nil {
+			c.facets = append(c.facets, &searchmsg.Facet{Name: name})
+		}
+	}
+	return c, nil
+}
+
+func (c *facetCounter) facet(name string) *searchmsg.Facet {
+	for _, f := range c.facets {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// indexFacets returns the names of the requested facets computed by the index
+func (c *facetCounter) indexFacets() []string {
+	var names []string
+	for _, f := range c.facets {
+		if f.Name != facetSpace {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// addSpace counts the matches of a space
+func (c *facetCounter) addSpace(spaceID, spaceName string, count int32) {
+	if f := c.facet(facetSpace); f != nil && count > 0 {
+		f.Values = append(f.Values, &searchmsg.FacetValue{Value: spaceID, Count: count, Label: spaceName})
+	}
+}
+
+// add sums up the counts of the facets of a space
+func (c *facetCounter) add(facets []*searchmsg.Facet) {
+	for _, facet := range facets {
+		f := c.facet(facet.Name)
+		if f == nil {
+			continue
+		}
+		for _, value := range facet.Values {
+			i := slices.IndexFunc(f.Values, func(v *searchmsg.FacetValue) bool { return v.Value == value.Value })
+			if i < 0 {
+				f.Values = append(f.Values, value)
+				continue
+			}
+			f.Values[i].Count += value.Count
+		}
+	}
+}
+
+// result returns the facets with the most frequent values first. The values of the modification
+// time facet are kept in the order of the time ranges.
+func (c *facetCounter) result() []*searchmsg.Facet {
+	for _, f := range c.facets {
+		values := f.Values
+		if f.Name == string(query.FieldMtime) {
+			sort.SliceStable(values, func(i, j int) bool {
+				return slices.Index(query.MtimeRanges, values[i].Value) < slices.Index(query.MtimeRanges, values[j].Value)
+			})
+			continue
+		}
+		sort.SliceStable(values, func(i, j int) bool {
+			if values[i].Count == values[j].Count {
+				return values[i].Value < values[j].Value
+			}
+			return values[i].Count > values[j].Count
+		})
+	}
+	return c.facets
+}
@@ -0,0 +1,343 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/errtypes"
+	"github.com/cs3org/reva/v2/pkg/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+)
+
+// indexHooks let the reindex of all spaces track and resume the walk of a space
+type indexHooks struct {
+	// resume is the path of the last container entered by an interrupted walk
+	resume string
+	// enter is called before a container is added to the index
+	enter func(path string)
+	// add is called after a resource has been added to the index
+	add func(err error)
+}
+
+// resumes reports whether the walk has to descend into the container at the given path
+// although it is unchanged. The containers the interrupted walk was in have been added
+// to the index before their children, so they look unchanged but are incomplete.
+func (h *indexHooks) resumes(path string) bool {
+	if h == nil || h.resume == "" {
+		return false
+	}
+	return path == h.resume || strings.HasPrefix(h.resume, path+"/")
+}
+
+func (h *indexHooks) entered(path string) {
+	if h != nil && h.enter != nil {
+		h.enter(path)
+	}
+}
+
+func (h *indexHooks) added(err error) {
+	if h != nil && h.add != nil {
+		h.add(err)
+	}
+}
+
+// reindexState is the persisted progress of the reindex of all spaces
+type reindexState struct {
+	UserID    string        `json:"user_id"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Documents int64         `json:"documents"`
+	Errors    int32         `json:"errors"`
+	Spaces    []*spaceState `json:"spaces"`
+}
+
+// spaceState is the progress of indexing a single space
+type spaceState struct {
+	ID string `json:"id"`
+	// OwnerID is the user that accesses the files, the user that started the reindex if empty
+	OwnerID string `json:"owner_id,omitempty"`
+	// Cursor is the path of the last container entered while walking the space
+	Cursor string `json:"cursor,omitempty"`
+	Done   bool   `json:"done"`
+}
+
+// reindexer indexes all spaces in the background. Its progress is persisted, so a reindex
+// interrupted by a restart of the service continues where it stopped.
+type reindexer struct {
+	// ctx is canceled when the service stops, the reindex then stops at its cursor
+	ctx       context.Context
+	p         *Provider
+	statePath string
+	throttle  time.Duration
+
+	mu      sync.Mutex
+	state   *reindexState
+	running bool
+}
+
+func newReindexer(ctx context.Context, p *Provider, statePath string, throttle time.Duration) *reindexer {
+	return &reindexer{
+		ctx:       ctx,
+		p:         p,
+		statePath: statePath,
+		throttle:  throttle,
+	}
+}
+
+// ConfigureReindex persists the progress of the reindex of all spaces in the given file and
+// pauses for the throttle duration after every resource added to the index. A reindex
+// which was interrupted is resumed in the background. The reindex stops when ctx is
+// canceled and is resumed the next time the service starts.
+func (p *Provider) ConfigureReindex(ctx context.Context, statePath string, throttle time.Duration) error {
+	r := newReindexer(ctx, p, statePath, throttle)
+	state, err := r.load()
+	if err != nil {
+		return err
+	}
+	r.state = state
+	p.reindexer = r
+
+	if state != nil && state.EndTime.IsZero() {
+		p.logger.Info().Str("user", state.UserID).Msg("resuming the reindex of all spaces")
+		r.running = true
+		go r.run(r.ctx)
+	}
+	return nil
+}
+
+// start lists the spaces and indexes them in the background. A reindex which is already
+// running is continued.
+func (r *reindexer) start(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		r.p.logger.Info().Msg("the reindex of all spaces is already running")
+		return nil
+	}
+	// claim the reindex before listing the spaces, the lock is not held during the requests
+	r.running = true
+	r.mu.Unlock()
+
+	spaces, err := r.p.listAllSpaces(ctx, userID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.running = false
+		return err
+	}
+	r.state = &reindexState{
+		UserID:    userID,
+		StartTime: time.Now(),
+		Spaces:    spaces,
+	}
+	if err := r.persist(); err != nil {
+		r.running = false
+		return err
+	}
+
+	r.p.logger.Info().Int("spaces", len(spaces)).Msg("starting the reindex of all spaces")
+	go r.run(r.ctx)
+	return nil
+}
+
+func (r *reindexer) run(ctx context.Context) {
+	r.mu.Lock()
+	spaces := r.state.Spaces
+	r.mu.Unlock()
+
+	for _, space := range spaces {
+		if ctx.Err() != nil {
+			r.stop()
+			return
+		}
+
+		r.mu.Lock()
+		done, cursor := space.Done, space.Cursor
+		ownerID := space.OwnerID
+		if ownerID == "" {
+			ownerID = r.state.UserID
+		}
+		r.mu.Unlock()
+		if done {
+			continue
+		}
+
+		space := space
+		hooks := &indexHooks{
+			resume: cursor,
+			enter: func(path string) {
+				r.mu.Lock()
+				defer r.mu.Unlock()
+				space.Cursor = path
+				r.persistOrLog()
+			},
+			add: func(err error) {
+				r.mu.Lock()
+				if err != nil {
+					r.state.Errors++
+				} else {
+					r.state.Documents++
+				}
+				r.mu.Unlock()
+				if r.throttle > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(r.throttle):
+					}
+				}
+			},
+		}
+		err := r.p.doIndexSpace(ctx, &provider.StorageSpaceId{OpaqueId: space.ID}, &user.UserId{OpaqueId: ownerID}, hooks)
+		if ctx.Err() != nil {
+			// the space is not done, the next run continues at its cursor
+			r.stop()
+			return
+		}
+
+		r.mu.Lock()
+		if err != nil {
+			r.p.logger.Error().Err(err).Str("spaceID", space.ID).Str("userID", ownerID).Msg("error while indexing a space")
+			r.state.Errors++
+		}
+		space.Done = true
+		space.Cursor = ""
+		r.persistOrLog()
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.EndTime = time.Now()
+	r.running = false
+	r.persistOrLog()
+	r.p.logger.Info().Int64("documents", r.state.Documents).Int32("errors", r.state.Errors).Msg("finished the reindex of all spaces")
+}
+
+// stop marks the reindex as no longer running without finishing it
+func (r *reindexer) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = false
+	r.persistOrLog()
+	r.p.logger.Info().Msg("stopped the reindex of all spaces, it is resumed on the next start")
+}
+
+// status returns the progress of the current or the last reindex
+func (r *reindexer) status() *searchsvc.GetIndexStatusResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := &searchsvc.GetIndexStatusResponse{Running: r.running}
+	if r.state == nil {
+		return res
+	}
+	res.SpacesTotal = int32(len(r.state.Spaces))
+	for _, space := range r.state.Spaces {
+		if space.Done {
+			res.SpacesDone++
+		}
+	}
+	res.DocumentsIndexed = r.state.Documents
+	res.Errors = r.state.Errors
+	res.StartTime = timestamppb.New(r.state.StartTime)
+	if !r.state.EndTime.IsZero() {
+		res.EndTime = timestamppb.New(r.state.EndTime)
+	}
+	return res
+}
+
+// load reads the persisted state, it returns nil if no reindex has been started yet
+func (r *reindexer) load() (*reindexState, error) {
+	if r.statePath == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(r.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state := &reindexState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("invalid reindex state %s: %w", r.statePath, err)
+	}
+	return state, nil
+}
+
+// persist writes the state to disk, the caller has to hold the lock
+func (r *reindexer) persist() error {
+	if r.statePath == "" {
+		return nil
+	}
+	b, err := json.Marshal(r.state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.statePath), 0700); err != nil {
+		return err
+	}
+	// replace the file atomically to not lose the state when the service stops while writing
+	tmp := r.statePath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.statePath)
+}
+
+func (r *reindexer) persistOrLog() {
+	if err := r.persist(); err != nil {
+		r.p.logger.Error().Err(err).Str("path", r.statePath).Msg("error persisting the progress of the reindex")
+	}
+}
+
+// listAllSpaces returns the personal and project spaces. Personal spaces are indexed as
+// their owner, the other spaces as the given user.
+func (p *Provider) listAllSpaces(ctx context.Context, userID string) ([]*spaceState, error) {
+	ctx, err := p.authenticate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.gwClient.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
+		Opaque: utils.AppendPlainToOpaque(nil, "unrestricted", "true"),
+		Filters: []*provider.ListStorageSpacesRequest_Filter{
+			{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+				Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "personal"},
+			},
+			{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+				Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "project"},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		return nil, errtypes.NewErrtypeFromStatus(res.Status)
+	}
+
+	spaces := make([]*spaceState, 0, len(res.StorageSpaces))
+	for _, space := range res.StorageSpaces {
+		s := &spaceState{ID: space.GetId().GetOpaqueId()}
+		if space.SpaceType == "personal" {
+			s.OwnerID = space.GetOwner().GetId().GetOpaqueId()
+		}
+		spaces = append(spaces, s)
+	}
+	return spaces, nil
+}
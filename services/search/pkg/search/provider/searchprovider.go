@@ -22,7 +22,10 @@ import (
 	"github.com/cs3org/reva/v2/pkg/storage/utils/walker"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
 	"github.com/cs3org/reva/v2/pkg/utils"
+	ocisevents "github.com/owncloud/ocis/v2/ocis-pkg/events"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
 	"github.com/owncloud/ocis/v2/services/search/pkg/search"
 
 	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
@@ -56,15 +59,19 @@ var ListenEvents = []events.Unmarshaller{
 	events.FileUploaded{},
 	events.FileTouched{},
 	events.FileVersionRestored{},
+	ocisevents.TagsAdded{},
+	ocisevents.TagsRemoved{},
 }
 
 type Provider struct {
 	logger            log.Logger
 	gwClient          gateway.GatewayAPIClient
 	indexClient       search.IndexClient
+	extractor         content.Extractor
 	machineAuthAPIKey string
 
 	indexSpaceDebouncer *SpaceDebouncer
+	reindexer           *reindexer
 }
 
 type MatchArray []*searchmsg.Match
@@ -79,16 +86,20 @@ func (s MatchArray) Less(i, j int) bool {
 	return s[i].Score > s[j].Score
 }
 
-func New(gwClient gateway.GatewayAPIClient, indexClient search.IndexClient, machineAuthAPIKey string, eventsChan <-chan interface{}, logger log.Logger) *Provider {
+// New returns a new provider. The content of the indexed files is retrieved with the
+// extractor, a nil extractor only indexes the file metadata.
+func New(gwClient gateway.GatewayAPIClient, indexClient search.IndexClient, extractor content.Extractor, machineAuthAPIKey string, eventsChan <-chan interface{}, logger log.Logger) *Provider {
 	p := &Provider{
 		gwClient:          gwClient,
 		indexClient:       indexClient,
+		extractor:         extractor,
 		machineAuthAPIKey: machineAuthAPIKey,
 		logger:            logger,
 	}
+	p.reindexer = newReindexer(context.Background(), p, "", 0)
 
 	p.indexSpaceDebouncer = NewSpaceDebouncer(50*time.Millisecond, func(id *provider.StorageSpaceId, userID *user.UserId) {
-		err := p.doIndexSpace(context.Background(), id, userID)
+		err := p.doIndexSpace(context.Background(), id, userID, nil)
 		if err != nil {
 			p.logger.Error().Err(err).Interface("spaceID", id).Interface("userID", userID).Msg("error while indexing a space")
 		}
@@ -108,8 +119,8 @@ func New(gwClient gateway.GatewayAPIClient, indexClient search.IndexClient, mach
 }
 
 // NewWithDebouncer returns a new provider with a customer index space debouncer
-func NewWithDebouncer(gwClient gateway.GatewayAPIClient, indexClient search.IndexClient, machineAuthAPIKey string, eventsChan <-chan interface{}, logger log.Logger, debouncer *SpaceDebouncer) *Provider {
-	p := New(gwClient, indexClient, machineAuthAPIKey, eventsChan, logger)
+func NewWithDebouncer(gwClient gateway.GatewayAPIClient, indexClient search.IndexClient, extractor content.Extractor, machineAuthAPIKey string, eventsChan <-chan interface{}, logger log.Logger, debouncer *SpaceDebouncer) *Provider {
+	p := New(gwClient, indexClient, extractor, machineAuthAPIKey, eventsChan, logger)
 	p.indexSpaceDebouncer = debouncer
 	return p
 }
@@ -118,6 +129,13 @@ func (p *Provider) Search(ctx context.Context, req *searchsvc.SearchRequest) (*s
 	if req.Query == "" {
 		return nil, errtypes.BadRequest("empty query provided")
 	}
+	if _, err := query.Parse(req.Query, time.Now()); err != nil {
+		return nil, errtypes.BadRequest("invalid query: " + err.Error())
+	}
+	facets, err := newFacetCounter(req.Facets)
+	if err != nil {
+		return nil, errtypes.BadRequest(err.Error())
+	}
 	p.logger.Debug().Str("query", req.Query).Msg("performing a search")
 
 	listSpacesRes, err := p.gwClient.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
@@ -167,12 +185,17 @@ func (p *Provider) Search(ctx context.Context, req *searchsvc.SearchRequest) (*s
 			mountpointRootID *searchmsg.ResourceID
 			rootName         string
 			permissions      *provider.ResourcePermissions
+			trashKeys        map[string]struct{}
 		)
+		spaceID, spaceName := space.Id.OpaqueId, space.Name
 		mountpointPrefix := ""
 		switch space.SpaceType {
 		case "mountpoint":
 			continue // mountpoint spaces are only "links" to the shared spaces. we have to search the shared "grant" space instead
 		case "grant":
+			if req.Trash {
+				continue // the trashed items of shared resources are in the trash bin of the shared space
+			}
 			// In case of grant spaces we search the root of the outer space and translate the paths to the according mountpoint
 			searchRootId.OpaqueId = space.Root.SpaceId
 			mountpointID, ok := mountpointMap[space.Id.OpaqueId]
@@ -204,19 +227,31 @@ func (p *Provider) Search(ctx context.Context, req *searchsvc.SearchRequest) (*s
 			}
 
 			rootName = filepath.Join("/", filepath.Base(gpRes.GetPath()))
+			spaceID, spaceName = mountpointID, filepath.Base(gpRes.GetPath())
 			permissions = space.GetRootInfo().GetPermissionSet()
 			p.logger.Debug().Interface("grantSpace", space).Interface("mountpointRootId", mountpointRootID).Msg("searching a grant")
 		case "personal":
 			permissions = space.GetRootInfo().GetPermissionSet()
 		}
 
+		if req.Trash {
+			// only search the trash bins the user is allowed to list
+			trashKeys, err = p.listTrashKeys(ctx, space)
+			if err != nil {
+				p.logger.Debug().Err(err).Str("space", space.Id.OpaqueId).Msg("skipping the trash bin of the space")
+				continue
+			}
+		}
+
 		res, err := p.indexClient.Search(ctx, &searchsvc.SearchIndexRequest{
-			Query: formatQuery(req.Query),
+			Query: req.Query,
 			Ref: &searchmsg.Reference{
 				ResourceId: searchRootId,
 				Path:       mountpointPrefix,
 			},
 			PageSize: req.PageSize,
+			Facets:   facets.indexFacets(),
+			Trash:    req.Trash,
 		})
 		if err != nil {
 			p.logger.Error().Err(err).Str("space", space.Id.OpaqueId).Msg("failed to search the index")
@@ -224,7 +259,13 @@ func (p *Provider) Search(ctx context.Context, req *searchsvc.SearchRequest) (*s
 		}
 		p.logger.Debug().Str("space", space.Id.OpaqueId).Int("hits", len(res.Matches)).Msg("space search done")
 
+		if req.Trash {
+			res.Matches, res.TotalMatches = p.filterTrashedMatches(res.Matches, res.TotalMatches, trashKeys)
+		}
+
 		total += res.TotalMatches
+		facets.addSpace(spaceID, spaceName, res.TotalMatches)
+		facets.add(res.Facets)
 		for _, match := range res.Matches {
 			if mountpointPrefix != "" {
 				match.Entity.Ref.Path = utils.MakeRelativePath(strings.TrimPrefix(match.Entity.Ref.Path, mountpointPrefix))
@@ -255,31 +296,98 @@ func (p *Provider) Search(ctx context.Context, req *searchsvc.SearchRequest) (*s
 	return &searchsvc.SearchResponse{
 		Matches:      matches,
 		TotalMatches: total,
+		Facets:       facets.result(),
 	}, nil
 }
 
+// listTrashKeys returns the keys of the items in the trash bin of the space. It fails if the
+// user is not allowed to list the trash bin.
+func (p *Provider) listTrashKeys(ctx context.Context, space *provider.StorageSpace) (map[string]struct{}, error) {
+	res, err := p.gwClient.ListRecycle(ctx, &provider.ListRecycleRequest{
+		Ref: &provider.Reference{ResourceId: space.Root, Path: "."},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.NewErrtypeFromStatus(res.Status)
+	}
+	keys := make(map[string]struct{}, len(res.RecycleItems))
+	for _, item := range res.RecycleItems {
+		keys[item.Key] = struct{}{}
+	}
+	return keys, nil
+}
+
+// filterTrashedMatches drops the matches which are no longer in the trash bin. The index is
+// not notified when the trash bin is emptied, so the documents of purged items are removed
+// from the index when they are found.
+func (p *Provider) filterTrashedMatches(matches []*searchmsg.Match, total int32, trashKeys map[string]struct{}) ([]*searchmsg.Match, int32) {
+	filtered := matches[:0]
+	for _, match := range matches {
+		key := match.GetEntity().GetTrashKey()
+		if _, ok := trashKeys[strings.SplitN(key, "/", 2)[0]]; ok {
+			filtered = append(filtered, match)
+			continue
+		}
+		total--
+		// documents indexed without trash keys can't be restored, but they are kept for restoring
+		// their trashed parents
+		if key == "" {
+			continue
+		}
+		id := match.GetEntity().GetId()
+		err := p.indexClient.Purge(&provider.ResourceId{StorageId: id.GetStorageId(), SpaceId: id.GetSpaceId(), OpaqueId: id.GetOpaqueId()})
+		if err != nil {
+			p.logger.Error().Err(err).Interface("id", id).Msg("failed to purge the document of a purged item")
+		}
+	}
+	return filtered, total
+}
+
 func (p *Provider) IndexSpace(ctx context.Context, req *searchsvc.IndexSpaceRequest) (*searchsvc.IndexSpaceResponse, error) {
-	err := p.doIndexSpace(ctx, &provider.StorageSpaceId{OpaqueId: req.SpaceId}, &user.UserId{OpaqueId: req.UserId})
+	if req.SpaceId == "" {
+		if err := p.reindexer.start(ctx, req.UserId); err != nil {
+			return nil, err
+		}
+		return &searchsvc.IndexSpaceResponse{}, nil
+	}
+
+	err := p.doIndexSpace(ctx, &provider.StorageSpaceId{OpaqueId: req.SpaceId}, &user.UserId{OpaqueId: req.UserId}, nil)
 	if err != nil {
 		return nil, err
 	}
 	return &searchsvc.IndexSpaceResponse{}, nil
 }
 
-func (p *Provider) doIndexSpace(ctx context.Context, spaceID *provider.StorageSpaceId, userID *user.UserId) error {
+// GetIndexStatus returns the progress of the reindex of all spaces
+func (p *Provider) GetIndexStatus(_ context.Context, _ *searchsvc.GetIndexStatusRequest) (*searchsvc.GetIndexStatusResponse, error) {
+	return p.reindexer.status(), nil
+}
+
+// authenticate returns a context that accesses the storage as the given user
+func (p *Provider) authenticate(ctx context.Context, userID string) (context.Context, error) {
 	authRes, err := p.gwClient.Authenticate(ctx, &gateway.AuthenticateRequest{
 		Type:         "machine",
-		ClientId:     "userid:" + userID.OpaqueId,
+		ClientId:     "userid:" + userID,
 		ClientSecret: p.machineAuthAPIKey,
 	})
-	if err != nil || authRes.GetStatus().GetCode() != rpc.Code_CODE_OK {
-		return err
+	if err != nil {
+		return nil, err
 	}
-
 	if authRes.GetStatus().GetCode() != rpc.Code_CODE_OK {
-		return fmt.Errorf("could not get authenticated context for user")
+		return nil, fmt.Errorf("could not get authenticated context for user")
+	}
+	return metadata.AppendToOutgoingContext(ctx, ctxpkg.TokenHeader, authRes.Token), nil
+}
+
+// doIndexSpace walks the space and adds the changed resources to the index. The hooks
+// are optional.
+func (p *Provider) doIndexSpace(ctx context.Context, spaceID *provider.StorageSpaceId, userID *user.UserId, hooks *indexHooks) error {
+	ownerCtx, err := p.authenticate(ctx, userID.OpaqueId)
+	if err != nil {
+		return err
 	}
-	ownerCtx := metadata.AppendToOutgoingContext(ctx, ctxpkg.TokenHeader, authRes.Token)
 
 	// Walk the space and index all files
 	walker := walker.NewWalker(p.gwClient)
@@ -312,9 +420,9 @@ func (p *Provider) doIndexSpace(ctx context.Context, spaceID *provider.StorageSp
 
 		// Has this item/subtree changed?
 		searchRes, err := p.indexClient.Search(ownerCtx, &searchsvc.SearchIndexRequest{
-			Query: "+ID:" + storagespace.FormatResourceID(*info.Id) + ` +Mtime:>="` + utils.TSToTime(info.Mtime).Format(time.RFC3339Nano) + `"`,
+			Query: `id:"` + storagespace.FormatResourceID(*info.Id) + `" mtime>="` + utils.TSToTime(info.Mtime).Format(time.RFC3339Nano) + `"`,
 		})
-		if err == nil && len(searchRes.Matches) >= 1 {
+		if err == nil && len(searchRes.Matches) >= 1 && !hooks.resumes(ref.Path) {
 			if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
 				p.logger.Debug().Str("path", ref.Path).Msg("subtree hasn't changed. Skipping.")
 				return filepath.SkipDir
@@ -323,12 +431,16 @@ func (p *Provider) doIndexSpace(ctx context.Context, spaceID *provider.StorageSp
 			return nil
 		}
 
-		err = p.indexClient.Add(ref, info)
+		if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			hooks.entered(ref.Path)
+		}
+		err = p.indexClient.Add(ref, info, p.extractContent(ownerCtx, info))
 		if err != nil {
 			p.logger.Error().Err(err).Msg("error adding resource to the index")
 		} else {
 			p.logger.Debug().Interface("ref", ref).Msg("added resource to index")
 		}
+		hooks.added(err)
 		return nil
 	})
 	if err != nil {
@@ -339,6 +451,20 @@ func (p *Provider) doIndexSpace(ctx context.Context, spaceID *provider.StorageSp
 	return nil
 }
 
+// extractContent returns the content of the resource. Resources whose content can't be
+// extracted are indexed without it.
+func (p *Provider) extractContent(ctx context.Context, ri *provider.ResourceInfo) content.Document {
+	if p.extractor == nil {
+		return content.Document{}
+	}
+	doc, err := p.extractor.Extract(ctx, ri)
+	if err != nil {
+		p.logger.Error().Err(err).Str("path", ri.GetPath()).Msg("error extracting the content of the resource")
+		return content.Document{}
+	}
+	return doc
+}
+
 func (p *Provider) logDocCount() {
 	c, err := p.indexClient.DocCount()
 	if err != nil {
@@ -347,21 +473,6 @@ func (p *Provider) logDocCount() {
 	p.logger.Debug().Interface("count", c).Msg("new document count")
 }
 
-func formatQuery(q string) string {
-	query := q
-	fields := []string{"RootID", "Path", "ID", "Name", "Size", "Mtime", "MimeType", "Type"}
-	for _, field := range fields {
-		query = strings.ReplaceAll(query, strings.ToLower(field)+":", field+":")
-	}
-
-	if strings.Contains(query, ":") {
-		return query // Sophisticated field based search
-	}
-
-	// this is a basic filename search
-	return "Name:*" + strings.ReplaceAll(strings.ToLower(query), " ", `\ `) + "*"
-}
-
 // NOTE: this converts CS3 to WebDAV permissions
 // since conversions pkg is reva internal we have no other choice than to duplicate the logic
 func convertToWebDAVPermissions(isShared, isMountpoint, isDir bool, p *provider.ResourcePermissions) string {
@@ -2,6 +2,8 @@ package provider_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -12,12 +14,16 @@ import (
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	sprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/v2/pkg/errtypes"
 	"github.com/cs3org/reva/v2/pkg/rgrpc/status"
 	"github.com/cs3org/reva/v2/pkg/utils"
 	cs3mocks "github.com/cs3org/reva/v2/tests/cs3mocks/mocks"
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	searchmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/search/v0"
 	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
+	contentmocks "github.com/owncloud/ocis/v2/services/search/pkg/content/mocks"
+	"github.com/owncloud/ocis/v2/services/search/pkg/query"
 	"github.com/owncloud/ocis/v2/services/search/pkg/search/mocks"
 	provider "github.com/owncloud/ocis/v2/services/search/pkg/search/provider"
 )
@@ -27,6 +33,7 @@ var _ = Describe("Searchprovider", func() {
 		p           *provider.Provider
 		gwClient    *cs3mocks.GatewayAPIClient
 		indexClient *mocks.IndexClient
+		extractor   *contentmocks.Extractor
 
 		ctx        context.Context
 		eventsChan chan interface{}
@@ -72,8 +79,9 @@ var _ = Describe("Searchprovider", func() {
 		eventsChan = make(chan interface{})
 		gwClient = &cs3mocks.GatewayAPIClient{}
 		indexClient = &mocks.IndexClient{}
+		extractor = &contentmocks.Extractor{}
 
-		p = provider.New(gwClient, indexClient, "", eventsChan, logger)
+		p = provider.New(gwClient, indexClient, extractor, "", eventsChan, logger)
 
 		gwClient.On("Authenticate", mock.Anything, mock.Anything).Return(&gateway.AuthenticateResponse{
 			Status: status.NewOK(ctx),
@@ -94,7 +102,7 @@ var _ = Describe("Searchprovider", func() {
 
 	Describe("New", func() {
 		It("returns a new instance", func() {
-			p := provider.New(gwClient, indexClient, "", eventsChan, logger)
+			p := provider.New(gwClient, indexClient, extractor, "", eventsChan, logger)
 			Expect(p).ToNot(BeNil())
 		})
 	})
@@ -105,9 +113,12 @@ var _ = Describe("Searchprovider", func() {
 				Status: status.NewOK(context.Background()),
 				User:   user,
 			}, nil)
+			extractor.On("Extract", mock.Anything, mock.MatchedBy(func(riToExtract *sprovider.ResourceInfo) bool {
+				return riToExtract.Id.OpaqueId == ri.Id.OpaqueId
+			})).Return(content.Document{Content: "file content"}, nil)
 			indexClient.On("Add", mock.Anything, mock.MatchedBy(func(riToIndex *sprovider.ResourceInfo) bool {
 				return riToIndex.Id.OpaqueId == ri.Id.OpaqueId
-			})).Return(nil)
+			}), content.Document{Content: "file content"}).Return(nil)
 			indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
 
 			res, err := p.IndexSpace(ctx, &searchsvc.IndexSpaceRequest{
@@ -116,6 +127,138 @@ var _ = Describe("Searchprovider", func() {
 			})
 			Expect(err).ToNot(HaveOccurred())
 			Expect(res).ToNot(BeNil())
+			indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+				_, err := query.Parse(req.Query, time.Now())
+				return err == nil
+			}))
+		})
+	})
+
+	Describe("IndexSpace for all spaces", func() {
+		var (
+			projectSpace = &sprovider.StorageSpace{
+				Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$projectspace!projectspace"},
+				Root:      &sprovider.ResourceId{StorageId: "storageid", SpaceId: "projectspace", OpaqueId: "projectspace"},
+				Name:      "projectspace",
+				SpaceType: "project",
+			}
+			statePath string
+		)
+
+		BeforeEach(func() {
+			statePath = filepath.Join(GinkgoT().TempDir(), "reindex.json")
+			Expect(p.ConfigureReindex(ctx, statePath, 0)).To(Succeed())
+
+			personalSpace := &sprovider.StorageSpace{
+				Id:        &sprovider.StorageSpaceId{OpaqueId: "storageid$personalspace!personalspace"},
+				Root:      &sprovider.ResourceId{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "personalspace"},
+				Name:      "personalspace",
+				SpaceType: "personal",
+				Owner:     otherUser,
+			}
+			gwClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status:        status.NewOK(ctx),
+				StorageSpaces: []*sprovider.StorageSpace{personalSpace, projectSpace},
+			}, nil)
+			extractor.On("Extract", mock.Anything, mock.Anything).Return(content.Document{}, nil)
+			indexClient.On("Add", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		})
+
+		indexStatus := func() *searchsvc.GetIndexStatusResponse {
+			res, err := p.GetIndexStatus(ctx, &searchsvc.GetIndexStatusRequest{})
+			Expect(err).ToNot(HaveOccurred())
+			return res
+		}
+
+		It("indexes all spaces in the background and reports the progress", func() {
+			indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{}, nil)
+
+			Expect(indexStatus().StartTime).To(BeNil())
+
+			_, err := p.IndexSpace(ctx, &searchsvc.IndexSpaceRequest{UserId: "user"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() bool { return indexStatus().Running }).Should(BeFalse())
+			res := indexStatus()
+			Expect(res.SpacesTotal).To(Equal(int32(2)))
+			Expect(res.SpacesDone).To(Equal(int32(2)))
+			Expect(res.DocumentsIndexed).To(Equal(int64(2)))
+			Expect(res.Errors).To(Equal(int32(0)))
+			Expect(res.EndTime).ToNot(BeNil())
+
+			gwClient.AssertCalled(GinkgoT(), "ListStorageSpaces", mock.Anything, mock.MatchedBy(func(req *sprovider.ListStorageSpacesRequest) bool {
+				return utils.ReadPlainFromOpaque(req.Opaque, "unrestricted") == "true"
+			}))
+			gwClient.AssertCalled(GinkgoT(), "Authenticate", mock.Anything, mock.MatchedBy(func(req *gateway.AuthenticateRequest) bool {
+				return req.ClientId == "userid:otheruser"
+			}))
+			Expect(statePath).To(BeAnExistingFile())
+		})
+
+		It("resumes an interrupted reindex at the cursor", func() {
+			// the resource looks unchanged, but the interrupted walk has not finished it
+			indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{
+				Matches: []*searchmsg.Match{{Entity: &searchmsg.Entity{Name: "foo.pdf"}}},
+			}, nil)
+			Expect(os.WriteFile(statePath, []byte(`{
+				"user_id": "user",
+				"start_time": "2026-10-01T10:00:00Z",
+				"documents": 5,
+				"spaces": [
+					{"id": "storageid$personalspace!personalspace", "owner_id": "otheruser", "done": true},
+					{"id": "storageid$projectspace!projectspace", "cursor": "./foo.pdf"}
+				]
+			}`), 0600)).To(Succeed())
+
+			Expect(p.ConfigureReindex(ctx, statePath, 0)).To(Succeed())
+
+			Eventually(func() bool { return indexStatus().Running }).Should(BeFalse())
+			res := indexStatus()
+			Expect(res.SpacesDone).To(Equal(int32(2)))
+			Expect(res.DocumentsIndexed).To(Equal(int64(6)))
+			indexClient.AssertNumberOfCalls(GinkgoT(), "Add", 1)
+			gwClient.AssertNotCalled(GinkgoT(), "ListStorageSpaces", mock.Anything, mock.Anything)
+		})
+
+		It("stops at the cursor when the context is canceled", func() {
+			state := `{
+				"user_id": "user",
+				"start_time": "2026-10-01T10:00:00Z",
+				"spaces": [
+					{"id": "storageid$personalspace!personalspace", "owner_id": "otheruser", "done": true},
+					{"id": "storageid$projectspace!projectspace", "cursor": "./foo.pdf"}
+				]
+			}`
+			Expect(os.WriteFile(statePath, []byte(state), 0600)).To(Succeed())
+
+			cancelCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			Expect(p.ConfigureReindex(cancelCtx, statePath, 0)).To(Succeed())
+
+			Eventually(func() bool { return indexStatus().Running }).Should(BeFalse())
+			res := indexStatus()
+			Expect(res.SpacesDone).To(Equal(int32(1)))
+			Expect(res.EndTime).To(BeNil())
+			indexClient.AssertNotCalled(GinkgoT(), "Add", mock.Anything, mock.Anything, mock.Anything)
+
+			b, err := os.ReadFile(statePath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(b)).To(ContainSubstring(`"cursor":"./foo.pdf"`))
+		})
+
+		It("fails when the spaces can't be listed", func() {
+			gwClient.ExpectedCalls = nil
+			gwClient.On("Authenticate", mock.Anything, mock.Anything).Return(&gateway.AuthenticateResponse{
+				Status: status.NewOK(ctx),
+				Token:  "authtoken",
+			}, nil)
+			gwClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+				Status: status.NewPermissionDenied(ctx, nil, "not allowed"),
+			}, nil)
+
+			_, err := p.IndexSpace(ctx, &searchsvc.IndexSpaceRequest{UserId: "user"})
+			Expect(err).To(HaveOccurred())
+			Expect(indexStatus().Running).To(BeFalse())
 		})
 	})
 
@@ -159,48 +302,31 @@ var _ = Describe("Searchprovider", func() {
 				}, nil)
 			})
 
-			It("lowercases the filename", func() {
+			It("passes the query to the index", func() {
 				p.Search(ctx, &searchsvc.SearchRequest{
 					Query: "Foo.pdf",
 				})
 				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
-					return req.Query == "Name:*foo.pdf*"
+					return req.Query == "Foo.pdf"
 				}))
 			})
 
-			It("does not mess with field-based searches", func() {
-				p.Search(ctx, &searchsvc.SearchRequest{
-					Query: "Size:<10",
-				})
-				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
-					return req.Query == "Size:<10"
-				}))
-			})
-
-			It("uppercases field names", func() {
-				tests := []struct {
-					Original string
-					Expected string
-				}{
-					{Original: "size:<100", Expected: "Size:<100"},
-				}
-				for _, test := range tests {
-					p.Search(ctx, &searchsvc.SearchRequest{
-						Query: test.Original,
+			It("rejects invalid queries", func() {
+				for _, q := range []string{"size>lots", "(foo", "type:printer", "foo AND"} {
+					_, err := p.Search(ctx, &searchsvc.SearchRequest{
+						Query: q,
 					})
-					indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
-						return req.Query == test.Expected
-					}))
+					Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")), q)
 				}
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
 			})
 
-			It("escapes special characters", func() {
-				p.Search(ctx, &searchsvc.SearchRequest{
-					Query: "Foo oo.pdf",
+			It("rejects unknown facets", func() {
+				_, err := p.Search(ctx, &searchsvc.SearchRequest{
+					Query:  "foo",
+					Facets: []string{"color"},
 				})
-				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
-					return req.Query == `Name:*foo\ oo.pdf*`
-				}))
+				Expect(err).To(BeAssignableToTypeOf(errtypes.BadRequest("")))
 			})
 
 			It("searches the personal user space", func() {
@@ -218,11 +344,83 @@ var _ = Describe("Searchprovider", func() {
 				Expect(match.Entity.Ref.Path).To(Equal("./path/to/Foo.pdf"))
 
 				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
-					return req.Query == "Name:*foo*" && req.Ref.ResourceId.OpaqueId == personalSpace.Root.OpaqueId && req.Ref.Path == ""
+					return req.Query == "foo" && req.Ref.ResourceId.OpaqueId == personalSpace.Root.OpaqueId && req.Ref.Path == ""
 				}))
 			})
 		})
 
+		Context("in the trash bin", func() {
+			BeforeEach(func() {
+				gwClient.On("ListStorageSpaces", mock.Anything, mock.Anything).Return(&sprovider.ListStorageSpacesResponse{
+					Status:        status.NewOK(ctx),
+					StorageSpaces: []*sprovider.StorageSpace{personalSpace},
+				}, nil)
+				indexClient.On("Search", mock.Anything, mock.Anything).Return(&searchsvc.SearchIndexResponse{
+					TotalMatches: 2,
+					Matches: []*searchmsg.Match{
+						{
+							Score: 1,
+							Entity: &searchmsg.Entity{
+								Ref:      &searchmsg.Reference{ResourceId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "personalspace"}, Path: "./trashed/report.pdf"},
+								Id:       &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "report-id"},
+								Name:     "report.pdf",
+								Deleted:  true,
+								TrashKey: "trashed-id/report.pdf",
+							},
+						},
+						{
+							Score: 1,
+							Entity: &searchmsg.Entity{
+								Ref:      &searchmsg.Reference{ResourceId: &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "personalspace"}, Path: "./report-old.pdf"},
+								Id:       &searchmsg.ResourceID{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "purged-id"},
+								Name:     "report-old.pdf",
+								Deleted:  true,
+								TrashKey: "purged-id",
+							},
+						},
+					},
+				}, nil)
+				indexClient.On("Purge", mock.Anything).Return(nil)
+			})
+
+			It("returns the items in the trash bin and purges the others from the index", func() {
+				gwClient.On("ListRecycle", mock.Anything, mock.Anything).Return(&sprovider.ListRecycleResponse{
+					Status:       status.NewOK(ctx),
+					RecycleItems: []*sprovider.RecycleItem{{Key: "trashed-id"}},
+				}, nil)
+
+				res, err := p.Search(ctx, &searchsvc.SearchRequest{
+					Query: "report",
+					Trash: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.TotalMatches).To(Equal(int32(1)))
+				Expect(len(res.Matches)).To(Equal(1))
+				Expect(res.Matches[0].Entity.TrashKey).To(Equal("trashed-id/report.pdf"))
+
+				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+					return req.Trash
+				}))
+				indexClient.AssertCalled(GinkgoT(), "Purge", &sprovider.ResourceId{StorageId: "storageid", SpaceId: "personalspace", OpaqueId: "purged-id"})
+				indexClient.AssertNumberOfCalls(GinkgoT(), "Purge", 1)
+			})
+
+			It("skips the trash bins the user is not allowed to list", func() {
+				gwClient.On("ListRecycle", mock.Anything, mock.Anything).Return(&sprovider.ListRecycleResponse{
+					Status: status.NewPermissionDenied(ctx, nil, "permission denied"),
+				}, nil)
+
+				res, err := p.Search(ctx, &searchsvc.SearchRequest{
+					Query: "report",
+					Trash: true,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.TotalMatches).To(Equal(int32(0)))
+				Expect(len(res.Matches)).To(Equal(0))
+				indexClient.AssertNotCalled(GinkgoT(), "Search", mock.Anything, mock.Anything)
+			})
+		})
+
 		Context("with received shares", func() {
 			var (
 				grantSpace      *sprovider.StorageSpace
@@ -298,7 +496,7 @@ var _ = Describe("Searchprovider", func() {
 				Expect(match.Entity.Ref.Path).To(Equal("./to/Shared.pdf"))
 
 				indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
-					return req.Query == "Name:*foo*" && req.Ref.ResourceId.StorageId == grantSpace.Root.StorageId && req.Ref.Path == "./grant/path"
+					return req.Query == "Foo" && req.Ref.ResourceId.StorageId == grantSpace.Root.StorageId && req.Ref.Path == "./grant/path"
 				}))
 			})
 
@@ -313,6 +511,9 @@ var _ = Describe("Searchprovider", func() {
 							req.Ref.ResourceId.SpaceId == grantSpace.Root.SpaceId
 					})).Return(&searchsvc.SearchIndexResponse{
 						TotalMatches: 2,
+						Facets: []*searchmsg.Facet{
+							{Name: "mediatype", Values: []*searchmsg.FacetValue{{Value: "pdf", Count: 2}}},
+						},
 						Matches: []*searchmsg.Match{
 							{
 								Score: 2,
@@ -357,6 +558,9 @@ var _ = Describe("Searchprovider", func() {
 							req.Ref.ResourceId.SpaceId == personalSpace.Root.SpaceId
 					})).Return(&searchsvc.SearchIndexResponse{
 						TotalMatches: 1,
+						Facets: []*searchmsg.Facet{
+							{Name: "mediatype", Values: []*searchmsg.FacetValue{{Value: "image", Count: 1}}},
+						},
 						Matches: []*searchmsg.Match{
 							{
 								Score: 1,
@@ -419,6 +623,31 @@ var _ = Describe("Searchprovider", func() {
 					ids := []string{res.Matches[0].Entity.Id.OpaqueId, res.Matches[1].Entity.Id.OpaqueId}
 					Expect(ids).To(Equal([]string{"grant-shared-id", "foo-id"}))
 				})
+
+				It("sums up the facets of all spaces", func() {
+					res, err := p.Search(ctx, &searchsvc.SearchRequest{
+						Query:  "foo",
+						Facets: []string{"space", "mediatype"},
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(res.Facets).To(HaveLen(2))
+
+					Expect(res.Facets[0].Name).To(Equal("space"))
+					Expect(res.Facets[0].Values).To(Equal([]*searchmsg.FacetValue{
+						{Value: mountpointSpace.Id.OpaqueId, Count: 2, Label: "path"},
+						{Value: personalSpace.Id.OpaqueId, Count: 1, Label: personalSpace.Name},
+					}))
+
+					Expect(res.Facets[1].Name).To(Equal("mediatype"))
+					Expect(res.Facets[1].Values).To(Equal([]*searchmsg.FacetValue{
+						{Value: "pdf", Count: 2},
+						{Value: "image", Count: 1},
+					}))
+
+					indexClient.AssertCalled(GinkgoT(), "Search", mock.Anything, mock.MatchedBy(func(req *searchsvc.SearchIndexRequest) bool {
+						return len(req.Facets) == 1 && req.Facets[0] == "mediatype"
+					}))
+				})
 			})
 		})
 	})
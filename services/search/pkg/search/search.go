@@ -23,6 +23,7 @@ import (
 
 	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
 )
 
 //go:generate mockery --name=ProviderClient
@@ -32,12 +33,13 @@ import (
 type ProviderClient interface {
 	Search(ctx context.Context, req *searchsvc.SearchRequest) (*searchsvc.SearchResponse, error)
 	IndexSpace(ctx context.Context, req *searchsvc.IndexSpaceRequest) (*searchsvc.IndexSpaceResponse, error)
+	GetIndexStatus(ctx context.Context, req *searchsvc.GetIndexStatusRequest) (*searchsvc.GetIndexStatusResponse, error)
 }
 
 // IndexClient is the interface to the search index
 type IndexClient interface {
 	Search(ctx context.Context, req *searchsvc.SearchIndexRequest) (*searchsvc.SearchIndexResponse, error)
-	Add(ref *providerv1beta1.Reference, ri *providerv1beta1.ResourceInfo) error
+	Add(ref *providerv1beta1.Reference, ri *providerv1beta1.ResourceInfo, doc content.Document) error
 	Move(id, parentID *providerv1beta1.ResourceId, fullPath string) error
 	Delete(id *providerv1beta1.ResourceId) error
 	Restore(id *providerv1beta1.ResourceId) error
@@ -24,6 +24,7 @@ func Server(opts ...Option) grpc.Service {
 	handle, err := svc.NewHandler(
 		svc.Config(options.Config),
 		svc.Logger(options.Logger),
+		svc.Context(options.Context),
 	)
 	if err != nil {
 		options.Logger.Error().
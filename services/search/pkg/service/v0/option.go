@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	"github.com/owncloud/ocis/v2/services/search/pkg/config"
 )
@@ -10,12 +12,15 @@ type Option func(o *Options)
 
 // Options defines the available options for this package.
 type Options struct {
-	Logger log.Logger
-	Config *config.Config
+	Logger  log.Logger
+	Config  *config.Config
+	Context context.Context
 }
 
 func newOptions(opts ...Option) Options {
-	opt := Options{}
+	opt := Options{
+		Context: context.Background(),
+	}
 
 	for _, o := range opts {
 		o(&opt)
@@ -37,3 +42,10 @@ func Config(val *config.Config) Option {
 		o.Config = val
 	}
 }
+
+// Context provides a function to set the Context option.
+func Context(val context.Context) Option {
+	return func(o *Options) {
+		o.Context = val
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"net/http"
 	"os"
 	"path/filepath"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
 	revactx "github.com/cs3org/reva/v2/pkg/ctx"
 	"github.com/cs3org/reva/v2/pkg/errtypes"
 	"github.com/cs3org/reva/v2/pkg/events"
@@ -23,8 +25,10 @@ import (
 	"github.com/owncloud/ocis/v2/ocis-pkg/log"
 	searchsvc "github.com/owncloud/ocis/v2/protogen/gen/ocis/services/search/v0"
 	"github.com/owncloud/ocis/v2/services/search/pkg/config"
+	"github.com/owncloud/ocis/v2/services/search/pkg/content"
 	"github.com/owncloud/ocis/v2/services/search/pkg/search"
 	"github.com/owncloud/ocis/v2/services/search/pkg/search/index"
+	"github.com/owncloud/ocis/v2/services/search/pkg/search/opensearch"
 	searchprovider "github.com/owncloud/ocis/v2/services/search/pkg/search/provider"
 )
 
@@ -68,29 +72,46 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, error) {
 		return nil, err
 	}
 
-	indexDir := filepath.Join(cfg.Datapath, "index.bleve")
-	bleveIndex, err := bleve.Open(indexDir)
-	if err != nil {
-		mapping, err := index.BuildMapping()
+	var idx search.IndexClient
+	switch cfg.Engine.Type {
+	case "opensearch":
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: cfg.Engine.OpenSearch.Insecure, //nolint:gosec
+		}
+		httpClient := &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Engine.OpenSearch.Timeout,
+		}
+		idx, err = opensearch.New(cfg.Engine.OpenSearch.URL, cfg.Engine.OpenSearch.Index, cfg.Engine.OpenSearch.Username, cfg.Engine.OpenSearch.Password, httpClient)
 		if err != nil {
 			return nil, err
 		}
-		bleveIndex, err = bleve.New(indexDir, mapping)
+	default:
+		idx, err = openBleveIndex(filepath.Join(cfg.Datapath, "index.bleve"), logger)
 		if err != nil {
 			return nil, err
 		}
 	}
-	index, err := index.New(bleveIndex)
-	if err != nil {
-		return nil, err
-	}
 
 	gwclient, err := pool.GetGatewayServiceClient(cfg.Reva.Address)
 	if err != nil {
 		logger.Fatal().Err(err).Str("addr", cfg.Reva.Address).Msg("could not get reva client")
 	}
 
-	provider := searchprovider.New(gwclient, index, cfg.MachineAuthAPIKey, evts, logger)
+	retriever := content.NewCS3Retriever(gwclient, cfg.Extractor.CS3AllowInsecure)
+	var extractor content.Extractor
+	switch cfg.Extractor.Type {
+	case "tika":
+		extractor = content.NewTikaExtractor(retriever, cfg.Extractor.Tika.TikaURL, cfg.Extractor.MaxFileSize, logger)
+	default:
+		extractor = content.NewBasicExtractor(retriever, cfg.Extractor.MaxFileSize, logger)
+	}
+
+	provider := searchprovider.New(gwclient, idx, extractor, cfg.MachineAuthAPIKey, evts, logger)
+	if err := provider.ConfigureReindex(options.Context, filepath.Join(cfg.Datapath, "reindex.json"), cfg.Reindex.Throttle); err != nil {
+		return nil, err
+	}
 
 	return &Service{
 		id:       cfg.GRPC.Namespace + "." + cfg.Service.Name,
@@ -100,6 +121,25 @@ func NewHandler(opts ...Option) (searchsvc.SearchProviderHandler, error) {
 	}, nil
 }
 
+// openBleveIndex opens the bleve index in the given directory or creates it
+func openBleveIndex(indexDir string, logger log.Logger) (*index.Index, error) {
+	bleveIndex, err := bleve.Open(indexDir)
+	if err != nil {
+		mapping, err := index.BuildMapping()
+		if err != nil {
+			return nil, err
+		}
+		bleveIndex, err = bleve.New(indexDir, mapping)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m, ok := bleveIndex.Mapping().(*mapping.IndexMappingImpl); ok && m.DefaultMapping.Properties["Content"] == nil {
+		logger.Warn().Str("path", indexDir).Msg("the search index was created without support for content search, remove it and reindex the spaces to search the file content")
+	}
+	return index.New(bleveIndex)
+}
+
 // Service implements the searchServiceHandler interface
 type Service struct {
 	id       string
@@ -121,6 +161,8 @@ func (s Service) Search(ctx context.Context, in *searchsvc.SearchRequest, out *s
 		Query:    in.Query,
 		PageSize: in.PageSize,
 		Ref:      in.Ref,
+		Facets:   in.Facets,
+		Trash:    in.Trash,
 	})
 	if err != nil {
 		switch err.(type) {
@@ -134,6 +176,7 @@ func (s Service) Search(ctx context.Context, in *searchsvc.SearchRequest, out *s
 	out.Matches = res.Matches
 	out.TotalMatches = res.TotalMatches
 	out.NextPageToken = res.NextPageToken
+	out.Facets = res.Facets
 	return nil
 }
 
@@ -141,3 +184,19 @@ func (s Service) IndexSpace(ctx context.Context, in *searchsvc.IndexSpaceRequest
 	_, err := s.provider.IndexSpace(ctx, in)
 	return err
 }
+
+func (s Service) GetIndexStatus(ctx context.Context, in *searchsvc.GetIndexStatusRequest, out *searchsvc.GetIndexStatusResponse) error {
+	res, err := s.provider.GetIndexStatus(ctx, in)
+	if err != nil {
+		return err
+	}
+
+	out.Running = res.Running
+	out.SpacesTotal = res.SpacesTotal
+	out.SpacesDone = res.SpacesDone
+	out.DocumentsIndexed = res.DocumentsIndexed
+	out.Errors = res.Errors
+	out.StartTime = res.StartTime
+	out.EndTime = res.EndTime
+	return nil
+}
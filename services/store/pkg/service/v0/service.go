@@ -116,6 +116,12 @@ func (s *Service) Read(c context.Context, rreq *storesvc.ReadRequest, rres *stor
 		}
 
 		searchRequest := bleve.NewSearchRequest(query)
+		// page through the matches, sorted by id to keep the order stable between pages
+		if rreq.Options.Limit > 0 {
+			searchRequest.Size = int(rreq.Options.Limit)
+		}
+		searchRequest.From = int(rreq.Options.Offset)
+		searchRequest.SortBy([]string{"_id"})
 		var searchResult *bleve.SearchResult
 		searchResult, err := s.index.Search(searchRequest)
 		if err != nil {
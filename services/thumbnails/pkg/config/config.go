@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/shared"
 )
@@ -39,4 +40,19 @@ type Thumbnail struct {
 	FontMapFile         string            `yaml:"font_map_file" env:"THUMBNAILS_TXT_FONTMAP_FILE" desc:"The path to a font file for txt thumbnails."`
 	TransferSecret      string            `yaml:"transfer_secret" env:"THUMBNAILS_TRANSFER_TOKEN" desc:"The secret to sign JWT to download the actual thumbnail file."`
 	DataEndpoint        string            `yaml:"data_endpoint" env:"THUMBNAILS_DATA_ENDPOINT" desc:"The HTTP endpoint where the actual thumbnail file can be downloaded."`
+	ExternalConverter   ExternalConverter `yaml:"external_converter"`
+	Quality             Quality           `yaml:"quality"`
+}
+
+// Quality defines the quality of the thumbnails in lossy formats.
+type Quality struct {
+	JPEG int `yaml:"jpeg" env:"THUMBNAILS_QUALITY_JPEG" desc:"The quality of JPEG thumbnails, from 1 to 100."`
+	WebP int `yaml:"webp" env:"THUMBNAILS_QUALITY_WEBP" desc:"The quality of WebP thumbnails, from 1 to 100."`
+}
+
+// ExternalConverter defines the command used to create thumbnails of files the service can't decode itself.
+type ExternalConverter struct {
+	Command   string        `yaml:"command" env:"THUMBNAILS_EXTERNAL_CONVERTER_COMMAND" desc:"The command to convert files to an image. It has to write a PNG, JPEG or GIF image to stdout. The placeholder {input} is replaced by the path of the file, without it the file is passed on stdin. Example for video keyframes: 'ffmpeg -loglevel error -i {input} -frames:v 1 -f image2pipe -vcodec png -'."`
+	MimeTypes []string      `yaml:"mime_types" env:"THUMBNAILS_EXTERNAL_CONVERTER_MIMETYPES" desc:"The mime types of the files which are converted with the external command, separated by comma. Wildcards like video/* are allowed."`
+	Timeout   time.Duration `yaml:"timeout" env:"THUMBNAILS_EXTERNAL_CONVERTER_TIMEOUT" desc:"The maximum time the external command may take to convert a file."`
 }
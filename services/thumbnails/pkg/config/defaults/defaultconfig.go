@@ -3,6 +3,7 @@ package defaults
 import (
 	"path"
 	"strings"
+	"time"
 
 	"github.com/owncloud/ocis/v2/ocis-pkg/config/defaults"
 	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/config"
@@ -44,6 +45,13 @@ func DefaultConfig() *config.Config {
 			RevaGateway:         "127.0.0.1:9142",
 			CS3AllowInsecure:    false,
 			DataEndpoint:        "http://127.0.0.1:9186/thumbnails/data",
+			ExternalConverter: config.ExternalConverter{
+				Timeout: 30 * time.Second,
+			},
+			Quality: config.Quality{
+				JPEG: 75,
+				WebP: 75,
+			},
 		},
 	}
 }
@@ -78,4 +86,7 @@ func Sanitize(cfg *config.Config) {
 	if len(cfg.Thumbnail.Resolutions) == 1 && strings.Contains(cfg.Thumbnail.Resolutions[0], ",") {
 		cfg.Thumbnail.Resolutions = strings.Split(cfg.Thumbnail.Resolutions[0], ",")
 	}
+	if len(cfg.Thumbnail.ExternalConverter.MimeTypes) == 1 && strings.Contains(cfg.Thumbnail.ExternalConverter.MimeTypes[0], ",") {
+		cfg.Thumbnail.ExternalConverter.MimeTypes = strings.Split(cfg.Thumbnail.ExternalConverter.MimeTypes[0], ",")
+	}
 }
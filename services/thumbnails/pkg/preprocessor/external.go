@@ -0,0 +1,104 @@
+package preprocessor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+)
+
+// inputPlaceholder is replaced by the path of the input file in the arguments of the external converter
+const inputPlaceholder = "{input}"
+
+// ExternalConverter runs a command to convert files which can't be decoded by the service,
+// e.g. ffmpeg to extract a keyframe of a video. The file is passed as a temporary file if one
+// of the arguments contains the {input} placeholder, on stdin otherwise. The command has to
+// write an image to stdout.
+type ExternalConverter struct {
+	Command   []string
+	MimeTypes []string
+	Timeout   time.Duration
+}
+
+// Supports checks if the converter is configured for the mime type
+func (e ExternalConverter) Supports(mimeType string) bool {
+	if len(e.Command) == 0 {
+		return false
+	}
+	mimeType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	for _, m := range e.MimeTypes {
+		if m == mimeType || (strings.HasSuffix(m, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(m, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ExternalConverter) Convert(r io.Reader) (interface{}, error) {
+	if len(e.Command) == 0 {
+		return nil, errors.New(`no external converter configured`)
+	}
+	ctx := context.Background()
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+
+	args := make([]string, len(e.Command)-1)
+	copy(args, e.Command[1:])
+	var stdin io.Reader = r
+	if usesInputFile(args) {
+		// some formats like mp4 can't be read from a pipe
+		f, err := os.CreateTemp("", "thumbnail-input-*")
+		if err != nil {
+			return nil, errors.Wrap(err, `could not create the input file`)
+		}
+		defer os.Remove(f.Name())
+		_, err = io.Copy(f, r)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, `could not write the input file`)
+		}
+		for i := range args {
+			args[i] = strings.ReplaceAll(args[i], inputPlaceholder, f.Name())
+		}
+		stdin = nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command[0], args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, `the external converter failed: %s`, strings.TrimSpace(stderr.String()))
+	}
+
+	img, err := imaging.Decode(&stdout)
+	if err != nil {
+		return nil, errors.Wrap(err, `could not decode the output of the external converter`)
+	}
+	return img, nil
+}
+
+func usesInputFile(args []string) bool {
+	for _, arg := range args {
+		if strings.Contains(arg, inputPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
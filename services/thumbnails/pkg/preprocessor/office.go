@@ -0,0 +1,85 @@
+package preprocessor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+)
+
+// OfficeThumbnailExtractor extracts the thumbnail which office applications embed into
+// OOXML (docx, xlsx, pptx) and ODF (odt, ods, odp, odg) documents when saving them.
+type OfficeThumbnailExtractor struct{}
+
+func (o OfficeThumbnailExtractor) Convert(r io.Reader) (interface{}, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, `could not read the document`)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, `could not open the document`)
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	candidates := []string{"Thumbnails/thumbnail.png"}
+	if rels, ok := files["_rels/.rels"]; ok {
+		if target := ooxmlThumbnailTarget(rels); target != "" {
+			candidates = append(candidates, target)
+		}
+	}
+	candidates = append(candidates, "docProps/thumbnail.jpeg", "docProps/thumbnail.jpg", "docProps/thumbnail.png")
+
+	for _, name := range candidates {
+		f, ok := files[name]
+		if !ok {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, errors.Wrap(err, `could not open the thumbnail of the document`)
+		}
+		img, err := imaging.Decode(rc)
+		rc.Close()
+		if err != nil {
+			// documents created on Windows often contain a WMF or EMF thumbnail
+			return nil, errors.Wrap(err, `could not decode the thumbnail of the document`)
+		}
+		return img, nil
+	}
+	return nil, errors.New(`the document contains no thumbnail`)
+}
+
+// ooxmlThumbnailTarget returns the name of the thumbnail referenced by the package relationships
+func ooxmlThumbnailTarget(f *zip.File) string {
+	rc, err := f.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+
+	var rels struct {
+		Relationships []struct {
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&rels); err != nil {
+		return ""
+	}
+	for _, rel := range rels.Relationships {
+		if strings.HasSuffix(rel.Type, "/metadata/thumbnail") {
+			// targets are relative to the package root
+			return strings.TrimPrefix(strings.TrimPrefix(rel.Target, "/"), "./")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package preprocessor
+
+import (
+	"math"
+)
+
+// pathBuilder adds segments given in user space to a path in device space. It keeps
+// track of the current point like the path operators of PDF and SVG do.
+type pathBuilder struct {
+	p          *path
+	m          matrix
+	cur, start point
+}
+
+func newPathBuilder(m matrix) *pathBuilder {
+	return &pathBuilder{p: &path{}, m: m}
+}
+
+func (b *pathBuilder) moveTo(x, y float64) {
+	b.cur, b.start = point{x, y}, point{x, y}
+	b.p.moveTo(b.m.apply(x, y))
+}
+
+func (b *pathBuilder) lineTo(x, y float64) {
+	b.cur = point{x, y}
+	b.p.lineTo(b.m.apply(x, y))
+}
+
+func (b *pathBuilder) cubeTo(x1, y1, x2, y2, x, y float64) {
+	b.cur = point{x, y}
+	b.p.cubeTo(b.m.apply(x1, y1), b.m.apply(x2, y2), b.m.apply(x, y))
+}
+
+func (b *pathBuilder) quadTo(x1, y1, x, y float64) {
+	b.cur = point{x, y}
+	b.p.quadTo(b.m.apply(x1, y1), b.m.apply(x, y))
+}
+
+func (b *pathBuilder) close() {
+	b.p.close()
+	b.cur = b.start
+}
+
+func (b *pathBuilder) rect(x, y, w, h float64) {
+	b.moveTo(x, y)
+	b.lineTo(x+w, y)
+	b.lineTo(x+w, y+h)
+	b.lineTo(x, y+h)
+	b.close()
+}
+
+func (b *pathBuilder) ellipse(cx, cy, rx, ry float64) {
+	b.moveTo(cx+rx, cy)
+	b.arcTo(rx, ry, 0, false, true, cx-rx, cy)
+	b.arcTo(rx, ry, 0, false, true, cx+rx, cy)
+	b.close()
+}
+
+// arcTo adds an elliptical arc given in the endpoint parameterization of SVG. The arc
+// is approximated by cubic curves of at most 90 degrees each.
+func (b *pathBuilder) arcTo(rx, ry, rotation float64, large, sweep bool, x, y float64) {
+	x1, y1 := b.cur.x, b.cur.y
+	if x1 == x && y1 == y {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		b.lineTo(x, y)
+		return
+	}
+
+	sinPhi, cosPhi := math.Sincos(rotation * math.Pi / 180)
+	dx2, dy2 := (x1-x)/2, (y1-y)/2
+	x1p := cosPhi*dx2 + sinPhi*dy2
+	y1p := -sinPhi*dx2 + cosPhi*dy2
+
+	// scale up radii which are too small to connect the points
+	if lambda := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1p/ry, -coef*ry*x1p/rx
+	cx := cosPhi*cxp - sinPhi*cyp + (x1+x)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y1+y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := angle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	at := func(a float64) (point, point) {
+		sin, cos := math.Sincos(a)
+		p := point{cx + rx*cos*cosPhi - ry*sin*sinPhi, cy + rx*cos*sinPhi + ry*sin*cosPhi}
+		d := point{-rx*sin*cosPhi - ry*cos*sinPhi, -rx*sin*sinPhi + ry*cos*cosPhi}
+		return p, d
+	}
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(n)
+	t := 4.0 / 3.0 * math.Tan(step/4)
+	for i := 0; i < n; i++ {
+		p1, d1 := at(theta + float64(i)*step)
+		p2, d2 := at(theta + float64(i+1)*step)
+		if i == n-1 {
+			// end exactly at the target point
+			p2 = point{x, y}
+		}
+		b.cubeTo(p1.x+t*d1.x, p1.y+t*d1.y, p2.x-t*d2.x, p2.y-t*d2.y, p2.x, p2.y)
+	}
+}
@@ -0,0 +1,747 @@
+package preprocessor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+
+	"github.com/owncloud/ocis/v2/ocis-pkg/pdf"
+	"github.com/pkg/errors"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// maxPdfFileSize limits the size of the PDF documents that are read
+	maxPdfFileSize = 64 << 20
+	// maxPdfDecodedSize limits the amount of data decompressed from the streams of a document
+	maxPdfDecodedSize = 128 << 20
+	// maxPdfOperators limits the number of operators executed to render a page
+	maxPdfOperators = 500000
+	// maxPdfFormDepth limits the nesting of form XObjects
+	maxPdfFormDepth = 8
+	// maxPdfImagePixels limits the size of the images drawn onto the page
+	maxPdfImagePixels = 16 << 20
+)
+
+// PdfToImageConverter renders the first page of PDF documents. The thumbnail embedded in the
+// page is used if there is one. Otherwise paths, images and text are drawn, text with a
+// replacement font and without clipping, shadings or patterns.
+type PdfToImageConverter struct{}
+
+func (p PdfToImageConverter) Convert(r io.Reader) (interface{}, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPdfFileSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, `could not read the pdf document`)
+	}
+	if len(data) > maxPdfFileSize {
+		return nil, errors.New(`the pdf document is too large`)
+	}
+	parsed, err := pdf.Parse(data, maxPdfDecodedSize)
+	if err != nil {
+		return nil, errors.Wrap(err, `could not parse the pdf document`)
+	}
+	if parsed.Catalog() == nil {
+		return nil, errors.New(`the pdf document has no catalog`)
+	}
+	doc := &pdfDocument{parsed}
+	page, err := doc.FirstPage()
+	if err != nil {
+		return nil, err
+	}
+
+	if thumb, ok := doc.Resolve(page["Thumb"]).(*pdf.Stream); ok {
+		if img, err := doc.image(thumb, nil, color.Black); err == nil {
+			return img, nil
+		}
+	}
+
+	img, err := doc.render(page)
+	if err != nil {
+		return nil, errors.Wrap(err, `could not render the pdf document`)
+	}
+	return img, nil
+}
+
+// pdfDocument adds the rendering to the parsed document
+type pdfDocument struct {
+	*pdf.Document
+}
+
+type pdfState struct {
+	ctm         matrix
+	fill        color.Color
+	stroke      color.Color
+	fillSpace   *pdfColorSpace
+	strokeSpace *pdfColorSpace
+	fillAlpha   float64
+	strokeAlpha float64
+	lineWidth   float64
+
+	font       *pdfFont
+	fontSize   float64
+	charSpace  float64
+	wordSpace  float64
+	horizScale float64
+	leading    float64
+	rise       float64
+	textRender int
+}
+
+type pdfRenderer struct {
+	doc       *pdfDocument
+	canvas    *canvas
+	state     pdfState
+	stack     []pdfState
+	path      *pathBuilder
+	tm, tlm   matrix
+	operators int
+	font      *opentype.Font
+	faces     map[int]font.Face
+}
+
+// render draws the page onto a white image whose longer side is renderSize pixels long
+func (d *pdfDocument) render(page pdf.Dict) (image.Image, error) {
+	box := d.Array(page["CropBox"])
+	if len(box) != 4 {
+		box = d.Array(page["MediaBox"])
+	}
+	x0, y0, x1, y1 := 0.0, 0.0, 612.0, 792.0
+	if len(box) == 4 {
+		x0, y0, x1, y1 = d.Number(box[0]), d.Number(box[1]), d.Number(box[2]), d.Number(box[3])
+	}
+	x0, x1 = math.Min(x0, x1), math.Max(x0, x1)
+	y0, y1 = math.Min(y0, y1), math.Max(y0, y1)
+	w, h := x1-x0, y1-y0
+	if w < 1 || h < 1 {
+		return nil, errors.New(`invalid page size`)
+	}
+
+	// map the page onto the image, flipping the y axis and applying the page rotation
+	s := renderSize / math.Max(w, h)
+	m := matrix{1, 0, 0, 1, -x0, -y0}
+	var width, height float64
+	switch (d.Int(page["Rotate"])%360 + 360) % 360 {
+	case 90:
+		m, width, height = m.then(matrix{0, s, s, 0, 0, 0}), h*s, w*s
+	case 180:
+		m, width, height = m.then(matrix{-s, 0, 0, s, w * s, 0}), w*s, h*s
+	case 270:
+		m, width, height = m.then(matrix{0, -s, -s, 0, h * s, w * s}), h*s, w*s
+	default:
+		m, width, height = m.then(matrix{s, 0, 0, -s, 0, h * s}), w*s, h*s
+	}
+
+	parsedFont, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, err
+	}
+	r := &pdfRenderer{
+		doc:    d,
+		canvas: newCanvas(int(math.Ceil(width)), int(math.Ceil(height)), color.White),
+		state: pdfState{
+			ctm:         m,
+			fill:        color.Black,
+			stroke:      color.Black,
+			fillAlpha:   1,
+			strokeAlpha: 1,
+			lineWidth:   1,
+			horizScale:  1,
+		},
+		font:  parsedFont,
+		faces: map[int]font.Face{},
+	}
+	defer func() {
+		for _, f := range r.faces {
+			f.Close()
+		}
+	}()
+
+	content, err := d.Content(page)
+	if err != nil {
+		return nil, err
+	}
+	r.run(content, d.Dict(page["Resources"]), 0)
+	return r.canvas.img, nil
+}
+
+// run executes the operators of a content stream
+func (r *pdfRenderer) run(content []byte, resources pdf.Dict, depth int) {
+	l := pdf.NewLexer(content)
+	var operands []interface{}
+	for r.operators < maxPdfOperators && !r.canvas.exhausted() {
+		obj, err := l.Next()
+		if err != nil {
+			return
+		}
+		op, ok := obj.(pdf.Keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		r.operators++
+		if op == "BI" {
+			l.SkipInlineImage()
+		} else {
+			r.execute(string(op), operands, resources, depth)
+		}
+		operands = operands[:0]
+	}
+}
+
+func (r *pdfRenderer) execute(op string, operands []interface{}, resources pdf.Dict, depth int) {
+	num := func(i int) float64 {
+		if i < len(operands) {
+			f, _ := operands[i].(float64)
+			return f
+		}
+		return 0
+	}
+	gs := &r.state
+
+	switch op {
+	// graphics state
+	case "q":
+		r.stack = append(r.stack, r.state)
+	case "Q":
+		if len(r.stack) > 0 {
+			r.state = r.stack[len(r.stack)-1]
+			r.stack = r.stack[:len(r.stack)-1]
+		}
+	case "cm":
+		if len(operands) == 6 {
+			gs.ctm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}.then(gs.ctm)
+		}
+	case "w":
+		gs.lineWidth = num(0)
+	case "gs":
+		if len(operands) == 1 {
+			name, _ := operands[0].(pdf.Name)
+			params := r.doc.Dict(r.doc.Dict(resources["ExtGState"])[name])
+			if ca, ok := r.doc.Resolve(params["ca"]).(float64); ok {
+				gs.fillAlpha = ca
+			}
+			if ca, ok := r.doc.Resolve(params["CA"]).(float64); ok {
+				gs.strokeAlpha = ca
+			}
+		}
+
+	// colors
+	case "g", "rg", "k":
+		gs.fillSpace = nil
+		gs.fill = pdfDeviceColor(numbers(operands))
+	case "G", "RG", "K":
+		gs.strokeSpace = nil
+		gs.stroke = pdfDeviceColor(numbers(operands))
+	case "cs", "CS":
+		// patterns and unsupported color spaces keep the current color
+		var cs *pdfColorSpace
+		if len(operands) == 1 {
+			cs = r.doc.colorSpace(operands[0], resources)
+		}
+		switch {
+		case op == "cs" && cs != nil:
+			gs.fillSpace, gs.fill = cs, color.Black
+		case op == "cs":
+			gs.fillSpace = &pdfColorSpace{}
+		case cs != nil:
+			gs.strokeSpace, gs.stroke = cs, color.Black
+		default:
+			gs.strokeSpace = &pdfColorSpace{}
+		}
+	case "sc", "scn":
+		if c := pdfSpaceColor(gs.fillSpace, numbers(operands)); c != nil {
+			gs.fill = c
+		}
+	case "SC", "SCN":
+		if c := pdfSpaceColor(gs.strokeSpace, numbers(operands)); c != nil {
+			gs.stroke = c
+		}
+
+	// paths
+	case "m", "l", "c", "v", "y", "h", "re":
+		if r.path == nil {
+			r.path = newPathBuilder(gs.ctm)
+		}
+		p := r.path
+		switch op {
+		case "m":
+			p.moveTo(num(0), num(1))
+		case "l":
+			p.lineTo(num(0), num(1))
+		case "c":
+			p.cubeTo(num(0), num(1), num(2), num(3), num(4), num(5))
+		case "v":
+			p.cubeTo(p.cur.x, p.cur.y, num(0), num(1), num(2), num(3))
+		case "y":
+			p.cubeTo(num(0), num(1), num(2), num(3), num(2), num(3))
+		case "h":
+			p.close()
+		case "re":
+			p.rect(num(0), num(1), num(2), num(3))
+		}
+	case "f", "F", "f*", "S", "s", "B", "B*", "b", "b*", "n":
+		if r.path == nil {
+			return
+		}
+		p := r.path
+		r.path = nil
+		if op == "s" || op == "b" || op == "b*" {
+			p.close()
+		}
+		if op != "S" && op != "s" && op != "n" {
+			r.canvas.fill(p.p, withAlpha(gs.fill, gs.fillAlpha))
+		}
+		if op == "S" || op == "s" || op == "B" || op == "B*" || op == "b" || op == "b*" {
+			r.canvas.stroke(p.p, gs.lineWidth*gs.ctm.scale(), withAlpha(gs.stroke, gs.strokeAlpha))
+		}
+
+	// external objects
+	case "Do":
+		if len(operands) == 1 {
+			name, _ := operands[0].(pdf.Name)
+			r.xObject(r.doc.Resolve(r.doc.Dict(resources["XObject"])[name]), resources, depth)
+		}
+
+	// text
+	case "BT":
+		r.tm, r.tlm = identity, identity
+	case "Tf":
+		if len(operands) == 2 {
+			name, _ := operands[0].(pdf.Name)
+			gs.font = r.doc.font(r.doc.Dict(r.doc.Dict(resources["Font"])[name]))
+			gs.fontSize = num(1)
+		}
+	case "Tc":
+		gs.charSpace = num(0)
+	case "Tw":
+		gs.wordSpace = num(0)
+	case "Tz":
+		gs.horizScale = num(0) / 100
+	case "TL":
+		gs.leading = num(0)
+	case "Ts":
+		gs.rise = num(0)
+	case "Tr":
+		gs.textRender = int(num(0))
+	case "Td":
+		r.moveText(num(0), num(1))
+	case "TD":
+		gs.leading = -num(1)
+		r.moveText(num(0), num(1))
+	case "Tm":
+		if len(operands) == 6 {
+			r.tm = matrix{num(0), num(1), num(2), num(3), num(4), num(5)}
+			r.tlm = r.tm
+		}
+	case "T*":
+		r.moveText(0, -gs.leading)
+	case "Tj":
+		if len(operands) == 1 {
+			r.showText(operands[0])
+		}
+	case "'":
+		r.moveText(0, -gs.leading)
+		if len(operands) == 1 {
+			r.showText(operands[0])
+		}
+	case "\"":
+		if len(operands) == 3 {
+			gs.wordSpace, gs.charSpace = num(0), num(1)
+			r.moveText(0, -gs.leading)
+			r.showText(operands[2])
+		}
+	case "TJ":
+		if len(operands) == 1 {
+			arr, _ := operands[0].(pdf.Array)
+			for _, item := range arr {
+				if adjust, ok := item.(float64); ok {
+					r.tm = matrix{1, 0, 0, 1, -adjust / 1000 * gs.fontSize * gs.horizScale, 0}.then(r.tm)
+				} else {
+					r.showText(item)
+				}
+			}
+		}
+	}
+}
+
+// xObject draws an image or a form
+func (r *pdfRenderer) xObject(obj interface{}, resources pdf.Dict, depth int) {
+	s, ok := obj.(*pdf.Stream)
+	if !ok {
+		return
+	}
+	switch r.doc.Name(s.Dict["Subtype"]) {
+	case "Image":
+		img, err := r.doc.image(s, resources, withAlpha(r.state.fill, r.state.fillAlpha))
+		if err != nil {
+			return
+		}
+		r.drawImage(img)
+	case "Form":
+		if depth >= maxPdfFormDepth {
+			return
+		}
+		content, err := r.doc.Decode(s)
+		if err != nil {
+			return
+		}
+		formResources := r.doc.Dict(s.Dict["Resources"])
+		if formResources == nil {
+			formResources = resources
+		}
+
+		saved, stackSize := r.state, len(r.stack)
+		if m := r.doc.Array(s.Dict["Matrix"]); len(m) == 6 {
+			r.state.ctm = matrix{
+				r.doc.Number(m[0]), r.doc.Number(m[1]), r.doc.Number(m[2]),
+				r.doc.Number(m[3]), r.doc.Number(m[4]), r.doc.Number(m[5]),
+			}.then(r.state.ctm)
+		}
+		r.run(content, formResources, depth+1)
+		r.state, r.stack, r.path = saved, r.stack[:stackSize], nil
+	}
+}
+
+// drawImage draws the image onto the unit square of the user space
+func (r *pdfRenderer) drawImage(img image.Image) {
+	m := r.state.ctm
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	if w == 0 || h == 0 || m[0]*m[3]-m[1]*m[2] == 0 {
+		return
+	}
+	corners := [4]point{m.apply(0, 0), m.apply(1, 0), m.apply(0, 1), m.apply(1, 1)}
+	minX, minY, maxX, maxY := corners[0].x, corners[0].y, corners[0].x, corners[0].y
+	for _, c := range corners[1:] {
+		minX, minY = math.Min(minX, c.x), math.Min(minY, c.y)
+		maxX, maxY = math.Max(maxX, c.x), math.Max(maxY, c.y)
+	}
+	if math.IsNaN(minX + minY + maxX + maxY) {
+		return
+	}
+	b := image.Rect(
+		int(math.Max(math.Floor(minX), -1)), int(math.Max(math.Floor(minY), -1)),
+		int(math.Min(math.Ceil(maxX), 1<<20)), int(math.Min(math.Ceil(maxY), 1<<20)),
+	).Intersect(r.canvas.img.Bounds())
+	if b.Empty() || !r.canvas.spend(b.Dx()*b.Dy()) {
+		return
+	}
+	// the first row of the image is at the top of the unit square
+	aff := f64.Aff3{m[0] / w, -m[2] / h, m[2] + m[4], m[1] / w, -m[3] / h, m[3] + m[5]}
+	xdraw.ApproxBiLinear.Transform(r.canvas.img, aff, img, img.Bounds(), xdraw.Over, nil)
+}
+
+func (r *pdfRenderer) moveText(tx, ty float64) {
+	r.tlm = matrix{1, 0, 0, 1, tx, ty}.then(r.tlm)
+	r.tm = r.tlm
+}
+
+// showText draws a string with the replacement font, glyph by glyph at the positions given by
+// the widths of the actual font
+func (r *pdfRenderer) showText(obj interface{}) {
+	s, ok := obj.(pdf.String)
+	gs := &r.state
+	if !ok || gs.font == nil || gs.font.composite {
+		return
+	}
+
+	visible := gs.textRender != 3 && gs.textRender != 7
+	var (
+		drawer *font.Drawer
+		// glyphArea is the number of pixels charged for drawing a glyph
+		glyphArea int
+	)
+	if visible {
+		trm := matrix{gs.fontSize * gs.horizScale, 0, 0, gs.fontSize, 0, gs.rise}.then(r.tm).then(gs.ctm)
+		if size := int(math.Round(math.Min(trm.scale(), 256))); size >= 1 {
+			if face := r.face(size); face != nil {
+				drawer = &font.Drawer{
+					Dst:  r.canvas.img,
+					Src:  image.NewUniform(withAlpha(gs.fill, gs.fillAlpha)),
+					Face: face,
+				}
+				glyphArea = size * size
+			}
+		}
+	}
+
+	for _, code := range s {
+		if drawer != nil && code > ' ' && code != 0x7f && (code < 0x80 || code >= 0xa0) && r.canvas.spend(glyphArea) {
+			trm := matrix{gs.fontSize * gs.horizScale, 0, 0, gs.fontSize, 0, gs.rise}.then(r.tm).then(gs.ctm)
+			origin := trm.apply(0, 0)
+			drawer.Dot = fixed.Point26_6{X: fixed.Int26_6(origin.x * 64), Y: fixed.Int26_6(origin.y * 64)}
+			// bytes are mapped to the Latin-1 characters, which is right for the most common encodings
+			drawer.DrawString(string(rune(code)))
+		}
+
+		tx := gs.font.width(code)*gs.fontSize + gs.charSpace
+		if code == ' ' {
+			tx += gs.wordSpace
+		}
+		r.tm = matrix{1, 0, 0, 1, tx * gs.horizScale, 0}.then(r.tm)
+	}
+}
+
+func (r *pdfRenderer) face(size int) font.Face {
+	if size > 256 {
+		size = 256
+	}
+	if f, ok := r.faces[size]; ok {
+		return f
+	}
+	f, err := opentype.NewFace(r.font, &opentype.FaceOptions{
+		Size:    float64(size),
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		return nil
+	}
+	r.faces[size] = f
+	return f
+}
+
+// pdfFont holds the glyph widths of a simple font in text space units
+type pdfFont struct {
+	composite    bool
+	firstChar    int
+	widths       []float64
+	missingWidth float64
+}
+
+func (d *pdfDocument) font(dict pdf.Dict) *pdfFont {
+	if dict == nil {
+		return nil
+	}
+	f := &pdfFont{missingWidth: 0.5}
+	if d.Name(dict["Subtype"]) == "Type0" {
+		// composite fonts use multi-byte codes which can't be mapped to characters without
+		// parsing the CMaps
+		f.composite = true
+		return f
+	}
+	if desc := d.Dict(dict["FontDescriptor"]); desc != nil {
+		if mw, ok := d.Resolve(desc["MissingWidth"]).(float64); ok && mw > 0 {
+			f.missingWidth = mw / 1000
+		}
+	}
+	f.firstChar = d.Int(dict["FirstChar"])
+	for _, w := range d.Array(dict["Widths"]) {
+		f.widths = append(f.widths, d.Number(w)/1000)
+	}
+	return f
+}
+
+func (f *pdfFont) width(code byte) float64 {
+	if i := int(code) - f.firstChar; i >= 0 && i < len(f.widths) && f.widths[i] > 0 {
+		return f.widths[i]
+	}
+	return f.missingWidth
+}
+
+// pdfColorSpace converts color components of a color space to colors
+type pdfColorSpace struct {
+	components int
+	// separation color spaces have a single tint component, which is drawn in shades of gray
+	separation bool
+	// indexed color spaces look up colors in a table of the base color space
+	base   *pdfColorSpace
+	lookup []byte
+}
+
+func (d *pdfDocument) colorSpace(obj interface{}, resources pdf.Dict) *pdfColorSpace {
+	obj = d.Resolve(obj)
+	if name, ok := obj.(pdf.Name); ok {
+		switch name {
+		case "DeviceGray", "G", "CalGray":
+			return &pdfColorSpace{components: 1}
+		case "DeviceRGB", "RGB", "CalRGB":
+			return &pdfColorSpace{components: 3}
+		case "DeviceCMYK", "CMYK":
+			return &pdfColorSpace{components: 4}
+		case "Pattern":
+			return nil
+		}
+		if named, ok := d.Dict(resources["ColorSpace"])[name]; ok && d.Name(named) != name {
+			return d.colorSpace(named, nil)
+		}
+		return nil
+	}
+
+	arr, ok := obj.(pdf.Array)
+	if !ok || len(arr) == 0 {
+		return nil
+	}
+	switch d.Name(arr[0]) {
+	case "CalGray":
+		return &pdfColorSpace{components: 1}
+	case "CalRGB", "Lab":
+		return &pdfColorSpace{components: 3}
+	case "ICCBased":
+		if len(arr) > 1 {
+			if n := d.Int(d.Dict(arr[1])["N"]); n == 1 || n == 3 || n == 4 {
+				return &pdfColorSpace{components: n}
+			}
+		}
+	case "Separation":
+		return &pdfColorSpace{components: 1, separation: true}
+	case "Indexed", "I":
+		if len(arr) < 4 {
+			return nil
+		}
+		base := d.colorSpace(arr[1], resources)
+		if base == nil || base.base != nil {
+			return nil
+		}
+		cs := &pdfColorSpace{components: 1, base: base}
+		switch lookup := d.Resolve(arr[3]).(type) {
+		case pdf.String:
+			cs.lookup = lookup
+		case *pdf.Stream:
+			cs.lookup, _ = d.Decode(lookup)
+		}
+		return cs
+	}
+	return nil
+}
+
+// color returns the color of the components, which are in the range of 0 to 1 except for
+// indexed color spaces. Color spaces without components stand for unsupported ones.
+func (cs *pdfColorSpace) color(c []float64) color.Color {
+	switch {
+	case cs.base != nil:
+		if len(c) < 1 {
+			return nil
+		}
+		n := cs.base.components
+		i := int(c[0]) * n
+		if i < 0 || i+n > len(cs.lookup) {
+			return color.Black
+		}
+		comps := make([]float64, n)
+		for j := range comps {
+			comps[j] = float64(cs.lookup[i+j]) / 255
+		}
+		return cs.base.color(comps)
+	case cs.separation:
+		if len(c) < 1 {
+			return nil
+		}
+		return pdfDeviceColor([]float64{1 - c[0]})
+	case cs.components == 0 || len(c) != cs.components:
+		return nil
+	}
+	return pdfDeviceColor(c)
+}
+
+// pdfSpaceColor returns the color set with the sc and scn operators, pattern colors are ignored
+func pdfSpaceColor(cs *pdfColorSpace, c []float64) color.Color {
+	if cs == nil {
+		return pdfDeviceColor(c)
+	}
+	return cs.color(c)
+}
+
+// pdfDeviceColor returns the gray, RGB or CMYK color depending on the number of components
+func pdfDeviceColor(c []float64) color.Color {
+	v := func(f float64) uint8 {
+		return uint8(math.Max(0, math.Min(1, f))*255 + 0.5)
+	}
+	switch len(c) {
+	case 1:
+		return color.Gray{Y: v(c[0])}
+	case 3:
+		return color.RGBA{R: v(c[0]), G: v(c[1]), B: v(c[2]), A: 255}
+	case 4:
+		return color.RGBA{R: v((1 - c[0]) * (1 - c[3])), G: v((1 - c[1]) * (1 - c[3])), B: v((1 - c[2]) * (1 - c[3])), A: 255}
+	}
+	return color.Black
+}
+
+func numbers(operands []interface{}) []float64 {
+	var nums []float64
+	for _, o := range operands {
+		if f, ok := o.(float64); ok {
+			nums = append(nums, f)
+		}
+	}
+	return nums
+}
+
+// image decodes an image XObject, stencil masks are painted with the given color
+func (d *pdfDocument) image(s *pdf.Stream, resources pdf.Dict, fill color.Color) (image.Image, error) {
+	data, err := d.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range d.Filters(s.Dict) {
+		if f == "DCTDecode" || f == "DCT" {
+			return jpeg.Decode(bytes.NewReader(data))
+		}
+	}
+
+	width, height := d.Int(s.Dict["Width"]), d.Int(s.Dict["Height"])
+	if width <= 0 || height <= 0 || width*height > maxPdfImagePixels {
+		return nil, errors.New(`invalid image size`)
+	}
+	mask, _ := d.Resolve(s.Dict["ImageMask"]).(bool)
+	bpc := d.Int(s.Dict["BitsPerComponent"])
+	cs := &pdfColorSpace{components: 1}
+	if mask {
+		bpc = 1
+	} else if cs = d.colorSpace(s.Dict["ColorSpace"], resources); cs == nil {
+		return nil, errors.New(`unsupported image color space`)
+	}
+	if bpc != 1 && bpc != 2 && bpc != 4 && bpc != 8 {
+		return nil, errors.Errorf(`unsupported number of bits per component %d`, bpc)
+	}
+
+	rowSize := (width*cs.components*bpc + 7) / 8
+	if len(data) < rowSize*height {
+		return nil, errors.New(`the image data is too short`)
+	}
+	maxValue := float64(int(1)<<bpc - 1)
+	if cs.base != nil {
+		// indexed images contain the indices instead of components
+		maxValue = 1
+	}
+	sample := func(row []byte, i int) float64 {
+		bit := i * bpc
+		v := int(row[bit/8]>>(8-bpc-bit%8)) & (1<<bpc - 1)
+		return float64(v) / maxValue
+	}
+
+	// stencil masks paint where the samples are 0, unless the decode array inverts them
+	paint := 0.0
+	if decode := d.Array(s.Dict["Decode"]); mask && len(decode) == 2 && d.Number(decode[0]) == 1 {
+		paint = 1
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	comps := make([]float64, cs.components)
+	for y := 0; y < height; y++ {
+		row := data[y*rowSize : (y+1)*rowSize]
+		for x := 0; x < width; x++ {
+			for c := range comps {
+				comps[c] = sample(row, x*cs.components+c)
+			}
+			switch {
+			case mask && comps[0] == paint:
+				img.Set(x, y, fill)
+			case mask:
+			default:
+				if col := cs.color(comps); col != nil {
+					img.Set(x, y, col)
+				}
+			}
+		}
+	}
+	return img, nil
+}
@@ -165,6 +165,9 @@ func ForType(mimeType string, opts map[string]interface{}) FileConverter {
 	// We can ignore the error here because we parse it in IsMimeTypeSupported before and if it fails
 	// return the service call. So we should only get here when the mimeType parses fine.
 	mimeType, _, _ = mime.ParseMediaType(mimeType)
+	if converter, ok := opts["externalConverter"].(ExternalConverter); ok && converter.Supports(mimeType) {
+		return converter
+	}
 	switch mimeType {
 	case "text/plain":
 		fontFileMap := ""
@@ -197,6 +200,18 @@ func ForType(mimeType string, opts map[string]interface{}) FileConverter {
 		}
 	case "image/gif":
 		return GifDecoder{}
+	case "image/svg+xml":
+		return SvgToImageConverter{}
+	case "application/pdf":
+		return PdfToImageConverter{}
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		"application/vnd.oasis.opendocument.text",
+		"application/vnd.oasis.opendocument.spreadsheet",
+		"application/vnd.oasis.opendocument.presentation",
+		"application/vnd.oasis.opendocument.graphics":
+		return OfficeThumbnailExtractor{}
 	default:
 		return ImageDecoder{}
 	}
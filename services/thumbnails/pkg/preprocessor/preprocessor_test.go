@@ -0,0 +1,268 @@
+package preprocessor
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForType(t *testing.T) {
+	external := ExternalConverter{Command: []string{"ffmpeg"}, MimeTypes: []string{"video/*", "application/pdf"}}
+	tables := []struct {
+		mimeType string
+		opts     map[string]interface{}
+		expected FileConverter
+	}{
+		{"image/png", nil, ImageDecoder{}},
+		{"image/gif", nil, GifDecoder{}},
+		{"image/svg+xml", nil, SvgToImageConverter{}},
+		{"application/pdf", nil, PdfToImageConverter{}},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", nil, OfficeThumbnailExtractor{}},
+		{"application/vnd.oasis.opendocument.presentation", nil, OfficeThumbnailExtractor{}},
+		{"video/mp4", map[string]interface{}{"externalConverter": external}, external},
+		{"application/pdf; charset=binary", map[string]interface{}{"externalConverter": external}, external},
+		{"image/png", map[string]interface{}{"externalConverter": external}, ImageDecoder{}},
+	}
+
+	for _, table := range tables {
+		t.Run(table.mimeType, func(t *testing.T) {
+			assert.Equal(t, table.expected, ForType(table.mimeType, table.opts))
+		})
+	}
+}
+
+func assertColor(t *testing.T, img image.Image, x, y int, expected color.Color) {
+	t.Helper()
+	r1, g1, b1, a1 := img.At(x, y).RGBA()
+	r2, g2, b2, a2 := expected.RGBA()
+	assert.Equal(t, [4]uint32{r2 >> 8, g2 >> 8, b2 >> 8, a2 >> 8}, [4]uint32{r1 >> 8, g1 >> 8, b1 >> 8, a1 >> 8}, "color at %d,%d", x, y)
+}
+
+func TestSvgToImageConverter(t *testing.T) {
+	svg := `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 200 100">
+  <defs>
+    <linearGradient id="gradient"><stop offset="0" stop-color="#00ff00"/><stop offset="1" stop-color="blue"/></linearGradient>
+    <rect id="hidden" width="200" height="100" fill="black"/>
+  </defs>
+  <rect x="0" y="0" width="100" height="100" fill="red"/>
+  <g style="fill: url(#gradient)" transform="translate(100 0)">
+    <path d="M0 0 h100 v50 H0 z"/>
+    <circle cx="50" cy="75" r="20" fill="rgb(0, 0, 255)" fill-opacity="0.5"/>
+  </g>
+</svg>`
+
+	img, err := SvgToImageConverter{}.Convert(strings.NewReader(svg))
+	assert.NoError(t, err)
+	rgba := img.(*image.RGBA)
+	assert.Equal(t, image.Rect(0, 0, 1024, 512), rgba.Bounds())
+
+	assertColor(t, rgba, 100, 100, color.RGBA{R: 255, A: 255})
+	assertColor(t, rgba, 800, 100, color.RGBA{G: 255, A: 255})
+	assertColor(t, rgba, 768, 384, color.RGBA{B: 127, A: 127})
+	assertColor(t, rgba, 1000, 500, color.RGBA{})
+
+	_, err = SvgToImageConverter{}.Convert(strings.NewReader(`<svg viewBox="0 0 10 10"><rect`))
+	assert.Error(t, err)
+
+	img, err = SvgToImageConverter{}.Convert(strings.NewReader(`<svg viewBox="0 0 10 10"><path d="M-1e12 -1e12 L1e12 -1e12 L0 1e12 z" fill="red"/></svg>`))
+	assert.NoError(t, err)
+	assertColor(t, img.(image.Image), 512, 512, color.RGBA{R: 255, A: 255})
+
+	img, err = SvgToImageConverter{}.Convert(strings.NewReader(`<svg width="10" height="10"></svg><rect width="5" height="5"/>`))
+	assert.NoError(t, err)
+	assertColor(t, img.(image.Image), 100, 100, color.RGBA{})
+}
+
+// pdfFile assembles a PDF file from the objects, the first one has to be the catalog
+func pdfFile(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, o := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func pdfStreamObject(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func TestPdfToImageConverter(t *testing.T) {
+	var content bytes.Buffer
+	zw := zlib.NewWriter(&content)
+	_, _ = zw.Write([]byte(`
+		1 0 0 rg 10 10 50 50 re f
+		q 0 0 1 RG 4 w 120 10 m 190 10 l S Q
+		q 20 0 0 20 150 60 cm /Im1 Do Q
+		0 g BT /F1 24 Tf 80 50 Td (Hi) Tj ET`))
+	_ = zw.Close()
+
+	doc := pdfFile(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 200 100] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> /XObject << /Im1 6 0 R >> >> >>",
+		pdfStreamObject("/Filter /FlateDecode", content.Bytes()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 72 /LastChar 72 /Widths [722] >>",
+		pdfStreamObject("/Type /XObject /Subtype /Image /Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /ASCIIHexDecode", []byte("00ff00 00ff00>")),
+	)
+
+	img, err := PdfToImageConverter{}.Convert(bytes.NewReader(doc))
+	assert.NoError(t, err)
+	rgba := img.(*image.RGBA)
+	assert.Equal(t, image.Rect(0, 0, 1024, 512), rgba.Bounds())
+
+	// the y axis of the page points upwards
+	assertColor(t, rgba, 180, 330, color.RGBA{R: 255, A: 255})
+	assertColor(t, rgba, 180, 100, color.White)
+	assertColor(t, rgba, 800, 461, color.RGBA{B: 255, A: 255})
+	assertColor(t, rgba, 820, 154, color.RGBA{G: 255, A: 255})
+
+	dark := 0
+	for x := 410; x < 520; x++ {
+		for y := 130; y < 260; y++ {
+			if r, _, _, _ := rgba.At(x, y).RGBA(); r < 0x8000 {
+				dark++
+			}
+		}
+	}
+	assert.Greater(t, dark, 100, "the text is drawn")
+}
+
+func TestPdfToImageConverterLimits(t *testing.T) {
+	render := func(content string) image.Image {
+		doc := pdfFile(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 200 100] >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+			pdfStreamObject("", []byte(content)),
+		)
+		start := time.Now()
+		img, err := PdfToImageConverter{}.Convert(bytes.NewReader(doc))
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		return img.(image.Image)
+	}
+
+	// coordinates far outside of the page are clipped
+	render("1999999990 10 m 20 20 30 30 40 40 c h B*")
+	img := render("1 0 0 rg -1000000000 -1000000000 m 1000000000 -1000000000 l 0 1000000000 l f")
+	assertColor(t, img, 512, 256, color.RGBA{R: 255, A: 255})
+
+	// painting stops once the budget of the page is spent
+	render(strings.Repeat("0 0 200 100 re f\n", 100000))
+}
+
+func TestPdfToImageConverterThumbnail(t *testing.T) {
+	doc := pdfFile(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Thumb 4 0 R >>",
+		pdfStreamObject("/Width 3 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8", []byte{0, 128, 255, 0, 128, 255}),
+	)
+
+	img, err := PdfToImageConverter{}.Convert(bytes.NewReader(doc))
+	assert.NoError(t, err)
+	thumb := img.(image.Image)
+	assert.Equal(t, image.Rect(0, 0, 3, 2), thumb.Bounds())
+	assertColor(t, thumb, 1, 1, color.Gray{Y: 128})
+}
+
+func TestPdfToImageConverterErrors(t *testing.T) {
+	_, err := PdfToImageConverter{}.Convert(strings.NewReader("no pdf"))
+	assert.Error(t, err)
+
+	_, err = PdfToImageConverter{}.Convert(bytes.NewReader(pdfFile("<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>")))
+	assert.Error(t, err)
+
+	_, err = PdfToImageConverter{}.Convert(bytes.NewReader(make([]byte, maxPdfFileSize+1)))
+	assert.Error(t, err)
+}
+
+func pngData(t *testing.T, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			img.Set(x, y, c)
+		}
+	}
+	var b bytes.Buffer
+	assert.NoError(t, png.Encode(&b, img))
+	return b.Bytes()
+}
+
+func zipData(t *testing.T, files map[string][]byte) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func TestOfficeThumbnailExtractor(t *testing.T) {
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/thumbnail" Target="docProps/preview.png"/>
+</Relationships>`
+	tables := []struct {
+		name     string
+		files    map[string][]byte
+		expected color.Color
+	}{
+		{"odf", map[string][]byte{"mimetype": []byte("application/vnd.oasis.opendocument.text"), "Thumbnails/thumbnail.png": pngData(t, color.RGBA{R: 255, A: 255})}, color.RGBA{R: 255, A: 255}},
+		{"ooxml", map[string][]byte{"_rels/.rels": []byte(rels), "docProps/preview.png": pngData(t, color.RGBA{B: 255, A: 255})}, color.RGBA{B: 255, A: 255}},
+		{"ooxml default name", map[string][]byte{"docProps/thumbnail.png": pngData(t, color.RGBA{G: 255, A: 255})}, color.RGBA{G: 255, A: 255}},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			img, err := OfficeThumbnailExtractor{}.Convert(bytes.NewReader(zipData(t, table.files)))
+			assert.NoError(t, err)
+			assertColor(t, img.(image.Image), 1, 1, table.expected)
+		})
+	}
+
+	_, err := OfficeThumbnailExtractor{}.Convert(bytes.NewReader(zipData(t, map[string][]byte{"word/document.xml": []byte("<document/>")})))
+	assert.Error(t, err)
+}
+
+func TestExternalConverter(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+	input := pngData(t, color.RGBA{R: 255, A: 255})
+
+	for _, command := range [][]string{{"cat"}, {"cat", "{input}"}} {
+		c := ExternalConverter{Command: command, MimeTypes: []string{"video/*"}, Timeout: 10 * time.Second}
+		img, err := c.Convert(bytes.NewReader(input))
+		assert.NoError(t, err)
+		assertColor(t, img.(image.Image), 1, 1, color.RGBA{R: 255, A: 255})
+	}
+
+	c := ExternalConverter{Command: []string{"sh", "-c", "echo broken >&2; exit 1"}}
+	_, err := c.Convert(bytes.NewReader(input))
+	assert.ErrorContains(t, err, "broken")
+
+	c = ExternalConverter{Command: []string{"cat"}, MimeTypes: []string{"video/*", "application/x-blender"}}
+	assert.True(t, c.Supports("video/mp4"))
+	assert.True(t, c.Supports("application/x-blender"))
+	assert.False(t, c.Supports("image/png"))
+	assert.False(t, ExternalConverter{MimeTypes: []string{"video/*"}}.Supports("video/mp4"))
+}
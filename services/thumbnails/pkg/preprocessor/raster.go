@@ -0,0 +1,317 @@
+package preprocessor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// matrix is an affine transformation in the notation of PDF and SVG. It maps
+// x, y to a*x + c*y + e, b*x + d*y + f
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// then returns the transformation applying m first and n afterwards
+func (m matrix) then(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m matrix) apply(x, y float64) point {
+	return point{m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]}
+}
+
+// scale returns the factor by which the transformation scales lengths on average
+func (m matrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+type point struct {
+	x, y float64
+}
+
+// path is a sequence of subpaths whose curves are flattened into lines. The points
+// are in device space.
+type path struct {
+	subpaths [][]point
+	closed   []bool
+}
+
+func (p *path) moveTo(pt point) {
+	p.subpaths = append(p.subpaths, []point{pt})
+	p.closed = append(p.closed, false)
+}
+
+func (p *path) lineTo(pt point) {
+	if len(p.subpaths) == 0 {
+		p.moveTo(pt)
+		return
+	}
+	i := len(p.subpaths) - 1
+	p.subpaths[i] = append(p.subpaths[i], pt)
+}
+
+func (p *path) cubeTo(c1, c2, pt point) {
+	start, ok := p.current()
+	if !ok {
+		p.moveTo(pt)
+		return
+	}
+	n := segments(start, c1, c2, pt)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		p.lineTo(point{
+			u*u*u*start.x + 3*u*u*t*c1.x + 3*u*t*t*c2.x + t*t*t*pt.x,
+			u*u*u*start.y + 3*u*u*t*c1.y + 3*u*t*t*c2.y + t*t*t*pt.y,
+		})
+	}
+}
+
+func (p *path) quadTo(c, pt point) {
+	start, ok := p.current()
+	if !ok {
+		p.moveTo(pt)
+		return
+	}
+	// elevate the quadratic curve to a cubic one
+	p.cubeTo(
+		point{start.x + 2.0/3.0*(c.x-start.x), start.y + 2.0/3.0*(c.y-start.y)},
+		point{pt.x + 2.0/3.0*(c.x-pt.x), pt.y + 2.0/3.0*(c.y-pt.y)},
+		pt,
+	)
+}
+
+func (p *path) close() {
+	if len(p.subpaths) == 0 {
+		return
+	}
+	i := len(p.subpaths) - 1
+	p.closed[i] = true
+	// following segments start at the first point of the closed subpath
+	p.moveTo(p.subpaths[i][0])
+}
+
+func (p *path) current() (point, bool) {
+	if len(p.subpaths) == 0 {
+		return point{}, false
+	}
+	sp := p.subpaths[len(p.subpaths)-1]
+	return sp[len(sp)-1], true
+}
+
+// segments returns the number of lines a curve is flattened into, depending on its size
+func segments(pts ...point) int {
+	l := 0.0
+	for i := 1; i < len(pts); i++ {
+		l += math.Hypot(pts[i].x-pts[i-1].x, pts[i].y-pts[i-1].y)
+	}
+	n := int(l / 4)
+	switch {
+	case n < 4:
+		return 4
+	case n > 64:
+		return 64
+	}
+	return n
+}
+
+const (
+	// clipMargin is the distance in pixels outside of the canvas up to which paths are kept,
+	// the edges added by clipping them are never visible
+	clipMargin = 16
+	// maxCanvasWork limits the number of pixels painted onto a canvas, as a multiple of its size
+	maxCanvasWork = 64
+)
+
+// canvas paints paths onto an image. Every painting operation is charged with the number of
+// pixels it touches, once the budget is spent nothing is painted anymore.
+type canvas struct {
+	img  *image.RGBA
+	r    *vector.Rasterizer
+	work int
+}
+
+func newCanvas(width, height int, background color.Color) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	return &canvas{
+		img:  img,
+		r:    vector.NewRasterizer(width, height),
+		work: maxCanvasWork * width * height,
+	}
+}
+
+// spend charges the canvas with n pixels and reports if they may be painted
+func (c *canvas) spend(n int) bool {
+	if c.work <= 0 {
+		return false
+	}
+	c.work -= n
+	return true
+}
+
+// exhausted reports if the budget of the canvas is spent
+func (c *canvas) exhausted() bool {
+	return c.work <= 0
+}
+
+// fill paints the area enclosed by the path
+func (c *canvas) fill(p *path, col color.Color) {
+	var polygons [][]point
+	for _, sp := range p.subpaths {
+		if len(sp) < 3 {
+			continue
+		}
+		polygons = append(polygons, c.clip(sp))
+	}
+	c.paint(polygons, col)
+}
+
+// stroke paints the outline of the path with the given width in pixels
+func (c *canvas) stroke(p *path, width float64, col color.Color) {
+	if width < 1 {
+		width = 1
+	}
+	var polygons [][]point
+	line := func(a, b point) {
+		dx, dy := b.x-a.x, b.y-a.y
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			return
+		}
+		// all quads are wound in the same direction, so overlapping ones don't cancel out
+		nx, ny := -dy/l*width/2, dx/l*width/2
+		polygons = append(polygons, c.clip([]point{
+			{a.x + nx, a.y + ny},
+			{b.x + nx, b.y + ny},
+			{b.x - nx, b.y - ny},
+			{a.x - nx, a.y - ny},
+		}))
+	}
+	for i, sp := range p.subpaths {
+		for j := 1; j < len(sp); j++ {
+			line(sp[j-1], sp[j])
+		}
+		if p.closed[i] && len(sp) > 1 {
+			line(sp[len(sp)-1], sp[0])
+		}
+	}
+	c.paint(polygons, col)
+}
+
+// paint rasterizes the closed polygons within their bounding box
+func (c *canvas) paint(polygons [][]point, col color.Color) {
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	points := 0
+	for _, poly := range polygons {
+		for _, pt := range poly {
+			minX, minY = math.Min(minX, pt.x), math.Min(minY, pt.y)
+			maxX, maxY = math.Max(maxX, pt.x), math.Max(maxY, pt.y)
+		}
+		points += len(poly)
+	}
+	if points == 0 {
+		return
+	}
+	b := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY))).Intersect(c.img.Bounds())
+	if b.Empty() || !c.spend(b.Dx()*b.Dy()+points*b.Dy()) {
+		return
+	}
+
+	c.r.Reset(b.Dx(), b.Dy())
+	ox, oy := float64(b.Min.X), float64(b.Min.Y)
+	for _, poly := range polygons {
+		if len(poly) < 3 {
+			continue
+		}
+		c.r.MoveTo(float32(poly[0].x-ox), float32(poly[0].y-oy))
+		for _, pt := range poly[1:] {
+			c.r.LineTo(float32(pt.x-ox), float32(pt.y-oy))
+		}
+		c.r.ClosePath()
+	}
+	c.r.Draw(c.img, b, image.NewUniform(col), image.Point{})
+}
+
+// clip clips the closed polygon to the canvas and its margin with the Sutherland-Hodgman
+// algorithm. The area of the polygon within the canvas stays the same, coordinates far
+// outside of it would make the rasterizer slow.
+func (c *canvas) clip(poly []point) []point {
+	for _, pt := range poly {
+		if math.IsNaN(pt.x) || math.IsNaN(pt.y) || math.IsInf(pt.x, 0) || math.IsInf(pt.y, 0) {
+			return nil
+		}
+	}
+	size := c.img.Bounds().Size()
+	edges := []struct {
+		inside func(point) bool
+		cross  func(a, b point) point
+	}{
+		{func(p point) bool { return p.x >= -clipMargin }, crossX(-clipMargin)},
+		{func(p point) bool { return p.x <= float64(size.X+clipMargin) }, crossX(float64(size.X + clipMargin))},
+		{func(p point) bool { return p.y >= -clipMargin }, crossY(-clipMargin)},
+		{func(p point) bool { return p.y <= float64(size.Y+clipMargin) }, crossY(float64(size.Y + clipMargin))},
+	}
+	for _, e := range edges {
+		if len(poly) == 0 {
+			return nil
+		}
+		in := poly
+		poly = make([]point, 0, len(in)+2)
+		prev := in[len(in)-1]
+		for _, pt := range in {
+			switch {
+			case e.inside(pt) && e.inside(prev):
+				poly = append(poly, pt)
+			case e.inside(pt):
+				poly = append(poly, e.cross(prev, pt), pt)
+			case e.inside(prev):
+				poly = append(poly, e.cross(prev, pt))
+			}
+			prev = pt
+		}
+	}
+	return poly
+}
+
+// crossX returns the function computing where a line crosses the vertical line at x
+func crossX(x float64) func(a, b point) point {
+	return func(a, b point) point {
+		return point{x, a.y + (b.y-a.y)*(x-a.x)/(b.x-a.x)}
+	}
+}
+
+// crossY returns the function computing where a line crosses the horizontal line at y
+func crossY(y float64) func(a, b point) point {
+	return func(a, b point) point {
+		return point{a.x + (b.x-a.x)*(y-a.y)/(b.y-a.y), y}
+	}
+}
+
+// withAlpha returns the color with its opacity multiplied by alpha
+func withAlpha(col color.Color, alpha float64) color.Color {
+	if alpha >= 1 {
+		return col
+	}
+	if alpha < 0 {
+		alpha = 0
+	}
+	r, g, b, a := col.RGBA()
+	return color.RGBA64{
+		R: uint16(float64(r) * alpha),
+		G: uint16(float64(g) * alpha),
+		B: uint16(float64(b) * alpha),
+		A: uint16(float64(a) * alpha),
+	}
+}
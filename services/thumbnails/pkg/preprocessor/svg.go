@@ -0,0 +1,687 @@
+package preprocessor
+
+import (
+	"encoding/xml"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/colornames"
+)
+
+// renderSize is the size in pixels of the longer side of rendered vector graphics and documents
+const renderSize = 1024
+
+// svgSkipElements are the elements whose content is not rendered
+var svgSkipElements = map[string]bool{
+	"defs": true, "clipPath": true, "mask": true, "pattern": true, "marker": true, "symbol": true,
+	"style": true, "title": true, "desc": true, "metadata": true, "text": true, "foreignObject": true,
+	"linearGradient": true, "radialGradient": true, "filter": true, "script": true,
+}
+
+// SvgToImageConverter rasterizes SVG images. It renders the shapes and paths with solid colors,
+// gradients are drawn with the color of their first stop. Text, embedded images, patterns,
+// clipping and filters are not rendered.
+type SvgToImageConverter struct{}
+
+type svgStyle struct {
+	fill          color.Color
+	stroke        color.Color
+	strokeWidth   float64
+	opacity       float64
+	fillOpacity   float64
+	strokeOpacity float64
+	transform     matrix
+	hidden        bool
+}
+
+type svgRenderer struct {
+	canvas    *canvas
+	gradients map[string]color.Color
+	hrefs     map[string]string
+	gradient  string
+}
+
+func (s SvgToImageConverter) Convert(r io.Reader) (interface{}, error) {
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	sr := &svgRenderer{
+		gradients: map[string]color.Color{},
+		hrefs:     map[string]string{},
+	}
+	var (
+		stack []svgStyle
+		skip  int
+	)
+parse:
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, `could not parse the svg image`)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			attrs := svgAttributes(t)
+			sr.collectGradient(t.Name.Local, attrs)
+			if skip > 0 || svgSkipElements[t.Name.Local] {
+				skip++
+				continue
+			}
+
+			if sr.canvas == nil {
+				if t.Name.Local != "svg" {
+					return nil, errors.New(`not an svg image`)
+				}
+				stack = append(stack, sr.setup(attrs))
+				continue
+			}
+
+			style := sr.style(stack[len(stack)-1], attrs)
+			if t.Name.Local == "svg" {
+				// nested viewports are positioned, but not scaled to their viewBox
+				x, y := svgLength(attrs["x"], 0), svgLength(attrs["y"], 0)
+				style.transform = matrix{1, 0, 0, 1, x, y}.then(style.transform)
+			}
+			stack = append(stack, style)
+			if !style.hidden {
+				sr.draw(t.Name.Local, attrs, style)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "linearGradient" || t.Name.Local == "radialGradient" {
+				sr.gradient = ""
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					// the root element ended, anything after it isn't rendered
+					break parse
+				}
+			}
+		}
+	}
+
+	if sr.canvas == nil {
+		return nil, errors.New(`not an svg image`)
+	}
+	return sr.canvas.img, nil
+}
+
+func svgAttributes(t xml.StartElement) map[string]string {
+	attrs := make(map[string]string, len(t.Attr))
+	for _, a := range t.Attr {
+		name := a.Name.Local
+		if a.Name.Space != "" && name == "href" {
+			// xlink:href
+			name = "href"
+		}
+		attrs[name] = strings.TrimSpace(a.Value)
+	}
+	// style declarations take precedence over presentation attributes
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if k, v, ok := strings.Cut(decl, ":"); ok {
+			attrs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return attrs
+}
+
+// setup creates the canvas for the root element and returns the style mapping its
+// viewBox onto the canvas
+func (sr *svgRenderer) setup(attrs map[string]string) svgStyle {
+	var vb []float64
+	if v, ok := attrs["viewBox"]; ok {
+		vb = svgNumbers(v)
+	}
+	hasViewBox := len(vb) == 4 && vb[2] > 0 && vb[3] > 0
+
+	w, h := 300.0, 150.0
+	if hasViewBox {
+		w, h = vb[2], vb[3]
+	}
+	if !strings.HasSuffix(attrs["width"], "%") {
+		w = svgLength(attrs["width"], w)
+	}
+	if !strings.HasSuffix(attrs["height"], "%") {
+		h = svgLength(attrs["height"], h)
+	}
+	if w <= 0 || h <= 0 {
+		w, h = 300, 150
+	}
+
+	scale := renderSize / math.Max(w, h)
+	width, height := int(math.Max(1, math.Round(w*scale))), int(math.Max(1, math.Round(h*scale)))
+	sr.canvas = newCanvas(width, height, color.Transparent)
+
+	m := matrix{scale, 0, 0, scale, 0, 0}
+	if hasViewBox {
+		// preserveAspectRatio="xMidYMid meet"
+		s := math.Min(w/vb[2], h/vb[3])
+		tx := (w-vb[2]*s)/2 - vb[0]*s
+		ty := (h-vb[3]*s)/2 - vb[1]*s
+		m = matrix{s, 0, 0, s, tx, ty}.then(m)
+	}
+
+	return sr.style(svgStyle{
+		fill:          color.Black,
+		strokeWidth:   1,
+		opacity:       1,
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		transform:     m,
+	}, attrs)
+}
+
+// collectGradient remembers the first stop color of the gradients
+func (sr *svgRenderer) collectGradient(name string, attrs map[string]string) {
+	switch name {
+	case "linearGradient", "radialGradient":
+		sr.gradient = attrs["id"]
+		if href := strings.TrimPrefix(attrs["href"], "#"); href != "" {
+			sr.hrefs[sr.gradient] = href
+		}
+	case "stop":
+		if _, ok := sr.gradients[sr.gradient]; ok || sr.gradient == "" {
+			return
+		}
+		col, ok := sr.color(attrs["stop-color"])
+		if !ok || col == nil {
+			col = color.Black
+		}
+		sr.gradients[sr.gradient] = withAlpha(col, svgOpacity(attrs["stop-opacity"], 1))
+	}
+}
+
+// style returns the style of an element inheriting the style of its parent
+func (sr *svgRenderer) style(parent svgStyle, attrs map[string]string) svgStyle {
+	s := parent
+	if col, ok := sr.color(attrs["fill"]); ok {
+		s.fill = col
+	}
+	if col, ok := sr.color(attrs["stroke"]); ok {
+		s.stroke = col
+	}
+	if v, ok := attrs["stroke-width"]; ok {
+		s.strokeWidth = svgLength(v, s.strokeWidth)
+	}
+	s.opacity *= svgOpacity(attrs["opacity"], 1)
+	s.fillOpacity = svgOpacity(attrs["fill-opacity"], s.fillOpacity)
+	s.strokeOpacity = svgOpacity(attrs["stroke-opacity"], s.strokeOpacity)
+	if attrs["display"] == "none" || attrs["visibility"] == "hidden" {
+		s.hidden = true
+	}
+	if v, ok := attrs["transform"]; ok {
+		s.transform = svgTransform(v).then(parent.transform)
+	}
+	return s
+}
+
+// color parses a paint. It returns false if the paint is not set or invalid, and a nil
+// color if nothing shall be painted.
+func (sr *svgRenderer) color(v string) (color.Color, bool) {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "" || v == "inherit":
+		return nil, false
+	case v == "none" || v == "transparent":
+		return nil, true
+	case v == "currentColor":
+		return color.Black, true
+	case strings.HasPrefix(v, "url("):
+		end := strings.Index(v, ")")
+		if end < 0 {
+			return nil, false
+		}
+		id := strings.TrimPrefix(strings.Trim(v[4:end], `'" `), "#")
+		for i := 0; i < 10 && id != ""; i++ {
+			if col, ok := sr.gradients[id]; ok {
+				return col, true
+			}
+			id = sr.hrefs[id]
+		}
+		// use the fallback color
+		return sr.color(v[end+1:])
+	case strings.HasPrefix(v, "#"):
+		hex := v[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			expanded := make([]byte, 0, 2*len(hex))
+			for i := 0; i < len(hex); i++ {
+				expanded = append(expanded, hex[i], hex[i])
+			}
+			hex = string(expanded)
+		}
+		if len(hex) != 6 && len(hex) != 8 {
+			return nil, false
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return nil, false
+		}
+		if len(hex) == 6 {
+			n = n<<8 | 0xff
+		}
+		return withAlpha(color.RGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: 0xff}, float64(n&0xff)/0xff), true
+	case strings.HasPrefix(v, "rgb"):
+		start, end := strings.Index(v, "("), strings.Index(v, ")")
+		if start < 0 || end < start {
+			return nil, false
+		}
+		parts := strings.FieldsFunc(v[start+1:end], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 {
+			return nil, false
+		}
+		var c [3]uint8
+		for i := range c {
+			p := parts[i]
+			f, err := strconv.ParseFloat(strings.TrimSuffix(p, "%"), 64)
+			if err != nil {
+				return nil, false
+			}
+			if strings.HasSuffix(p, "%") {
+				f = f * 255 / 100
+			}
+			c[i] = uint8(math.Max(0, math.Min(255, math.Round(f))))
+		}
+		col := color.RGBA{R: c[0], G: c[1], B: c[2], A: 0xff}
+		if len(parts) > 3 {
+			return withAlpha(col, svgOpacity(parts[3], 1)), true
+		}
+		return col, true
+	}
+	if col, ok := colornames.Map[strings.ToLower(v)]; ok {
+		return col, true
+	}
+	return nil, false
+}
+
+// draw paints a shape
+func (sr *svgRenderer) draw(name string, attrs map[string]string, style svgStyle) {
+	b := newPathBuilder(style.transform)
+	num := func(name string) float64 {
+		return svgLength(attrs[name], 0)
+	}
+
+	switch name {
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return
+		}
+		rx, ry := num("rx"), num("ry")
+		if _, ok := attrs["ry"]; !ok {
+			ry = rx
+		}
+		if _, ok := attrs["rx"]; !ok {
+			rx = ry
+		}
+		rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+		if rx <= 0 || ry <= 0 {
+			b.rect(x, y, w, h)
+			break
+		}
+		b.moveTo(x+rx, y)
+		b.lineTo(x+w-rx, y)
+		b.arcTo(rx, ry, 0, false, true, x+w, y+ry)
+		b.lineTo(x+w, y+h-ry)
+		b.arcTo(rx, ry, 0, false, true, x+w-rx, y+h)
+		b.lineTo(x+rx, y+h)
+		b.arcTo(rx, ry, 0, false, true, x, y+h-ry)
+		b.lineTo(x, y+ry)
+		b.arcTo(rx, ry, 0, false, true, x+rx, y)
+		b.close()
+	case "circle":
+		if r := num("r"); r > 0 {
+			b.ellipse(num("cx"), num("cy"), r, r)
+		}
+	case "ellipse":
+		if rx, ry := num("rx"), num("ry"); rx > 0 && ry > 0 {
+			b.ellipse(num("cx"), num("cy"), rx, ry)
+		}
+	case "line":
+		b.moveTo(num("x1"), num("y1"))
+		b.lineTo(num("x2"), num("y2"))
+	case "polyline", "polygon":
+		pts := svgNumbers(attrs["points"])
+		for i := 0; i+1 < len(pts); i += 2 {
+			if i == 0 {
+				b.moveTo(pts[i], pts[i+1])
+			} else {
+				b.lineTo(pts[i], pts[i+1])
+			}
+		}
+		if name == "polygon" && len(pts) >= 4 {
+			b.close()
+		}
+	case "path":
+		svgPath(b, attrs["d"])
+	default:
+		return
+	}
+
+	if style.fill != nil && name != "line" {
+		sr.canvas.fill(b.p, withAlpha(style.fill, style.opacity*style.fillOpacity))
+	}
+	if style.stroke != nil && style.strokeWidth > 0 {
+		sr.canvas.stroke(b.p, style.strokeWidth*style.transform.scale(), withAlpha(style.stroke, style.opacity*style.strokeOpacity))
+	}
+}
+
+// svgPath adds the segments of the path data to the builder
+func svgPath(b *pathBuilder, d string) {
+	s := &svgScanner{s: d}
+	var (
+		cmd        byte
+		ctrl       point // the last control point for the smooth curve commands
+		lastCurve  byte
+		firstParam = true
+	)
+	for {
+		s.skipSeparators()
+		if s.done() {
+			return
+		}
+		if c := s.s[s.i]; isSvgCommand(c) {
+			cmd = c
+			s.i++
+			firstParam = true
+		} else if cmd == 0 {
+			return
+		}
+
+		rel := cmd >= 'a'
+		ox, oy := 0.0, 0.0
+		if rel {
+			ox, oy = b.cur.x, b.cur.y
+		}
+		args := func(n int) ([]float64, bool) {
+			v := make([]float64, n)
+			for i := range v {
+				f, ok := s.number()
+				if !ok {
+					return nil, false
+				}
+				v[i] = f
+			}
+			return v, true
+		}
+
+		curve := byte(0)
+		switch cmd | 0x20 {
+		case 'z':
+			b.close()
+			firstParam = true
+			cmd = 0
+			continue
+		case 'm':
+			v, ok := args(2)
+			if !ok {
+				return
+			}
+			if firstParam {
+				b.moveTo(ox+v[0], oy+v[1])
+				// following coordinate pairs are implicit lineto commands
+				if rel {
+					cmd = 'l'
+				} else {
+					cmd = 'L'
+				}
+			} else {
+				b.lineTo(ox+v[0], oy+v[1])
+			}
+		case 'l':
+			v, ok := args(2)
+			if !ok {
+				return
+			}
+			b.lineTo(ox+v[0], oy+v[1])
+		case 'h':
+			v, ok := args(1)
+			if !ok {
+				return
+			}
+			if !rel {
+				ox = 0
+			}
+			b.lineTo(ox+v[0], b.cur.y)
+		case 'v':
+			v, ok := args(1)
+			if !ok {
+				return
+			}
+			if !rel {
+				oy = 0
+			}
+			b.lineTo(b.cur.x, oy+v[0])
+		case 'c':
+			v, ok := args(6)
+			if !ok {
+				return
+			}
+			ctrl = point{ox + v[2], oy + v[3]}
+			b.cubeTo(ox+v[0], oy+v[1], ctrl.x, ctrl.y, ox+v[4], oy+v[5])
+			curve = 'c'
+		case 's':
+			v, ok := args(4)
+			if !ok {
+				return
+			}
+			c1 := b.cur
+			if lastCurve == 'c' {
+				c1 = point{2*b.cur.x - ctrl.x, 2*b.cur.y - ctrl.y}
+			}
+			ctrl = point{ox + v[0], oy + v[1]}
+			b.cubeTo(c1.x, c1.y, ctrl.x, ctrl.y, ox+v[2], oy+v[3])
+			curve = 'c'
+		case 'q':
+			v, ok := args(4)
+			if !ok {
+				return
+			}
+			ctrl = point{ox + v[0], oy + v[1]}
+			b.quadTo(ctrl.x, ctrl.y, ox+v[2], oy+v[3])
+			curve = 'q'
+		case 't':
+			v, ok := args(2)
+			if !ok {
+				return
+			}
+			c := b.cur
+			if lastCurve == 'q' {
+				c = point{2*b.cur.x - ctrl.x, 2*b.cur.y - ctrl.y}
+			}
+			ctrl = c
+			b.quadTo(c.x, c.y, ox+v[0], oy+v[1])
+			curve = 'q'
+		case 'a':
+			v, ok := args(3)
+			if !ok {
+				return
+			}
+			large, ok1 := s.flag()
+			sweep, ok2 := s.flag()
+			end, ok3 := args(2)
+			if !ok1 || !ok2 || !ok3 {
+				return
+			}
+			b.arcTo(v[0], v[1], v[2], large, sweep, ox+end[0], oy+end[1])
+		default:
+			return
+		}
+		lastCurve = curve
+		firstParam = false
+	}
+}
+
+func isSvgCommand(c byte) bool {
+	return strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0
+}
+
+// svgScanner reads the numbers of path data and lists, which may omit the separators
+// between numbers, e.g. "M1.5.5-2e1"
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (s *svgScanner) done() bool {
+	return s.i >= len(s.s)
+}
+
+func (s *svgScanner) skipSeparators() {
+	for !s.done() && strings.IndexByte(" \t\r\n,", s.s[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+func (s *svgScanner) number() (float64, bool) {
+	s.skipSeparators()
+	start := s.i
+	if !s.done() && (s.s[s.i] == '+' || s.s[s.i] == '-') {
+		s.i++
+	}
+	digits, dot := false, false
+	for ; !s.done(); s.i++ {
+		c := s.s[s.i]
+		if c == '.' && !dot {
+			dot = true
+		} else if c >= '0' && c <= '9' {
+			digits = true
+		} else {
+			break
+		}
+	}
+	if digits && !s.done() && (s.s[s.i] == 'e' || s.s[s.i] == 'E') {
+		j := s.i + 1
+		if j < len(s.s) && (s.s[j] == '+' || s.s[j] == '-') {
+			j++
+		}
+		if j < len(s.s) && s.s[j] >= '0' && s.s[j] <= '9' {
+			for j < len(s.s) && s.s[j] >= '0' && s.s[j] <= '9' {
+				j++
+			}
+			s.i = j
+		}
+	}
+	if !digits {
+		s.i = start
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s.s[start:s.i], 64)
+	return f, err == nil
+}
+
+// flag reads an arc flag, which doesn't need to be followed by a separator
+func (s *svgScanner) flag() (bool, bool) {
+	s.skipSeparators()
+	if s.done() || (s.s[s.i] != '0' && s.s[s.i] != '1') {
+		return false, false
+	}
+	s.i++
+	return s.s[s.i-1] == '1', true
+}
+
+// svgNumbers parses a list of numbers
+func svgNumbers(v string) []float64 {
+	s := &svgScanner{s: v}
+	var numbers []float64
+	for {
+		f, ok := s.number()
+		if !ok {
+			return numbers
+		}
+		numbers = append(numbers, f)
+	}
+}
+
+// svgLength parses a length in user units. Percentages and invalid values return the default.
+func svgLength(v string, def float64) float64 {
+	v = strings.TrimSpace(v)
+	units := map[string]float64{"px": 1, "pt": 4.0 / 3.0, "pc": 16, "mm": 96 / 25.4, "cm": 96 / 2.54, "in": 96, "em": 16, "ex": 8}
+	factor := 1.0
+	for unit, f := range units {
+		if strings.HasSuffix(v, unit) {
+			v, factor = strings.TrimSuffix(v, unit), f
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return def
+	}
+	return f * factor
+}
+
+// svgOpacity parses an opacity given as number or percentage
+func svgOpacity(v string, def float64) float64 {
+	v = strings.TrimSpace(v)
+	factor := 1.0
+	if strings.HasSuffix(v, "%") {
+		v, factor = strings.TrimSuffix(v, "%"), 0.01
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def
+	}
+	return math.Max(0, math.Min(1, f*factor))
+}
+
+// svgTransform parses a list of transformations
+func svgTransform(v string) matrix {
+	m := identity
+	for {
+		start := strings.Index(v, "(")
+		end := strings.Index(v, ")")
+		if start < 0 || end < start {
+			return m
+		}
+		name := strings.TrimSpace(strings.Trim(strings.TrimSpace(v[:start]), ","))
+		args := svgNumbers(v[start+1 : end])
+		v = v[end+1:]
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		var t matrix
+		switch name {
+		case "matrix":
+			if len(args) != 6 {
+				continue
+			}
+			copy(t[:], args)
+		case "translate":
+			t = matrix{1, 0, 0, 1, arg(0, 0), arg(1, 0)}
+		case "scale":
+			sx := arg(0, 1)
+			t = matrix{sx, 0, 0, arg(1, sx), 0, 0}
+		case "rotate":
+			sin, cos := math.Sincos(arg(0, 0) * math.Pi / 180)
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = matrix{1, 0, 0, 1, -cx, -cy}.then(matrix{cos, sin, -sin, cos, 0, 0}).then(matrix{1, 0, 0, 1, cx, cy})
+		case "skewX":
+			t = matrix{1, 0, math.Tan(arg(0, 0) * math.Pi / 180), 1, 0, 0}
+		case "skewY":
+			t = matrix{1, math.Tan(arg(0, 0) * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		// the transformations are applied from right to left
+		m = t.then(m)
+	}
+}
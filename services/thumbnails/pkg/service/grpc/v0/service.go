@@ -48,9 +48,18 @@ func NewService(opts ...Option) decorators.DecoratedService {
 		cs3Client:    options.CS3Client,
 		preprocessorOpts: PreprocessorOpts{
 			TxtFontFileMap: options.Config.Thumbnail.FontMapFile,
+			ExternalConverter: preprocessor.ExternalConverter{
+				Command:   strings.Fields(options.Config.Thumbnail.ExternalConverter.Command),
+				MimeTypes: options.Config.Thumbnail.ExternalConverter.MimeTypes,
+				Timeout:   options.Config.Thumbnail.ExternalConverter.Timeout,
+			},
 		},
 		dataEndpoint:   options.Config.Thumbnail.DataEndpoint,
 		transferSecret: options.Config.Thumbnail.TransferSecret,
+		quality: map[string]int{
+			thumbnailsmsg.ThumbnailType_JPG.String():  options.Config.Thumbnail.Quality.JPEG,
+			thumbnailsmsg.ThumbnailType_WEBP.String(): options.Config.Thumbnail.Quality.WebP,
+		},
 	}
 
 	return svc
@@ -67,10 +76,13 @@ type Thumbnail struct {
 	logger           log.Logger
 	cs3Client        gateway.GatewayAPIClient
 	preprocessorOpts PreprocessorOpts
+	// quality holds the quality of the lossy thumbnail types
+	quality map[string]int
 }
 
 type PreprocessorOpts struct {
-	TxtFontFileMap string
+	TxtFontFileMap    string
+	ExternalConverter preprocessor.ExternalConverter
 }
 
 // GetThumbnail retrieves a thumbnail for an image
@@ -85,7 +97,7 @@ func (g Thumbnail) GetThumbnail(ctx context.Context, req *thumbnailssvc.GetThumb
 		g.logger.Debug().Str("thumbnail_type", tType).Msg("unsupported thumbnail type")
 		return nil
 	}
-	encoder, err := thumbnail.EncoderForType(tType)
+	encoder, err := thumbnail.EncoderForType(tType, g.quality[tType])
 	if err != nil {
 		g.logger.Debug().Str("thumbnail_type", tType).Msg("unsupported thumbnail type")
 		return nil
@@ -155,7 +167,8 @@ func (g Thumbnail) handleCS3Source(ctx context.Context,
 	}
 	defer r.Close() // nolint:errcheck
 	ppOpts := map[string]interface{}{
-		"fontFileMap": g.preprocessorOpts.TxtFontFileMap,
+		"fontFileMap":       g.preprocessorOpts.TxtFontFileMap,
+		"externalConverter": g.preprocessorOpts.ExternalConverter,
 	}
 	pp := preprocessor.ForType(sRes.GetInfo().GetMimeType(), ppOpts)
 	img, err := pp.Convert(r)
@@ -238,7 +251,8 @@ func (g Thumbnail) handleWebdavSource(ctx context.Context,
 	}
 	defer r.Close() // nolint:errcheck
 	ppOpts := map[string]interface{}{
-		"fontFileMap": g.preprocessorOpts.TxtFontFileMap,
+		"fontFileMap":       g.preprocessorOpts.TxtFontFileMap,
+		"externalConverter": g.preprocessorOpts.ExternalConverter,
 	}
 	pp := preprocessor.ForType(sRes.GetInfo().GetMimeType(), ppOpts)
 	img, err := pp.Convert(r)
@@ -288,7 +302,7 @@ func (g Thumbnail) stat(path, auth string) (*provider.StatResponse, error) {
 		g.logger.Error().Msg("resource info is missing checksum")
 		return nil, merrors.NotFound(g.serviceID, "resource info is missing a checksum")
 	}
-	if !thumbnail.IsMimeTypeSupported(rsp.Info.MimeType) {
+	if !thumbnail.IsMimeTypeSupported(rsp.Info.MimeType) && !g.preprocessorOpts.ExternalConverter.Supports(rsp.Info.MimeType) {
 		return nil, merrors.NotFound(g.serviceID, "Unsupported file type")
 	}
 	return rsp, nil
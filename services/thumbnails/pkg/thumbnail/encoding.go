@@ -8,6 +8,8 @@ import (
 	"image/png"
 	"io"
 	"strings"
+
+	"github.com/owncloud/ocis/v2/services/thumbnails/pkg/thumbnail/webp"
 )
 
 const (
@@ -15,6 +17,7 @@ const (
 	typeJpg  = "jpg"
 	typeJpeg = "jpeg"
 	typeGif  = "gif"
+	typeWebp = "webp"
 )
 
 var (
@@ -32,6 +35,8 @@ type Encoder interface {
 	Types() []string
 	// MimeType returns the mimetype used by the encoder.
 	MimeType() string
+	// Quality returns the quality setting of lossy formats, 0 means the default quality.
+	Quality() int
 }
 
 // PngEncoder encodes to png
@@ -56,8 +61,15 @@ func (e PngEncoder) MimeType() string {
 	return "image/png"
 }
 
+// Quality returns 0, png is lossless.
+func (e PngEncoder) Quality() int {
+	return 0
+}
+
 // JpegEncoder encodes to jpg.
-type JpegEncoder struct{}
+type JpegEncoder struct {
+	quality int
+}
 
 // Encode encodes to jpg
 func (e JpegEncoder) Encode(w io.Writer, img interface{}) error {
@@ -65,7 +77,11 @@ func (e JpegEncoder) Encode(w io.Writer, img interface{}) error {
 	if !ok {
 		return ErrInvalidType
 	}
-	return jpeg.Encode(w, m, nil)
+	var o *jpeg.Options
+	if e.quality > 0 {
+		o = &jpeg.Options{Quality: e.quality}
+	}
+	return jpeg.Encode(w, m, o)
 }
 
 // Types returns the jpg suffixes.
@@ -78,6 +94,11 @@ func (e JpegEncoder) MimeType() string {
 	return "image/jpeg"
 }
 
+// Quality returns the jpg quality.
+func (e JpegEncoder) Quality() int {
+	return e.quality
+}
+
 type GifEncoder struct{}
 
 func (e GifEncoder) Encode(w io.Writer, img interface{}) error {
@@ -96,16 +117,64 @@ func (e GifEncoder) MimeType() string {
 	return "image/gif"
 }
 
+func (e GifEncoder) Quality() int {
+	return 0
+}
+
+// WebpEncoder encodes to webp.
+type WebpEncoder struct {
+	quality int
+}
+
+// Encode encodes to webp
+func (e WebpEncoder) Encode(w io.Writer, img interface{}) error {
+	m, ok := img.(image.Image)
+	if !ok {
+		return ErrInvalidType
+	}
+	var o *webp.Options
+	if e.quality > 0 {
+		o = &webp.Options{Quality: e.quality}
+	}
+	return webp.Encode(w, m, o)
+}
+
+// Types returns the webp suffix.
+func (e WebpEncoder) Types() []string {
+	return []string{typeWebp}
+}
+
+// MimeType returns the mimetype for webp files.
+func (e WebpEncoder) MimeType() string {
+	return "image/webp"
+}
+
+// Quality returns the webp quality.
+func (e WebpEncoder) Quality() int {
+	return e.quality
+}
+
 // EncoderForType returns the encoder for a given file type
-// or nil if the type is not supported.
-func EncoderForType(fileType string) (Encoder, error) {
+// or nil if the type is not supported. The quality is used by
+// lossy formats, 0 means the default quality of the format.
+// The default quality is reported as 0, so that it is not part
+// of the storage keys of the thumbnails.
+func EncoderForType(fileType string, quality int) (Encoder, error) {
 	switch strings.ToLower(fileType) {
 	case typePng:
 		return PngEncoder{}, nil
 	case typeJpg, typeJpeg:
-		return JpegEncoder{}, nil
+		if quality == jpeg.DefaultQuality {
+			quality = 0
+		}
+		return JpegEncoder{quality: quality}, nil
 	case typeGif:
 		return GifEncoder{}, nil
+	case typeWebp:
+		if quality == webp.DefaultQuality {
+			quality = 0
+		}
+		return WebpEncoder{quality: quality}, nil
 	default:
 		return nil, ErrNoEncoderForType
 	}
@@ -10,13 +10,37 @@ func TestEncoderForType(t *testing.T) {
 		"JPEG":    JpegEncoder{},
 		"png":     PngEncoder{},
 		"PNG":     PngEncoder{},
+		"webp":    WebpEncoder{},
+		"WEBP":    WebpEncoder{},
 		"invalid": nil,
 	}
 
 	for k, v := range table {
-		e, _ := EncoderForType(k)
+		e, _ := EncoderForType(k, 0)
 		if e != v {
 			t.Fail()
 		}
 	}
 }
+
+func TestEncoderForTypeQuality(t *testing.T) {
+	table := []struct {
+		fileType string
+		quality  int
+		expected int
+	}{
+		{"jpg", 90, 90},
+		{"webp", 60, 60},
+		{"jpg", 75, 0},
+		{"webp", 75, 0},
+		{"png", 90, 0},
+		{"gif", 90, 0},
+	}
+
+	for _, row := range table {
+		e, err := EncoderForType(row.fileType, row.quality)
+		if err != nil || e.Quality() != row.expected {
+			t.Errorf("unexpected quality of %s", row.fileType)
+		}
+	}
+}
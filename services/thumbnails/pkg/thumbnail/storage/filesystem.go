@@ -74,15 +74,23 @@ func (s FileSystem) Put(key string, img []byte) error {
 // BuildKey generate the unique key for a thumbnail.
 // The key is structure as follows:
 //
-// <first two letters of checksum>/<next two letters of checksum>/<rest of checksum>/<width>x<height>.<filetype>
+// <first two letters of checksum>/<next two letters of checksum>/<rest of checksum>/<width>x<height>[-q<quality>].<filetype>
 //
 // e.g. 97/9f/4c8db98f7b82e768ef478d3c8612/500x300.png
+// or 97/9f/4c8db98f7b82e768ef478d3c8612/500x300-q90.webp
+//
+// The quality is left out for lossless formats and the default quality of a format, so that
+// thumbnails stored before the quality was configurable are still found.
 //
 // The key also represents the path to the thumbnail in the filesystem under the configured root directory.
 func (s FileSystem) BuildKey(r Request) string {
 	checksum := r.Checksum
 	filetype := r.Types[0]
-	filename := strconv.Itoa(r.Resolution.Dx()) + "x" + strconv.Itoa(r.Resolution.Dy()) + "." + filetype
+	filename := strconv.Itoa(r.Resolution.Dx()) + "x" + strconv.Itoa(r.Resolution.Dy())
+	if r.Quality > 0 {
+		filename += "-q" + strconv.Itoa(r.Quality)
+	}
+	filename += "." + filetype
 
 	return filepath.Join(checksum[:2], checksum[2:4], checksum[4:], filename)
 }
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -39,6 +40,7 @@ func (s InMemory) BuildKey(r Request) string {
 		r.Checksum,
 		r.Resolution.String(),
 		strings.Join(r.Types, ","),
+		strconv.Itoa(r.Quality),
 	}
 	return strings.Join(parts, "+")
 }
@@ -13,6 +13,9 @@ type Request struct {
 	// Contains the mimetypes of the thumbnail.
 	// In case of jpg/jpeg it will contain both.
 	Types []string
+	// The quality setting of the encoder.
+	// Is 0 for lossless formats or the default quality.
+	Quality int
 	// The resolution of the thumbnail
 	Resolution image.Rectangle
 }
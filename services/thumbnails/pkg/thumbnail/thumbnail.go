@@ -13,14 +13,23 @@ import (
 var (
 	// SupportedMimeTypes contains a all mimetypes which are supported by the thumbnailer.
 	SupportedMimeTypes = map[string]struct{}{
-		"image/png":      {},
-		"image/jpg":      {},
-		"image/jpeg":     {},
-		"image/gif":      {},
-		"image/bmp":      {},
-		"image/x-ms-bmp": {},
-		"image/tiff":     {},
-		"text/plain":     {},
+		"image/png":       {},
+		"image/jpg":       {},
+		"image/jpeg":      {},
+		"image/gif":       {},
+		"image/bmp":       {},
+		"image/x-ms-bmp":  {},
+		"image/tiff":      {},
+		"text/plain":      {},
+		"image/svg+xml":   {},
+		"application/pdf": {},
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   {},
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         {},
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation": {},
+		"application/vnd.oasis.opendocument.text":                                   {},
+		"application/vnd.oasis.opendocument.spreadsheet":                            {},
+		"application/vnd.oasis.opendocument.presentation":                           {},
+		"application/vnd.oasis.opendocument.graphics":                               {},
 	}
 )
 
@@ -101,6 +110,7 @@ func mapToStorageRequest(r Request) storage.Request {
 		Checksum:   r.Checksum,
 		Resolution: r.Resolution,
 		Types:      r.Encoder.Types(),
+		Quality:    r.Encoder.Quality(),
 	}
 }
 
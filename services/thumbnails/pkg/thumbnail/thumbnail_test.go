@@ -40,7 +40,7 @@ func BenchmarkGet(b *testing.B) {
 	f, _ := os.Open(p)
 	defer f.Close()
 	img, ext, _ := image.Decode(f)
-	req.Encoder, _ = EncoderForType(ext)
+	req.Encoder, _ = EncoderForType(ext, 0)
 	for i := 0; i < b.N; i++ {
 		_, _ = sut.Generate(req, img)
 	}
@@ -0,0 +1,72 @@
+package webp
+
+// boolEncoder is the boolean entropy encoder specified in section 7.3.
+type boolEncoder struct {
+	buf      []byte
+	rng      uint32
+	bottom   uint32
+	bitCount int
+}
+
+func newBoolEncoder() *boolEncoder {
+	return &boolEncoder{rng: 255, bitCount: 24}
+}
+
+// putBit writes a bit which is false with the probability prob/256.
+func (e *boolEncoder) putBit(bit bool, prob uint8) {
+	split := 1 + (((e.rng - 1) * uint32(prob)) >> 8)
+	if bit {
+		e.bottom += split
+		e.rng -= split
+	} else {
+		e.rng = split
+	}
+	for e.rng < 128 {
+		e.rng <<= 1
+		if e.bottom&(1<<31) != 0 {
+			e.carry()
+		}
+		e.bottom <<= 1
+		e.bitCount--
+		if e.bitCount == 0 {
+			e.buf = append(e.buf, byte(e.bottom>>24))
+			e.bottom &= 1<<24 - 1
+			e.bitCount = 8
+		}
+	}
+}
+
+// putLiteral writes the n least significant bits of v, the most significant first.
+func (e *boolEncoder) putLiteral(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		e.putBit(v>>uint(i)&1 != 0, 128)
+	}
+}
+
+// carry propagates a carry into the bytes already written.
+func (e *boolEncoder) carry() {
+	for i := len(e.buf) - 1; i >= 0; i-- {
+		e.buf[i]++
+		if e.buf[i] != 0 {
+			return
+		}
+	}
+}
+
+// flush writes the remaining bits and returns the encoded data.
+func (e *boolEncoder) flush() []byte {
+	c := e.bitCount
+	v := e.bottom
+	if v&(1<<uint(32-c)) != 0 {
+		e.carry()
+	}
+	v <<= uint(c & 7)
+	for c >>= 3; c > 0; c-- {
+		v <<= 8
+	}
+	for i := 0; i < 4; i++ {
+		e.buf = append(e.buf, byte(v>>24))
+		v <<= 8
+	}
+	return e.buf
+}
@@ -0,0 +1,605 @@
+package webp
+
+import (
+	"errors"
+)
+
+// The prediction modes of whole macroblocks, specified in section 12.2.
+const (
+	predDC = iota
+	predVE
+	predHE
+	predTM
+	nPred
+)
+
+// quantizer holds the step sizes of the DC and the AC coefficients.
+type quantizer [2]int32
+
+// macroblock holds the information written to the first partition.
+type macroblock struct {
+	yMode  int
+	uvMode int
+	skip   bool
+}
+
+// nzContext records if the blocks at an edge of a macroblock have non-zero coefficients:
+// 4 Y blocks, 2 U blocks, 2 V blocks and the Y2 block.
+type nzContext [9]bool
+
+const nzY2 = 8
+
+// encoder encodes a VP8 key frame. The loop filter is disabled, the prediction uses the
+// reconstructed planes which match the frame decoded by the decoder exactly.
+type encoder struct {
+	width, height    int
+	mbw, mbh         int
+	yStride, cStride int
+
+	// y, u and v are the source planes, ry, ru and rv the reconstructed ones
+	y, u, v    []uint8
+	ry, ru, rv []uint8
+
+	qi         int
+	y1, y2, uv quantizer
+
+	mbs    []macroblock
+	tokens *boolEncoder
+	topNz  []nzContext
+	leftNz nzContext
+}
+
+func newEncoder(width, height, quality int) *encoder {
+	e := &encoder{
+		width:  width,
+		height: height,
+		mbw:    (width + 15) / 16,
+		mbh:    (height + 15) / 16,
+		tokens: newBoolEncoder(),
+	}
+	e.yStride, e.cStride = e.mbw*16, e.mbw*8
+	ySize, cSize := e.yStride*e.mbh*16, e.cStride*e.mbh*8
+	e.y, e.ry = make([]uint8, ySize), make([]uint8, ySize)
+	e.u, e.ru = make([]uint8, cSize), make([]uint8, cSize)
+	e.v, e.rv = make([]uint8, cSize), make([]uint8, cSize)
+	e.mbs = make([]macroblock, 0, e.mbw*e.mbh)
+	e.topNz = make([]nzContext, e.mbw)
+
+	// the step sizes are derived like the decoder does, see section 9.6
+	e.qi = (100 - quality) * 127 / 100
+	e.y1 = quantizer{dcTable[e.qi], acTable[e.qi]}
+	e.y2 = quantizer{dcTable[e.qi] * 2, acTable[e.qi] * 155 / 100}
+	if e.y2[1] < 8 {
+		e.y2[1] = 8
+	}
+	uvQi := e.qi
+	if uvQi > 117 {
+		uvQi = 117
+	}
+	e.uv = quantizer{dcTable[uvQi], acTable[e.qi]}
+	return e
+}
+
+// encode encodes the planes and returns the frame.
+func (e *encoder) encode() ([]byte, error) {
+	for mby := 0; mby < e.mbh; mby++ {
+		e.leftNz = nzContext{}
+		for mbx := 0; mbx < e.mbw; mbx++ {
+			e.encodeMacroblock(mbx, mby)
+		}
+	}
+
+	header := e.header()
+	if len(header) >= 1<<19 {
+		return nil, errors.New("webp: the first partition is too large")
+	}
+	tokens := e.tokens.flush()
+
+	// the frame tag and the key frame header are specified in section 9.1
+	frame := make([]byte, 10, 10+len(header)+len(tokens))
+	tag := uint32(len(header))<<5 | 1<<4
+	frame[0], frame[1], frame[2] = byte(tag), byte(tag>>8), byte(tag>>16)
+	frame[3], frame[4], frame[5] = 0x9d, 0x01, 0x2a
+	frame[6], frame[7] = byte(e.width), byte(e.width>>8)
+	frame[8], frame[9] = byte(e.height), byte(e.height>>8)
+	frame = append(frame, header...)
+	return append(frame, tokens...), nil
+}
+
+// header returns the first partition with the frame header and the modes of the macroblocks.
+func (e *encoder) header() []byte {
+	b := newBoolEncoder()
+	b.putBit(false, 128) // color space
+	b.putBit(false, 128) // clamping type
+	b.putBit(false, 128) // segmentation
+	b.putBit(false, 128) // filter type
+	b.putLiteral(0, 6)   // loop filter level
+	b.putLiteral(0, 3)   // sharpness
+	b.putBit(false, 128) // loop filter deltas
+	b.putLiteral(0, 2)   // a single token partition
+	b.putLiteral(uint32(e.qi), 7)
+	for i := 0; i < 5; i++ {
+		b.putBit(false, 128) // quantizer deltas
+	}
+	b.putBit(false, 128) // refresh entropy probs
+	for i := range tokenProbUpdateProb {
+		for j := range tokenProbUpdateProb[i] {
+			for k := range tokenProbUpdateProb[i][j] {
+				for _, p := range tokenProbUpdateProb[i][j][k] {
+					b.putBit(false, p)
+				}
+			}
+		}
+	}
+
+	skipped := 0
+	for _, mb := range e.mbs {
+		if mb.skip {
+			skipped++
+		}
+	}
+	skipProb := uint8(0)
+	if skipped > 0 {
+		p := (len(e.mbs) - skipped) * 255 / len(e.mbs)
+		if p < 1 {
+			p = 1
+		}
+		skipProb = uint8(p)
+		b.putBit(true, 128)
+		b.putLiteral(uint32(skipProb), 8)
+	} else {
+		b.putBit(false, 128)
+	}
+
+	// the trees of the modes are specified in section 11.2
+	for _, mb := range e.mbs {
+		if skipped > 0 {
+			b.putBit(mb.skip, skipProb)
+		}
+		b.putBit(true, 145)
+		switch mb.yMode {
+		case predDC:
+			b.putBit(false, 156)
+			b.putBit(false, 163)
+		case predVE:
+			b.putBit(false, 156)
+			b.putBit(true, 163)
+		case predHE:
+			b.putBit(true, 156)
+			b.putBit(false, 128)
+		case predTM:
+			b.putBit(true, 156)
+			b.putBit(true, 128)
+		}
+		switch mb.uvMode {
+		case predDC:
+			b.putBit(false, 142)
+		case predVE:
+			b.putBit(true, 142)
+			b.putBit(false, 114)
+		case predHE:
+			b.putBit(true, 142)
+			b.putBit(true, 114)
+			b.putBit(false, 183)
+		case predTM:
+			b.putBit(true, 142)
+			b.putBit(true, 114)
+			b.putBit(true, 183)
+		}
+	}
+	return b.flush()
+}
+
+// encodeMacroblock predicts the macroblock, quantizes the residuals, writes the tokens and
+// reconstructs the macroblock.
+func (e *encoder) encodeMacroblock(mbx, mby int) {
+	hasTop, hasLeft := mby > 0, mbx > 0
+	yOff := mby*16*e.yStride + mbx*16
+	cOff := mby*8*e.cStride + mbx*8
+
+	var yPred [256]uint8
+	yMode := bestMode(hasTop, hasLeft, func(mode int) int {
+		predict(mode, e.ry, yOff, e.yStride, 16, hasTop, hasLeft, yPred[:])
+		return sad(e.y[yOff:], e.yStride, yPred[:], 16)
+	})
+	predict(yMode, e.ry, yOff, e.yStride, 16, hasTop, hasLeft, yPred[:])
+
+	var uPred, vPred [64]uint8
+	uvMode := bestMode(hasTop, hasLeft, func(mode int) int {
+		predict(mode, e.ru, cOff, e.cStride, 8, hasTop, hasLeft, uPred[:])
+		predict(mode, e.rv, cOff, e.cStride, 8, hasTop, hasLeft, vPred[:])
+		return sad(e.u[cOff:], e.cStride, uPred[:], 8) + sad(e.v[cOff:], e.cStride, vPred[:], 8)
+	})
+	predict(uvMode, e.ru, cOff, e.cStride, 8, hasTop, hasLeft, uPred[:])
+	predict(uvMode, e.rv, cOff, e.cStride, 8, hasTop, hasLeft, vPred[:])
+
+	// the DC coefficients of the luma blocks are transformed again into the Y2 block
+	var yCoeffs [16][16]int32
+	var dcs [16]int32
+	for i := range yCoeffs {
+		x, y := (i%4)*4, (i/4)*4
+		fdct(e.y[yOff+y*e.yStride+x:], e.yStride, yPred[y*16+x:], 16, &yCoeffs[i])
+		dcs[i] = yCoeffs[i][0]
+	}
+	var y2Coeffs [16]int32
+	fwht(&dcs, &y2Coeffs)
+	var y2Levels [16]int32
+	nonZero := quantize(&y2Coeffs, e.y2, 0, &y2Levels)
+	iwht(&y2Coeffs, &dcs)
+
+	var yLevels [16][16]int32
+	for i := range yCoeffs {
+		nonZero = quantize(&yCoeffs[i], e.y1, 1, &yLevels[i]) || nonZero
+		yCoeffs[i][0] = dcs[i]
+		x, y := (i%4)*4, (i/4)*4
+		idct(&yCoeffs[i], yPred[y*16+x:], 16, e.ry[yOff+y*e.yStride+x:], e.yStride)
+	}
+
+	var uvLevels [8][16]int32
+	for i := range uvLevels {
+		src, rec, pred := e.u, e.ru, uPred[:]
+		if i >= 4 {
+			src, rec, pred = e.v, e.rv, vPred[:]
+		}
+		x, y := (i%2)*4, (i%4/2)*4
+		var coeffs [16]int32
+		fdct(src[cOff+y*e.cStride+x:], e.cStride, pred[y*8+x:], 8, &coeffs)
+		nonZero = quantize(&coeffs, e.uv, 0, &uvLevels[i]) || nonZero
+		idct(&coeffs, pred[y*8+x:], 8, rec[cOff+y*e.cStride+x:], e.cStride)
+	}
+
+	e.mbs = append(e.mbs, macroblock{yMode: yMode, uvMode: uvMode, skip: !nonZero})
+	top := &e.topNz[mbx]
+	if !nonZero {
+		*top, e.leftNz = nzContext{}, nzContext{}
+		return
+	}
+
+	// the order of the blocks is specified in section 13
+	left := &e.leftNz
+	nz := e.putTokens(planeY2, ctx(top[nzY2], left[nzY2]), &y2Levels, 0)
+	top[nzY2], left[nzY2] = nz, nz
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			nz := e.putTokens(planeY1AfterY2, ctx(top[x], left[y]), &yLevels[y*4+x], 1)
+			top[x], left[y] = nz, nz
+		}
+	}
+	for c := 0; c < 4; c += 2 {
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 2; x++ {
+				nz := e.putTokens(planeUV, ctx(top[4+c+x], left[4+c+y]), &uvLevels[2*c+y*2+x], 0)
+				top[4+c+x], left[4+c+y] = nz, nz
+			}
+		}
+	}
+}
+
+func ctx(top, left bool) int {
+	c := 0
+	if top {
+		c++
+	}
+	if left {
+		c++
+	}
+	return c
+}
+
+// putTokens writes the quantized coefficients of a block in zigzag order, starting at first,
+// and returns if any of them is non-zero. The token tree is specified in section 13.2.
+func (e *encoder) putTokens(plane, ctx int, levels *[16]int32, first int) bool {
+	last := -1
+	for n := 15; n >= first; n-- {
+		if levels[n] != 0 {
+			last = n
+			break
+		}
+	}
+	b := e.tokens
+	probs := &defaultTokenProb[plane]
+	p := &probs[bands[first]][ctx]
+	if last < 0 {
+		b.putBit(false, p[0])
+		return false
+	}
+	b.putBit(true, p[0])
+	for n := first; ; {
+		v := levels[n]
+		n++
+		if v == 0 {
+			// a zero can't be followed by the end of the block
+			b.putBit(false, p[1])
+			p = &probs[bands[n]][0]
+			continue
+		}
+		b.putBit(true, p[1])
+		a := v
+		if a < 0 {
+			a = -a
+		}
+		putValue(b, a, p)
+		b.putBit(v < 0, 128)
+
+		if a == 1 {
+			p = &probs[bands[n]][1]
+		} else {
+			p = &probs[bands[n]][2]
+		}
+		if n == 16 {
+			return true
+		}
+		if n > last {
+			b.putBit(false, p[0])
+			return true
+		}
+		b.putBit(true, p[0])
+	}
+}
+
+// putValue writes the token of an absolute value greater than zero and its extra bits.
+func putValue(b *boolEncoder, v int32, p *[nProb]uint8) {
+	if v == 1 {
+		b.putBit(false, p[2])
+		return
+	}
+	b.putBit(true, p[2])
+	switch {
+	case v <= 4:
+		b.putBit(false, p[3])
+		if v == 2 {
+			b.putBit(false, p[4])
+			return
+		}
+		b.putBit(true, p[4])
+		b.putBit(v == 4, p[5])
+	case v <= 10:
+		b.putBit(true, p[3])
+		b.putBit(false, p[6])
+		if v <= 6 {
+			b.putBit(false, p[7])
+			b.putBit(v == 6, 159)
+			return
+		}
+		b.putBit(true, p[7])
+		b.putBit((v-7)&2 != 0, 165)
+		b.putBit((v-7)&1 != 0, 145)
+	default:
+		b.putBit(true, p[3])
+		b.putBit(true, p[6])
+		cat := 0
+		for cat < 3 && v >= 3+(8<<uint(cat+1)) {
+			cat++
+		}
+		b.putBit(cat >= 2, p[8])
+		b.putBit(cat%2 == 1, p[9+cat/2])
+		extra := v - (3 + (8 << uint(cat)))
+		bits := catProbs[cat]
+		for i, prob := range bits {
+			b.putBit(extra>>uint(len(bits)-1-i)&1 != 0, prob)
+		}
+	}
+}
+
+// bestMode returns the available prediction mode with the smallest error.
+func bestMode(hasTop, hasLeft bool, err func(mode int) int) int {
+	best, bestErr := predDC, err(predDC)
+	for mode := predVE; mode < nPred; mode++ {
+		if (mode == predVE || mode == predTM) && !hasTop || (mode == predHE || mode == predTM) && !hasLeft {
+			continue
+		}
+		if e := err(mode); e < bestErr {
+			best, bestErr = mode, e
+		}
+	}
+	return best
+}
+
+// predict writes the prediction of a n×n block at the offset off of the reconstructed plane
+// to dst. The modes which need unavailable pixels are not used.
+func predict(mode int, rec []uint8, off, stride, n int, hasTop, hasLeft bool, dst []uint8) {
+	top := func(x int) int32 { return int32(rec[off-stride+x]) }
+	left := func(y int) int32 { return int32(rec[off+y*stride-1]) }
+	switch mode {
+	case predDC:
+		shift := 3
+		if n == 16 {
+			shift = 4
+		}
+		var sum int32
+		dc := int32(0x80)
+		switch {
+		case hasTop && hasLeft:
+			for i := 0; i < n; i++ {
+				sum += top(i) + left(i)
+			}
+			dc = (sum + int32(n)) >> uint(shift+1)
+		case hasTop:
+			for i := 0; i < n; i++ {
+				sum += top(i)
+			}
+			dc = (sum + int32(n/2)) >> uint(shift)
+		case hasLeft:
+			for i := 0; i < n; i++ {
+				sum += left(i)
+			}
+			dc = (sum + int32(n/2)) >> uint(shift)
+		}
+		for i := 0; i < n*n; i++ {
+			dst[i] = uint8(dc)
+		}
+	case predVE:
+		for y := 0; y < n; y++ {
+			copy(dst[y*n:(y+1)*n], rec[off-stride:off-stride+n])
+		}
+	case predHE:
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				dst[y*n+x] = uint8(left(y))
+			}
+		}
+	case predTM:
+		topLeft := int32(rec[off-stride-1])
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				dst[y*n+x] = clip8(left(y) + top(x) - topLeft)
+			}
+		}
+	}
+}
+
+// sad returns the sum of absolute differences of a n×n block to its prediction.
+func sad(src []uint8, stride int, pred []uint8, n int) int {
+	s := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			d := int(src[y*stride+x]) - int(pred[y*n+x])
+			if d < 0 {
+				d = -d
+			}
+			s += d
+		}
+	}
+	return s
+}
+
+func clip8(v int32) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// quantize quantizes the coefficients, starting at the zigzag position first. The levels are
+// written in zigzag order, the coefficients are replaced by the dequantized values.
+func quantize(coeffs *[16]int32, q quantizer, first int, levels *[16]int32) bool {
+	nonZero := false
+	for n := first; n < 16; n++ {
+		i := zigzag[n]
+		step, bias := q[1], q[1]/3
+		if i == 0 {
+			step, bias = q[0], q[0]/2
+		}
+		c := coeffs[i]
+		a := c
+		if a < 0 {
+			a = -a
+		}
+		l := (a + bias) / step
+		if l > 2048 {
+			l = 2048
+		}
+		if c < 0 {
+			l = -l
+		}
+		levels[n] = l
+		coeffs[i] = l * step
+		nonZero = nonZero || l != 0
+	}
+	return nonZero
+}
+
+// fdct transforms the difference of a 4×4 block and its prediction.
+func fdct(src []uint8, srcStride int, pred []uint8, predStride int, out *[16]int32) {
+	var tmp [16]int32
+	for i := 0; i < 4; i++ {
+		s, p := src[i*srcStride:], pred[i*predStride:]
+		d0 := int32(s[0]) - int32(p[0])
+		d1 := int32(s[1]) - int32(p[1])
+		d2 := int32(s[2]) - int32(p[2])
+		d3 := int32(s[3]) - int32(p[3])
+		a0, a1, a2, a3 := d0+d3, d1+d2, d1-d2, d0-d3
+		tmp[i*4+0] = (a0 + a1) * 8
+		tmp[i*4+1] = (a2*2217 + a3*5352 + 1812) >> 9
+		tmp[i*4+2] = (a0 - a1) * 8
+		tmp[i*4+3] = (a3*2217 - a2*5352 + 937) >> 9
+	}
+	for i := 0; i < 4; i++ {
+		a0, a1 := tmp[i]+tmp[12+i], tmp[4+i]+tmp[8+i]
+		a2, a3 := tmp[4+i]-tmp[8+i], tmp[i]-tmp[12+i]
+		out[i] = (a0 + a1 + 7) >> 4
+		out[4+i] = (a2*2217 + a3*5352 + 12000) >> 16
+		if a3 != 0 {
+			out[4+i]++
+		}
+		out[8+i] = (a0 - a1 + 7) >> 4
+		out[12+i] = (a3*2217 - a2*5352 + 51000) >> 16
+	}
+}
+
+// idct adds the inverse transform of the coefficients to the prediction, the same way the
+// decoder does it.
+func idct(coeffs *[16]int32, pred []uint8, predStride int, dst []uint8, dstStride int) {
+	const (
+		c1 = 85627 // 65536 * cos(pi/8) * sqrt(2).
+		c2 = 35468 // 65536 * sin(pi/8) * sqrt(2).
+	)
+	var m [4][4]int32
+	for i := 0; i < 4; i++ {
+		a := coeffs[i] + coeffs[8+i]
+		b := coeffs[i] - coeffs[8+i]
+		c := (coeffs[4+i] * c2 >> 16) - (coeffs[12+i] * c1 >> 16)
+		d := (coeffs[4+i] * c1 >> 16) + (coeffs[12+i] * c2 >> 16)
+		m[i] = [4]int32{a + d, b + c, b - c, a - d}
+	}
+	for j := 0; j < 4; j++ {
+		dc := m[0][j] + 4
+		a := dc + m[2][j]
+		b := dc - m[2][j]
+		c := (m[1][j] * c2 >> 16) - (m[3][j] * c1 >> 16)
+		d := (m[1][j] * c1 >> 16) + (m[3][j] * c2 >> 16)
+		p, o := pred[j*predStride:], dst[j*dstStride:]
+		o[0] = clip8(int32(p[0]) + (a+d)>>3)
+		o[1] = clip8(int32(p[1]) + (b+c)>>3)
+		o[2] = clip8(int32(p[2]) + (b-c)>>3)
+		o[3] = clip8(int32(p[3]) + (a-d)>>3)
+	}
+}
+
+// fwht applies the Walsh-Hadamard transform to the DC coefficients of the luma blocks.
+func fwht(in *[16]int32, out *[16]int32) {
+	var tmp [16]int32
+	for i := 0; i < 4; i++ {
+		a0, a1 := in[i*4+0]+in[i*4+2], in[i*4+1]+in[i*4+3]
+		a2, a3 := in[i*4+1]-in[i*4+3], in[i*4+0]-in[i*4+2]
+		tmp[i*4+0] = a0 + a1
+		tmp[i*4+1] = a3 + a2
+		tmp[i*4+2] = a3 - a2
+		tmp[i*4+3] = a0 - a1
+	}
+	for i := 0; i < 4; i++ {
+		a0, a1 := tmp[i]+tmp[8+i], tmp[4+i]+tmp[12+i]
+		a2, a3 := tmp[4+i]-tmp[12+i], tmp[i]-tmp[8+i]
+		out[i] = (a0 + a1) >> 1
+		out[4+i] = (a3 + a2) >> 1
+		out[8+i] = (a3 - a2) >> 1
+		out[12+i] = (a0 - a1) >> 1
+	}
+}
+
+// iwht inverts the Walsh-Hadamard transform the same way the decoder does it.
+func iwht(in *[16]int32, out *[16]int32) {
+	var m [16]int32
+	for i := 0; i < 4; i++ {
+		a0, a1 := in[i]+in[12+i], in[4+i]+in[8+i]
+		a2, a3 := in[4+i]-in[8+i], in[i]-in[12+i]
+		m[i] = a0 + a1
+		m[8+i] = a0 - a1
+		m[4+i] = a3 + a2
+		m[12+i] = a3 - a2
+	}
+	for i := 0; i < 4; i++ {
+		dc := m[i*4] + 3
+		a0 := dc + m[i*4+3]
+		a1 := m[i*4+1] + m[i*4+2]
+		a2 := m[i*4+1] - m[i*4+2]
+		a3 := dc - m[i*4+3]
+		out[i*4+0] = (a0 + a1) >> 3
+		out[i*4+1] = (a3 + a2) >> 3
+		out[i*4+2] = (a0 - a1) >> 3
+		out[i*4+3] = (a3 - a2) >> 3
+	}
+}
@@ -0,0 +1,159 @@
+// Package webp implements a WebP encoder.
+//
+// The images are encoded lossy as VP8 key frames predicting whole macroblocks. Images which
+// are not opaque get an uncompressed alpha channel. The output can be read by all WebP
+// decoders, the compression is not as good as the one of libwebp.
+package webp
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+// DefaultQuality is the default quality encoding parameter.
+const DefaultQuality = 75
+
+// maxSize is the maximum width and height of VP8 frames
+const maxSize = 1<<14 - 1
+
+// Options are the encoding parameters.
+// Quality ranges from 1 to 100 inclusive, higher is better.
+type Options struct {
+	Quality int
+}
+
+// Encode writes the Image m to w in WebP format with the given options.
+// Default parameters are used if a nil *Options is passed.
+func Encode(w io.Writer, m image.Image, o *Options) error {
+	b := m.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 || b.Dx() > maxSize || b.Dy() > maxSize {
+		return errors.New("webp: invalid image size")
+	}
+	quality := DefaultQuality
+	if o != nil && o.Quality > 0 {
+		quality = o.Quality
+	}
+	if quality > 100 {
+		quality = 100
+	}
+
+	e := newEncoder(b.Dx(), b.Dy(), quality)
+	alpha := e.convert(m)
+	frame, err := e.encode()
+	if err != nil {
+		return err
+	}
+
+	var chunks []byte
+	if alpha != nil {
+		// the extended format is required for the alpha channel
+		vp8x := make([]byte, 10)
+		vp8x[0] = 1 << 4
+		putUint24(vp8x[4:], uint32(b.Dx()-1))
+		putUint24(vp8x[7:], uint32(b.Dy()-1))
+		chunks = appendChunk(chunks, "VP8X", vp8x)
+		// no preprocessing, no filtering and no compression
+		chunks = appendChunk(chunks, "ALPH", append([]byte{0}, alpha...))
+	}
+	chunks = appendChunk(chunks, "VP8 ", frame)
+
+	header := make([]byte, 12)
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+len(chunks)))
+	copy(header[8:], "WEBP")
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(chunks)
+	return err
+}
+
+func appendChunk(b []byte, fourCC string, data []byte) []byte {
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(len(data)))
+	b = append(b, fourCC...)
+	b = append(b, size...)
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+// convert fills the luma and chroma planes of the encoder and returns the alpha channel,
+// or nil if the image is opaque. The planes are padded to whole macroblocks by repeating
+// the pixels at the right and bottom edge.
+func (e *encoder) convert(m image.Image) []byte {
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	rgb := make([]uint8, 3*e.yStride*e.mbh*16)
+	alpha := make([]byte, w*h)
+	opaque := true
+	for y := 0; y < e.mbh*16; y++ {
+		for x := 0; x < e.yStride; x++ {
+			i := 3 * (y*e.yStride + x)
+			if x >= w || y >= h {
+				// repeat the edge
+				sx, sy := x, y
+				if sx >= w {
+					sx = w - 1
+				}
+				if sy >= h {
+					sy = h - 1
+				}
+				copy(rgb[i:i+3], rgb[3*(sy*e.yStride+sx):])
+				continue
+			}
+			c := pixel(m, b.Min.X+x, b.Min.Y+y)
+			rgb[i], rgb[i+1], rgb[i+2] = c.R, c.G, c.B
+			alpha[y*w+x] = c.A
+			opaque = opaque && c.A == 0xff
+		}
+	}
+
+	// the conversion of BT.601 with limited range, which is expected by WebP decoders
+	for y := 0; y < e.mbh*16; y++ {
+		for x := 0; x < e.yStride; x++ {
+			i := 3 * (y*e.yStride + x)
+			r, g, b := int32(rgb[i]), int32(rgb[i+1]), int32(rgb[i+2])
+			e.y[y*e.yStride+x] = uint8((16839*r + 33059*g + 6420*b + 16<<16 + 1<<15) >> 16)
+		}
+	}
+	for y := 0; y < e.mbh*8; y++ {
+		for x := 0; x < e.cStride; x++ {
+			var r, g, b int32
+			for _, o := range [4]int{0, 1, e.yStride, e.yStride + 1} {
+				i := 3 * ((2*y)*e.yStride + 2*x + o)
+				r, g, b = r+int32(rgb[i]), g+int32(rgb[i+1]), b+int32(rgb[i+2])
+			}
+			e.u[y*e.cStride+x] = uint8((-9719*r - 19081*g + 28800*b + 4*(128<<16) + 1<<17) >> 18)
+			e.v[y*e.cStride+x] = uint8((28800*r - 24116*g - 4684*b + 4*(128<<16) + 1<<17) >> 18)
+		}
+	}
+
+	if opaque {
+		return nil
+	}
+	return alpha
+}
+
+// pixel returns the color of the pixel without premultiplied alpha
+func pixel(m image.Image, x, y int) color.NRGBA {
+	switch i := m.(type) {
+	case *image.NRGBA:
+		o := i.PixOffset(x, y)
+		return color.NRGBA{R: i.Pix[o], G: i.Pix[o+1], B: i.Pix[o+2], A: i.Pix[o+3]}
+	case *image.YCbCr:
+		c := i.YCbCrAt(x, y)
+		r, g, b := color.YCbCrToRGB(c.Y, c.Cb, c.Cr)
+		return color.NRGBA{R: r, G: g, B: b, A: 0xff}
+	}
+	return color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+}
@@ -0,0 +1,156 @@
+package webp
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+// testImage returns an image with gradients, edges and noise
+func testImage(w, h int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(1)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			seed = seed*1103515245 + 12345
+			c := color.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: uint8(seed >> 27), A: 0xff}
+			if x > w/2 && y > h/2 {
+				c.R, c.G = 240, 30
+			}
+			if alpha {
+				c.A = uint8((x + y) * 255 / (w + h))
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncode(t *testing.T) {
+	tables := []struct {
+		name    string
+		w, h    int
+		alpha   bool
+		quality int
+	}{
+		{"single pixel", 1, 1, false, 0},
+		{"odd size", 37, 21, false, 75},
+		{"alpha", 64, 48, true, 90},
+		{"low quality", 100, 80, false, 1},
+		{"high quality", 50, 70, false, 100},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			src := testImage(table.w, table.h, table.alpha)
+			var b bytes.Buffer
+			err := Encode(&b, src, &Options{Quality: table.quality})
+			assert.NoError(t, err)
+
+			cfg, err := webp.DecodeConfig(bytes.NewReader(b.Bytes()))
+			assert.NoError(t, err)
+			assert.Equal(t, table.w, cfg.Width)
+			assert.Equal(t, table.h, cfg.Height)
+
+			img, err := webp.Decode(bytes.NewReader(b.Bytes()))
+			assert.NoError(t, err)
+			if !assert.Equal(t, src.Bounds(), img.Bounds()) {
+				return
+			}
+
+			// the decoder has to reconstruct the same planes as the encoder
+			e := newEncoder(table.w, table.h, table.quality)
+			if table.quality == 0 {
+				e = newEncoder(table.w, table.h, DefaultQuality)
+			}
+			e.convert(src)
+			_, err = e.encode()
+			assert.NoError(t, err)
+			var ycbcr *image.YCbCr
+			switch i := img.(type) {
+			case *image.YCbCr:
+				ycbcr = i
+			case *image.NYCbCrA:
+				ycbcr = &i.YCbCr
+				assert.True(t, table.alpha)
+				for y := 0; y < table.h; y++ {
+					for x := 0; x < table.w; x++ {
+						assert.Equal(t, src.NRGBAAt(x, y).A, i.A[i.AOffset(x, y)])
+					}
+				}
+			default:
+				t.Fatalf("unexpected image type %T", img)
+			}
+			for y := 0; y < table.h; y++ {
+				for x := 0; x < table.w; x++ {
+					if ycbcr.Y[ycbcr.YOffset(x, y)] != e.ry[y*e.yStride+x] {
+						t.Fatalf("luma differs at %d,%d", x, y)
+					}
+					c := ycbcr.COffset(x, y)
+					if ycbcr.Cb[c] != e.ru[y/2*e.cStride+x/2] || ycbcr.Cr[c] != e.rv[y/2*e.cStride+x/2] {
+						t.Fatalf("chroma differs at %d,%d", x, y)
+					}
+				}
+			}
+
+			if table.quality >= 75 {
+				assert.Greater(t, psnr(e.y, e.ry), 30.0)
+			}
+		})
+	}
+}
+
+func TestEncodeColors(t *testing.T) {
+	colors := []color.NRGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+		{R: 255, G: 255, B: 255, A: 255},
+		{A: 255},
+		{R: 128, G: 64, B: 200, A: 255},
+	}
+	for _, c := range colors {
+		src := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+		for i := 0; i < len(src.Pix); i += 4 {
+			src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		var b bytes.Buffer
+		assert.NoError(t, Encode(&b, src, nil))
+		img, err := webp.Decode(&b)
+		assert.NoError(t, err)
+
+		// the decoder returns BT.601 with limited range
+		yc := img.(*image.YCbCr).YCbCrAt(16, 16)
+		y := (float64(yc.Y) - 16) * 255 / 219
+		cb, cr := (float64(yc.Cb)-128)*255/224, (float64(yc.Cr)-128)*255/224
+		r := y + 1.402*cr
+		g := y - 0.344136*cb - 0.714136*cr
+		bl := y + 1.772*cb
+		assert.InDelta(t, float64(c.R), r, 6, "red of %v", c)
+		assert.InDelta(t, float64(c.G), g, 6, "green of %v", c)
+		assert.InDelta(t, float64(c.B), bl, 6, "blue of %v", c)
+	}
+}
+
+func TestEncodeInvalidSize(t *testing.T) {
+	var b bytes.Buffer
+	assert.Error(t, Encode(&b, image.NewNRGBA(image.Rect(0, 0, 0, 10)), nil))
+	assert.Error(t, Encode(&b, image.NewNRGBA(image.Rect(0, 0, 1<<14, 1)), nil))
+}
+
+func psnr(a, b []uint8) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	if sum == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255*float64(len(a))/sum)
+}
@@ -66,7 +66,17 @@ func DefaultConfig() *config.Config {
 					},
 				},
 				Options: map[string]interface{}{
-					"previewFileMimeTypes": []string{"image/gif", "image/png", "image/jpeg", "text/plain", "image/tiff", "image/bmp", "image/x-ms-bmp"},
+					"previewFileMimeTypes": []string{
+						"image/gif", "image/png", "image/jpeg", "text/plain", "image/tiff", "image/bmp", "image/x-ms-bmp",
+						"image/svg+xml", "application/pdf",
+						"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+						"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+						"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+						"application/vnd.oasis.opendocument.text",
+						"application/vnd.oasis.opendocument.spreadsheet",
+						"application/vnd.oasis.opendocument.presentation",
+						"application/vnd.oasis.opendocument.graphics",
+					},
 				},
 			},
 		},
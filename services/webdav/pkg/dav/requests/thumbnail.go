@@ -2,16 +2,19 @@ package requests
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/v2/pkg/storagespace"
 	"github.com/cs3org/reva/v2/pkg/utils"
 	"github.com/go-chi/chi/v5"
 
+	thumbnailsmsg "github.com/owncloud/ocis/v2/protogen/gen/ocis/messages/thumbnails/v0"
 	"github.com/owncloud/ocis/v2/services/webdav/pkg/constants"
 )
 
@@ -30,6 +33,8 @@ type ThumbnailRequest struct {
 	Filename string
 	// The file extension
 	Extension string
+	// The type of the thumbnail, negotiated with the Accept header
+	ThumbnailType thumbnailsmsg.ThumbnailType
 	// The requested width of the thumbnail
 	Width int32
 	// The requested height of the thumbnail
@@ -67,10 +72,12 @@ func ParseThumbnailRequest(r *http.Request) (*ThumbnailRequest, error) {
 		return nil, err
 	}
 
+	ext := filepath.Ext(fp)
 	return &ThumbnailRequest{
 		Filepath:        fp,
 		Filename:        filepath.Base(fp),
-		Extension:       filepath.Ext(fp),
+		Extension:       ext,
+		ThumbnailType:   negotiateThumbnailType(strings.TrimLeft(ext, "."), r.Header.Get("Accept")),
 		Width:           int32(width),
 		Height:          int32(height),
 		PublicLinkToken: chi.URLParam(r, "token"),
@@ -78,6 +85,65 @@ func ParseThumbnailRequest(r *http.Request) (*ThumbnailRequest, error) {
 	}, nil
 }
 
+// negotiateThumbnailType returns the thumbnail type for a file extension. WebP is used
+// instead of PNG or JPG if the client explicitly accepts it with at least the same
+// preference. GIF thumbnails are kept to preserve animations.
+func negotiateThumbnailType(ext, accept string) thumbnailsmsg.ThumbnailType {
+	var t thumbnailsmsg.ThumbnailType
+	var mimeType string
+	switch strings.ToUpper(ext) {
+	case "GIF":
+		return thumbnailsmsg.ThumbnailType_GIF
+	case "PNG":
+		t, mimeType = thumbnailsmsg.ThumbnailType_PNG, "image/png"
+	default:
+		t, mimeType = thumbnailsmsg.ThumbnailType_JPG, "image/jpeg"
+	}
+
+	// clients sending only wildcards like */* can't necessarily display webp images
+	webp, explicit := acceptQuality(accept, "image/webp")
+	if explicit && webp > 0 {
+		if q, _ := acceptQuality(accept, mimeType); webp >= q {
+			return thumbnailsmsg.ThumbnailType_WEBP
+		}
+	}
+	return t
+}
+
+// acceptQuality returns the quality value of the most specific range in the Accept header
+// matching the mime type and if the mime type is listed explicitly.
+func acceptQuality(accept, mimeType string) (float64, bool) {
+	quality, specificity := 0.0, -1
+	for _, r := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+		if err != nil {
+			continue
+		}
+		var s int
+		switch {
+		case mediaRange == mimeType:
+			s = 2
+		case mediaRange == "*/*":
+			s = 0
+		case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(mediaRange, "*")):
+			s = 1
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		quality, specificity = q, s
+	}
+	return quality, specificity == 2
+}
+
 func parseDimensions(q url.Values) (int64, int64, error) {
 	width, err := parseDimension(q.Get("x"), "width", DefaultWidth)
 	if err != nil {
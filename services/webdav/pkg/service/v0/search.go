@@ -27,6 +27,9 @@ import (
 const (
 	elementNameSearchFiles = "search-files"
 	// TODO elementNameFilterFiles = "filter-files"
+
+	// trashbinPath is the path of the trash bins below /dav/spaces/
+	trashbinPath = "trash-bin"
 )
 
 // Search is the endpoint for retrieving search results for REPORT requests
@@ -54,19 +57,26 @@ func (g Webdav) Search(w http.ResponseWriter, r *http.Request) {
 		PageSize: int32(rep.SearchFiles.Search.Limit),
 	}
 
-	// Limit search to the according space when searching /dav/spaces/
+	// Limit search to the according space when searching /dav/spaces/ and search the trash bins
+	// when searching /dav/spaces/trash-bin/
 	if strings.HasPrefix(r.URL.Path, "/dav/spaces") {
 		space := strings.TrimPrefix(r.URL.Path, "/dav/spaces/")
-		rid, err := storagespace.ParseID(space)
-		if err != nil {
-			logger.Debug().Err(err).Msg("error parsing the space id for filtering")
-		} else {
-			req.Ref = &searchmsg.Reference{
-				ResourceId: &searchmsg.ResourceID{
-					StorageId: rid.StorageId,
-					SpaceId:   rid.SpaceId,
-					OpaqueId:  rid.SpaceId,
-				},
+		if space == trashbinPath || strings.HasPrefix(space, trashbinPath+"/") {
+			req.Trash = true
+			space = strings.Trim(strings.TrimPrefix(space, trashbinPath), "/")
+		}
+		if space != "" {
+			rid, err := storagespace.ParseID(space)
+			if err != nil {
+				logger.Debug().Err(err).Msg("error parsing the space id for filtering")
+			} else {
+				req.Ref = &searchmsg.Reference{
+					ResourceId: &searchmsg.ResourceID{
+						StorageId: rid.StorageId,
+						SpaceId:   rid.SpaceId,
+						OpaqueId:  rid.SpaceId,
+					},
+				}
 			}
 		}
 	}
@@ -162,6 +172,13 @@ func matchToPropResponse(ctx context.Context, match *searchmsg.Match) (*propfind
 		Href:     net.EncodePath(path.Join("/remote.php/dav/spaces/", ref)),
 		Propstat: []propfind.PropstatXML{},
 	}
+	if match.Entity.Deleted {
+		// trashed items are addressed by their key in the trash bin of the space
+		response.Href = net.EncodePath(path.Join("/remote.php/dav/spaces/", trashbinPath, storagespace.FormatResourceID(provider.ResourceId{
+			StorageId: match.Entity.Ref.ResourceId.StorageId,
+			SpaceId:   match.Entity.Ref.ResourceId.SpaceId,
+		}), match.Entity.TrashKey))
+	}
 
 	propstatOK := propfind.PropstatXML{
 		Status: "HTTP/1.1 200 OK",
@@ -188,6 +205,18 @@ func matchToPropResponse(ctx context.Context, match *searchmsg.Match) (*propfind
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("d:getlastmodified", match.Entity.LastModifiedTime.AsTime().Format(time.RFC3339)))
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("d:getcontenttype", match.Entity.MimeType))
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:permissions", match.Entity.Permissions))
+	if len(match.Entity.Tags) > 0 {
+		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:tags", strings.Join(match.Entity.Tags, ",")))
+	}
+	if match.Entity.Deleted {
+		deletionTime := match.Entity.DeletionTime.AsTime()
+		propstatOK.Prop = append(propstatOK.Prop,
+			prop.Escaped("oc:trashbin-original-filename", match.Entity.Name),
+			prop.Escaped("oc:trashbin-original-location", strings.TrimPrefix(match.Entity.Ref.Path, "./")),
+			prop.Escaped("oc:trashbin-delete-timestamp", strconv.FormatInt(deletionTime.Unix(), 10)),
+			prop.Escaped("oc:trashbin-delete-datetime", deletionTime.Format(time.RFC1123Z)),
+		)
+	}
 
 	// those seem empty - bug?
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("d:getetag", match.Entity.Etag))
@@ -204,6 +233,9 @@ func matchToPropResponse(ctx context.Context, match *searchmsg.Match) (*propfind
 	}
 	score := strconv.FormatFloat(float64(match.Score), 'f', -1, 64)
 	propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:score", score))
+	if len(match.Highlights) > 0 {
+		propstatOK.Prop = append(propstatOK.Prop, prop.Escaped("oc:highlights", strings.Join(match.Highlights, " … ")))
+	}
 
 	if len(propstatOK.Prop) > 0 {
 		response.Propstat = append(response.Propstat, propstatOK)